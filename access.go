@@ -0,0 +1,368 @@
+package empire
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/remind101/pkg/timex"
+	"golang.org/x/net/context"
+)
+
+// Role represents a level of access to an app. Each role includes the access
+// granted by the roles below it.
+type Role string
+
+const (
+	// RoleViewer can view an app, including its releases, config vars and
+	// logs.
+	RoleViewer Role = "viewer"
+
+	// RoleDeployer can deploy, rollback, scale, restart and run processes,
+	// and change config vars.
+	RoleDeployer Role = "deployer"
+
+	// RoleAdmin can create and destroy apps, manage domains and
+	// certificates, and grant access to other users.
+	RoleAdmin Role = "admin"
+)
+
+// roleLevels maps a Role to its level. A higher level includes all of the
+// access of the lower levels.
+var roleLevels = map[Role]int{
+	RoleViewer:   1,
+	RoleDeployer: 2,
+	RoleAdmin:    3,
+}
+
+// Valid returns true if the role is a known role.
+func (r Role) Valid() bool {
+	_, ok := roleLevels[r]
+	return ok
+}
+
+// Includes returns true if the role includes the access granted by the other
+// role.
+func (r Role) Includes(other Role) bool {
+	return r.Valid() && other.Valid() && roleLevels[r] >= roleLevels[other]
+}
+
+// ErrAccessGrantPrincipal is returned when an access grant isn't for exactly
+// one user or team.
+var ErrAccessGrantPrincipal = &ValidationError{errors.New("Access must be granted to either a user or a team.")}
+
+// AccessDeniedError is returned when a user doesn't have the role required to
+// perform an action on an app.
+type AccessDeniedError struct {
+	// The user that was denied access.
+	User *User
+
+	// The name of the app that access was denied to.
+	App string
+
+	// The role that's required.
+	Role Role
+}
+
+func (e *AccessDeniedError) Error() string {
+	name := "anonymous"
+	if e.User != nil {
+		name = e.User.Name
+	}
+	return fmt.Sprintf("%s does not have the %s role on %s.", name, e.Role, e.App)
+}
+
+// AccessGrant grants a Role to a user, or a GitHub team, on the apps matching
+// a name pattern.
+type AccessGrant struct {
+	ID string
+
+	// The name of the user that's granted access.
+	User string
+
+	// The GitHub team that's granted access, as org/slug (e.g.
+	// remind101/engineering). Only one of User or Team is set.
+	Team string
+
+	// The name of an app, or a pattern (e.g. acme-*) matching app names.
+	// Patterns use the syntax of path.Match.
+	App string
+
+	// The role that's granted.
+	Role Role
+
+	CreatedAt *time.Time
+}
+
+// BeforeCreate sets created_at before inserting.
+func (g *AccessGrant) BeforeCreate() error {
+	t := timex.Now()
+	g.CreatedAt = &t
+	return nil
+}
+
+// Validate checks that the grant is for exactly one user or team, on a valid
+// pattern, with a known role.
+func (g *AccessGrant) Validate() error {
+	if (g.User == "") == (g.Team == "") {
+		return ErrAccessGrantPrincipal
+	}
+
+	if !g.Role.Valid() {
+		return &ValidationError{Err: fmt.Errorf("%q is not a valid role. Valid roles are viewer, deployer and admin.", g.Role)}
+	}
+
+	if _, err := path.Match(g.App, ""); g.App == "" || err != nil {
+		return &ValidationError{Err: fmt.Errorf("%q is not a valid app name or pattern", g.App)}
+	}
+
+	return nil
+}
+
+// Principal returns the user or team that the grant applies to.
+func (g *AccessGrant) Principal() string {
+	if g.Team != "" {
+		return g.Team
+	}
+	return g.User
+}
+
+// Matches returns true if the grant applies to the user and app.
+func (g *AccessGrant) Matches(user *User, app string) bool {
+	if ok, _ := path.Match(g.App, app); !ok {
+		return false
+	}
+
+	return user.Is(g.Principal())
+}
+
+// AccessGrantsQuery is a scope implementation for common things to filter
+// access grants by.
+type AccessGrantsQuery struct {
+	// If provided, finds the access grant with the given id.
+	ID *string
+
+	// If provided, finds the access grants to any of these users or
+	// teams.
+	Principals []string
+}
+
+// scope implements the scope interface.
+func (q AccessGrantsQuery) scope(db *gorm.DB) *gorm.DB {
+	var scope composedScope
+
+	if q.ID != nil {
+		scope = append(scope, idEquals(*q.ID))
+	}
+
+	if q.Principals != nil {
+		scope = append(scope, scopeFunc(func(db *gorm.DB) *gorm.DB {
+			return db.Where(`"user" IN (?) OR team IN (?)`, q.Principals, q.Principals)
+		}))
+	}
+
+	scope = append(scope, order("app, role"))
+
+	return scope.scope(db)
+}
+
+// accessGrantsFind returns the first matching access grant.
+func accessGrantsFind(db *gorm.DB, scope scope) (*AccessGrant, error) {
+	var grant AccessGrant
+	return &grant, first(db, scope, &grant)
+}
+
+// accessGrants returns all access grants matching the scope.
+func accessGrants(db *gorm.DB, scope scope) ([]*AccessGrant, error) {
+	var grants []*AccessGrant
+	return grants, find(db, scope, &grants)
+}
+
+// accessGrantsCreate inserts an AccessGrant into the database.
+func accessGrantsCreate(db *gorm.DB, grant *AccessGrant) (*AccessGrant, error) {
+	return grant, db.Create(grant).Error
+}
+
+// accessGrantsDestroy removes an AccessGrant from the database.
+func accessGrantsDestroy(db *gorm.DB, grant *AccessGrant) error {
+	return db.Delete(grant).Error
+}
+
+// Permissions are the roles that a user has on apps. They're loaded once, so
+// that the user's access to many apps can be checked without querying the
+// access grants each time.
+type Permissions struct {
+	user *User
+
+	// True if the user has every role on every app.
+	all bool
+
+	// The access grants to the user, and the teams the user is a member
+	// of.
+	grants []*AccessGrant
+}
+
+// Authorize returns an AccessDeniedError if the user doesn't have the role on
+// the app.
+func (p *Permissions) Authorize(app string, role Role) error {
+	if p.all {
+		return nil
+	}
+
+	for _, g := range p.grants {
+		if g.Role.Includes(role) && g.Matches(p.user, app) {
+			return nil
+		}
+	}
+
+	return &AccessDeniedError{User: p.user, App: app, Role: role}
+}
+
+// accessService checks the access grants of users.
+type accessService struct {
+	*Empire
+}
+
+// Permissions loads the access grants for the user.
+func (s *accessService) Permissions(db *gorm.DB, user *User) (*Permissions, error) {
+	if user == nil {
+		return &Permissions{}, nil
+	}
+
+	if user.system {
+		return &Permissions{user: user, all: true}, nil
+	}
+
+	for _, admin := range s.Admins {
+		if user.Is(admin) {
+			return &Permissions{user: user, all: true}, nil
+		}
+	}
+
+	principals := append([]string{user.Name}, user.Teams...)
+	grants, err := accessGrants(db, AccessGrantsQuery{Principals: principals})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Permissions{user: user, grants: grants}, nil
+}
+
+// Authorize returns an AccessDeniedError if the user doesn't have the role on
+// the app.
+func (s *accessService) Authorize(db *gorm.DB, user *User, app string, role Role) error {
+	p, err := s.Permissions(db, user)
+	if err != nil {
+		return err
+	}
+
+	return p.Authorize(app, role)
+}
+
+// AccessGrantsCreateOpts are options provided when granting access.
+type AccessGrantsCreateOpts struct {
+	// User performing the action.
+	User *User
+
+	// The access to grant.
+	Grant *AccessGrant
+}
+
+func (opts AccessGrantsCreateOpts) Validate(e *Empire) error {
+	if err := opts.Grant.Validate(); err != nil {
+		return err
+	}
+
+	// Only admins of the apps matching the pattern can grant access to
+	// them.
+	return e.Authorize(opts.User, opts.Grant.App, RoleAdmin)
+}
+
+// AccessGrantsCreate grants access to the apps matching a pattern.
+func (e *Empire) AccessGrantsCreate(ctx context.Context, opts AccessGrantsCreateOpts) (*AccessGrant, error) {
+	if err := opts.Validate(e); err != nil {
+		return nil, err
+	}
+
+	return accessGrantsCreate(e.db, opts.Grant)
+}
+
+// AccessGrantsDestroyOpts are options provided when revoking access.
+type AccessGrantsDestroyOpts struct {
+	// User performing the action.
+	User *User
+
+	// The access to revoke.
+	Grant *AccessGrant
+}
+
+func (opts AccessGrantsDestroyOpts) Validate(e *Empire) error {
+	return e.Authorize(opts.User, opts.Grant.App, RoleAdmin)
+}
+
+// AccessGrantsDestroy revokes access to the apps matching a pattern.
+func (e *Empire) AccessGrantsDestroy(ctx context.Context, opts AccessGrantsDestroyOpts) error {
+	if err := opts.Validate(e); err != nil {
+		return err
+	}
+
+	return accessGrantsDestroy(e.db, opts.Grant)
+}
+
+// AccessGrants returns all access grants matching the query.
+func (e *Empire) AccessGrants(q AccessGrantsQuery) ([]*AccessGrant, error) {
+	return accessGrants(e.db, q)
+}
+
+// AccessGrantsVisible returns the access grants that the user can see, which
+// are the grants on apps that the user administers, and the grants to the user
+// or their teams.
+func (e *Empire) AccessGrantsVisible(user *User) ([]*AccessGrant, error) {
+	p, err := e.Permissions(user)
+	if err != nil {
+		return nil, err
+	}
+
+	grants, err := accessGrants(e.db, AccessGrantsQuery{})
+	if err != nil {
+		return nil, err
+	}
+
+	var visible []*AccessGrant
+	for _, g := range grants {
+		if (user != nil && user.Is(g.Principal())) || p.Authorize(g.App, RoleAdmin) == nil {
+			visible = append(visible, g)
+		}
+	}
+
+	return visible, nil
+}
+
+// AccessGrantsFind returns the first access grant matching the query.
+func (e *Empire) AccessGrantsFind(q AccessGrantsQuery) (*AccessGrant, error) {
+	return accessGrantsFind(e.db, q)
+}
+
+// Authorize returns an AccessDeniedError if the user doesn't have the role on
+// the app with the given name. When access control is disabled, every user has
+// access to every app.
+func (e *Empire) Authorize(user *User, app string, role Role) error {
+	if !e.AccessControl {
+		return nil
+	}
+
+	return e.access.Authorize(e.db, user, app, role)
+}
+
+// Permissions loads the roles that the user has on apps, which can be used to
+// check access to many apps at once (e.g. when listing apps). When access
+// control is disabled, the user has every role on every app.
+func (e *Empire) Permissions(user *User) (*Permissions, error) {
+	if !e.AccessControl {
+		return &Permissions{user: user, all: true}, nil
+	}
+
+	return e.access.Permissions(e.db, user)
+}
@@ -0,0 +1,108 @@
+package empire
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRole_Includes(t *testing.T) {
+	tests := []struct {
+		role, other Role
+		includes    bool
+	}{
+		{RoleViewer, RoleViewer, true},
+		{RoleViewer, RoleDeployer, false},
+		{RoleDeployer, RoleViewer, true},
+		{RoleDeployer, RoleAdmin, false},
+		{RoleAdmin, RoleDeployer, true},
+		{Role("owner"), RoleViewer, false},
+		{RoleAdmin, Role("owner"), false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.includes, tt.role.Includes(tt.other), "%s includes %s", tt.role, tt.other)
+	}
+}
+
+func TestAccessGrant_Validate(t *testing.T) {
+	tests := []struct {
+		grant AccessGrant
+		err   error
+	}{
+		{AccessGrant{User: "ejholmes", App: "acme-inc", Role: RoleAdmin}, nil},
+		{AccessGrant{Team: "remind101/engineering", App: "acme-*", Role: RoleViewer}, nil},
+		{AccessGrant{App: "acme-inc", Role: RoleAdmin}, ErrAccessGrantPrincipal},
+		{AccessGrant{User: "ejholmes", Team: "remind101/engineering", App: "acme-inc", Role: RoleAdmin}, ErrAccessGrantPrincipal},
+		{AccessGrant{User: "ejholmes", App: "acme-inc", Role: "owner"}, &ValidationError{}},
+		{AccessGrant{User: "ejholmes", Role: RoleAdmin}, &ValidationError{}},
+		{AccessGrant{User: "ejholmes", App: "acme-[", Role: RoleAdmin}, &ValidationError{}},
+	}
+
+	for _, tt := range tests {
+		err := tt.grant.Validate()
+		if tt.err == nil {
+			assert.NoError(t, err)
+		} else {
+			assert.IsType(t, tt.err, err)
+		}
+	}
+}
+
+func TestAccessGrant_Matches(t *testing.T) {
+	user := &User{Name: "ejholmes", Teams: []string{"remind101/engineering"}}
+
+	tests := []struct {
+		grant   AccessGrant
+		app     string
+		matches bool
+	}{
+		{AccessGrant{User: "ejholmes", App: "acme-inc"}, "acme-inc", true},
+		{AccessGrant{User: "ejholmes", App: "acme-inc"}, "acme-api", false},
+		{AccessGrant{User: "phobologic", App: "acme-inc"}, "acme-inc", false},
+		{AccessGrant{Team: "remind101/engineering", App: "acme-*"}, "acme-api", true},
+		{AccessGrant{Team: "remind101/ops", App: "acme-*"}, "acme-api", false},
+		{AccessGrant{User: "ejholmes", App: "*"}, "r101-api", true},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.matches, tt.grant.Matches(user, tt.app), "%v matches %s", tt.grant, tt.app)
+	}
+}
+
+func TestPermissions_Authorize(t *testing.T) {
+	user := &User{Name: "ejholmes", Teams: []string{"remind101/engineering"}}
+	p := &Permissions{
+		user: user,
+		grants: []*AccessGrant{
+			{Team: "remind101/engineering", App: "acme-*", Role: RoleDeployer},
+			{User: "ejholmes", App: "acme-inc", Role: RoleAdmin},
+		},
+	}
+
+	assert.NoError(t, p.Authorize("acme-api", RoleDeployer))
+	assert.NoError(t, p.Authorize("acme-inc", RoleAdmin))
+	assert.IsType(t, &AccessDeniedError{}, p.Authorize("acme-api", RoleAdmin))
+	assert.IsType(t, &AccessDeniedError{}, p.Authorize("r101-api", RoleViewer))
+
+	// Permissions for no user deny everything.
+	p = &Permissions{}
+	assert.EqualError(t, p.Authorize("acme-inc", RoleViewer), "anonymous does not have the viewer role on acme-inc.")
+}
+
+func TestEmpire_Authorize(t *testing.T) {
+	e := New(&DB{})
+
+	// Everyone has access when access control is disabled.
+	assert.NoError(t, e.Authorize(nil, "acme-inc", RoleAdmin))
+
+	e.AccessControl = true
+	e.Admins = []string{"remind101/ops"}
+
+	err := e.Authorize(nil, "acme-inc", RoleViewer)
+	assert.IsType(t, &AccessDeniedError{}, err)
+	assert.EqualError(t, err, "anonymous does not have the viewer role on acme-inc.")
+
+	user := &User{Name: "ejholmes", Teams: []string{"remind101/ops"}}
+	assert.NoError(t, e.Authorize(user, "acme-inc", RoleAdmin))
+}
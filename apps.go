@@ -46,6 +46,10 @@ type App struct {
 	// The name of an SSL cert for the web process of this app.
 	Cert string
 
+	// If true, deployments that fail to stabilize will automatically be
+	// rolled back to the previous release.
+	AutoRollback bool
+
 	// The time that this application was created.
 	CreatedAt *time.Time
 }
@@ -120,12 +124,16 @@ func (s *appsService) Restart(ctx context.Context, db *gorm.DB, opts RestartOpts
 		return s.Scheduler.Stop(ctx, opts.PID)
 	}
 
-	return s.releases.ReleaseApp(ctx, db, opts.App)
+	return s.releases.RestartApp(ctx, db, opts.App)
 }
 
 func (s *appsService) Scale(ctx context.Context, db *gorm.DB, opts ScaleOpts) ([]*Process, error) {
 	app := opts.App
 
+	if err := canaryGuard(db, app); err != nil {
+		return nil, err
+	}
+
 	release, err := releasesFind(db, ReleasesQuery{App: app})
 	if err != nil {
 		return nil, err
@@ -135,6 +143,8 @@ func (s *appsService) Scale(ctx context.Context, db *gorm.DB, opts ScaleOpts) ([
 	}
 
 	event := opts.Event()
+	event.sizes = s.DynoSizes()
+	current := release.Formation.clone()
 
 	var ps []*Process
 	for i, up := range opts.Updates {
@@ -145,6 +155,10 @@ func (s *appsService) Scale(ctx context.Context, db *gorm.DB, opts ScaleOpts) ([
 			return nil, &ValidationError{Err: fmt.Errorf("no %s process type in release", t)}
 		}
 
+		if a := p.Autoscaling; a != nil && q != a.Bound(q) {
+			return nil, &ValidationError{Err: fmt.Errorf("%s is autoscaled between %d and %d instances", t, a.Min, a.Max)}
+		}
+
 		eventUpdate := event.Updates[i]
 		eventUpdate.PreviousQuantity = p.Quantity
 		eventUpdate.PreviousConstraints = p.Constraints()
@@ -159,6 +173,10 @@ func (s *appsService) Scale(ctx context.Context, db *gorm.DB, opts ScaleOpts) ([
 		ps = append(ps, &p)
 	}
 
+	if err := s.checkCapacity(ctx, current, release.Formation); err != nil {
+		return nil, err
+	}
+
 	// Save the new formation.
 	if err := releasesUpdate(db, release); err != nil {
 		return nil, err
@@ -169,7 +187,60 @@ func (s *appsService) Scale(ctx context.Context, db *gorm.DB, opts ScaleOpts) ([
 		return ps, err
 	}
 
-	return ps, s.PublishEvent(event)
+	return ps, s.publishEvent(db, event)
+}
+
+// Autoscale sets, or removes, the autoscaling policy of a process, and releases
+// the new formation.
+func (s *appsService) Autoscale(ctx context.Context, db *gorm.DB, opts AutoscaleOpts) (*Process, error) {
+	app := opts.App
+
+	if err := canaryGuard(db, app); err != nil {
+		return nil, err
+	}
+
+	release, err := releasesFind(db, ReleasesQuery{App: app})
+	if err != nil {
+		return nil, err
+	}
+	if release == nil {
+		return nil, &ValidationError{Err: fmt.Errorf("no releases for %s", app.Name)}
+	}
+
+	p, ok := release.Formation[opts.Process]
+	if !ok {
+		return nil, &ValidationError{Err: fmt.Errorf("no %s process type in release", opts.Process)}
+	}
+	if p.Cron != nil {
+		return nil, &ValidationError{Err: fmt.Errorf("%s is a scheduled process, and can't be autoscaled", opts.Process)}
+	}
+
+	event := opts.Event()
+	event.Previous = p.Autoscaling
+	current := release.Formation.clone()
+
+	p.Autoscaling = opts.Autoscaling
+	if a := p.Autoscaling; a != nil {
+		// The quantity is used as the initial number of instances, so
+		// it needs to be within the bounds of the policy.
+		p.Quantity = a.Bound(p.Quantity)
+	}
+	release.Formation[opts.Process] = p
+
+	if err := s.checkQuotas(db, app, current, release.Formation); err != nil {
+		return nil, err
+	}
+
+	// Save the new formation.
+	if err := releasesUpdate(db, release); err != nil {
+		return nil, err
+	}
+
+	if err := s.releases.Release(ctx, release, nil); err != nil {
+		return &p, err
+	}
+
+	return &p, s.publishEvent(db, event)
 }
 
 // appsEnsureRepo will set the repo if it's not set.
@@ -0,0 +1,256 @@
+package empire
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/remind101/empire/pkg/headerutil"
+	"github.com/remind101/pkg/timex"
+)
+
+// EventStatus represents the delivery status of an AuditEvent.
+type EventStatus string
+
+const (
+	// EventPending is the status of an event that hasn't been delivered
+	// to the EventStream yet.
+	EventPending EventStatus = "pending"
+
+	// EventDelivered is the status of an event that was delivered to the
+	// EventStream.
+	EventDelivered EventStatus = "delivered"
+
+	// EventDead is the status of an event that failed to be delivered
+	// after the maximum number of attempts. Dead events won't be retried
+	// unless they're requeued.
+	EventDead EventStatus = "dead"
+)
+
+// Valid returns true if the status is a known status.
+func (s EventStatus) Valid() bool {
+	switch s {
+	case EventPending, EventDelivered, EventDead:
+		return true
+	default:
+		return false
+	}
+}
+
+// AuditEvent is an Event that was published by Empire, and stored in the
+// audit log. The audit log also acts as an outbox; events are stored in the
+// same transaction as the change that triggered them, and delivered to the
+// EventStream by an eventDispatcher.
+type AuditEvent struct {
+	ID string
+
+	// The type of event (e.g. deploy, scale).
+	Type string
+
+	// The name of the user that triggered the event.
+	User string
+
+	// The name of the app that the event relates to, if any.
+	App string
+
+	// A human readable description of the event.
+	Message string
+
+	// The event, encoded as JSON.
+	Payload []byte
+
+	// The number of failed attempts to deliver the event.
+	Attempts int
+
+	// The error from the last failed delivery attempt.
+	LastError string
+
+	// The time after which delivery of the event should be attempted.
+	NextAttemptAt *time.Time
+
+	// The time that the event was delivered to the EventStream.
+	DeliveredAt *time.Time
+
+	// The time that delivery of the event was given up on.
+	DeadAt *time.Time
+
+	CreatedAt *time.Time
+}
+
+// TableName implements the gorm tabler interface.
+func (AuditEvent) TableName() string {
+	return "events"
+}
+
+// BeforeCreate sets created_at before inserting.
+func (e *AuditEvent) BeforeCreate() error {
+	t := timex.Now()
+	e.CreatedAt = &t
+	e.NextAttemptAt = &t
+	return nil
+}
+
+// Status returns the delivery status of the event.
+func (e *AuditEvent) Status() EventStatus {
+	switch {
+	case e.DeliveredAt != nil:
+		return EventDelivered
+	case e.DeadAt != nil:
+		return EventDead
+	default:
+		return EventPending
+	}
+}
+
+// newAuditEvent returns an AuditEvent for the given Event.
+func newAuditEvent(event Event) (*AuditEvent, error) {
+	m, err := NewEventMessage(event)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AuditEvent{
+		Type:    m.Event,
+		User:    m.User,
+		App:     m.App,
+		Message: m.Message,
+		Payload: []byte(m.Data),
+	}, nil
+}
+
+// AuditEventsQuery is a scope implementation for common things to filter
+// audit events by.
+type AuditEventsQuery struct {
+	// If provided, finds the event with the given id.
+	ID *string
+
+	// If provided, filters events for the given app.
+	App *string
+
+	// If provided, filters events for any of the given apps.
+	Apps []string
+
+	// If provided, filters events triggered by the given user.
+	User *string
+
+	// If provided, filters events of the given type.
+	Type *string
+
+	// If provided, only returns events that happened at or after this time.
+	Since *time.Time
+
+	// If provided, filters events by their delivery status.
+	Status *EventStatus
+
+	// If provided, uses the limit and sorting parameters specified in the range.
+	Range headerutil.Range
+}
+
+// scope implements the scope interface.
+func (q AuditEventsQuery) scope(db *gorm.DB) *gorm.DB {
+	var scope composedScope
+
+	if q.ID != nil {
+		scope = append(scope, idEquals(*q.ID))
+	}
+
+	if q.App != nil {
+		scope = append(scope, fieldEquals("app", *q.App))
+	}
+
+	if q.Apps != nil {
+		scope = append(scope, scopeFunc(func(db *gorm.DB) *gorm.DB {
+			if len(q.Apps) == 0 {
+				return db.Where("false")
+			}
+			return db.Where("app IN (?)", q.Apps)
+		}))
+	}
+
+	if q.User != nil {
+		scope = append(scope, fieldEquals(`"user"`, *q.User))
+	}
+
+	if q.Type != nil {
+		scope = append(scope, fieldEquals("type", *q.Type))
+	}
+
+	if q.Since != nil {
+		scope = append(scope, scopeFunc(func(db *gorm.DB) *gorm.DB {
+			return db.Where("created_at >= ?", *q.Since)
+		}))
+	}
+
+	if q.Status != nil {
+		scope = append(scope, eventStatusEquals(*q.Status))
+	}
+
+	scope = append(scope, inRange(q.Range.WithDefaults(q.DefaultRange())))
+
+	return scope.scope(db)
+}
+
+// DefaultRange returns the default headerutil.Range used if values aren't
+// provided.
+func (q AuditEventsQuery) DefaultRange() headerutil.Range {
+	sort, order, max := "created_at", "desc", 100
+	return headerutil.Range{
+		Sort:  &sort,
+		Order: &order,
+		Max:   &max,
+	}
+}
+
+// eventStatusEquals returns a scope that filters events by their delivery
+// status.
+func eventStatusEquals(status EventStatus) scope {
+	return scopeFunc(func(db *gorm.DB) *gorm.DB {
+		switch status {
+		case EventDelivered:
+			return db.Where("delivered_at IS NOT NULL")
+		case EventDead:
+			return db.Where("delivered_at IS NULL AND dead_at IS NOT NULL")
+		default:
+			return db.Where("delivered_at IS NULL AND dead_at IS NULL")
+		}
+	})
+}
+
+// auditEventsFind returns the first matching audit event.
+func auditEventsFind(db *gorm.DB, scope scope) (*AuditEvent, error) {
+	var event AuditEvent
+	return &event, first(db, scope, &event)
+}
+
+// auditEvents returns all audit events matching the scope.
+func auditEvents(db *gorm.DB, scope scope) ([]*AuditEvent, error) {
+	var events []*AuditEvent
+	return events, find(db, scope, &events)
+}
+
+// auditEventsCreate inserts an AuditEvent into the database.
+func auditEventsCreate(db *gorm.DB, event *AuditEvent) (*AuditEvent, error) {
+	return event, db.Create(event).Error
+}
+
+// auditEventsUpdate updates an existing AuditEvent.
+func auditEventsUpdate(db *gorm.DB, event *AuditEvent) error {
+	return db.Save(event).Error
+}
+
+// auditService stores published events in the audit log.
+type auditService struct {
+	*Empire
+}
+
+// Record stores the event in the audit log, where it's picked up by the
+// eventDispatcher. When db is a transaction, the event is only published if the
+// transaction is committed.
+func (s *auditService) Record(db *gorm.DB, event Event) error {
+	e, err := newAuditEvent(event)
+	if err != nil {
+		return err
+	}
+
+	_, err = auditEventsCreate(db, e)
+	return err
+}
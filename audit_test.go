@@ -0,0 +1,65 @@
+package empire
+
+import (
+	"testing"
+	"time"
+
+	"github.com/remind101/empire/pkg/headerutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditEventsQuery(t *testing.T) {
+	app := "acme-inc"
+	user := "ejholmes"
+	typ := "deploy"
+	since := time.Date(2016, time.January, 1, 0, 0, 0, 0, time.UTC)
+	dead := EventDead
+	max := 20
+	rangeHeader := headerutil.Range{Max: &max}
+
+	tests := scopeTests{
+		{AuditEventsQuery{}, "ORDER BY created_at desc LIMIT 100", []interface{}{}},
+		{AuditEventsQuery{App: &app}, "WHERE (app = $1) ORDER BY created_at desc LIMIT 100", []interface{}{app}},
+		{AuditEventsQuery{User: &user}, `WHERE ("user" = $1) ORDER BY created_at desc LIMIT 100`, []interface{}{user}},
+		{AuditEventsQuery{Type: &typ}, "WHERE (type = $1) ORDER BY created_at desc LIMIT 100", []interface{}{typ}},
+		{AuditEventsQuery{Since: &since}, "WHERE (created_at >= $1) ORDER BY created_at desc LIMIT 100", []interface{}{since}},
+		{AuditEventsQuery{Apps: []string{"acme-inc", "acme-api"}}, "WHERE (app IN ($1,$2)) ORDER BY created_at desc LIMIT 100", []interface{}{"acme-inc", "acme-api"}},
+		{AuditEventsQuery{Apps: []string{}}, "WHERE (false) ORDER BY created_at desc LIMIT 100", []interface{}{}},
+		{AuditEventsQuery{Status: &dead}, "WHERE (delivered_at IS NULL AND dead_at IS NOT NULL) ORDER BY created_at desc LIMIT 100", []interface{}{}},
+		{AuditEventsQuery{App: &app, Type: &typ, Range: rangeHeader}, "WHERE (app = $1) AND (type = $2) ORDER BY created_at desc LIMIT 20", []interface{}{app, typ}},
+	}
+
+	tests.Run(t)
+}
+
+func TestNewAuditEvent(t *testing.T) {
+	e, err := newAuditEvent(ScaleEvent{
+		User: "ejholmes",
+		App:  "acme-inc",
+		Updates: []*ScaleEventUpdate{
+			{Process: "web", Quantity: 2, PreviousQuantity: 1},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "scale", e.Type)
+	assert.Equal(t, "ejholmes", e.User)
+	assert.Equal(t, "acme-inc", e.App)
+	assert.Equal(t, "ejholmes scaled `web` on acme-inc from 1(0:0) to 2(0:0)", e.Message)
+	assert.Contains(t, string(e.Payload), `"process":"web"`)
+
+	e, err = newAuditEvent(CreateEvent{
+		User: "ejholmes",
+		Name: "acme-inc",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "create", e.Type)
+	assert.Equal(t, "acme-inc", e.App)
+}
+
+func TestAuditEvent_Status(t *testing.T) {
+	now := time.Now()
+
+	assert.Equal(t, EventPending, (&AuditEvent{}).Status())
+	assert.Equal(t, EventDelivered, (&AuditEvent{DeliveredAt: &now}).Status())
+	assert.Equal(t, EventDead, (&AuditEvent{DeadAt: &now}).Status())
+}
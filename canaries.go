@@ -0,0 +1,236 @@
+package empire
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/remind101/empire/scheduler"
+	"github.com/remind101/pkg/timex"
+	"golang.org/x/net/context"
+)
+
+// Canary represents a release that is running alongside the stable release of
+// an app. Until the canary is promoted or aborted, no other releases can be
+// created for the app.
+type Canary struct {
+	// The id of the app that this canary relates to.
+	AppID string `gorm:"primary_key"`
+
+	// The id of the canary release.
+	ReleaseID string
+
+	// The id of the release that was running when the canary was started.
+	StableReleaseID string
+
+	// The percentage of instances, for each process, that run the canary
+	// release. A value of 100 means that a full set of instances for the
+	// canary are running alongside the stable release (blue/green).
+	Percent int
+
+	// The time that the canary was started.
+	CreatedAt *time.Time
+}
+
+// BeforeCreate sets created_at before inserting.
+func (c *Canary) BeforeCreate() error {
+	t := timex.Now()
+	c.CreatedAt = &t
+	return nil
+}
+
+// DeployStrategy controls how a new release is rolled out.
+type DeployStrategy struct {
+	// If non-zero, the new release is started as a canary, alongside the
+	// current release, with this percentage of the instances for each
+	// process.
+	Canary int
+
+	// If true, a full set of instances for the new release is started
+	// alongside the current release.
+	BlueGreen bool
+}
+
+// Percent returns the percentage of instances that should run the new release
+// while it's a canary. If zero, the release should be deployed normally.
+func (s DeployStrategy) Percent() int {
+	if s.BlueGreen {
+		return 100
+	}
+	return s.Canary
+}
+
+// Validate validates the strategy.
+func (s DeployStrategy) Validate() error {
+	if s.BlueGreen && s.Canary != 0 {
+		return &ValidationError{Err: fmt.Errorf("canary and blue/green deploys can't be used together")}
+	}
+	if s.Canary < 0 || s.Canary > 100 {
+		return &ValidationError{Err: fmt.Errorf("canary percentage must be between 1 and 100")}
+	}
+	return nil
+}
+
+// canariesService starts, promotes and aborts canary releases.
+type canariesService struct {
+	*Empire
+}
+
+// Start submits the release to the scheduler as a canary, alongside the
+// release that came before it.
+func (s *canariesService) Start(ctx context.Context, db *gorm.DB, r *Release, percent int, ss scheduler.StatusStream) error {
+	if r.Version == 1 {
+		return &ValidationError{Err: fmt.Errorf("the first release of an app can't be deployed as a canary")}
+	}
+
+	version := r.Version - 1
+	stable, err := releasesFind(db, ReleasesQuery{App: r.App, Version: &version})
+	if err != nil {
+		return err
+	}
+
+	if err := db.Create(&Canary{
+		AppID:           r.App.ID,
+		ReleaseID:       r.ID,
+		StableReleaseID: stable.ID,
+		Percent:         percent,
+	}).Error; err != nil {
+		return err
+	}
+
+	domains, err := schedulerDomains(db, r.App)
+	if err != nil {
+		return err
+	}
+
+	aliases, err := schedulerAliases(db, r.App)
+	if err != nil {
+		return err
+	}
+
+	a := newCanarySchedulerApp(r, stable, percent)
+	a.Domains, a.Stable.Domains = domains, domains
+	a.Aliases, a.Stable.Aliases = aliases, aliases
+
+	return s.Scheduler.Submit(ctx, a, ss)
+}
+
+// Promote replaces the stable release with the canary release.
+func (s *canariesService) Promote(ctx context.Context, db *gorm.DB, opts PromoteOpts) (*Release, error) {
+	c, err := canariesFind(db, opts.App)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := releasesFind(db, idEquals(c.ReleaseID))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := canariesDestroy(db, c); err != nil {
+		return r, err
+	}
+
+	return r, s.releases.Release(ctx, r, nil)
+}
+
+// Abort stops the canary, by creating a new release from the stable release.
+func (s *canariesService) Abort(ctx context.Context, db *gorm.DB, opts AbortOpts) (*Release, error) {
+	c, err := canariesFind(db, opts.App)
+	if err != nil {
+		return nil, err
+	}
+
+	canary, err := releasesFind(db, idEquals(c.ReleaseID))
+	if err != nil {
+		return nil, err
+	}
+
+	stable, err := releasesFind(db, idEquals(c.StableReleaseID))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := canariesDestroy(db, c); err != nil {
+		return nil, err
+	}
+
+	desc := fmt.Sprintf("Abort canary v%d, rollback to v%d", canary.Version, stable.Version)
+	desc = appendMessageToDescription(desc, opts.User, opts.Message)
+	return s.releases.CreateAndRelease(ctx, db, &Release{
+		App:         opts.App,
+		Config:      stable.Config,
+		Slug:        stable.Slug,
+		Formation:   stable.Formation,
+		Description: desc,
+	}, nil)
+}
+
+// canariesFind returns the canary for the app, or ErrNoCanary if there isn't
+// one in progress.
+func canariesFind(db *gorm.DB, app *App) (*Canary, error) {
+	var canary Canary
+	if err := first(db, forApp(app), &canary); err != nil {
+		if err == gorm.RecordNotFound {
+			return nil, ErrNoCanary
+		}
+		return nil, err
+	}
+	return &canary, nil
+}
+
+// canariesDestroy removes the canary.
+func canariesDestroy(db *gorm.DB, canary *Canary) error {
+	return db.Delete(canary).Error
+}
+
+// canaryGuard returns a ValidationError if the app has a canary in progress.
+func canaryGuard(db *gorm.DB, app *App) error {
+	_, err := canariesFind(db, app)
+	if err == ErrNoCanary {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return &ValidationError{Err: fmt.Errorf("%s has a canary in progress, which needs to be promoted or aborted first", app.Name)}
+}
+
+// newCanarySchedulerApp returns a scheduler.App for the canary release, which
+// will be run alongside the stable release.
+func newCanarySchedulerApp(release, stable *Release, percent int) *scheduler.App {
+	a := newSchedulerApp(release)
+	a.Stable = newSchedulerApp(stable)
+
+	stableProcesses := make(map[string]*scheduler.Process)
+	for _, p := range a.Stable.Processes {
+		stableProcesses[p.Type] = p
+	}
+
+	for _, p := range a.Processes {
+		p.Instances = canaryInstances(p.Instances, percent)
+
+		// For canaries, the stable process is scaled down to make room
+		// for the canary instances, but we always leave at least 1
+		// stable instance running. For blue/green, both run at full
+		// scale.
+		if sp, ok := stableProcesses[p.Type]; ok && percent < 100 {
+			if p.Instances < sp.Instances {
+				sp.Instances -= p.Instances
+			} else if sp.Instances > 0 {
+				sp.Instances = 1
+			}
+		}
+	}
+
+	return a
+}
+
+// canaryInstances returns the number of instances that should run the canary
+// release, rounding up so that at least 1 instance runs the canary.
+func canaryInstances(instances uint, percent int) uint {
+	if percent >= 100 {
+		return instances
+	}
+	return (instances*uint(percent) + 99) / 100
+}
@@ -0,0 +1,87 @@
+package empire
+
+import (
+	"testing"
+
+	"github.com/remind101/empire/pkg/image"
+	"github.com/remind101/empire/scheduler"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeployStrategy_Validate(t *testing.T) {
+	tests := []struct {
+		strategy DeployStrategy
+		valid    bool
+	}{
+		{DeployStrategy{}, true},
+		{DeployStrategy{Canary: 10}, true},
+		{DeployStrategy{Canary: 100}, true},
+		{DeployStrategy{BlueGreen: true}, true},
+		{DeployStrategy{Canary: -1}, false},
+		{DeployStrategy{Canary: 101}, false},
+		{DeployStrategy{Canary: 10, BlueGreen: true}, false},
+	}
+
+	for _, tt := range tests {
+		err := tt.strategy.Validate()
+		if tt.valid {
+			assert.NoError(t, err)
+		} else {
+			assert.IsType(t, &ValidationError{}, err)
+		}
+	}
+}
+
+func TestCanaryInstances(t *testing.T) {
+	tests := []struct {
+		instances uint
+		percent   int
+		out       uint
+	}{
+		{10, 10, 1},
+		{10, 25, 3},
+		{1, 10, 1},
+		{0, 10, 0},
+		{10, 100, 10},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.out, canaryInstances(tt.instances, tt.percent))
+	}
+}
+
+func TestNewCanarySchedulerApp(t *testing.T) {
+	app := &App{ID: "1234", Name: "acme-inc"}
+	newRelease := func(version int, tag string, web, worker int) *Release {
+		return &Release{
+			App:     app,
+			Version: version,
+			Config:  &Config{Vars: Vars{}},
+			Slug:    &Slug{Image: image.Image{Repository: "remind101/acme-inc", Tag: tag}},
+			Formation: Formation{
+				"web":    Process{Command: Command{"./bin/web"}, Quantity: web},
+				"worker": Process{Command: Command{"./bin/worker"}, Quantity: worker},
+			},
+		}
+	}
+
+	instances := func(a *scheduler.App) map[string]uint {
+		m := make(map[string]uint)
+		for _, p := range a.Processes {
+			m[p.Type] = p.Instances
+		}
+		return m
+	}
+
+	canary := newCanarySchedulerApp(newRelease(2, "v2", 10, 1), newRelease(1, "v1", 10, 1), 10)
+	assert.Equal(t, "v2", canary.Release)
+	assert.Equal(t, map[string]uint{"web": 1, "worker": 1}, instances(canary))
+	if assert.NotNil(t, canary.Stable) {
+		assert.Equal(t, "v1", canary.Stable.Release)
+		assert.Equal(t, map[string]uint{"web": 9, "worker": 1}, instances(canary.Stable))
+	}
+
+	blueGreen := newCanarySchedulerApp(newRelease(2, "v2", 10, 1), newRelease(1, "v1", 10, 1), 100)
+	assert.Equal(t, map[string]uint{"web": 10, "worker": 1}, instances(blueGreen))
+	assert.Equal(t, map[string]uint{"web": 10, "worker": 1}, instances(blueGreen.Stable))
+}
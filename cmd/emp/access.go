@@ -0,0 +1,135 @@
+package main
+
+import (
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/remind101/empire/pkg/heroku"
+)
+
+var cmdAccess = &Command{
+	Run:      runAccess,
+	Usage:    "access",
+	Category: "access",
+	Short:    "list access grants",
+	Long: `
+Lists the roles that are granted to users and GitHub teams. Roles are only
+checked when Empire is started with --rbac.
+
+Examples:
+
+    $ emp access
+    acme-*    remind101/engineering  deployer
+    acme-inc  ejholmes               admin
+`,
+}
+
+func runAccess(cmd *Command, args []string) {
+	if len(args) != 0 {
+		cmd.PrintUsage()
+		os.Exit(2)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 1, 2, 2, ' ', 0)
+	defer w.Flush()
+
+	grants, err := client.AccessGrantList()
+	must(err)
+
+	for _, g := range grants {
+		listRec(w, g.App, grantPrincipal(g), g.Role)
+	}
+}
+
+var cmdAccessAdd = &Command{
+	Run:      runAccessAdd,
+	Usage:    "access-add <user|org/team> <role> <app>",
+	Category: "access",
+	Short:    "grant a role on apps",
+	Long: `
+Grants a role on an app, or on all of the apps matching a pattern, to a user
+or a GitHub team. Roles are:
+
+    viewer    can view apps, releases, config vars and logs
+    deployer  can also deploy, rollback, scale, restart, run and set config vars
+    admin     can also create and destroy apps, manage domains and grant access
+
+Examples:
+
+    $ emp access-add ejholmes admin acme-inc
+    Granted admin on acme-inc to ejholmes.
+
+    $ emp access-add remind101/engineering deployer 'acme-*'
+    Granted deployer on acme-* to remind101/engineering.
+`,
+}
+
+func runAccessAdd(cmd *Command, args []string) {
+	if len(args) != 3 {
+		cmd.PrintUsage()
+		os.Exit(2)
+	}
+
+	principal, role, app := args[0], args[1], args[2]
+	opts := heroku.AccessGrantCreateOpts{
+		App:  app,
+		Role: role,
+	}
+	if strings.Contains(principal, "/") {
+		opts.Team = principal
+	} else {
+		opts.User = principal
+	}
+
+	_, err := client.AccessGrantCreate(opts)
+	must(err)
+	log.Printf("Granted %s on %s to %s.", role, app, principal)
+}
+
+var cmdAccessRemove = &Command{
+	Run:      runAccessRemove,
+	Usage:    "access-remove <user|org/team> <app>",
+	Category: "access",
+	Short:    "revoke a role on apps",
+	Long: `
+Revokes the role granted to a user or a GitHub team on an app, or on the apps
+matching a pattern.
+
+Examples:
+
+    $ emp access-remove remind101/engineering 'acme-*'
+    Revoked access on acme-* from remind101/engineering.
+`,
+}
+
+func runAccessRemove(cmd *Command, args []string) {
+	if len(args) != 2 {
+		cmd.PrintUsage()
+		os.Exit(2)
+	}
+
+	principal, app := args[0], args[1]
+
+	grants, err := client.AccessGrantList()
+	must(err)
+
+	for _, g := range grants {
+		if g.App == app && grantPrincipal(g) == principal {
+			must(client.AccessGrantDelete(g.Id))
+			log.Printf("Revoked access on %s from %s.", app, principal)
+			return
+		}
+	}
+
+	printFatal("%s has no access grant on %s", principal, app)
+}
+
+// grantPrincipal returns the user or team that an access grant applies to.
+func grantPrincipal(g heroku.AccessGrant) string {
+	if g.Team != "" {
+		return g.Team
+	}
+	return g.User
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/remind101/empire/pkg/heroku"
+)
+
+var cmdAutoscale = &Command{
+	Run:             maybeMessage(runAutoscale),
+	Usage:           "autoscale [<type> min=<n> max=<n> [cpu=<percent>] [memory=<percent>] [scale-in-cooldown=<sec>] [scale-out-cooldown=<sec>]]",
+	NeedsApp:        true,
+	OptionalMessage: true,
+	Category:        "dyno",
+	Short:           "scale dynos automatically",
+	Long: `
+Autoscale sets the autoscaling policy of a process type. The quantity of
+the process is scaled between min and max, to keep the average CPU or
+memory utilization of its dynos near the target percentage. The
+cooldowns are the number of seconds to wait after scaling in, or out,
+before scaling in the same direction again.
+
+Without arguments, the autoscaling policy of each process type is shown.
+
+Options:
+
+    --disable disable autoscaling of the process type. The process stays
+    at its current quantity.
+
+Examples:
+
+    $ emp autoscale web min=2 max=20 cpu=60
+    Autoscaled web on myapp with min=2 max=20 cpu=60.
+
+    $ emp autoscale
+    TYPE    QUANTITY  MIN  MAX  CPU  MEMORY
+    web     2         2    20   60%  -
+    worker  1         -    -    -    -
+
+    $ emp autoscale web --disable
+    Disabled autoscaling of web on myapp.
+`,
+}
+
+var flagAutoscaleDisable bool
+
+func init() {
+	cmdAutoscale.Flag.BoolVar(&flagAutoscaleDisable, "disable", false, "disable autoscaling")
+}
+
+func runAutoscale(cmd *Command, args []string) {
+	appname := mustApp()
+
+	if len(args) == 0 && !flagAutoscaleDisable {
+		listAutoscaling(appname)
+		return
+	}
+
+	if len(args) == 0 {
+		cmd.PrintUsage()
+		os.Exit(2)
+	}
+
+	pstype := args[0]
+
+	if flagAutoscaleDisable {
+		if len(args) != 1 {
+			cmd.PrintUsage()
+			os.Exit(2)
+		}
+		must(client.FormationAutoscaleDisable(appname, pstype, getMessage()))
+		log.Printf("Disabled autoscaling of %s on %s.", pstype, appname)
+		return
+	}
+
+	autoscaling, err := parseAutoscaleArgs(args[1:])
+	if err != nil {
+		printError("%s", err)
+		cmd.PrintUsage()
+		os.Exit(2)
+	}
+
+	f, err := client.FormationAutoscale(appname, pstype, autoscaling, getMessage())
+	must(err)
+	log.Printf("Autoscaled %s on %s with %s.", f.Type, appname, strings.Join(args[1:], " "))
+}
+
+func listAutoscaling(appname string) {
+	f, err := client.FormationList(appname, nil)
+	must(err)
+
+	formations := formationsByType(f)
+	sort.Sort(formations)
+
+	percent := func(v int) string {
+		if v == 0 {
+			return "-"
+		}
+		return fmt.Sprintf("%d%%", v)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 1, 2, 2, ' ', 0)
+	defer w.Flush()
+	listRec(w, "TYPE", "QUANTITY", "MIN", "MAX", "CPU", "MEMORY")
+	for _, f := range formations {
+		a := f.Autoscaling
+		if a == nil {
+			listRec(w, f.Type, f.Quantity, "-", "-", "-", "-")
+			continue
+		}
+		listRec(w, f.Type, f.Quantity, a.Min, a.Max, percent(a.CPU), percent(a.Memory))
+	}
+}
+
+// parseAutoscaleArgs parses arguments of the form "min=2", "max=20", "cpu=60".
+func parseAutoscaleArgs(args []string) (*heroku.FormationAutoscaling, error) {
+	a := new(heroku.FormationAutoscaling)
+	seen := make(map[string]bool)
+	for _, arg := range args {
+		parts := strings.SplitN(arg, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid argument: %s", arg)
+		}
+		key, value := strings.ToLower(parts[0]), parts[1]
+
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for %s: %s", key, value)
+		}
+
+		switch key {
+		case "min":
+			a.Min = n
+		case "max":
+			a.Max = n
+		case "cpu":
+			a.CPU = n
+		case "memory", "mem":
+			a.Memory = n
+		case "scale-in-cooldown":
+			a.ScaleInCooldown = n
+		case "scale-out-cooldown":
+			a.ScaleOutCooldown = n
+		default:
+			return nil, fmt.Errorf("unknown setting: %s", key)
+		}
+		seen[key] = true
+	}
+
+	if !seen["min"] || !seen["max"] {
+		return nil, fmt.Errorf("min and max are required")
+	}
+
+	return a, nil
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/remind101/empire/pkg/heroku"
+)
+
+var parseAutoscaleTests = []struct {
+	in  []string
+	out *heroku.FormationAutoscaling
+	err bool
+}{
+	{[]string{"min=2", "max=20", "cpu=60"}, &heroku.FormationAutoscaling{Min: 2, Max: 20, CPU: 60}, false},
+	{[]string{"min=0", "max=5", "memory=80", "scale-in-cooldown=600", "scale-out-cooldown=30"}, &heroku.FormationAutoscaling{Max: 5, Memory: 80, ScaleInCooldown: 600, ScaleOutCooldown: 30}, false},
+	{[]string{"max=20", "cpu=60"}, nil, true},
+	{[]string{"min=2", "max=20", "cpu"}, nil, true},
+	{[]string{"min=2", "max=20", "cpu=high"}, nil, true},
+	{[]string{"min=2", "max=20", "disk=60"}, nil, true},
+}
+
+func TestParseAutoscaleArgs(t *testing.T) {
+	for i, tt := range parseAutoscaleTests {
+		out, err := parseAutoscaleArgs(tt.in)
+		if tt.err != (err != nil) {
+			t.Errorf("%d. parseAutoscaleArgs(%q).err => %v", i, tt.in, err)
+		}
+		if !reflect.DeepEqual(out, tt.out) {
+			t.Errorf("%d. parseAutoscaleArgs(%q) => %#v, want %#v", i, tt.in, out, tt.out)
+		}
+	}
+}
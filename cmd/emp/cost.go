@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/remind101/empire/pkg/heroku"
+)
+
+var costMonths int
+
+var cmdCost = &Command{
+	Run:         runCost,
+	Usage:       "cost [--months <n>]",
+	OptionalApp: true,
+	Category:    "emp",
+	Short:       "show estimated costs",
+	Long: `
+Shows how much apps are estimated to cost, based on the memory and CPU
+shares of their processes, and the load balancers of exposed processes.
+Prices are configured by the operator of Empire.
+
+When an app is given, or found from the git remote, the estimated cost of
+each of its processes is shown, with the estimated cost of each month.
+Otherwise, every app is listed, most expensive first.
+
+Options:
+
+    --months <n>  number of months of history to show (default 6)
+
+Examples:
+
+    $ emp cost -a acme-inc
+    TYPE    QUANTITY  SIZE  LB   MONTHLY
+    web     2         1X    yes  62.05
+    worker  1         PX    -    118.99
+
+    Estimated monthly cost: 181.04
+
+    MONTH    COST
+    2016-01  170.20
+    2016-02  60.10
+
+    $ emp cost
+    APP       MONTHLY  THIS MONTH
+    acme-inc  181.04   60.10
+    other     12.41    4.03
+`,
+}
+
+func init() {
+	cmdCost.Flag.IntVar(&costMonths, "months", 0, "number of months of history to show")
+}
+
+func runCost(cmd *Command, args []string) {
+	if len(args) != 0 {
+		cmd.PrintUsage()
+		os.Exit(2)
+	}
+
+	if appName, _ := app(); appName != "" {
+		c, err := client.AppCostInfo(appName, costMonths)
+		must(err)
+		printAppCost(c)
+		return
+	}
+
+	costs, err := client.AppCostList(costMonths)
+	must(err)
+
+	w := tabwriter.NewWriter(os.Stdout, 1, 2, 2, ' ', 0)
+	defer w.Flush()
+	listRec(w, "APP", "MONTHLY", "THIS MONTH")
+	for _, c := range costs {
+		listRec(w, c.App.Name, formatCost(c.Monthly), formatCost(thisMonth(c)))
+	}
+}
+
+func printAppCost(c *heroku.AppCost) {
+	w := tabwriter.NewWriter(os.Stdout, 1, 2, 2, ' ', 0)
+	listRec(w, "TYPE", "QUANTITY", "SIZE", "LB", "MONTHLY")
+	for _, p := range c.Processes {
+		lb := "-"
+		if p.LoadBalancer {
+			lb = "yes"
+		}
+		listRec(w, p.Type, p.Quantity, p.Size, lb, formatCost(p.Monthly))
+	}
+	w.Flush()
+
+	fmt.Printf("\nEstimated monthly cost: %s\n\n", formatCost(c.Monthly))
+
+	w = tabwriter.NewWriter(os.Stdout, 1, 2, 2, ' ', 0)
+	listRec(w, "MONTH", "COST")
+	for _, m := range c.History {
+		listRec(w, m.Month.Format("2006-01"), formatCost(m.Cost))
+	}
+	w.Flush()
+}
+
+// thisMonth returns the estimated cost of the current month so far.
+func thisMonth(c heroku.AppCost) float64 {
+	if len(c.History) == 0 {
+		return 0
+	}
+	return c.History[len(c.History)-1].Cost
+}
+
+func formatCost(cost float64) string {
+	return fmt.Sprintf("%.2f", cost)
+}
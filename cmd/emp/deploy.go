@@ -10,11 +10,16 @@ import (
 	"github.com/remind101/empire/pkg/heroku"
 )
 
-var stream bool
+var (
+	stream     bool
+	canary     int
+	blueGreen  bool
+	deployPlan bool
+)
 
 var cmdDeploy = &Command{
 	Run:             maybeMessage(runDeploy),
-	Usage:           "deploy [<registry>]<image>:[<tag>] [-s]",
+	Usage:           "deploy [<registry>]<image>:[<tag>] [-s] [--canary <percent> | --blue-green | --plan]",
 	OptionalApp:     true,
 	OptionalMessage: true,
 	Category:        "deploy",
@@ -28,6 +33,18 @@ Options:
     command will wait until the scheduler has finished deploying the new
     release.
 
+    --canary <percent> deploy the image as a canary, alongside the current
+    release, with the given percentage of instances for each process. Once
+    the canary looks good, use "emp promote" to finish the deploy, or "emp
+    abort" to roll back.
+
+    --blue-green deploy the image alongside the current release, with a full
+    set of instances. Like a canary, use "emp promote" or "emp abort" to
+    finish the deploy.
+
+    --plan show the changes that the deploy would make to the app's
+    resources (load balancers, services, etc), without deploying anything.
+
 Examples:
 
     $ emp deploy remind101/acme-inc:latest
@@ -43,16 +60,31 @@ Examples:
     Status: Created new release v1 for acme-inc
     $ emp releases
     v1    Jan 1 12:55  Deploy remind101/acme-inc:latest
+    $ emp deploy remind101/acme-inc:v2 --canary 10
+    ...
+    Status: Created new release v2 for acme-inc
+    Status: Started canary for release v2 of acme-inc (10%), use ` + "`emp promote`" + ` or ` + "`emp abort`" + ` to finish the deploy
+    $ emp deploy remind101/acme-inc:v3 --plan
+    ...
+    Status: Replace webLoadBalancer (AWS::ElasticLoadBalancing::LoadBalancer)
+    Status: Modify web (AWS::ECS::Service)
+    Status: Planned release v3 for acme-inc, nothing was deployed
 `,
 }
 
 func init() {
 	cmdDeploy.Flag.BoolVarP(&stream, "stream", "s", false, "boolean to enable the status stream")
+	cmdDeploy.Flag.IntVar(&canary, "canary", 0, "deploy as a canary with this percentage of instances")
+	cmdDeploy.Flag.BoolVar(&blueGreen, "blue-green", false, "deploy alongside the current release with a full set of instances")
+	cmdDeploy.Flag.BoolVar(&deployPlan, "plan", false, "show the changes that the deploy would make, without deploying")
 }
 
 type PostDeployForm struct {
-	Image  string `json:"image"`
-	Stream bool   `json:"stream"`
+	Image     string `json:"image"`
+	Stream    bool   `json:"stream"`
+	Canary    int    `json:"canary,omitempty"`
+	BlueGreen bool   `json:"blue_green,omitempty"`
+	Plan      bool   `json:"plan,omitempty"`
 }
 
 func runDeploy(cmd *Command, args []string) {
@@ -64,7 +96,14 @@ func runDeploy(cmd *Command, args []string) {
 
 	image := args[0]
 	message := getMessage()
-	form := &PostDeployForm{Image: image, Stream: stream}
+	if canary != 0 && blueGreen {
+		printFatal("--canary and --blue-green can't be used together")
+	}
+	if deployPlan && (canary != 0 || blueGreen) {
+		printFatal("--plan can't be used with --canary or --blue-green")
+	}
+
+	form := &PostDeployForm{Image: image, Stream: stream, Canary: canary, BlueGreen: blueGreen, Plan: deployPlan}
 
 	var endpoint string
 	appName, _ := app()
@@ -52,6 +52,19 @@ var cmdDomainAdd = &Command{
 	NeedsApp: true,
 	Category: "domain",
 	Short:    "add a domain",
+	Long: `
+Adds a domain to an app, which makes the app public. When Empire is using
+Application Load Balancers, the domain can include a path, in which case only
+requests for paths under it are routed to the app.
+
+Examples:
+
+    $ emp domain-add www.test.com
+    Added www.test.com to test.
+
+    $ emp domain-add api.test.com/v2
+    Added api.test.com/v2 to test.
+`,
 }
 
 func runDomainAdd(cmd *Command, args []string) {
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+
+	"github.com/remind101/empire/pkg/heroku"
+)
+
+var cmdDrift = &Command{
+	Run:             maybeMessage(runDrift),
+	Usage:           "drift [--fix]",
+	NeedsApp:        true,
+	OptionalMessage: true,
+	Category:        "app",
+	Short:           "check for drift from the latest release",
+	Long: `
+Drift compares the formation of the latest release of an app with what's
+running in the scheduler. For each process, it shows the quantity in the
+formation, the number of instances the scheduler is configured to run (if the
+scheduler reports it), the number of instances that are running, and how many
+of those belong to an older release.
+
+Options:
+
+    --fix re-submit the latest release to the scheduler if the app has
+    drifted.
+
+Examples:
+
+    $ emp drift -a acme-inc
+    TYPE    QUANTITY  DESIRED  RUNNING  OUTDATED
+    web     5         3        3        0         drifted
+    worker  1         1        1        0
+    $ emp drift -a acme-inc --fix
+    ...
+    Reconciled acme-inc with v12.
+`,
+}
+
+var flagDriftFix bool
+
+func init() {
+	cmdDrift.Flag.BoolVar(&flagDriftFix, "fix", false, "re-submit the latest release if the app has drifted")
+}
+
+func runDrift(cmd *Command, args []string) {
+	if len(args) != 0 {
+		cmd.PrintUsage()
+		os.Exit(2)
+	}
+
+	appname := mustApp()
+
+	var drift *heroku.Drift
+	var err error
+	if flagDriftFix {
+		drift, err = client.DriftReconcile(appname, getMessage())
+	} else {
+		drift, err = client.DriftInfo(appname)
+	}
+	must(err)
+
+	w := tabwriter.NewWriter(os.Stdout, 1, 2, 2, ' ', 0)
+	listRec(w, "TYPE", "QUANTITY", "DESIRED", "RUNNING", "OUTDATED", "")
+	for _, p := range drift.Processes {
+		desired := "-"
+		if p.Desired != nil {
+			desired = fmt.Sprintf("%d", *p.Desired)
+		}
+		status := ""
+		if p.Drifted {
+			status = "drifted"
+		}
+		listRec(w, p.Type, p.Quantity, desired, p.Running, p.Outdated, status)
+	}
+	w.Flush()
+
+	switch {
+	case !drift.Drifted:
+		log.Printf("No drift from v%d.", drift.Release)
+	case flagDriftFix:
+		log.Printf("Reconciled %s with v%d.", appname, drift.Release)
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/remind101/empire/pkg/heroku"
+)
+
+var (
+	historyCount  int
+	historyUser   string
+	historyType   string
+	historySince  string
+	historyStatus string
+)
+
+var cmdHistory = &Command{
+	Run:         runHistory,
+	Usage:       "history [-n <limit>] [--user <user>] [--type <type>] [--since <time>] [--status <status>]",
+	OptionalApp: true,
+	Category:    "emp",
+	Short:       "show the audit log of events",
+	Long: `
+Shows the most recent events (deploys, scales, config changes, etc) from the
+audit log. When an app is given, or found from the git remote, only events
+for that app are shown.
+
+Options:
+
+    -n <limit>        maximum number of events to display
+    --user <user>     only show events triggered by this user
+    --type <type>     only show events of this type (e.g. deploy, scale, set)
+    --since <time>    only show events since a duration ago (e.g. 24h), or an
+                      RFC3339 timestamp
+    --status <status> only show events with this delivery status (pending,
+                      delivered or dead). Pending and dead events are shown
+                      with their id, attempts and last error.
+
+Examples:
+
+    $ emp history -a acme-inc
+    Jun 13 18:14  acme-inc  deploy  ejholmes  ejholmes deployed remind101/acme-inc:master to acme-inc
+    Jun 13 18:31  acme-inc  scale   ejholmes  ejholmes scaled web on acme-inc from 1 to 2
+
+    $ emp history --user ejholmes --since 24h
+
+    $ emp history --status dead
+    3f9c4f6e-...  Jun 13 18:14  acme-inc  deploy  10  unexpected response: 500 Internal Server Error
+`,
+}
+
+func init() {
+	cmdHistory.Flag.IntVarP(&historyCount, "number", "n", 20, "max number of recent events to display")
+	cmdHistory.Flag.StringVar(&historyUser, "user", "", "only show events triggered by this user")
+	cmdHistory.Flag.StringVar(&historyType, "type", "", "only show events of this type")
+	cmdHistory.Flag.StringVar(&historySince, "since", "", "only show events since a duration ago, or a timestamp")
+	cmdHistory.Flag.StringVar(&historyStatus, "status", "", "only show events with this delivery status")
+}
+
+func runHistory(cmd *Command, args []string) {
+	if len(args) != 0 {
+		cmd.PrintUsage()
+		os.Exit(2)
+	}
+
+	appName, _ := app()
+	opts := &heroku.EventListOpts{
+		App:    appName,
+		User:   historyUser,
+		Type:   historyType,
+		Status: historyStatus,
+	}
+
+	if historySince != "" {
+		since, err := parseSince(historySince)
+		if err != nil {
+			printFatal("invalid --since %q: must be a duration (e.g. 24h) or an RFC3339 timestamp", historySince)
+		}
+		opts.Since = &since
+	}
+
+	events, err := client.EventList(opts, &heroku.ListRange{
+		Field:      "created_at",
+		Max:        historyCount,
+		Descending: true,
+	})
+	must(err)
+
+	w := tabwriter.NewWriter(os.Stdout, 1, 2, 2, ' ', 0)
+	defer w.Flush()
+
+	// Display the oldest events first, like `emp releases`.
+	for i := len(events) - 1; i >= 0; i-- {
+		e := events[i]
+		if historyStatus == "pending" || historyStatus == "dead" {
+			listRec(w, e.Id, prettyTime{e.CreatedAt}, e.App, e.Type, e.Attempts, e.LastError)
+			continue
+		}
+		listRec(w, prettyTime{e.CreatedAt}, e.App, e.Type, e.User, e.Message)
+	}
+}
+
+var cmdEventRequeue = &Command{
+	Run:      runEventRequeue,
+	Usage:    "event-requeue <id>",
+	Category: "emp",
+	Short:    "retry delivery of a dead event",
+	Long: `
+Requeues an event that failed to be delivered to the events backend, so that
+delivery is attempted again. Dead events can be found with
+` + "`emp history --status dead`" + `.
+
+Examples:
+
+    $ emp event-requeue 3f9c4f6e-7d1b-4c1e-9d4a-2b0e6a7f3c11
+    Requeued event 3f9c4f6e-7d1b-4c1e-9d4a-2b0e6a7f3c11.
+`,
+}
+
+func runEventRequeue(cmd *Command, args []string) {
+	if len(args) != 1 {
+		cmd.PrintUsage()
+		os.Exit(2)
+	}
+
+	id := args[0]
+	must(client.EventRequeue(id))
+	log.Printf("Requeued event %s.", id)
+}
+
+// parseSince parses a duration before now, or an RFC3339 timestamp.
+func parseSince(since string) (time.Time, error) {
+	if d, err := time.ParseDuration(since); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	return time.Parse(time.RFC3339, since)
+}
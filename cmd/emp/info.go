@@ -24,4 +24,5 @@ func runInfo(cmd *Command, args []string) {
 	fmt.Printf("Name: %s\n", app.Name)
 	fmt.Printf("ID:   %s\n", app.Id)
 	fmt.Printf("Cert: %s\n", app.Cert)
+	fmt.Printf("Auto rollback: %s\n", onOff(app.AutoRollback))
 }
@@ -111,12 +111,22 @@ var commands = []*Command{
 	cmdReleases,
 	cmdReleaseInfo,
 	cmdRollback,
+	cmdPromote,
+	cmdAbort,
+	cmdAutoRollback,
 	cmdScale,
+	cmdAutoscale,
+	cmdScheduleScale,
+	cmdScheduleScales,
+	cmdScheduleScaleRemove,
+	cmdSizes,
 	cmdRestart,
 	cmdEnvLoad,
 	cmdSet,
 	cmdUnset,
 	cmdEnv,
+	cmdEnvHistory,
+	cmdEnvDiff,
 	cmdRun,
 	cmdLog,
 	cmdInfo,
@@ -127,6 +137,16 @@ var commands = []*Command{
 	cmdDomainRemove,
 	cmdCertAttach,
 	cmdDeploy,
+	cmdApply,
+	cmdExport,
+	cmdPlan,
+	cmdDrift,
+	cmdAccess,
+	cmdAccessAdd,
+	cmdAccessRemove,
+	cmdHistory,
+	cmdCost,
+	cmdEventRequeue,
 	cmdVersion,
 	cmdHelp,
 
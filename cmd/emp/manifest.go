@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+
+	"github.com/remind101/empire/pkg/heroku"
+	"gopkg.in/yaml.v2"
+)
+
+var cmdApply = &Command{
+	Run:             maybeMessage(runApply),
+	Usage:           "apply -f <file>",
+	OptionalApp:     true,
+	OptionalMessage: true,
+	Category:        "deploy",
+	Short:           "apply an app manifest",
+	Long: `
+Apply makes an app match a YAML manifest, creating the app if it doesn't exist.
+Only what differs from the current state of the app is changed, and all of the
+changes are applied atomically. Fields that are omitted from the manifest are
+left unchanged.
+
+Use "emp export" to generate a manifest from an existing app. Secrets are
+exported as "[secret]", which keeps the existing value.
+
+Options:
+
+    -f <file> the manifest to apply, or - to read from stdin.
+
+Example:
+
+    $ cat empire.yml
+    name: acme-inc
+    image: remind101/acme-inc:v2
+    config:
+      RAILS_ENV: production
+    formation:
+      web:
+        quantity: 2
+        size: 2X
+    domains:
+    - acme-inc.example.com
+    $ emp apply -f empire.yml
+    deploy remind101/acme-inc:v2
+    set RAILS_ENV
+    scale web from 1(1X) to 2(2X)
+    Applied 3 changes to acme-inc.
+`,
+}
+
+var flagManifestFile string
+
+func init() {
+	cmdApply.Flag.StringVarP(&flagManifestFile, "file", "f", "", "manifest file")
+}
+
+func runApply(cmd *Command, args []string) {
+	if len(args) != 0 || flagManifestFile == "" {
+		cmd.PrintUsage()
+		os.Exit(2)
+	}
+
+	var raw []byte
+	var err error
+	if flagManifestFile == "-" {
+		raw, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		raw, err = ioutil.ReadFile(flagManifestFile)
+	}
+	must(err)
+
+	var manifest heroku.Manifest
+	if err := yaml.Unmarshal(raw, &manifest); err != nil {
+		printFatal("invalid manifest: %v", err)
+	}
+
+	if manifest.Name == "" {
+		manifest.Name = mustApp()
+	}
+
+	changes, err := client.ManifestApply(manifest.Name, &manifest, getMessage())
+	must(err)
+
+	if len(changes) == 0 {
+		log.Printf("%s is up to date.", manifest.Name)
+		return
+	}
+
+	for _, c := range changes {
+		fmt.Println(c.Description)
+	}
+	log.Printf("Applied %d changes to %s.", len(changes), manifest.Name)
+}
+
+var cmdExport = &Command{
+	Run:      runExport,
+	Usage:    "export",
+	NeedsApp: true,
+	Category: "app",
+	Short:    "export an app manifest",
+	Long: `
+Export prints a YAML manifest describing the current state of an app, which can
+be applied with "emp apply".
+
+Example:
+
+    $ emp export -a acme-inc > empire.yml
+`,
+}
+
+func runExport(cmd *Command, args []string) {
+	if len(args) != 0 {
+		cmd.PrintUsage()
+		os.Exit(2)
+	}
+
+	manifest, err := client.ManifestInfo(mustApp())
+	must(err)
+
+	raw, err := yaml.Marshal(manifest)
+	must(err)
+
+	os.Stdout.Write(raw)
+}
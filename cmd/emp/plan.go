@@ -0,0 +1,50 @@
+package main
+
+import (
+	"os"
+	"text/tabwriter"
+)
+
+var cmdPlan = &Command{
+	Run:      runPlan,
+	Usage:    "plan",
+	NeedsApp: true,
+	Category: "deploy",
+	Short:    "preview changes to an app's resources",
+	Long: `
+Plan shows the changes that re-releasing the current release of an app would
+make to its resources (load balancers, services, etc), without making them.
+Resources that would be replaced are shown as "Replace"; when that depends on
+values that can't be known until the change is made, "(conditional)" is shown.
+
+To preview the changes that deploying a new image would make, use "emp deploy
+--plan".
+
+Examples:
+
+    $ emp plan
+    Modify   web              AWS::ECS::Service                        arn:aws:ecs:us-east-1:012345678910:service/acme-inc-web
+    Replace  webLoadBalancer  AWS::ElasticLoadBalancing::LoadBalancer  acme-inc-web
+`,
+}
+
+func runPlan(cmd *Command, args []string) {
+	if len(args) != 0 {
+		cmd.PrintUsage()
+		os.Exit(2)
+	}
+
+	plan, err := client.ResourcePlanInfo(mustApp())
+	must(err)
+
+	w := tabwriter.NewWriter(os.Stdout, 1, 2, 2, ' ', 0)
+	defer w.Flush()
+
+	for _, c := range plan.Changes {
+		action := c.Action
+		if c.Conditional {
+			action += " (conditional)"
+		}
+		listRec(w, action, c.Name, c.Type, c.ID)
+	}
+}
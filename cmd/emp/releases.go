@@ -230,3 +230,126 @@ func runRollback(cmd *Command, args []string) {
 	must(err)
 	log.Printf("Rolled back %s to v%s as v%d.\n", appname, ver, rel.Version)
 }
+
+var cmdPromote = &Command{
+	Run:             maybeMessage(runPromote),
+	Usage:           "promote",
+	NeedsApp:        true,
+	OptionalMessage: true,
+	Category:        "release",
+	Short:           "promote a canary release",
+	Long: `
+Promote replaces the stable release of an app with the canary release that
+was started with "emp deploy --canary" or "emp deploy --blue-green".
+
+Examples:
+
+    $ emp promote
+    Promoted v7 on myapp.
+`,
+}
+
+func runPromote(cmd *Command, args []string) {
+	appname := mustApp()
+	message := getMessage()
+	if len(args) != 0 {
+		cmd.PrintUsage()
+		os.Exit(2)
+	}
+	rel, err := client.ReleasePromote(appname, message)
+	must(err)
+	log.Printf("Promoted v%d on %s.\n", rel.Version, appname)
+}
+
+var cmdAbort = &Command{
+	Run:             maybeMessage(runAbort),
+	Usage:           "abort",
+	NeedsApp:        true,
+	OptionalMessage: true,
+	Category:        "release",
+	Short:           "abort a canary release",
+	Long: `
+Abort stops the canary release of an app, and rolls back to the stable
+release. This action creates a new release based on the stable release.
+
+Examples:
+
+    $ emp abort
+    Aborted the canary on myapp, rolled back as v8.
+`,
+}
+
+func runAbort(cmd *Command, args []string) {
+	appname := mustApp()
+	message := getMessage()
+	if len(args) != 0 {
+		cmd.PrintUsage()
+		os.Exit(2)
+	}
+	rel, err := client.ReleaseAbort(appname, message)
+	must(err)
+	log.Printf("Aborted the canary on %s, rolled back as v%d.\n", appname, rel.Version)
+}
+
+var cmdAutoRollback = &Command{
+	Run:      runAutoRollback,
+	Usage:    "auto-rollback [on|off]",
+	NeedsApp: true,
+	Category: "release",
+	Short:    "enable or disable automatic rollbacks",
+	Long: `
+Auto-rollback shows or changes whether deploys to an app are rolled back
+automatically. When enabled, a deploy that fails to stabilize (e.g. because
+the new processes are crash looping) is rolled back to the previous release.
+
+Examples:
+
+    $ emp auto-rollback
+    Auto rollback is off for myapp.
+
+    $ emp auto-rollback on
+    Enabled auto rollback for myapp.
+`,
+}
+
+func runAutoRollback(cmd *Command, args []string) {
+	appname := mustApp()
+	if len(args) == 0 {
+		app, err := client.AppInfo(appname)
+		must(err)
+		log.Printf("Auto rollback is %s for %s.\n", onOff(app.AutoRollback), appname)
+		return
+	}
+	if len(args) != 1 {
+		cmd.PrintUsage()
+		os.Exit(2)
+	}
+
+	var enabled bool
+	switch args[0] {
+	case "on":
+		enabled = true
+	case "off":
+		enabled = false
+	default:
+		cmd.PrintUsage()
+		os.Exit(2)
+	}
+
+	_, err := client.AppUpdate(appname, &heroku.AppUpdateOpts{
+		AutoRollback: &enabled,
+	})
+	must(err)
+	if enabled {
+		log.Printf("Enabled auto rollback for %s.\n", appname)
+	} else {
+		log.Printf("Disabled auto rollback for %s.\n", appname)
+	}
+}
+
+func onOff(b bool) string {
+	if b {
+		return "on"
+	}
+	return "off"
+}
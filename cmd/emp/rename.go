@@ -3,21 +3,24 @@ package main
 import (
 	"log"
 	"os"
-
-	"github.com/remind101/empire/pkg/heroku"
 )
 
 var cmdRename = &Command{
-	Run:      runRename,
-	Usage:    "rename <oldname> <newname>",
-	Category: "app",
-	Short:    "rename an app",
+	Run:             maybeMessage(runRename),
+	Usage:           "rename <oldname> <newname>",
+	OptionalMessage: true,
+	Category:        "app",
+	Short:           "rename an app",
 	Long: `
-Rename renames a heroku app.
+Rename renames an app. The latest release is re-submitted, so that DNS
+records for the app use the new name. The old name can still be used
+to refer to the app for a while, so that scripts using it keep working
+until they're updated.
 
 Example:
 
     $ emp rename myapp myapp2
+    Renamed myapp to myapp2.
 `,
 }
 
@@ -27,7 +30,8 @@ func runRename(cmd *Command, args []string) {
 		os.Exit(2)
 	}
 	oldname, newname := args[0], args[1]
-	app, err := client.AppUpdate(oldname, &heroku.AppUpdateOpts{Name: &newname})
+	message := getMessage()
+	app, err := client.AppRename(oldname, newname, message)
 	must(err)
 	log.Printf("Renamed %s to %s.", oldname, app.Name)
 	log.Println("Ensure you update your git remote URL.")
@@ -23,7 +23,8 @@ var cmdScale = &Command{
 	Long: `
 Scale changes the quantity of dynos (horizontal scale) and/or the
 dyno size (vertical scale) for each process type. Note that
-changing dyno size will restart all dynos of that type.
+changing dyno size will restart all dynos of that type. Run
+"emp sizes" to list the available dyno sizes.
 
 Options:
 
@@ -131,11 +132,8 @@ func parseScaleArg(arg string) (pstype string, qty int, size string, err error)
 	}
 
 	if iColon := strings.IndexRune(rem, ':'); iColon == -1 {
-		if iX := strings.IndexRune(rem, 'X'); iX == -1 {
-			qty, err = strconv.Atoi(rem)
-			if err != nil {
-				return pstype, -1, "", errInvalidScaleArg
-			}
+		if n, aerr := strconv.Atoi(rem); aerr == nil {
+			qty = n
 		} else {
 			size = rem
 		}
@@ -20,6 +20,8 @@ var parseScaleTests = []struct {
 	{"web=1x", "web", -1, "1X", nil},
 	{"web=PX", "web", -1, "PX", nil},
 	{"web=px", "web", -1, "PX", nil},
+	{"web=2:highmem", "web", 2, "HIGHMEM", nil},
+	{"web=highmem", "web", -1, "HIGHMEM", nil},
 	{"web=1X:5", "web", -1, "", errInvalidScaleArg},
 	{"web=PX:5", "web", -1, "", errInvalidScaleArg},
 	{"web", "", -1, "", errInvalidScaleArg},
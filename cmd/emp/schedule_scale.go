@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/remind101/empire/pkg/heroku"
+)
+
+var cmdScheduleScale = &Command{
+	Run:      runScheduleScale,
+	Usage:    "schedule-scale <cron> (<type>=<qty>... | restore)",
+	NeedsApp: true,
+	Category: "dyno",
+	Short:    "scale dynos on a schedule",
+	Long: `
+Schedule-scale adds a rule that scales the app's processes at the times
+matching a cron expression. Cron expressions are evaluated in UTC and
+need to be quoted.
+
+The type "*" applies to every process type that isn't listed. Processes
+that are autoscaled are left alone.
+
+Instead of a formation, "restore" scales the processes back to the
+quantities they had before the app's last scheduled scale.
+
+Examples:
+
+    $ emp schedule-scale "0 20 * * 1-5" "*=0"
+    Scheduled scale 01234567-89ab-cdef-0123-456789abcdef on myapp. Next run at Oct 19 20:00.
+
+    $ emp schedule-scale "0 7 * * 1-5" restore
+    Scheduled scale 76543210-89ab-cdef-0123-456789abcdef on myapp. Next run at Oct 19 07:00.
+`,
+}
+
+func runScheduleScale(cmd *Command, args []string) {
+	appname := mustApp()
+	if len(args) < 2 {
+		cmd.PrintUsage()
+		os.Exit(2)
+	}
+
+	opts, err := parseScheduleScaleArgs(args[0], args[1:])
+	if err != nil {
+		printError("%s", err)
+		cmd.PrintUsage()
+		os.Exit(2)
+	}
+
+	s, err := client.ScaleScheduleCreate(appname, *opts)
+	must(err)
+	log.Printf("Scheduled scale %s on %s. Next run at %s.", s.Id, appname, prettyTime{s.NextRunAt})
+}
+
+// parseScheduleScaleArgs parses a cron expression, and either "restore" or
+// arguments of the form "web=2", "*=0".
+func parseScheduleScaleArgs(schedule string, args []string) (*heroku.ScaleScheduleCreateOpts, error) {
+	opts := &heroku.ScaleScheduleCreateOpts{Schedule: schedule}
+
+	if len(args) == 1 && args[0] == "restore" {
+		opts.Restore = true
+		return opts, nil
+	}
+
+	opts.Formation = make(map[string]int)
+	for _, arg := range args {
+		parts := strings.SplitN(arg, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid argument: %s", arg)
+		}
+
+		n, err := strconv.Atoi(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid quantity for %s: %s", parts[0], parts[1])
+		}
+
+		opts.Formation[parts[0]] = n
+	}
+
+	return opts, nil
+}
+
+var cmdScheduleScales = &Command{
+	Run:      runScheduleScales,
+	Usage:    "schedule-scales",
+	NeedsApp: true,
+	Category: "dyno",
+	Short:    "list scale schedules",
+	Long: `
+Lists the scale schedules of an app. Cron expressions are in UTC.
+
+Examples:
+
+    $ emp schedule-scales
+    ID                                    SCHEDULE        FORMATION  NEXT RUN
+    01234567-89ab-cdef-0123-456789abcdef  0 20 * * 1-5    *=0        Oct 19 20:00
+    76543210-89ab-cdef-0123-456789abcdef  0 7 * * 1-5     restore    Oct 19 07:00
+`,
+}
+
+func runScheduleScales(cmd *Command, args []string) {
+	appname := mustApp()
+	if len(args) != 0 {
+		cmd.PrintUsage()
+		os.Exit(2)
+	}
+
+	schedules, err := client.ScaleScheduleList(appname)
+	must(err)
+
+	w := tabwriter.NewWriter(os.Stdout, 1, 2, 2, ' ', 0)
+	defer w.Flush()
+	listRec(w, "ID", "SCHEDULE", "FORMATION", "NEXT RUN")
+	for _, s := range schedules {
+		listRec(w, s.Id, s.Schedule, formatScaleSchedule(s), prettyTime{s.NextRunAt})
+	}
+}
+
+// formatScaleSchedule returns what a scale schedule scales to, like
+// "web=2 worker=0".
+func formatScaleSchedule(s heroku.ScaleSchedule) string {
+	if s.Restore {
+		return "restore"
+	}
+
+	var formation []string
+	for process, quantity := range s.Formation {
+		formation = append(formation, fmt.Sprintf("%s=%d", process, quantity))
+	}
+	sort.Strings(formation)
+
+	return strings.Join(formation, " ")
+}
+
+var cmdScheduleScaleRemove = &Command{
+	Run:      runScheduleScaleRemove,
+	Usage:    "schedule-scale-remove <id>",
+	NeedsApp: true,
+	Category: "dyno",
+	Short:    "remove a scale schedule",
+	Long: `
+Removes a scale schedule from an app. The current quantities of the
+app's processes aren't changed.
+
+Examples:
+
+    $ emp schedule-scale-remove 01234567-89ab-cdef-0123-456789abcdef
+    Removed scale schedule 01234567-89ab-cdef-0123-456789abcdef from myapp.
+`,
+}
+
+func runScheduleScaleRemove(cmd *Command, args []string) {
+	appname := mustApp()
+	if len(args) != 1 {
+		cmd.PrintUsage()
+		os.Exit(2)
+	}
+
+	id := args[0]
+	must(client.ScaleScheduleDelete(appname, id))
+	log.Printf("Removed scale schedule %s from %s.", id, appname)
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/remind101/empire/pkg/heroku"
+)
+
+var parseScheduleScaleTests = []struct {
+	schedule string
+	in       []string
+	out      *heroku.ScaleScheduleCreateOpts
+	err      bool
+}{
+	{"0 20 * * *", []string{"*=0"}, &heroku.ScaleScheduleCreateOpts{Schedule: "0 20 * * *", Formation: map[string]int{"*": 0}}, false},
+	{"0 20 * * *", []string{"web=1", "worker=0"}, &heroku.ScaleScheduleCreateOpts{Schedule: "0 20 * * *", Formation: map[string]int{"web": 1, "worker": 0}}, false},
+	{"0 7 * * *", []string{"restore"}, &heroku.ScaleScheduleCreateOpts{Schedule: "0 7 * * *", Restore: true}, false},
+	{"0 7 * * *", []string{"restore", "web=1"}, nil, true},
+	{"0 20 * * *", []string{"web"}, nil, true},
+	{"0 20 * * *", []string{"web=none"}, nil, true},
+}
+
+func TestParseScheduleScaleArgs(t *testing.T) {
+	for i, tt := range parseScheduleScaleTests {
+		out, err := parseScheduleScaleArgs(tt.schedule, tt.in)
+		if tt.err != (err != nil) {
+			t.Errorf("%d. parseScheduleScaleArgs(%q, %q).err => %v", i, tt.schedule, tt.in, err)
+		}
+		if !reflect.DeepEqual(out, tt.out) {
+			t.Errorf("%d. parseScheduleScaleArgs(%q, %q) => %#v, want %#v", i, tt.schedule, tt.in, out, tt.out)
+		}
+	}
+}
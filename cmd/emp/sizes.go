@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+)
+
+var cmdSizes = &Command{
+	Run:      runSizes,
+	Usage:    "sizes",
+	Category: "dyno",
+	Short:    "list dyno sizes",
+	Long: `
+Lists the dyno sizes that processes can be scaled to, or run with.
+
+Examples:
+
+    $ emp sizes
+    NAME  CPU SHARE  MEMORY  NPROC
+    1X    256        0.50GB  256
+    2X    512        1.00GB  512
+    PX    1024       6.00GB  -
+`,
+}
+
+func runSizes(cmd *Command, args []string) {
+	if len(args) != 0 {
+		cmd.PrintUsage()
+		os.Exit(2)
+	}
+
+	sizes, err := client.DynoSizeList(nil)
+	must(err)
+
+	w := tabwriter.NewWriter(os.Stdout, 1, 2, 2, ' ', 0)
+	defer w.Flush()
+	listRec(w, "NAME", "CPU SHARE", "MEMORY", "NPROC")
+	for _, s := range sizes {
+		nproc := "-"
+		if s.Nproc != 0 {
+			nproc = fmt.Sprint(s.Nproc)
+		}
+		listRec(w, s.Name, s.Compute, fmt.Sprintf("%.2fGB", s.Memory), nproc)
+	}
+}
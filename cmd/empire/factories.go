@@ -1,11 +1,13 @@
 package main
 
 import (
+	"encoding/base64"
 	"fmt"
 	"html/template"
 	"log"
 	"net/url"
 	"os"
+	"strings"
 
 	"golang.org/x/net/context"
 
@@ -20,6 +22,8 @@ import (
 	"github.com/remind101/empire/events/app"
 	"github.com/remind101/empire/events/sns"
 	"github.com/remind101/empire/events/stdout"
+	"github.com/remind101/empire/events/webhook"
+	"github.com/remind101/empire/pkg/constraints"
 	"github.com/remind101/empire/pkg/dockerauth"
 	"github.com/remind101/empire/pkg/dockerutil"
 	"github.com/remind101/empire/pkg/ecsutil"
@@ -28,6 +32,10 @@ import (
 	"github.com/remind101/empire/scheduler/cloudformation"
 	"github.com/remind101/empire/scheduler/docker"
 	"github.com/remind101/empire/scheduler/ecs"
+	"github.com/remind101/empire/scheduler/kubernetes"
+	"github.com/remind101/empire/secrets/local"
+	"github.com/remind101/empire/secrets/ssm"
+	"github.com/remind101/empire/secrets/vault"
 	"github.com/remind101/pkg/logger"
 	"github.com/remind101/pkg/reporter"
 	"github.com/remind101/pkg/reporter/hb"
@@ -70,7 +78,12 @@ func newEmpire(db *empire.DB, c *cli.Context) (*empire.Empire, error) {
 		return nil, err
 	}
 
-	scheduler, err := newScheduler(db, c)
+	secrets, err := newSecretStore(c)
+	if err != nil {
+		return nil, err
+	}
+
+	scheduler, err := newScheduler(db, c, secrets)
 	if err != nil {
 		return nil, err
 	}
@@ -90,14 +103,44 @@ func newEmpire(db *empire.DB, c *cli.Context) (*empire.Empire, error) {
 		return nil, err
 	}
 
+	dynoSizes, err := newDynoSizes(c)
+	if err != nil {
+		return nil, err
+	}
+
+	appQuota, err := newQuota(c, FlagQuotaAppInstances, FlagQuotaAppMemory, FlagQuotaAppCPU)
+	if err != nil {
+		return nil, err
+	}
+
+	quota, err := newQuota(c, FlagQuotaInstances, FlagQuotaMemory, FlagQuotaCPU)
+	if err != nil {
+		return nil, err
+	}
+
 	e := empire.New(db)
 	e.Scheduler = scheduler
 	e.Secret = []byte(c.String(FlagSecret))
-	e.EventStream = empire.AsyncEvents(streams)
+	e.EventStream = streams
+	e.EventMaxAttempts = c.Int(FlagEventsAttempts)
+	e.AppAliasTTL = c.Duration(FlagAppAliasTTL)
 	e.ProcfileExtractor = empire.PullAndExtract(docker)
 	e.Environment = c.String(FlagEnvironment)
 	e.RunRecorder = runRecorder
+	e.Secrets = secrets
 	e.MessagesRequired = c.Bool(FlagMessagesRequired)
+	e.AccessControl = c.Bool(FlagRBAC)
+	e.Admins = c.StringSlice(FlagRBACAdmins)
+	e.AvailableDynoSizes = dynoSizes
+	e.RestrictDynoSizes = c.Bool(FlagDynoSizesRestrict)
+	e.AppQuota = appQuota
+	e.Quota = quota
+	e.CheckCapacity = c.Bool(FlagCapacityCheck)
+	e.CostModel = empire.CostModel{
+		GBHour:           c.Float64(FlagCostGBHour),
+		CPUShareHour:     c.Float64(FlagCostCPUShareHour),
+		LoadBalancerHour: c.Float64(FlagCostLoadBalancerHour),
+	}
 	if logs != nil {
 		e.LogsStreamer = logs
 	}
@@ -105,9 +148,49 @@ func newEmpire(db *empire.DB, c *cli.Context) (*empire.Empire, error) {
 	return e, nil
 }
 
+// newDynoSizes loads the dyno sizes from the file provided by --dyno-sizes, if
+// any. When no file is provided, the default dyno sizes are used.
+func newDynoSizes(c *cli.Context) (empire.DynoSizes, error) {
+	path := c.String(FlagDynoSizes)
+	if path == "" {
+		return nil, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	sizes, err := empire.ParseDynoSizes(f)
+	if err != nil {
+		return nil, fmt.Errorf("error loading dyno sizes from %s: %v", path, err)
+	}
+
+	return sizes, nil
+}
+
+// newQuota returns the quota from the given instances, memory and cpu flags.
+func newQuota(c *cli.Context, instancesFlag, memoryFlag, cpuFlag string) (empire.Resources, error) {
+	q := empire.Resources{
+		Instances: c.Int(instancesFlag),
+		CPUShare:  c.Int(cpuFlag),
+	}
+
+	if m := c.String(memoryFlag); m != "" {
+		memory, err := constraints.ParseMemory(m)
+		if err != nil {
+			return q, fmt.Errorf("invalid --%s: %v", memoryFlag, err)
+		}
+		q.Memory = memory
+	}
+
+	return q, nil
+}
+
 // Scheduler ============================
 
-func newScheduler(db *empire.DB, c *cli.Context) (scheduler.Scheduler, error) {
+func newScheduler(db *empire.DB, c *cli.Context, secrets scheduler.SecretResolver) (scheduler.Scheduler, error) {
 	var (
 		s   scheduler.Scheduler
 		err error
@@ -120,6 +203,10 @@ func newScheduler(db *empire.DB, c *cli.Context) (scheduler.Scheduler, error) {
 		s, err = newMigrationScheduler(db, c)
 	case "cloudformation":
 		s, err = newCloudFormationScheduler(db, c)
+	case "kubernetes":
+		s, err = newKubernetesScheduler(c, secrets)
+	case "docker":
+		s, err = newDockerScheduler(c, secrets)
 	default:
 		return nil, fmt.Errorf("unknown scheduler: %s", c.String(FlagScheduler))
 	}
@@ -128,6 +215,14 @@ func newScheduler(db *empire.DB, c *cli.Context) (scheduler.Scheduler, error) {
 		return nil, fmt.Errorf("failed to initialize %s scheduler: %v", c.String(FlagScheduler), err)
 	}
 
+	switch s.(type) {
+	case *kubernetes.Scheduler, *docker.Scheduler:
+		// These schedulers are able to run attached processes
+		// themselves, so we don't wrap them with the docker attached
+		// runner.
+		return s, nil
+	}
+
 	d, err := newDockerClient(c)
 	if err != nil {
 		return nil, err
@@ -135,6 +230,7 @@ func newScheduler(db *empire.DB, c *cli.Context) (scheduler.Scheduler, error) {
 
 	a := docker.RunAttachedWithDocker(s, d)
 	a.ShowAttached = c.Bool(FlagXShowAttached)
+	a.Secrets = secrets
 	return a, nil
 }
 
@@ -176,7 +272,11 @@ func newCloudFormationScheduler(db *empire.DB, c *cli.Context) (*cloudformation.
 		HostedZone:              zone,
 		ServiceRole:             c.String(FlagECSServiceRole),
 		CustomResourcesTopic:    c.String(FlagCustomResourcesTopic),
+		VpcID:                   c.String(FlagEC2VPC),
+		InternalALB:             newApplicationLoadBalancer(c.String(FlagALBPrivateDNSName), c.String(FlagALBPrivateListener), c.String(FlagALBPrivateHTTPSListener)),
+		ExternalALB:             newApplicationLoadBalancer(c.String(FlagALBPublicDNSName), c.String(FlagALBPublicListener), c.String(FlagALBPublicHTTPSListener)),
 		LogConfiguration:        logConfiguration,
+		ExecutionRoleArn:        c.String(FlagECSExecutionRole),
 		ExtraOutputs: map[string]troposphere.Output{
 			"EmpireVersion": troposphere.Output{Value: empire.Version},
 		},
@@ -206,10 +306,31 @@ func newCloudFormationScheduler(db *empire.DB, c *cli.Context) (*cloudformation.
 	log.Println(fmt.Sprintf("  ExternalSubnetIDs: %v", t.ExternalSubnetIDs))
 	log.Println(fmt.Sprintf("  ZoneID: %v", zoneID))
 	log.Println(fmt.Sprintf("  LogConfiguration: %v", t.LogConfiguration))
+	log.Println(fmt.Sprintf("  ExecutionRoleArn: %v", t.ExecutionRoleArn))
+	if t.InternalALB != nil {
+		log.Println(fmt.Sprintf("  InternalALB: %v", t.InternalALB.DNSName))
+	}
+	if t.ExternalALB != nil {
+		log.Println(fmt.Sprintf("  ExternalALB: %v", t.ExternalALB.DNSName))
+	}
 
 	return s, nil
 }
 
+// newApplicationLoadBalancer returns a cloudformation.ApplicationLoadBalancer
+// when a DNS name or listener is provided.
+func newApplicationLoadBalancer(dnsName, listener, httpsListener string) *cloudformation.ApplicationLoadBalancer {
+	if dnsName == "" && listener == "" {
+		return nil
+	}
+
+	return &cloudformation.ApplicationLoadBalancer{
+		DNSName:          dnsName,
+		HTTPListenerArn:  listener,
+		HTTPSListenerArn: httpsListener,
+	}
+}
+
 // prefixedStackName returns a text/template that prefixes the stack name with
 // the given prefix, if it's set.
 func prefixedStackName(prefix string) *template.Template {
@@ -252,6 +373,50 @@ func newECSScheduler(db *empire.DB, c *cli.Context) (*ecs.Scheduler, error) {
 	return s, nil
 }
 
+func newDockerScheduler(c *cli.Context, secrets scheduler.SecretResolver) (*docker.Scheduler, error) {
+	client, err := newDockerClient(c)
+	if err != nil {
+		return nil, err
+	}
+
+	s := docker.NewScheduler(client)
+	s.Secrets = secrets
+
+	log.Println("Using Docker backend with the following configuration:")
+	log.Println(fmt.Sprintf("  Socket: %v", c.String(FlagDockerSocket)))
+
+	go s.Start()
+
+	return s, nil
+}
+
+func newKubernetesScheduler(c *cli.Context, secrets scheduler.SecretResolver) (*kubernetes.Scheduler, error) {
+	var (
+		client *kubernetes.Client
+		err    error
+	)
+
+	host := c.String(FlagKubernetesHost)
+	if host == "" {
+		client, err = kubernetes.NewInClusterClient()
+	} else {
+		client, err = kubernetes.NewClientWithCredentials(host, c.String(FlagKubernetesTokenFile), c.String(FlagKubernetesCA))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s := kubernetes.NewScheduler(client)
+	s.Namespace = c.String(FlagKubernetesNamespace)
+	s.Secrets = secrets
+
+	log.Println("Using Kubernetes backend with the following configuration:")
+	log.Println(fmt.Sprintf("  Host: %v", client.URL))
+	log.Println(fmt.Sprintf("  Namespace: %v", s.Namespace))
+
+	return s, nil
+}
+
 func newConfigProvider(c *cli.Context) client.ConfigProvider {
 	p := session.New()
 
@@ -296,6 +461,43 @@ func newKinesisLogsStreamer(c *cli.Context) (empire.LogsStreamer, error) {
 
 // Events ==============================
 
+// SecretStore ==========================
+
+func newSecretStore(c *cli.Context) (empire.SecretStore, error) {
+	switch c.String(FlagSecretsBackend) {
+	case "local":
+		key, err := base64.StdEncoding.DecodeString(c.String(FlagSecretsLocalKey))
+		if err != nil {
+			return nil, fmt.Errorf("invalid --%s: %v", FlagSecretsLocalKey, err)
+		}
+		s, err := local.NewSecretStore(key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --%s: %v", FlagSecretsLocalKey, err)
+		}
+		log.Println("Using local secrets backend")
+		return s, nil
+	case "vault":
+		s := vault.NewSecretStore(c.String(FlagSecretsVaultAddr), c.String(FlagSecretsVaultToken))
+		s.Path = c.String(FlagSecretsVaultPath)
+		log.Println("Using Vault secrets backend with the following configuration:")
+		log.Println(fmt.Sprintf("  Addr: %v", s.URL))
+		log.Println(fmt.Sprintf("  Path: %v", s.Path))
+		return s, nil
+	case "ssm":
+		s := ssm.NewSecretStore(newConfigProvider(c))
+		s.Path = c.String(FlagSecretsSSMPath)
+		s.KeyID = c.String(FlagSecretsSSMKeyID)
+		log.Println("Using SSM Parameter Store secrets backend with the following configuration:")
+		log.Println(fmt.Sprintf("  Path: %v", s.Path))
+		log.Println(fmt.Sprintf("  KeyID: %v", s.KeyID))
+		return s, nil
+	case "":
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unknown secrets backend: %s", c.String(FlagSecretsBackend))
+	}
+}
+
 func newEventStreams(c *cli.Context) (empire.MultiEventStream, error) {
 	var streams empire.MultiEventStream
 	switch c.String(FlagEventsBackend) {
@@ -311,6 +513,12 @@ func newEventStreams(c *cli.Context) (empire.MultiEventStream, error) {
 			return streams, err
 		}
 		streams = append(streams, e)
+	case "webhook":
+		e, err := newWebhookEventStream(c)
+		if err != nil {
+			return streams, err
+		}
+		streams = append(streams, e)
 	default:
 		e := empire.NullEventStream
 		streams = append(streams, e)
@@ -342,6 +550,40 @@ func newSNSEventStream(c *cli.Context) (empire.EventStream, error) {
 	return e, nil
 }
 
+func newWebhookEventStream(c *cli.Context) (empire.EventStream, error) {
+	var endpoints []*webhook.Endpoint
+	for _, u := range c.StringSlice(FlagWebhookURLs) {
+		endpoint, err := webhook.ParseEndpoint(u)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --%s: %v", FlagWebhookURLs, err)
+		}
+		endpoints = append(endpoints, endpoint)
+	}
+
+	if len(endpoints) == 0 {
+		return nil, fmt.Errorf("--%s is required when using the webhook events backend", FlagWebhookURLs)
+	}
+
+	var secret []byte
+	if s := c.String(FlagWebhookSecret); s != "" {
+		secret = []byte(s)
+	}
+
+	e := webhook.NewEventStream(endpoints, secret)
+	e.MaxAttempts = c.Int(FlagWebhookMaxAttempts)
+
+	log.Println("Using webhook events backend with the following configuration:")
+	for _, endpoint := range endpoints {
+		events := "all"
+		if len(endpoint.Events) > 0 {
+			events = strings.Join(endpoint.Events, ",")
+		}
+		log.Println(fmt.Sprintf("  URL: %s (%s)", endpoint.URL, events))
+	}
+
+	return e, nil
+}
+
 func newStdoutEventStream(c *cli.Context) (empire.EventStream, error) {
 	e := stdout.NewEventStream(newConfigProvider(c))
 	log.Println("Using Stdout events backend")
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/codegangsta/cli"
+	"golang.org/x/net/context"
+)
+
+func runGC(c *cli.Context) {
+	db, err := newDB(c)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	e, err := newEmpire(db, c)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	orphans, err := e.Orphans(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if len(orphans) == 0 {
+		fmt.Println("No orphaned resources")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 1, 2, 2, ' ', 0)
+	fmt.Fprintln(w, "TYPE\tID\tAPP")
+	for _, orphan := range orphans {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", orphan.Type, orphan.ID, orphan.App)
+	}
+	w.Flush()
+
+	if !c.Bool(FlagApply) {
+		fmt.Printf("\nFound %d orphaned resources. Run with --apply to remove them\n", len(orphans))
+		return
+	}
+
+	// Resources are only removed if they're still orphaned after a
+	// while, so that an app that's still being created isn't mistaken for
+	// garbage.
+	wait := c.Duration(FlagConfirmAfter)
+	fmt.Printf("\nChecking that the resources are still orphaned in %v\n", wait)
+	time.Sleep(wait)
+
+	orphans, err = e.ConfirmOrphans(ctx, orphans)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := e.RemoveOrphans(ctx, orphans); err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("Removed %d orphaned resources\n", len(orphans))
+}
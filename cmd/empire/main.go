@@ -3,9 +3,14 @@ package main
 import (
 	"os"
 	"path"
+	"time"
 
 	"github.com/codegangsta/cli"
 	"github.com/remind101/empire"
+	"github.com/remind101/empire/events/webhook"
+	"github.com/remind101/empire/scheduler/kubernetes"
+	"github.com/remind101/empire/secrets/ssm"
+	"github.com/remind101/empire/secrets/vault"
 	"github.com/remind101/empire/server/github"
 )
 
@@ -46,18 +51,67 @@ const (
 	FlagECSServiceRole       = "ecs.service.role"
 	FlagECSLogDriver         = "ecs.logdriver"
 	FlagECSLogOpts           = "ecs.logopt"
+	FlagECSExecutionRole     = "ecs.execution.role"
+
+	FlagKubernetesHost      = "kubernetes.host"
+	FlagKubernetesTokenFile = "kubernetes.token.file"
+	FlagKubernetesCA        = "kubernetes.ca"
+	FlagKubernetesNamespace = "kubernetes.namespace"
 
 	FlagELBSGPrivate = "elb.sg.private"
 	FlagELBSGPublic  = "elb.sg.public"
 
+	FlagALBPrivateDNSName       = "alb.private.dnsname"
+	FlagALBPrivateListener      = "alb.private.listener"
+	FlagALBPrivateHTTPSListener = "alb.private.listener.https"
+	FlagALBPublicDNSName        = "alb.public.dnsname"
+	FlagALBPublicListener       = "alb.public.listener"
+	FlagALBPublicHTTPSListener  = "alb.public.listener.https"
+
 	FlagEC2SubnetsPrivate = "ec2.subnets.private"
 	FlagEC2SubnetsPublic  = "ec2.subnets.public"
+	FlagEC2VPC            = "ec2.vpc"
 
 	FlagRoute53InternalZoneID = "route53.zoneid.internal"
 
 	FlagSNSTopic           = "sns.topic"
 	FlagCloudWatchLogGroup = "cloudwatch.loggroup"
 
+	FlagEventsAttempts         = "events.attempts"
+	FlagEventsDispatchInterval = "events.dispatch.interval"
+
+	FlagDriftInterval = "drift.interval"
+	FlagDriftConverge = "drift.converge"
+
+	FlagGCInterval = "gc.interval"
+	FlagGCApply    = "gc.apply"
+
+	FlagScaleSchedulesInterval = "scale-schedules.interval"
+
+	FlagAppAliasTTL = "apps.alias.ttl"
+
+	FlagDynoSizes         = "dyno-sizes"
+	FlagDynoSizesRestrict = "dyno-sizes.restrict"
+
+	FlagQuotaAppInstances = "quota.app.instances"
+	FlagQuotaAppMemory    = "quota.app.memory"
+	FlagQuotaAppCPU       = "quota.app.cpu"
+	FlagQuotaInstances    = "quota.instances"
+	FlagQuotaMemory       = "quota.memory"
+	FlagQuotaCPU          = "quota.cpu"
+	FlagCapacityCheck     = "capacity.check"
+
+	FlagCostGBHour           = "cost.gb-hour"
+	FlagCostCPUShareHour     = "cost.cpu-share-hour"
+	FlagCostLoadBalancerHour = "cost.lb-hour"
+
+	FlagApply        = "apply"
+	FlagConfirmAfter = "confirm-after"
+
+	FlagWebhookURLs        = "events.webhook.url"
+	FlagWebhookSecret      = "events.webhook.secret"
+	FlagWebhookMaxAttempts = "events.webhook.attempts"
+
 	FlagSecret       = "secret"
 	FlagReporter     = "reporter"
 	FlagRunner       = "runner"
@@ -65,6 +119,17 @@ const (
 
 	FlagEnvironment = "environment"
 
+	FlagSecretsBackend    = "secrets.backend"
+	FlagSecretsLocalKey   = "secrets.local.key"
+	FlagSecretsVaultAddr  = "secrets.vault.addr"
+	FlagSecretsVaultToken = "secrets.vault.token"
+	FlagSecretsVaultPath  = "secrets.vault.path"
+	FlagSecretsSSMPath    = "secrets.ssm.path"
+	FlagSecretsSSMKeyID   = "secrets.ssm.key"
+
+	FlagRBAC       = "rbac"
+	FlagRBACAdmins = "rbac.admins"
+
 	// Expiremental flags.
 	FlagXShowAttached = "x.showattached"
 )
@@ -89,7 +154,7 @@ var Commands = []cli.Command{
 			cli.StringFlag{
 				Name:   FlagScheduler,
 				Value:  "ecs",
-				Usage:  "The scheduling backend to use. Current options are `ecs`, `cloudformation-migration`, and `cloudformation`.",
+				Usage:  "The scheduling backend to use. Current options are `ecs`, `cloudformation-migration`, `cloudformation`, `kubernetes` and `docker`.",
 				EnvVar: "EMPIRE_SCHEDULER",
 			},
 			cli.StringFlag{
@@ -167,6 +232,22 @@ var Commands = []cli.Command{
 		Flags:  DBFlags,
 		Action: runMigrate,
 	},
+	{
+		Name:  "gc",
+		Usage: "Find, and remove, AWS resources that belong to apps that no longer exist",
+		Flags: append([]cli.Flag{
+			cli.BoolFlag{
+				Name:  FlagApply,
+				Usage: "If true, the orphaned resources are removed. Otherwise, they're only printed",
+			},
+			cli.DurationFlag{
+				Name:  FlagConfirmAfter,
+				Value: time.Minute,
+				Usage: "When applying, how long to wait before checking that the resources are still orphaned. Only resources that are still orphaned are removed",
+			},
+		}, append(EmpireFlags, DBFlags...)...),
+		Action: runGC,
+	},
 }
 
 var DBFlags = []cli.Flag{
@@ -241,6 +322,36 @@ var EmpireFlags = []cli.Flag{
 		Usage:  "Log driver to options. Maps to the --log-opt docker cli arg",
 		EnvVar: "EMPIRE_ECS_LOG_OPT",
 	},
+	cli.StringFlag{
+		Name:   FlagECSExecutionRole,
+		Value:  "",
+		Usage:  "The ARN of the IAM role that ECS uses to fetch secrets from SSM Parameter Store when tasks are launched. Required to deploy apps with secrets",
+		EnvVar: "EMPIRE_ECS_EXECUTION_ROLE",
+	},
+	cli.StringFlag{
+		Name:   FlagKubernetesHost,
+		Value:  "",
+		Usage:  "When using the kubernetes backend, the url of the Kubernetes API server. If not provided, the in cluster service account will be used.",
+		EnvVar: "EMPIRE_KUBERNETES_HOST",
+	},
+	cli.StringFlag{
+		Name:   FlagKubernetesTokenFile,
+		Value:  "",
+		Usage:  "When using the kubernetes backend, a path to a file containing a bearer token to authenticate with",
+		EnvVar: "EMPIRE_KUBERNETES_TOKEN_FILE",
+	},
+	cli.StringFlag{
+		Name:   FlagKubernetesCA,
+		Value:  "",
+		Usage:  "When using the kubernetes backend, a path to the CA certificate of the Kubernetes API server",
+		EnvVar: "EMPIRE_KUBERNETES_CA",
+	},
+	cli.StringFlag{
+		Name:   FlagKubernetesNamespace,
+		Value:  kubernetes.DefaultNamespace,
+		Usage:  "When using the kubernetes backend, the namespace to create resources in",
+		EnvVar: "EMPIRE_KUBERNETES_NAMESPACE",
+	},
 	cli.StringFlag{
 		Name:   FlagELBSGPrivate,
 		Value:  "",
@@ -253,6 +364,48 @@ var EmpireFlags = []cli.Flag{
 		Usage:  "The ELB security group to assign public load balancers",
 		EnvVar: "EMPIRE_ELB_SG_PUBLIC",
 	},
+	cli.StringFlag{
+		Name:   FlagALBPrivateDNSName,
+		Value:  "",
+		Usage:  "The DNS name of a shared Application Load Balancer to route private http and https processes through, instead of creating an ELB for each process",
+		EnvVar: "EMPIRE_ALB_PRIVATE_DNSNAME",
+	},
+	cli.StringFlag{
+		Name:   FlagALBPrivateListener,
+		Value:  "",
+		Usage:  "The ARN of the HTTP listener of the private Application Load Balancer",
+		EnvVar: "EMPIRE_ALB_PRIVATE_LISTENER",
+	},
+	cli.StringFlag{
+		Name:   FlagALBPrivateHTTPSListener,
+		Value:  "",
+		Usage:  "The ARN of the HTTPS listener of the private Application Load Balancer",
+		EnvVar: "EMPIRE_ALB_PRIVATE_LISTENER_HTTPS",
+	},
+	cli.StringFlag{
+		Name:   FlagALBPublicDNSName,
+		Value:  "",
+		Usage:  "The DNS name of a shared Application Load Balancer to route public http and https processes through, instead of creating an ELB for each process",
+		EnvVar: "EMPIRE_ALB_PUBLIC_DNSNAME",
+	},
+	cli.StringFlag{
+		Name:   FlagALBPublicListener,
+		Value:  "",
+		Usage:  "The ARN of the HTTP listener of the public Application Load Balancer",
+		EnvVar: "EMPIRE_ALB_PUBLIC_LISTENER",
+	},
+	cli.StringFlag{
+		Name:   FlagALBPublicHTTPSListener,
+		Value:  "",
+		Usage:  "The ARN of the HTTPS listener of the public Application Load Balancer",
+		EnvVar: "EMPIRE_ALB_PUBLIC_LISTENER_HTTPS",
+	},
+	cli.StringFlag{
+		Name:   FlagEC2VPC,
+		Value:  "",
+		Usage:  "The ID of the VPC to create target groups in. Required when using Application Load Balancers",
+		EnvVar: "EMPIRE_EC2_VPC",
+	},
 	cli.StringSliceFlag{
 		Name:   FlagEC2SubnetsPrivate,
 		Value:  &cli.StringSlice{},
@@ -301,18 +454,194 @@ var EmpireFlags = []cli.Flag{
 		Usage:  "The backend implementation to use to send event notifactions",
 		EnvVar: "EMPIRE_EVENTS_BACKEND",
 	},
+	cli.IntFlag{
+		Name:   FlagEventsAttempts,
+		Value:  empire.DefaultEventMaxAttempts,
+		Usage:  "The number of attempts to deliver an event to the events backend, before it's considered dead",
+		EnvVar: "EMPIRE_EVENTS_ATTEMPTS",
+	},
+	cli.DurationFlag{
+		Name:   FlagEventsDispatchInterval,
+		Value:  empire.DefaultEventDispatchInterval,
+		Usage:  "How often to check for events that need to be delivered to the events backend",
+		EnvVar: "EMPIRE_EVENTS_DISPATCH_INTERVAL",
+	},
+	cli.DurationFlag{
+		Name:   FlagDriftInterval,
+		Value:  0,
+		Usage:  "If provided, how often to check apps for drift between their latest release and what's running in the scheduler",
+		EnvVar: "EMPIRE_DRIFT_INTERVAL",
+	},
+	cli.BoolFlag{
+		Name:   FlagDriftConverge,
+		Usage:  "If true, apps that stay drifted for two consecutive drift checks are re-submitted to the scheduler",
+		EnvVar: "EMPIRE_DRIFT_CONVERGE",
+	},
+	cli.DurationFlag{
+		Name:   FlagGCInterval,
+		Value:  0,
+		Usage:  "If provided, how often to look for AWS resources that belong to apps that no longer exist",
+		EnvVar: "EMPIRE_GC_INTERVAL",
+	},
+	cli.BoolFlag{
+		Name:   FlagGCApply,
+		Usage:  "If true, resources that stay orphaned for two consecutive checks are removed",
+		EnvVar: "EMPIRE_GC_APPLY",
+	},
+	cli.DurationFlag{
+		Name:   FlagScaleSchedulesInterval,
+		Value:  empire.DefaultScaleScheduleInterval,
+		Usage:  "How often to check for scale schedules that are due. A value of 0 disables scheduled scaling",
+		EnvVar: "EMPIRE_SCALE_SCHEDULES_INTERVAL",
+	},
+	cli.DurationFlag{
+		Name:   FlagAppAliasTTL,
+		Value:  empire.DefaultAppAliasTTL,
+		Usage:  "How long the previous name of a renamed app can still be used to find it",
+		EnvVar: "EMPIRE_APP_ALIAS_TTL",
+	},
+	cli.StringFlag{
+		Name:   FlagDynoSizes,
+		Value:  "",
+		Usage:  "Path to a JSON file with the dyno sizes that processes can be scaled to. The default sizes are 1X, 2X and PX",
+		EnvVar: "EMPIRE_DYNO_SIZES",
+	},
+	cli.BoolFlag{
+		Name:   FlagDynoSizesRestrict,
+		Usage:  "If true, processes can only be scaled, or run, with one of the dyno sizes, rather than arbitrary <cpushare>:<memory> constraints",
+		EnvVar: "EMPIRE_DYNO_SIZES_RESTRICT",
+	},
+	cli.IntFlag{
+		Name:   FlagQuotaAppInstances,
+		Value:  0,
+		Usage:  "If provided, the maximum number of instances that the processes of each app can run",
+		EnvVar: "EMPIRE_QUOTA_APP_INSTANCES",
+	},
+	cli.StringFlag{
+		Name:   FlagQuotaAppMemory,
+		Value:  "",
+		Usage:  "If provided, the maximum amount of memory that the processes of each app can use (e.g. 64GB)",
+		EnvVar: "EMPIRE_QUOTA_APP_MEMORY",
+	},
+	cli.IntFlag{
+		Name:   FlagQuotaAppCPU,
+		Value:  0,
+		Usage:  "If provided, the maximum number of CPU shares that the processes of each app can use",
+		EnvVar: "EMPIRE_QUOTA_APP_CPU",
+	},
+	cli.IntFlag{
+		Name:   FlagQuotaInstances,
+		Value:  0,
+		Usage:  "If provided, the maximum number of instances that the processes of all apps can run together",
+		EnvVar: "EMPIRE_QUOTA_INSTANCES",
+	},
+	cli.StringFlag{
+		Name:   FlagQuotaMemory,
+		Value:  "",
+		Usage:  "If provided, the maximum amount of memory that the processes of all apps can use together (e.g. 1TB)",
+		EnvVar: "EMPIRE_QUOTA_MEMORY",
+	},
+	cli.IntFlag{
+		Name:   FlagQuotaCPU,
+		Value:  0,
+		Usage:  "If provided, the maximum number of CPU shares that the processes of all apps can use together",
+		EnvVar: "EMPIRE_QUOTA_CPU",
+	},
+	cli.BoolFlag{
+		Name:   FlagCapacityCheck,
+		Usage:  "If true, scaling and deploys are rejected when the new instances can't be placed on the hosts in the ECS cluster. Only supported by the CloudFormation scheduler",
+		EnvVar: "EMPIRE_CAPACITY_CHECK",
+	},
+	cli.Float64Flag{
+		Name:   FlagCostGBHour,
+		Value:  0,
+		Usage:  "The price of 1 GB of memory for an hour, used to estimate the cost of apps",
+		EnvVar: "EMPIRE_COST_GB_HOUR",
+	},
+	cli.Float64Flag{
+		Name:   FlagCostCPUShareHour,
+		Value:  0,
+		Usage:  "The price of 1 CPU share for an hour, used to estimate the cost of apps",
+		EnvVar: "EMPIRE_COST_CPU_SHARE_HOUR",
+	},
+	cli.Float64Flag{
+		Name:   FlagCostLoadBalancerHour,
+		Value:  0,
+		Usage:  "The price of a load balancer for an hour, used to estimate the cost of apps",
+		EnvVar: "EMPIRE_COST_LB_HOUR",
+	},
 	cli.StringFlag{
 		Name:   FlagRunLogsBackend,
 		Value:  "stdout",
 		Usage:  "The backend implementation to use to record the logs from interactive runs. Current supports `cloudwatch` and `stdout`",
 		EnvVar: "EMPIRE_RUN_LOGS_BACKEND",
 	},
+	cli.StringFlag{
+		Name:   FlagSecretsBackend,
+		Value:  "",
+		Usage:  "The backend implementation to use to store secret config vars. Currently supports `local`, `vault` and `ssm`",
+		EnvVar: "EMPIRE_SECRETS_BACKEND",
+	},
+	cli.StringFlag{
+		Name:   FlagSecretsLocalKey,
+		Value:  "",
+		Usage:  "When using the `local` secrets backend, a base64 encoded 32 byte key used to encrypt secrets",
+		EnvVar: "EMPIRE_SECRETS_LOCAL_KEY",
+	},
+	cli.StringFlag{
+		Name:   FlagSecretsVaultAddr,
+		Value:  "",
+		Usage:  "When using the `vault` secrets backend, the address of the Vault server",
+		EnvVar: "EMPIRE_SECRETS_VAULT_ADDR",
+	},
+	cli.StringFlag{
+		Name:   FlagSecretsVaultToken,
+		Value:  "",
+		Usage:  "When using the `vault` secrets backend, the token used to authenticate with Vault",
+		EnvVar: "EMPIRE_SECRETS_VAULT_TOKEN",
+	},
+	cli.StringFlag{
+		Name:   FlagSecretsVaultPath,
+		Value:  vault.DefaultPath,
+		Usage:  "When using the `vault` secrets backend, the path that secrets are written under",
+		EnvVar: "EMPIRE_SECRETS_VAULT_PATH",
+	},
+	cli.StringFlag{
+		Name:   FlagSecretsSSMPath,
+		Value:  ssm.DefaultPath,
+		Usage:  "When using the `ssm` secrets backend, the path that parameters are created under",
+		EnvVar: "EMPIRE_SECRETS_SSM_PATH",
+	},
+	cli.StringFlag{
+		Name:   FlagSecretsSSMKeyID,
+		Value:  "",
+		Usage:  "When using the `ssm` secrets backend, the KMS key used to encrypt parameters. Defaults to the account's default key for SSM",
+		EnvVar: "EMPIRE_SECRETS_SSM_KEY",
+	},
 	cli.StringFlag{
 		Name:   FlagSNSTopic,
 		Value:  "",
 		Usage:  "When using the SNS events backend, this is the SNS topic that gets published to",
 		EnvVar: "EMPIRE_SNS_TOPIC",
 	},
+	cli.StringSliceFlag{
+		Name:   FlagWebhookURLs,
+		Value:  &cli.StringSlice{},
+		Usage:  "When using the webhook events backend, a url to POST events to. Can be provided multiple times. Add a fragment with a comma separated list of event types to only deliver those events (e.g. https://example.com/hook#deploy,scale)",
+		EnvVar: "EMPIRE_EVENTS_WEBHOOK_URL",
+	},
+	cli.StringFlag{
+		Name:   FlagWebhookSecret,
+		Value:  "",
+		Usage:  "When using the webhook events backend, a secret used to sign the request body. The signature is sent in the X-Empire-Signature header",
+		EnvVar: "EMPIRE_EVENTS_WEBHOOK_SECRET",
+	},
+	cli.IntFlag{
+		Name:   FlagWebhookMaxAttempts,
+		Value:  webhook.DefaultMaxAttempts,
+		Usage:  "When using the webhook events backend, the maximum number of attempts to deliver an event to a url",
+		EnvVar: "EMPIRE_EVENTS_WEBHOOK_ATTEMPTS",
+	},
 	cli.StringFlag{
 		Name:   FlagEnvironment,
 		Value:  "",
@@ -325,6 +654,17 @@ var EmpireFlags = []cli.Flag{
 		Usage:  "When using the `cloudwatch` backend with the `--" + FlagRunLogsBackend + "` flag , this is the log group that CloudWatch log streams will be created in.",
 		EnvVar: "EMPIRE_CLOUDWATCH_LOG_GROUP",
 	},
+	cli.BoolFlag{
+		Name:   FlagRBAC,
+		Usage:  "If true, users need to be granted a role (viewer, deployer or admin) on an app to act on it.",
+		EnvVar: "EMPIRE_RBAC",
+	},
+	cli.StringSliceFlag{
+		Name:   FlagRBACAdmins,
+		Value:  &cli.StringSlice{},
+		Usage:  "Users, or GitHub teams (as org/slug), that have the admin role on every app when --rbac is enabled.",
+		EnvVar: "EMPIRE_RBAC_ADMINS",
+	},
 	cli.BoolFlag{
 		Name:   FlagMessagesRequired,
 		Usage:  "If true, messages will be required for empire actions that emit events.",
@@ -17,6 +17,7 @@ import (
 	"github.com/remind101/empire/server/cloudformation"
 	"github.com/remind101/empire/server/github"
 	"github.com/remind101/empire/server/middleware"
+	"golang.org/x/net/context"
 	"golang.org/x/oauth2"
 )
 
@@ -58,6 +59,24 @@ func runServer(c *cli.Context) {
 		go p.Start()
 	}
 
+	log.Printf("Starting event dispatcher")
+	go e.DispatchEvents(context.Background(), c.Duration(FlagEventsDispatchInterval))
+
+	if interval := c.Duration(FlagDriftInterval); interval > 0 {
+		log.Printf("Starting drift reconciler")
+		go e.ReconcileDrift(context.Background(), interval, c.Bool(FlagDriftConverge))
+	}
+
+	if interval := c.Duration(FlagGCInterval); interval > 0 {
+		log.Printf("Starting garbage collector")
+		go e.CollectGarbage(context.Background(), interval, c.Bool(FlagGCApply))
+	}
+
+	if interval := c.Duration(FlagScaleSchedulesInterval); interval > 0 {
+		log.Printf("Starting scale scheduler")
+		go e.RunScaleSchedules(context.Background(), interval)
+	}
+
 	s, err := newServer(c, e)
 	if err != nil {
 		log.Fatal(err)
@@ -153,6 +172,19 @@ func newAuthenticator(c *cli.Context, e *empire.Empire) auth.Authenticator {
 	// try access token before falling back to github.
 	authenticator := auth.MultiAuthenticator(authenticators...)
 
+	// When access control is enabled, roles can be granted to GitHub teams,
+	// so we need to know which teams the user is a member of.
+	if client != nil && c.Bool(FlagRBAC) {
+		log.Println("Adding GitHub Team lookups for access control")
+
+		authenticator = auth.WithTeams(
+			authenticator,
+			// Cache the teams for 30 minutes, like the membership
+			// checks below.
+			auth.CacheTeams(githubauth.NewTeamFinder(client), 30*time.Minute),
+		)
+	}
+
 	// After the user is authenticated, check their GitHub Organization membership.
 	if org := c.String(FlagGithubOrg); org != "" {
 		authorizer := githubauth.NewOrganizationAuthorizer(client)
@@ -132,6 +132,13 @@ func (s *configsService) Set(ctx context.Context, db *gorm.DB, opts SetOpts) (*C
 		return nil, err
 	}
 
+	if opts.Secret {
+		vars, err = putSecrets(ctx, s.Secrets, app, vars)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	c, err := configsCreate(db, newConfig(old, vars))
 	if err != nil {
 		return c, err
@@ -225,3 +232,182 @@ func configsApplyReleaseDesc(opts SetOpts) string {
 	desc := fmt.Sprintf("%s %s config var%s", verb, strings.Join(keys, ", "), plural)
 	return appendMessageToDescription(desc, opts.User, opts.Message)
 }
+
+// ConfigChangeType describes how a config var changed between two configs.
+type ConfigChangeType string
+
+const (
+	ConfigVarAdded   ConfigChangeType = "added"
+	ConfigVarChanged ConfigChangeType = "changed"
+	ConfigVarRemoved ConfigChangeType = "removed"
+)
+
+// ConfigChange represents a change to a single config var.
+type ConfigChange struct {
+	// The config var that changed.
+	Name Variable
+
+	// How the config var changed.
+	Type ConfigChangeType
+
+	// The value of the config var before the change. Nil if the config var
+	// was added.
+	Old *string
+
+	// The value of the config var after the change. Nil if the config var
+	// was removed.
+	New *string
+}
+
+// ConfigDiff represents the changes between two configs, sorted by the name
+// of the config var.
+type ConfigDiff []*ConfigChange
+
+// Masked returns a copy of the ConfigDiff with the values of secrets masked.
+func (d ConfigDiff) Masked() ConfigDiff {
+	masked := make(ConfigDiff, len(d))
+	for i, c := range d {
+		cp := *c
+		cp.Old = maskSecret(c.Old)
+		cp.New = maskSecret(c.New)
+		masked[i] = &cp
+	}
+	return masked
+}
+
+func maskSecret(v *string) *string {
+	if v != nil && isSecret(*v) {
+		s := MaskedSecret
+		return &s
+	}
+	return v
+}
+
+// diffVars returns the changes required to go from the old vars to the new
+// vars.
+func diffVars(old, new Vars) ConfigDiff {
+	var diff ConfigDiff
+
+	for n, v := range new {
+		o, ok := old[n]
+		switch {
+		case !ok:
+			diff = append(diff, &ConfigChange{Name: n, Type: ConfigVarAdded, New: v})
+		case !varEqual(o, v):
+			diff = append(diff, &ConfigChange{Name: n, Type: ConfigVarChanged, Old: o, New: v})
+		}
+	}
+
+	for n, o := range old {
+		if _, ok := new[n]; !ok {
+			diff = append(diff, &ConfigChange{Name: n, Type: ConfigVarRemoved, Old: o})
+		}
+	}
+
+	sort.Sort(diff)
+	return diff
+}
+
+func varEqual(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// Len implements sort.Interface.
+func (d ConfigDiff) Len() int { return len(d) }
+
+// Less implements sort.Interface.
+func (d ConfigDiff) Less(i, j int) bool { return d[i].Name < d[j].Name }
+
+// Swap implements sort.Interface.
+func (d ConfigDiff) Swap(i, j int) { d[i], d[j] = d[j], d[i] }
+
+// ConfigVersion represents the changes that were made to the config of an app
+// in a release.
+type ConfigVersion struct {
+	// The release that the config was changed in.
+	Release *Release
+
+	// The changes from the config in the previous release.
+	Changes ConfigDiff
+}
+
+// configHistory returns the changes that were made to the config in each of
+// the releases matching the query. Releases that didn't change the config are
+// omitted.
+func configHistory(db *gorm.DB, q ReleasesQuery) ([]*ConfigVersion, error) {
+	rels, err := releases(db, q)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Release)
+	for _, r := range rels {
+		byVersion[r.Version] = r
+	}
+
+	var history []*ConfigVersion
+	for _, r := range rels {
+		prev, ok := byVersion[r.Version-1]
+		if !ok {
+			prev, err = previousRelease(db, r)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		var old Vars
+		if prev != nil {
+			old = prev.Config.Vars
+		}
+
+		changes := diffVars(old, r.Config.Vars)
+		if len(changes) == 0 {
+			continue
+		}
+
+		history = append(history, &ConfigVersion{
+			Release: r,
+			Changes: changes,
+		})
+	}
+
+	return history, nil
+}
+
+// previousRelease returns the release before r, or nil if r is the first
+// release of the app.
+func previousRelease(db *gorm.DB, r *Release) (*Release, error) {
+	if r.Version <= 1 {
+		return nil, nil
+	}
+
+	version := r.Version - 1
+	prev, err := releasesFind(db, ReleasesQuery{App: &App{ID: r.AppID}, Version: &version})
+	if err == gorm.RecordNotFound {
+		return nil, nil
+	}
+	return prev, err
+}
+
+// configDiff returns the changes to the config of an app between two
+// releases.
+func configDiff(db *gorm.DB, app *App, from, to int) (ConfigDiff, error) {
+	var configs []*Config
+	for _, version := range []int{from, to} {
+		r, err := releasesFind(db, ReleasesQuery{App: app, Version: &version})
+		if err != nil {
+			return nil, err
+		}
+
+		c, err := configsFind(db, ConfigsQuery{ID: &r.ConfigID})
+		if err != nil {
+			return nil, err
+		}
+		configs = append(configs, c)
+	}
+
+	return diffVars(configs[0].Vars, configs[1].Vars), nil
+}
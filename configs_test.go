@@ -125,3 +125,74 @@ func TestReleaseDesc(t *testing.T) {
 		}
 	}
 }
+
+func TestDiffVars(t *testing.T) {
+	var (
+		PRODUCTION   = "production"
+		STAGING      = "staging"
+		EMPTY        = ""
+		DATABASE_URL = "postgres://localhost"
+	)
+
+	tests := []struct {
+		old, new Vars
+		diff     ConfigDiff
+	}{
+		{nil, nil, nil},
+		{Vars{"RAILS_ENV": &PRODUCTION}, Vars{"RAILS_ENV": &PRODUCTION}, nil},
+
+		// Adding a variable
+		{
+			nil,
+			Vars{"RAILS_ENV": &PRODUCTION},
+			ConfigDiff{
+				{Name: "RAILS_ENV", Type: ConfigVarAdded, New: &PRODUCTION},
+			},
+		},
+
+		// Changing, adding and removing variables
+		{
+			Vars{"RAILS_ENV": &PRODUCTION, "DATABASE_URL": &DATABASE_URL},
+			Vars{"RAILS_ENV": &STAGING, "EMPTY": &EMPTY},
+			ConfigDiff{
+				{Name: "DATABASE_URL", Type: ConfigVarRemoved, Old: &DATABASE_URL},
+				{Name: "EMPTY", Type: ConfigVarAdded, New: &EMPTY},
+				{Name: "RAILS_ENV", Type: ConfigVarChanged, Old: &PRODUCTION, New: &STAGING},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		diff := diffVars(tt.old, tt.new)
+		if got, want := diff, tt.diff; !reflect.DeepEqual(got, want) {
+			t.Errorf("diffVars(%v, %v) => %v; want %v", tt.old, tt.new, got, want)
+		}
+	}
+}
+
+func TestConfigDiff_Masked(t *testing.T) {
+	var (
+		PRODUCTION = "production"
+		SECRET     = secretPrefix + "local:acme-inc/DATABASE_URL:abcd"
+		MASKED     = MaskedSecret
+	)
+
+	diff := ConfigDiff{
+		{Name: "DATABASE_URL", Type: ConfigVarChanged, Old: &PRODUCTION, New: &SECRET},
+		{Name: "RAILS_ENV", Type: ConfigVarAdded, New: &PRODUCTION},
+	}
+
+	expected := ConfigDiff{
+		{Name: "DATABASE_URL", Type: ConfigVarChanged, Old: &PRODUCTION, New: &MASKED},
+		{Name: "RAILS_ENV", Type: ConfigVarAdded, New: &PRODUCTION},
+	}
+
+	if got, want := diff.Masked(), expected; !reflect.DeepEqual(got, want) {
+		t.Errorf("Masked() => %v; want %v", got, want)
+	}
+
+	// The original diff should be untouched.
+	if got, want := *diff[0].New, SECRET; got != want {
+		t.Errorf("New => %v; want %v", got, want)
+	}
+}
@@ -2,7 +2,6 @@ package empire
 
 import (
 	"encoding/json"
-	"fmt"
 
 	. "github.com/remind101/empire/pkg/bytesize"
 	"github.com/remind101/empire/pkg/constraints"
@@ -13,13 +12,6 @@ var (
 	Constraints2X = Constraints{constraints.CPUShare(512), constraints.Memory(1 * GB), constraints.Nproc(512)}
 	ConstraintsPX = Constraints{constraints.CPUShare(1024), constraints.Memory(6 * GB), 0}
 
-	// NamedConstraints maps a heroku dynos size to a Constraints.
-	NamedConstraints = map[string]Constraints{
-		"1X": Constraints1X,
-		"2X": Constraints2X,
-		"PX": ConstraintsPX,
-	}
-
 	// DefaultConstraints defaults to 1X process size.
 	DefaultConstraints = Constraints1X
 )
@@ -28,25 +20,9 @@ var (
 // json.Unmarshaller interface.
 type Constraints constraints.Constraints
 
-func parseConstraints(con string) (*Constraints, error) {
-	if con == "" {
-		return nil, nil
-	}
-
-	if n, ok := NamedConstraints[con]; ok {
-		c := Constraints(n)
-		return &c, nil
-	}
-
-	c, err := constraints.Parse(con)
-	if err != nil {
-		return nil, err
-	}
-
-	r := Constraints(c)
-	return &r, nil
-}
-
+// UnmarshalJSON implements the json.Unmarshaler interface. Only the default dyno
+// sizes are recognized by name; use DynoSizes.Parse to parse the dyno sizes
+// that an Empire instance is configured with.
 func (c *Constraints) UnmarshalJSON(b []byte) error {
 	var s string
 
@@ -54,7 +30,7 @@ func (c *Constraints) UnmarshalJSON(b []byte) error {
 		return err
 	}
 
-	cc, err := parseConstraints(s)
+	cc, err := DefaultDynoSizes.Parse(s)
 	if err != nil {
 		return err
 	}
@@ -66,16 +42,8 @@ func (c *Constraints) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
+// String implements the fmt.Stringer interface, using the names of the
+// default dyno sizes.
 func (c Constraints) String() string {
-	for n, constraint := range NamedConstraints {
-		if c == Constraints(constraint) {
-			return n
-		}
-	}
-
-	if c.Nproc == 0 {
-		return fmt.Sprintf("%d:%s", c.CPUShare, c.Memory)
-	} else {
-		return fmt.Sprintf("%d:%s:nproc=%d", c.CPUShare, c.Memory, c.Nproc)
-	}
+	return DefaultDynoSizes.Format(c)
 }
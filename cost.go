@@ -0,0 +1,263 @@
+package empire
+
+import (
+	"sort"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/remind101/empire/pkg/bytesize"
+	"github.com/remind101/pkg/timex"
+	"golang.org/x/net/context"
+)
+
+// HoursPerMonth is the average number of hours in a month, used to turn hourly
+// prices into monthly estimates.
+const HoursPerMonth = 730
+
+// DefaultCostMonths is the number of months of history that cost estimates
+// include by default.
+const DefaultCostMonths = 6
+
+// CostModel is the hourly price of the resources that apps use. Prices don't
+// have a currency; they're in whatever the operator configures them in.
+type CostModel struct {
+	// Price of 1 GB of memory for an hour.
+	GBHour float64
+
+	// Price of 1 CPU share for an hour.
+	CPUShareHour float64
+
+	// Price of a load balancer for an hour. Each exposed process has its
+	// own load balancer.
+	LoadBalancerHour float64
+}
+
+// ProcessCost is the estimated cost of a process.
+type ProcessCost struct {
+	// The process type.
+	Type string
+
+	// The number of instances, and the constraints of each of them.
+	Quantity int
+	Constraints
+
+	// True if the process is exposed with a load balancer.
+	LoadBalancer bool
+
+	// The estimated cost of the process for a month.
+	Monthly float64
+}
+
+// MonthlyCost is the estimated cost of an app over a calendar month.
+type MonthlyCost struct {
+	// The first day of the month, in UTC.
+	Month time.Time
+
+	// The estimated cost for the month. For the current month, this is the
+	// cost so far.
+	Cost float64
+}
+
+// AppCost is the estimated cost of an app.
+type AppCost struct {
+	App *App
+
+	// The estimated cost of running the current formation for a month.
+	Monthly float64
+
+	// The estimated cost of each process in the current formation.
+	Processes []*ProcessCost
+
+	// The estimated cost of each month, oldest first, ending with the
+	// current month.
+	History []*MonthlyCost
+}
+
+// hourly returns the cost of running the process for an hour.
+func (m CostModel) hourly(app *App, name string, p Process) float64 {
+	gb := float64(p.Memory) / float64(bytesize.GB)
+
+	cost := float64(p.Quantity) * (gb*m.GBHour + float64(p.CPUShare)*m.CPUShareHour)
+	if processExposure(app, name, p) != nil {
+		cost += m.LoadBalancerHour
+	}
+	return cost
+}
+
+// formationHourly returns the cost of running the formation for an hour.
+func (m CostModel) formationHourly(app *App, f Formation) float64 {
+	var cost float64
+	for name, p := range f {
+		if !alwaysOn(name, p) {
+			continue
+		}
+		cost += m.hourly(app, name, p)
+	}
+	return cost
+}
+
+// alwaysOn returns true if the process is kept running. Scheduled processes
+// and the release process only run to completion, so their quantity isn't
+// the number of instances that are running.
+func alwaysOn(name string, p Process) bool {
+	return p.Cron == nil && name != releaseProcessType
+}
+
+// processCosts returns the monthly cost of each process in the formation,
+// sorted by process type.
+func (m CostModel) processCosts(app *App, f Formation) []*ProcessCost {
+	var names []string
+	for name, p := range f {
+		if alwaysOn(name, p) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var costs []*ProcessCost
+	for _, name := range names {
+		p := f[name]
+		costs = append(costs, &ProcessCost{
+			Type:         name,
+			Quantity:     p.Quantity,
+			Constraints:  p.Constraints(),
+			LoadBalancer: processExposure(app, name, p) != nil,
+			Monthly:      m.hourly(app, name, p) * HoursPerMonth,
+		})
+	}
+	return costs
+}
+
+// releasePeriod is a formation of an app, and when it was created, either by a
+// new release, or by scaling the current release.
+type releasePeriod struct {
+	Formation Formation
+	CreatedAt time.Time
+}
+
+// history returns the estimated cost of each of the given number of months,
+// ending with the month of now. Each formation is assumed to have run from
+// when it was created, until the next one was created.
+func (m CostModel) history(app *App, releases []releasePeriod, months int, now time.Time) []*MonthlyCost {
+	now = now.UTC()
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -(months - 1), 0)
+
+	var history []*MonthlyCost
+	for i := 0; i < months; i++ {
+		history = append(history, &MonthlyCost{Month: start.AddDate(0, i, 0)})
+	}
+
+	for i, r := range releases {
+		end := now
+		if i+1 < len(releases) {
+			end = releases[i+1].CreatedAt
+		}
+
+		hourly := m.formationHourly(app, r.Formation)
+		for _, month := range history {
+			from, to := month.Month, month.Month.AddDate(0, 1, 0)
+			if r.CreatedAt.After(from) {
+				from = r.CreatedAt
+			}
+			if end.Before(to) {
+				to = end
+			}
+			if to.After(from) {
+				month.Cost += hourly * to.Sub(from).Hours()
+			}
+		}
+	}
+
+	return history
+}
+
+// costsService estimates the cost of apps, using the CostModel.
+type costsService struct {
+	*Empire
+}
+
+// AppCost returns the estimated cost of the app, with the given number of
+// months of history.
+func (s *costsService) AppCost(db *gorm.DB, app *App, months int) (*AppCost, error) {
+	if months <= 0 {
+		months = DefaultCostMonths
+	}
+
+	now := timex.Now()
+	since := now.UTC().AddDate(0, -months, 0)
+
+	releases, err := releasePeriods(db, app, since)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &AppCost{
+		App:     app,
+		History: s.CostModel.history(app, releases, months, now),
+	}
+
+	if len(releases) > 0 {
+		current := releases[len(releases)-1].Formation
+		c.Processes = s.CostModel.processCosts(app, current)
+		c.Monthly = s.CostModel.formationHourly(app, current) * HoursPerMonth
+	}
+
+	return c, nil
+}
+
+// formationChangesCreate records the formation of the app, so that the
+// history of its cost includes changes that are made by scaling, as well as by
+// new releases.
+func formationChangesCreate(db *gorm.DB, app *App, f Formation) error {
+	return db.Exec(`INSERT INTO formation_changes (app_id, formation, created_at) VALUES (?, ?, ?)`, app.ID, f, timex.Now()).Error
+}
+
+// releasePeriods returns the formations of the app since the given time,
+// oldest first, including the formation that was current at that time.
+func releasePeriods(db *gorm.DB, app *App, since time.Time) ([]releasePeriod, error) {
+	rows, err := db.Raw(`SELECT formation, created_at FROM formation_changes WHERE app_id = ? AND created_at >= (SELECT COALESCE(MAX(created_at), '-infinity') FROM formation_changes WHERE app_id = ? AND created_at <= ?) ORDER BY created_at`, app.ID, app.ID, since).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var releases []releasePeriod
+	for rows.Next() {
+		var r releasePeriod
+		if err := rows.Scan(&r.Formation, &r.CreatedAt); err != nil {
+			return nil, err
+		}
+		releases = append(releases, r)
+	}
+
+	return releases, rows.Err()
+}
+
+// AppCost returns the estimated cost of an app, with the given number of months
+// of history. If months is 0, DefaultCostMonths is used.
+func (e *Empire) AppCost(ctx context.Context, app *App, months int) (*AppCost, error) {
+	return e.costs.AppCost(e.db, app, months)
+}
+
+// AppCosts returns the estimated cost of each of the apps, most expensive
+// first.
+func (e *Empire) AppCosts(ctx context.Context, apps []*App, months int) ([]*AppCost, error) {
+	var costs []*AppCost
+	for _, app := range apps {
+		c, err := e.costs.AppCost(e.db, app, months)
+		if err != nil {
+			return nil, err
+		}
+		costs = append(costs, c)
+	}
+
+	sort.Stable(byMonthlyCost(costs))
+	return costs, nil
+}
+
+// byMonthlyCost sorts app costs by their monthly cost, most expensive first.
+type byMonthlyCost []*AppCost
+
+func (s byMonthlyCost) Len() int           { return len(s) }
+func (s byMonthlyCost) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s byMonthlyCost) Less(i, j int) bool { return s[i].Monthly > s[j].Monthly }
@@ -0,0 +1,83 @@
+package empire
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/remind101/empire/pkg/bytesize"
+	"github.com/remind101/empire/pkg/constraints"
+	"github.com/stretchr/testify/assert"
+)
+
+var testCostModel = CostModel{
+	GBHour:           0.01,
+	CPUShareHour:     0.0001,
+	LoadBalancerHour: 0.025,
+}
+
+func TestCostModel_ProcessCosts(t *testing.T) {
+	app := &App{Name: "acme-inc"}
+	f := Formation{
+		"web":    Process{Quantity: 2, CPUShare: 256, Memory: constraints.Memory(1 * GB)},
+		"worker": Process{Quantity: 1, CPUShare: 1024, Memory: constraints.Memory(6 * GB)},
+	}
+
+	costs := testCostModel.processCosts(app, f)
+	assert.Equal(t, 2, len(costs))
+
+	// 2 * (1GB * 0.01 + 256 * 0.0001) + 0.025 for the load balancer.
+	assert.Equal(t, "web", costs[0].Type)
+	assert.True(t, costs[0].LoadBalancer)
+	assert.InDelta(t, (2*(0.01+0.0256)+0.025)*HoursPerMonth, costs[0].Monthly, 0.0001)
+
+	assert.Equal(t, "worker", costs[1].Type)
+	assert.False(t, costs[1].LoadBalancer)
+	assert.InDelta(t, (0.06+0.1024)*HoursPerMonth, costs[1].Monthly, 0.0001)
+
+	assert.InDelta(t, costs[0].Monthly+costs[1].Monthly, testCostModel.formationHourly(app, f)*HoursPerMonth, 0.0001)
+}
+
+func TestCostModel_ProcessCosts_RunToCompletion(t *testing.T) {
+	app := &App{Name: "acme-inc"}
+	cron := "0 * * * ? *"
+	f := Formation{
+		"worker":           Process{Quantity: 1, CPUShare: 256, Memory: constraints.Memory(1 * GB)},
+		"scheduled":        Process{Quantity: 1, CPUShare: 256, Memory: constraints.Memory(1 * GB), Cron: &cron},
+		releaseProcessType: Process{Quantity: 1, CPUShare: 256, Memory: constraints.Memory(1 * GB)},
+	}
+
+	costs := testCostModel.processCosts(app, f)
+	if assert.Equal(t, 1, len(costs)) {
+		assert.Equal(t, "worker", costs[0].Type)
+	}
+	assert.InDelta(t, 0.01+0.0256, testCostModel.formationHourly(app, f), 0.0001)
+}
+
+func TestCostModel_History(t *testing.T) {
+	app := &App{Name: "acme-inc"}
+	m := CostModel{GBHour: 1}
+
+	oneGB := Formation{"worker": Process{Quantity: 1, CPUShare: 256, Memory: constraints.Memory(1 * GB)}}
+	twoGB := Formation{"worker": Process{Quantity: 2, CPUShare: 256, Memory: constraints.Memory(1 * GB)}}
+
+	releases := []releasePeriod{
+		{Formation: oneGB, CreatedAt: time.Date(2015, time.December, 15, 0, 0, 0, 0, time.UTC)},
+		{Formation: twoGB, CreatedAt: time.Date(2016, time.February, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	now := time.Date(2016, time.February, 2, 12, 0, 0, 0, time.UTC)
+	history := m.history(app, releases, 3, now)
+
+	assert.Equal(t, []*MonthlyCost{
+		// The history starts in December, but the first release was
+		// created in the middle of it.
+		{Month: time.Date(2015, time.December, 1, 0, 0, 0, 0, time.UTC), Cost: 17 * 24},
+		{Month: time.Date(2016, time.January, 1, 0, 0, 0, 0, time.UTC), Cost: 31 * 24},
+		// The current month only includes the cost so far.
+		{Month: time.Date(2016, time.February, 1, 0, 0, 0, 0, time.UTC), Cost: 2 * 36},
+	}, history)
+
+	// Months before the first release cost nothing.
+	history = m.history(app, releases, 4, now)
+	assert.Equal(t, 0.0, history[0].Cost)
+}
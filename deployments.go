@@ -19,6 +19,80 @@ type deployerService struct {
 
 // createRelease creates a new release that can be deployed
 func (s *deployerService) createRelease(ctx context.Context, db *gorm.DB, ss scheduler.StatusStream, opts DeployOpts) (*Release, error) {
+	r, err := s.newRelease(ctx, db, opts)
+	if err != nil {
+		return r, err
+	}
+
+	return s.releases.Create(ctx, db, r)
+}
+
+// beginRelease creates a new release that can be deployed, in a transaction
+// that's returned so that the caller can commit it. If the release has a
+// release process, the app and slug are committed first, and the release
+// process is run before the transaction to create the release is started, so
+// that a long running release process doesn't hold any locks.
+func (s *deployerService) beginRelease(ctx context.Context, opts DeployOpts) (*gorm.DB, *Release, error) {
+	tx := s.db.Begin()
+
+	r, err := s.newRelease(ctx, tx, opts)
+	if err != nil {
+		tx.Rollback()
+		return nil, r, err
+	}
+
+	if err := canaryGuard(tx, r.App); err != nil {
+		tx.Rollback()
+		return nil, r, err
+	}
+
+	if err := buildFormation(tx, r); err != nil {
+		tx.Rollback()
+		return nil, r, err
+	}
+
+	var version int
+	if _, ok := r.Formation[releaseProcessType]; ok {
+		v, err := releasesLastVersion(tx, r.App.ID)
+		if err != nil {
+			tx.Rollback()
+			return nil, r, err
+		}
+		version = v + 1
+
+		if err := tx.Commit().Error; err != nil {
+			tx.Rollback()
+			return nil, r, err
+		}
+
+		r.Version = version
+		if err := s.runReleaseProcess(ctx, r, opts.Output); err != nil {
+			return nil, r, err
+		}
+
+		tx = s.db.Begin()
+	}
+
+	// The formation is built again once the releases are locked, in case
+	// it was changed while the release process was running.
+	r.Formation = nil
+	if _, err := s.releases.Create(ctx, tx, r); err != nil {
+		tx.Rollback()
+		return nil, r, err
+	}
+
+	// If another release was created in the meantime, the release process
+	// ran against a different version than the one we'd be committing.
+	if version != 0 && r.Version != version {
+		tx.Rollback()
+		return nil, r, ErrReleaseConflict
+	}
+
+	return tx, r, nil
+}
+
+// newRelease builds a new Release for the image, without creating it.
+func (s *deployerService) newRelease(ctx context.Context, db *gorm.DB, opts DeployOpts) (*Release, error) {
 	app, img := opts.App, opts.Image
 
 	// If no app is specified, attempt to find the app that relates to this
@@ -54,23 +128,70 @@ func (s *deployerService) createRelease(ctx context.Context, db *gorm.DB, ss sch
 	desc := fmt.Sprintf("Deploy %s", img.String())
 	desc = appendMessageToDescription(desc, opts.User, opts.Message)
 
-	r, err := s.releases.Create(ctx, db, &Release{
+	return &Release{
 		App:         app,
 		Config:      config,
 		Slug:        slug,
 		Description: desc,
-	})
-	return r, err
+	}, nil
 }
 
 func (s *deployerService) createInTransaction(ctx context.Context, stream scheduler.StatusStream, opts DeployOpts) (*Release, error) {
-	tx := s.db.Begin()
-	r, err := s.createRelease(ctx, tx, stream, opts)
+	// The release is only committed if the release process succeeds.
+	tx, r, err := s.beginRelease(ctx, opts)
 	if err != nil {
+		return r, err
+	}
+
+	if err := s.publishDeployEvent(tx, r, opts); err != nil {
+		tx.Rollback()
+		return r, err
+	}
+
+	if err := tx.Commit().Error; err != nil {
 		tx.Rollback()
 		return r, err
 	}
-	return r, tx.Commit().Error
+
+	return r, nil
+}
+
+// publishDeployEvent records the DeployEvent for the new release in db, so that
+// the event is only published if the release is committed.
+func (s *deployerService) publishDeployEvent(db *gorm.DB, r *Release, opts DeployOpts) error {
+	event := opts.Event()
+	event.Release = r.Version
+	event.Environment = s.Environment
+	// Deals with new app creation on first deploy
+	if event.App == "" && r.App != nil {
+		event.App = r.App.Name
+		event.app = r.App
+	}
+
+	return s.publishEvent(db, event)
+}
+
+// runReleaseProcess runs the release process from the Procfile, if there is
+// one, to completion with the image and config of the new release. The output
+// of the process is written to the DeploymentStream.
+func (s *deployerService) runReleaseProcess(ctx context.Context, r *Release, w *DeploymentStream) error {
+	p, ok := r.Formation[releaseProcessType]
+	if !ok {
+		return nil
+	}
+
+	if err := w.Status(fmt.Sprintf("Running release process for v%d of %s", r.Version, r.App.Name)); err != nil {
+		return err
+	}
+
+	p.Quantity = 1
+
+	a := newSchedulerApp(r)
+	if err := s.Scheduler.Run(ctx, a, newSchedulerProcess(r, releaseProcessType, p), nil, &releaseProcessOutput{w}); err != nil {
+		return &ReleaseProcessError{Err: err}
+	}
+
+	return nil
 }
 
 // Deploy is a thin wrapper around deploy to that adds the error to the
@@ -83,6 +204,14 @@ func (s *deployerService) Deploy(ctx context.Context, opts DeployOpts) (*Release
 		stream = w
 	}
 
+	if opts.Plan {
+		return s.planDeploy(ctx, opts)
+	}
+
+	if opts.Strategy.Percent() > 0 {
+		return s.deployCanary(ctx, stream, opts)
+	}
+
 	r, err := s.createInTransaction(ctx, stream, opts)
 	if err != nil {
 		return r, w.Error(err)
@@ -92,13 +221,153 @@ func (s *deployerService) Deploy(ctx context.Context, opts DeployOpts) (*Release
 		return r, err
 	}
 
+	// When the app has opted into automatic rollbacks, we need to wait
+	// for the new release to stabilize, so that we know whether it should
+	// be rolled back.
+	if r.App.AutoRollback {
+		stream = w
+	}
+
 	if err := s.releases.Release(ctx, r, stream); err != nil {
+		if _, ok := err.(*scheduler.UnstableError); ok && r.App.AutoRollback {
+			return r, w.Error(s.autoRollback(ctx, r, err, opts))
+		}
 		return r, w.Error(err)
 	}
 
 	return r, w.Status(fmt.Sprintf("Finished processing events for release v%d of %s", r.Version, r.App.Name))
 }
 
+// planDeploy creates a new release, and writes a preview of the changes that
+// releasing it would make to the DeploymentStream. The release is never
+// committed, and the release process isn't run.
+func (s *deployerService) planDeploy(ctx context.Context, opts DeployOpts) (*Release, error) {
+	w := opts.Output
+
+	tx := s.db.Begin()
+
+	r, err := s.createRelease(ctx, tx, nil, opts)
+	if err != nil {
+		tx.Rollback()
+		return r, w.Error(err)
+	}
+
+	plan, err := s.releases.Plan(ctx, tx, r)
+	if err != nil {
+		tx.Rollback()
+		return r, w.Error(err)
+	}
+
+	if err := tx.Rollback().Error; err != nil {
+		return r, w.Error(err)
+	}
+
+	if len(plan.Changes) == 0 {
+		if err := w.Status("No resources would be changed"); err != nil {
+			return r, err
+		}
+	}
+
+	for _, c := range plan.Changes {
+		if err := w.Status(c.String()); err != nil {
+			return r, err
+		}
+	}
+
+	return r, w.Status(fmt.Sprintf("Planned release v%d for %s, nothing was deployed", r.Version, r.App.Name))
+}
+
+// autoRollback rolls the app back to the release before r, because r failed to
+// stabilize. The original error is returned, so that the deploy is still
+// considered failed.
+func (s *deployerService) autoRollback(ctx context.Context, r *Release, cause error, opts DeployOpts) error {
+	w := opts.Output
+
+	if r.Version <= 1 {
+		// Nothing to rollback to.
+		return cause
+	}
+
+	version := r.Version - 1
+	if err := w.Status(fmt.Sprintf("Release v%d failed to stabilize, rolling back to v%d", r.Version, version)); err != nil {
+		return err
+	}
+
+	if _, err := s.Empire.Rollback(ctx, RollbackOpts{
+		User:    opts.User,
+		App:     r.App,
+		Version: version,
+		Message: opts.Message,
+		Reason:  cause.Error(),
+	}); err != nil {
+		return fmt.Errorf("%v (automatic rollback to v%d failed: %v)", cause, version, err)
+	}
+
+	if err := w.Status(fmt.Sprintf("Rolled back %s to v%d", r.App.Name, version)); err != nil {
+		return err
+	}
+
+	return cause
+}
+
+// deployCanary creates a new release, and starts it as a canary alongside the
+// current release.
+func (s *deployerService) deployCanary(ctx context.Context, stream scheduler.StatusStream, opts DeployOpts) (*Release, error) {
+	w := opts.Output
+	percent := opts.Strategy.Percent()
+
+	// The release is only committed if the canary was successfully
+	// submitted to the scheduler.
+	tx, r, err := s.beginRelease(ctx, opts)
+	if err != nil {
+		return r, w.Error(err)
+	}
+
+	if err := s.canaries.Start(ctx, tx, r, percent, stream); err != nil {
+		tx.Rollback()
+		return r, w.Error(err)
+	}
+
+	if err := s.publishDeployEvent(tx, r, opts); err != nil {
+		tx.Rollback()
+		return r, w.Error(err)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		return r, w.Error(err)
+	}
+
+	if err := w.Status(fmt.Sprintf("Created new release v%d for %s", r.Version, r.App.Name)); err != nil {
+		return r, err
+	}
+
+	return r, w.Status(fmt.Sprintf("Started canary for release v%d of %s (%d%%), use `emp promote` or `emp abort` to finish the deploy", r.Version, r.App.Name, percent))
+}
+
+// ReleaseProcessError is returned when the release process fails, which aborts
+// the deploy.
+type ReleaseProcessError struct {
+	Err error
+}
+
+func (e *ReleaseProcessError) Error() string {
+	return fmt.Sprintf("release process failed: %v", e.Err)
+}
+
+// releaseProcessOutput is an io.Writer that writes the output of the release
+// process to a DeploymentStream.
+type releaseProcessOutput struct {
+	w *DeploymentStream
+}
+
+func (o *releaseProcessOutput) Write(b []byte) (int, error) {
+	if err := o.w.encode(jsonmessage.JSONMessage{Stream: string(b)}); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
 // DeploymentStream provides a wrapper around an io.Writer for writing
 // jsonmessage statuses, and implements the scheduler.StatusStream interface.
 type DeploymentStream struct {
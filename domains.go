@@ -1,17 +1,27 @@
 package empire
 
 import (
+	"fmt"
+	"sort"
+	"strings"
+
 	"golang.org/x/net/context"
 
 	"time"
 
 	"github.com/jinzhu/gorm"
+	"github.com/remind101/empire/scheduler"
 	"github.com/remind101/pkg/timex"
 )
 
 type Domain struct {
-	ID        string
-	Hostname  string
+	ID string
+
+	// The hostname, optionally followed by a path (e.g.
+	// api.example.com/v2), in which case only requests matching the path
+	// are routed to the app.
+	Hostname string
+
 	CreatedAt *time.Time
 
 	AppID string
@@ -24,11 +34,34 @@ func (d *Domain) BeforeCreate() error {
 	return nil
 }
 
+// Split returns the host and the path of the domain. The path is empty if the
+// domain doesn't include a path.
+func (d *Domain) Split() (host, path string) {
+	i := strings.Index(d.Hostname, "/")
+	if i == -1 {
+		return d.Hostname, ""
+	}
+	return d.Hostname[:i], d.Hostname[i:]
+}
+
+// Validate checks that the domain is a host, followed by an optional path.
+func (d *Domain) Validate() error {
+	host, path := d.Split()
+	if host == "" || path == "/" || strings.ContainsAny(d.Hostname, " *") {
+		return &ValidationError{Err: fmt.Errorf("%s is not a valid domain", d.Hostname)}
+	}
+	return nil
+}
+
 type domainsService struct {
 	*Empire
 }
 
 func (s *domainsService) DomainsCreate(ctx context.Context, db *gorm.DB, domain *Domain) (*Domain, error) {
+	if err := domain.Validate(); err != nil {
+		return domain, err
+	}
+
 	d, err := domainsFind(db, DomainsQuery{Hostname: &domain.Hostname})
 	if err != nil && err != gorm.RecordNotFound {
 		return domain, err
@@ -119,6 +152,36 @@ func domainsDestroy(db *gorm.DB, domain *Domain) error {
 	return db.Delete(domain).Error
 }
 
+// schedulerDomains returns the domains for the app, to pass along to the
+// scheduler.
+func schedulerDomains(db *gorm.DB, app *App) ([]scheduler.Domain, error) {
+	ds, err := domains(db, DomainsQuery{App: app})
+	if err != nil {
+		return nil, err
+	}
+
+	var sds []scheduler.Domain
+	for _, d := range ds {
+		host, path := d.Split()
+		sds = append(sds, scheduler.Domain{Hostname: host, Path: path})
+	}
+	sort.Sort(byHostnameAndPath(sds))
+	return sds, nil
+}
+
+// byHostnameAndPath implements the sort.Interface interface to sort domains by
+// hostname, then by path.
+type byHostnameAndPath []scheduler.Domain
+
+func (d byHostnameAndPath) Len() int      { return len(d) }
+func (d byHostnameAndPath) Swap(i, j int) { d[i], d[j] = d[j], d[i] }
+func (d byHostnameAndPath) Less(i, j int) bool {
+	if d[i].Hostname == d[j].Hostname {
+		return d[i].Path < d[j].Path
+	}
+	return d[i].Hostname < d[j].Hostname
+}
+
 func makePublic(db *gorm.DB, appID string) error {
 	a, err := appsFind(db, AppsQuery{ID: &appID})
 	if err != nil {
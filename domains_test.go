@@ -14,3 +14,47 @@ func TestDomainsQuery(t *testing.T) {
 
 	tests.Run(t)
 }
+
+func TestDomain_Split(t *testing.T) {
+	tests := []struct {
+		hostname   string
+		host, path string
+	}{
+		{"api.example.com", "api.example.com", ""},
+		{"api.example.com/v2", "api.example.com", "/v2"},
+		{"api.example.com/v2/users", "api.example.com", "/v2/users"},
+	}
+
+	for _, tt := range tests {
+		d := &Domain{Hostname: tt.hostname}
+		host, path := d.Split()
+		if host != tt.host || path != tt.path {
+			t.Errorf("Split(%q) => %q, %q; want %q, %q", tt.hostname, host, path, tt.host, tt.path)
+		}
+	}
+}
+
+func TestDomain_Validate(t *testing.T) {
+	tests := []struct {
+		hostname string
+		valid    bool
+	}{
+		{"api.example.com", true},
+		{"api.example.com/v2", true},
+		{"api.example.com/", false},
+		{"/v2", false},
+		{"*.example.com", false},
+		{"api example.com", false},
+	}
+
+	for _, tt := range tests {
+		d := &Domain{Hostname: tt.hostname}
+		err := d.Validate()
+		if tt.valid && err != nil {
+			t.Errorf("Validate(%q) => %v; want no error", tt.hostname, err)
+		}
+		if !tt.valid && err == nil {
+			t.Errorf("Validate(%q) => nil; want an error", tt.hostname)
+		}
+	}
+}
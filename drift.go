@@ -0,0 +1,346 @@
+package empire
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/remind101/empire/scheduler"
+	"golang.org/x/net/context"
+)
+
+// The name of the user that background jobs, like reconciliations, are
+// attributed to.
+const reconcilerUserName = "empire"
+
+// ProcessDrift describes how a process that's running in the scheduler differs
+// from the formation of the latest release.
+type ProcessDrift struct {
+	// The process type.
+	Type string
+
+	// The quantity in the formation of the latest release.
+	Quantity int
+
+	// The number of instances that the scheduler is configured to run, or
+	// -1 if the scheduler doesn't report this.
+	Desired int
+
+	// The number of instances that are running, or starting.
+	Running int
+
+	// The number of running instances that belong to a different release.
+	Outdated int
+
+	// If the process is autoscaled, the bounds of the autoscaling policy.
+	// The desired count is expected to change within these bounds.
+	Autoscaling *Autoscaling
+}
+
+// Drifted returns true if the process differs from the formation.
+func (d *ProcessDrift) Drifted() bool {
+	expected := d.Quantity
+	if a := d.Autoscaling; a != nil {
+		if d.Desired < 0 {
+			return d.Running != a.Bound(d.Running) || d.Outdated > 0
+		}
+		expected = d.Desired
+		if expected != a.Bound(expected) {
+			return true
+		}
+	} else if d.Desired >= 0 && d.Desired != d.Quantity {
+		return true
+	}
+	return d.Running != expected || d.Outdated > 0
+}
+
+// Drift describes how an app that's running in the scheduler differs from the
+// latest release.
+type Drift struct {
+	// The app.
+	App *App
+
+	// The version of the latest release.
+	Release int
+
+	// Each process in the formation of the latest release, sorted by type.
+	Processes []*ProcessDrift
+}
+
+// Drifted returns true if any of the processes differ from the formation.
+func (d *Drift) Drifted() bool {
+	return len(d.DriftedProcesses()) > 0
+}
+
+// DriftedProcesses returns the types of the processes that differ from the
+// formation.
+func (d *Drift) DriftedProcesses() []string {
+	var types []string
+	for _, p := range d.Processes {
+		if p.Drifted() {
+			types = append(types, p.Type)
+		}
+	}
+	return types
+}
+
+// driftService compares what's running in the scheduler with the latest
+// release of an app, and re-submits the release to converge them.
+type driftService struct {
+	*Empire
+
+	// Apps that had drifted on the last reconciliation, so that drift is
+	// only converged when it persists, and not while a release is still
+	// rolling out.
+	drifted map[string]bool
+}
+
+// Drift returns how the app that's running in the scheduler differs from the
+// latest release. Scheduled processes are ignored, since they only run
+// periodically, as are one-off processes.
+func (s *driftService) Drift(ctx context.Context, db *gorm.DB, app *App) (*Drift, error) {
+	// The latest release is the canary, which is only running a fraction
+	// of its formation.
+	if err := canaryGuard(db, app); err != nil {
+		return nil, err
+	}
+
+	release, err := releasesFind(db, ReleasesQuery{App: app})
+	if err != nil {
+		if err == gorm.RecordNotFound {
+			return nil, ErrNoReleases
+		}
+		return nil, err
+	}
+
+	instances, err := s.Scheduler.Instances(ctx, app.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	desired, err := scheduler.DesiredCounts(ctx, s.Scheduler, app.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return newDrift(release, instances, desired), nil
+}
+
+// newDrift compares the formation of the release with the instances, and
+// desired counts, from the scheduler.
+func newDrift(release *Release, instances []*scheduler.Instance, desired map[string]uint) *Drift {
+	d := &Drift{
+		App:     release.App,
+		Release: release.Version,
+	}
+
+	processes := make(map[string]*ProcessDrift)
+	for name, p := range release.Formation {
+		if p.Cron != nil || name == releaseProcessType {
+			continue
+		}
+
+		pd := &ProcessDrift{
+			Type:        name,
+			Quantity:    p.Quantity,
+			Desired:     -1,
+			Autoscaling: p.Autoscaling,
+		}
+		if n, ok := desired[name]; ok {
+			pd.Desired = int(n)
+		}
+		processes[name] = pd
+		d.Processes = append(d.Processes, pd)
+	}
+	sort.Sort(processDriftsByType(d.Processes))
+
+	version := fmt.Sprintf("v%d", release.Version)
+	for _, i := range instances {
+		if strings.EqualFold(i.State, "STOPPED") {
+			continue
+		}
+
+		pd, ok := processes[i.Process.Type]
+		if !ok {
+			continue
+		}
+
+		pd.Running++
+		if v, ok := i.Process.Env["EMPIRE_RELEASE"]; ok && v != version {
+			pd.Outdated++
+		}
+	}
+
+	return d
+}
+
+// Reconcile re-submits the latest release of the app to the scheduler, if it has
+// drifted. The drift that was detected is returned.
+func (s *driftService) Reconcile(ctx context.Context, db *gorm.DB, app *App) (*Drift, error) {
+	d, err := s.Drift(ctx, db, app)
+	if err != nil {
+		return nil, err
+	}
+
+	if !d.Drifted() {
+		return d, nil
+	}
+
+	return d, s.releases.ReleaseApp(ctx, db, app)
+}
+
+// ReconcileAll checks every app for drift. When converge is true, apps that
+// were drifted on the previous check, and still are, are re-submitted.
+func (s *driftService) ReconcileAll(ctx context.Context, converge bool) error {
+	apps, err := apps(s.db, AppsQuery{})
+	if err != nil {
+		return err
+	}
+
+	drifted := make(map[string]bool)
+	for _, app := range apps {
+		d, err := s.Drift(ctx, s.db, app)
+		if err != nil {
+			// Apps that have never been released, or that have a
+			// canary in progress, are expected.
+			if _, ok := err.(*ValidationError); !ok && err != ErrNoReleases {
+				log.Printf("drift: error checking %s: %v\n", app.Name, err)
+			}
+			continue
+		}
+
+		if !d.Drifted() {
+			continue
+		}
+
+		types := d.DriftedProcesses()
+		log.Printf("drift: %s has drifted from v%d (%s)\n", app.Name, d.Release, strings.Join(types, ", "))
+		drifted[app.ID] = true
+
+		if !converge || !s.drifted[app.ID] {
+			continue
+		}
+
+		if err := s.releases.ReleaseApp(ctx, s.db, app); err != nil {
+			log.Printf("drift: error reconciling %s: %v\n", app.Name, err)
+			continue
+		}
+
+		event := ReconcileEvent{
+			User:      reconcilerUserName,
+			App:       app.Name,
+			Release:   d.Release,
+			Processes: types,
+			app:       app,
+		}
+		if err := s.PublishEvent(event); err != nil {
+			log.Printf("drift: error publishing event for %s: %v\n", app.Name, err)
+		}
+
+		// Give the release a chance to roll out before it's considered
+		// drifted again.
+		delete(drifted, app.ID)
+	}
+	s.drifted = drifted
+
+	return nil
+}
+
+// processDriftsByType sorts ProcessDrifts by their type.
+type processDriftsByType []*ProcessDrift
+
+func (s processDriftsByType) Len() int           { return len(s) }
+func (s processDriftsByType) Less(i, j int) bool { return s[i].Type < s[j].Type }
+func (s processDriftsByType) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+// DriftOpts are options provided when checking an app for drift.
+type DriftOpts struct {
+	// User performing the action.
+	User *User
+
+	// The associated app.
+	App *App
+}
+
+func (opts DriftOpts) Validate(e *Empire) error {
+	return e.Authorize(opts.User, opts.App.Name, RoleViewer)
+}
+
+// Drift returns how the app that's running in the scheduler differs from its
+// latest release.
+func (e *Empire) Drift(ctx context.Context, opts DriftOpts) (*Drift, error) {
+	if err := opts.Validate(e); err != nil {
+		return nil, err
+	}
+
+	return e.drift.Drift(ctx, e.db, opts.App)
+}
+
+// ReconcileOpts are options provided when reconciling an app with its latest
+// release.
+type ReconcileOpts struct {
+	// User performing the action.
+	User *User
+
+	// The associated app.
+	App *App
+
+	// Commit message
+	Message string
+}
+
+func (opts ReconcileOpts) Validate(e *Empire) error {
+	if err := e.Authorize(opts.User, opts.App.Name, RoleDeployer); err != nil {
+		return err
+	}
+	return e.requireMessages(opts.Message)
+}
+
+// Reconcile re-submits the latest release of the app to the scheduler if it has
+// drifted, and returns the drift that was detected.
+func (e *Empire) Reconcile(ctx context.Context, opts ReconcileOpts) (*Drift, error) {
+	if err := opts.Validate(e); err != nil {
+		return nil, err
+	}
+
+	d, err := e.drift.Reconcile(ctx, e.db, opts.App)
+	if err != nil {
+		return d, err
+	}
+
+	if !d.Drifted() {
+		return d, nil
+	}
+
+	return d, e.PublishEvent(ReconcileEvent{
+		User:      opts.User.Name,
+		App:       opts.App.Name,
+		Release:   d.Release,
+		Processes: d.DriftedProcesses(),
+		Message:   opts.Message,
+		app:       opts.App,
+	})
+}
+
+// ReconcileDrift checks every app for drift every interval, until the context
+// is canceled. Drift is logged and, when converge is true, apps that stay
+// drifted for two consecutive checks are re-submitted to the scheduler.
+func (e *Empire) ReconcileDrift(ctx context.Context, interval time.Duration, converge bool) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := e.drift.ReconcileAll(ctx, converge); err != nil {
+			log.Printf("drift: error reconciling apps: %v\n", err)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package empire
+
+import (
+	"testing"
+
+	"github.com/remind101/empire/scheduler"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewDrift(t *testing.T) {
+	cron := "* * * * *"
+	release := &Release{
+		Version: 2,
+		Formation: Formation{
+			"web":       Process{Quantity: 2},
+			"worker":    Process{Quantity: 1},
+			"scheduled": Process{Quantity: 0, Cron: &cron},
+			"release":   Process{Quantity: 0},
+		},
+	}
+
+	instance := func(process, release, state string) *scheduler.Instance {
+		return &scheduler.Instance{
+			State: state,
+			Process: &scheduler.Process{
+				Type: process,
+				Env:  map[string]string{"EMPIRE_RELEASE": release},
+			},
+		}
+	}
+
+	d := newDrift(release, []*scheduler.Instance{
+		instance("web", "v2", "RUNNING"),
+		instance("web", "v1", "RUNNING"),
+		instance("web", "v1", "STOPPED"),
+		instance("worker", "v2", "PENDING"),
+		instance("run", "v2", "RUNNING"),
+	}, map[string]uint{
+		"web":    2,
+		"worker": 1,
+	})
+
+	assert.Equal(t, 2, d.Release)
+	assert.Equal(t, []*ProcessDrift{
+		{Type: "web", Quantity: 2, Desired: 2, Running: 2, Outdated: 1},
+		{Type: "worker", Quantity: 1, Desired: 1, Running: 1},
+	}, d.Processes)
+	assert.True(t, d.Drifted())
+	assert.Equal(t, []string{"web"}, d.DriftedProcesses())
+}
+
+func TestProcessDrift_Drifted(t *testing.T) {
+	tests := []struct {
+		drift   ProcessDrift
+		drifted bool
+	}{
+		{ProcessDrift{Quantity: 1, Desired: 1, Running: 1}, false},
+		{ProcessDrift{Quantity: 1, Desired: -1, Running: 1}, false},
+		{ProcessDrift{Quantity: 1, Desired: 3, Running: 1}, true},
+		{ProcessDrift{Quantity: 1, Desired: -1, Running: 0}, true},
+		{ProcessDrift{Quantity: 1, Desired: 1, Running: 1, Outdated: 1}, true},
+
+		// Autoscaled processes can have any desired count within the
+		// bounds of the policy.
+		{ProcessDrift{Quantity: 2, Desired: 5, Running: 5, Autoscaling: &Autoscaling{Min: 2, Max: 10}}, false},
+		{ProcessDrift{Quantity: 2, Desired: -1, Running: 5, Autoscaling: &Autoscaling{Min: 2, Max: 10}}, false},
+		{ProcessDrift{Quantity: 2, Desired: 5, Running: 4, Autoscaling: &Autoscaling{Min: 2, Max: 10}}, true},
+		{ProcessDrift{Quantity: 2, Desired: 12, Running: 12, Autoscaling: &Autoscaling{Min: 2, Max: 10}}, true},
+		{ProcessDrift{Quantity: 2, Desired: -1, Running: 1, Autoscaling: &Autoscaling{Min: 2, Max: 10}}, true},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.drifted, tt.drift.Drifted())
+	}
+}
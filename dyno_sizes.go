@@ -0,0 +1,177 @@
+package empire
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/remind101/empire/pkg/constraints"
+)
+
+// ErrDynoSize is returned when processes are scaled, or run, with constraints
+// that don't match one of the available dyno sizes, and RestrictDynoSizes is
+// enabled.
+var ErrDynoSize = &ValidationError{errors.New("Only the available dyno sizes can be used. Run `emp sizes` to list them.")}
+
+// DynoSize is a named set of constraints, like "2X", that processes can be
+// scaled to, or run with.
+type DynoSize struct {
+	Name string
+	Constraints
+}
+
+// DynoSizes is a list of dyno sizes.
+type DynoSizes []DynoSize
+
+// DefaultDynoSizes are the dyno sizes that are available when none have been
+// configured.
+var DefaultDynoSizes = DynoSizes{
+	{"1X", Constraints1X},
+	{"2X", Constraints2X},
+	{"PX", ConstraintsPX},
+}
+
+// Find returns the dyno size with the given name. Names are case insensitive.
+func (s DynoSizes) Find(name string) (DynoSize, bool) {
+	for _, size := range s {
+		if strings.EqualFold(size.Name, name) {
+			return size, true
+		}
+	}
+	return DynoSize{}, false
+}
+
+// Named returns the dyno size that matches the given constraints.
+func (s DynoSizes) Named(c Constraints) (DynoSize, bool) {
+	for _, size := range s {
+		if size.Constraints == c {
+			return size, true
+		}
+	}
+	return DynoSize{}, false
+}
+
+// Parse parses a dyno size name (e.g. 2X), or explicit constraints (e.g.
+// 512:1GB), into Constraints. An empty string returns nil.
+func (s DynoSizes) Parse(con string) (*Constraints, error) {
+	if con == "" {
+		return nil, nil
+	}
+
+	if n, ok := s.Find(con); ok {
+		c := n.Constraints
+		return &c, nil
+	}
+
+	c, err := constraints.Parse(con)
+	if err != nil {
+		return nil, err
+	}
+
+	r := Constraints(c)
+	return &r, nil
+}
+
+// Format returns the name of the dyno size that matches the constraints, or
+// the constraints themselves if none match.
+func (s DynoSizes) Format(c Constraints) string {
+	if n, ok := s.Named(c); ok {
+		return n.Name
+	}
+
+	if c.Nproc == 0 {
+		return fmt.Sprintf("%d:%s", c.CPUShare, c.Memory)
+	}
+	return fmt.Sprintf("%d:%s:nproc=%d", c.CPUShare, c.Memory, c.Nproc)
+}
+
+// Validate checks that each dyno size has a unique name, and a CPU share and
+// memory limit.
+func (s DynoSizes) Validate() error {
+	if len(s) == 0 {
+		return errors.New("at least one dyno size is required")
+	}
+
+	names := make(map[string]bool)
+	for _, size := range s {
+		if size.Name == "" || strings.Contains(size.Name, constraints.ConstraintsSeparator) {
+			return fmt.Errorf("%q is not a valid dyno size name", size.Name)
+		}
+		name := strings.ToUpper(size.Name)
+		if names[name] {
+			return fmt.Errorf("dyno size %s is defined more than once", size.Name)
+		}
+		names[name] = true
+
+		if size.CPUShare <= 0 {
+			return fmt.Errorf("dyno size %s needs a cpu_share", size.Name)
+		}
+		if size.Memory == 0 {
+			return fmt.Errorf("dyno size %s needs a memory limit", size.Name)
+		}
+	}
+
+	return nil
+}
+
+// ParseDynoSizes parses a JSON list of dyno sizes, like:
+//
+//	[
+//	  {"name": "1X", "cpu_share": 256, "memory": "512MB", "nproc": 256},
+//	  {"name": "4X", "cpu_share": 1024, "memory": "4GB"}
+//	]
+func ParseDynoSizes(r io.Reader) (DynoSizes, error) {
+	var raw []struct {
+		Name     string `json:"name"`
+		CPUShare int    `json:"cpu_share"`
+		Memory   string `json:"memory"`
+		Nproc    uint   `json:"nproc"`
+	}
+
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("error decoding dyno sizes: %v", err)
+	}
+
+	var sizes DynoSizes
+	for _, s := range raw {
+		memory, err := constraints.ParseMemory(s.Memory)
+		if err != nil {
+			return nil, fmt.Errorf("invalid memory for dyno size %s: %v", s.Name, err)
+		}
+
+		sizes = append(sizes, DynoSize{
+			Name: s.Name,
+			Constraints: Constraints{
+				CPUShare: constraints.CPUShare(s.CPUShare),
+				Memory:   memory,
+				Nproc:    constraints.Nproc(s.Nproc),
+			},
+		})
+	}
+
+	return sizes, sizes.Validate()
+}
+
+// DynoSizes returns the dyno sizes that are available.
+func (e *Empire) DynoSizes() DynoSizes {
+	if len(e.AvailableDynoSizes) == 0 {
+		return DefaultDynoSizes
+	}
+	return e.AvailableDynoSizes
+}
+
+// validateConstraints returns ErrDynoSize if RestrictDynoSizes is enabled, and
+// the constraints don't match an available dyno size.
+func (e *Empire) validateConstraints(c *Constraints) error {
+	if c == nil || !e.RestrictDynoSizes {
+		return nil
+	}
+
+	if _, ok := e.DynoSizes().Named(*c); !ok {
+		return ErrDynoSize
+	}
+
+	return nil
+}
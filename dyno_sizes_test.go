@@ -0,0 +1,89 @@
+package empire
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/remind101/empire/pkg/bytesize"
+	"github.com/remind101/empire/pkg/constraints"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDynoSizes(t *testing.T) {
+	sizes, err := ParseDynoSizes(strings.NewReader(`[
+		{"name": "1X", "cpu_share": 256, "memory": "512MB", "nproc": 256},
+		{"name": "4X", "cpu_share": 1024, "memory": "4GB"}
+	]`))
+	assert.NoError(t, err)
+	assert.Equal(t, DynoSizes{
+		{"1X", Constraints1X},
+		{"4X", Constraints{constraints.CPUShare(1024), constraints.Memory(4 * GB), 0}},
+	}, sizes)
+
+	_, err = ParseDynoSizes(strings.NewReader(`[{"name": "4X", "cpu_share": 1024, "memory": "lots"}]`))
+	assert.Error(t, err)
+
+	_, err = ParseDynoSizes(strings.NewReader(`{"name": "4X"}`))
+	assert.Error(t, err)
+}
+
+func TestDynoSizes_Validate(t *testing.T) {
+	tests := []struct {
+		sizes DynoSizes
+		err   bool
+	}{
+		{DefaultDynoSizes, false},
+		{DynoSizes{}, true},
+		{DynoSizes{{"", Constraints1X}}, true},
+		{DynoSizes{{"1X:2", Constraints1X}}, true},
+		{DynoSizes{{"1X", Constraints1X}, {"1x", Constraints2X}}, true},
+		{DynoSizes{{"1X", Constraints{0, constraints.Memory(512 * MB), 0}}}, true},
+		{DynoSizes{{"1X", Constraints{256, 0, 0}}}, true},
+	}
+
+	for i, tt := range tests {
+		err := tt.sizes.Validate()
+		if tt.err {
+			assert.Error(t, err, "#%d", i)
+		} else {
+			assert.NoError(t, err, "#%d", i)
+		}
+	}
+}
+
+func TestDynoSizes_Parse(t *testing.T) {
+	c4X := Constraints{constraints.CPUShare(1024), constraints.Memory(4 * GB), 0}
+	sizes := DynoSizes{{"1X", Constraints1X}, {"4X", c4X}}
+
+	c, err := sizes.Parse("4x")
+	assert.NoError(t, err)
+	assert.Equal(t, &c4X, c)
+	assert.Equal(t, "4X", sizes.Format(c4X))
+	assert.Equal(t, "512:1.00gb:nproc=512", sizes.Format(Constraints2X))
+
+	_, err = sizes.Parse("2X")
+	assert.Error(t, err)
+
+	// Other Empire instances aren't affected.
+	e := &Empire{AvailableDynoSizes: sizes}
+	assert.Equal(t, sizes, e.DynoSizes())
+	assert.Equal(t, DefaultDynoSizes, (&Empire{}).DynoSizes())
+	assert.Equal(t, "2X", Constraints2X.String())
+}
+
+func TestEmpire_ValidateConstraints(t *testing.T) {
+	e := &Empire{}
+	custom := Constraints{constraints.CPUShare(100), constraints.Memory(1 * MB), 0}
+
+	assert.NoError(t, e.validateConstraints(nil))
+	assert.NoError(t, e.validateConstraints(&custom))
+
+	e.RestrictDynoSizes = true
+	assert.NoError(t, e.validateConstraints(nil))
+	assert.NoError(t, e.validateConstraints(&Constraints2X))
+	assert.Equal(t, ErrDynoSize, e.validateConstraints(&custom))
+
+	e.AvailableDynoSizes = DynoSizes{{"custom", custom}}
+	assert.NoError(t, e.validateConstraints(&custom))
+	assert.Equal(t, ErrDynoSize, e.validateConstraints(&Constraints2X))
+}
@@ -17,6 +17,10 @@ import (
 const (
 	// webProcessType is the process type we assume are web server processes.
 	webProcessType = "web"
+
+	// releaseProcessType is the process type that's run to completion
+	// before a new release is submitted to the scheduler.
+	releaseProcessType = "release"
 )
 
 // Various errors that may be returned.
@@ -26,6 +30,10 @@ var (
 	ErrDomainNotFound     = errors.New("Domain could not be found.")
 	ErrUserName           = errors.New("Name is required")
 	ErrNoReleases         = errors.New("no releases")
+	ErrNoCanary           = &ValidationError{errors.New("No canary in progress.")}
+	ErrScaleRelease       = &ValidationError{errors.New("The release process can't be scaled.")}
+	ErrPlanStrategy       = &ValidationError{errors.New("A plan can't be made for a canary or blue-green deploy.")}
+	ErrReleaseConflict    = &ValidationError{errors.New("Another release was created while the release process was running. Deploy again to release on top of it.")}
 	// ErrInvalidName is used to indicate that the app name is not valid.
 	ErrInvalidName = &ValidationError{
 		errors.New("An app name must be alphanumeric and dashes only, 3-30 chars in length."),
@@ -38,16 +46,26 @@ type Empire struct {
 	DB *DB
 	db *gorm.DB
 
-	accessTokens *accessTokensService
-	apps         *appsService
-	configs      *configsService
-	domains      *domainsService
-	tasks        *tasksService
-	releases     *releasesService
-	deployer     *deployerService
-	runner       *runnerService
-	slugs        *slugsService
-	certs        *certsService
+	access         *accessService
+	accessTokens   *accessTokensService
+	audit          *auditService
+	apps           *appsService
+	configs        *configsService
+	domains        *domainsService
+	tasks          *tasksService
+	releases       *releasesService
+	manifests      *manifestsService
+	deployer       *deployerService
+	runner         *runnerService
+	slugs          *slugsService
+	certs          *certsService
+	canaries       *canariesService
+	dispatcher     *eventDispatcher
+	drift          *driftService
+	gc             *gcService
+	scaleSchedules *scaleSchedulesService
+	costs          *costsService
+	renames        *renamesService
 
 	// Secret is used to sign JWT access tokens.
 	Secret []byte
@@ -65,14 +83,61 @@ type Empire struct {
 	// Environment represents the environment this Empire server is responsible for
 	Environment string
 
-	// EventStream service for publishing Empire events.
+	// EventStream service for publishing Empire events. Events are stored in
+	// an outbox, and only delivered to the EventStream by DispatchEvents.
 	EventStream
 
+	// EventMaxAttempts is the number of attempts to deliver an event to
+	// the EventStream before giving up on it. The default is
+	// DefaultEventMaxAttempts.
+	EventMaxAttempts int
+
 	// RunRecorder is used to record the logs from interactive runs.
 	RunRecorder RunRecorder
 
+	// Secrets is the backend used to store the values of secret config
+	// vars. If nil, secret config vars can't be set.
+	Secrets SecretStore
+
 	// MessagesRequired is a boolean used to determine if messages should be required for events.
 	MessagesRequired bool
+
+	// AccessControl enables role based access control. When true, users
+	// need to be granted a Role on an app to act on it.
+	AccessControl bool
+
+	// Admins are the users, or GitHub teams (as org/slug), that have the
+	// admin role on every app when AccessControl is enabled.
+	Admins []string
+
+	// AppAliasTTL is how long the previous name of a renamed app can still
+	// be used to find it. The default is DefaultAppAliasTTL.
+	AppAliasTTL time.Duration
+
+	// AvailableDynoSizes are the dyno sizes that processes can be scaled
+	// to, or run with. The default is DefaultDynoSizes.
+	AvailableDynoSizes DynoSizes
+
+	// RestrictDynoSizes, when true, only allows processes to be scaled, or
+	// run, with one of the available dyno sizes, rather than arbitrary
+	// <cpushare>:<memory> constraints.
+	RestrictDynoSizes bool
+
+	// AppQuota limits the resources that the processes of each app can
+	// use. Zero values are unlimited.
+	AppQuota Resources
+
+	// Quota limits the resources that the processes of all apps can use
+	// together. Zero values are unlimited.
+	Quota Resources
+
+	// CheckCapacity, when true, checks that new instances can be placed on
+	// the hosts in the cluster before scaling, or deploying, if the
+	// Scheduler reports its hosts.
+	CheckCapacity bool
+
+	// CostModel is used to estimate the cost of apps.
+	CostModel CostModel
 }
 
 // New returns a new Empire instance.
@@ -85,7 +150,9 @@ func New(db *DB) *Empire {
 		db: db.DB,
 	}
 
+	e.access = &accessService{Empire: e}
 	e.accessTokens = &accessTokensService{Empire: e}
+	e.audit = &auditService{Empire: e}
 	e.apps = &appsService{Empire: e}
 	e.configs = &configsService{Empire: e}
 	e.deployer = &deployerService{Empire: e}
@@ -94,7 +161,15 @@ func New(db *DB) *Empire {
 	e.tasks = &tasksService{Empire: e}
 	e.runner = &runnerService{Empire: e}
 	e.releases = &releasesService{Empire: e}
+	e.manifests = &manifestsService{Empire: e}
 	e.certs = &certsService{Empire: e}
+	e.canaries = &canariesService{Empire: e}
+	e.dispatcher = &eventDispatcher{Empire: e}
+	e.drift = &driftService{Empire: e}
+	e.gc = &gcService{Empire: e}
+	e.scaleSchedules = &scaleSchedulesService{Empire: e}
+	e.costs = &costsService{Empire: e}
+	e.renames = &renamesService{Empire: e}
 	return e
 }
 
@@ -110,7 +185,12 @@ func (e *Empire) AccessTokensCreate(accessToken *AccessToken) (*AccessToken, err
 
 // AppsFind finds the first app matching the query.
 func (e *Empire) AppsFind(q AppsQuery) (*App, error) {
-	return appsFind(e.db, q)
+	a, err := appsFind(e.db, q)
+	if err == gorm.RecordNotFound && q.Name != nil && q.ID == nil && q.Repo == nil {
+		// The app may have been renamed recently.
+		return appsFindByAlias(e.db, *q.Name)
+	}
+	return a, err
 }
 
 // Apps returns all Apps.
@@ -125,6 +205,57 @@ func (e *Empire) requireMessages(m string) error {
 	return nil
 }
 
+// PublishEvent stores the event in the outbox, to be delivered to the
+// EventStream by DispatchEvents.
+func (e *Empire) PublishEvent(event Event) error {
+	return e.publishEvent(e.db, event)
+}
+
+// publishEvent stores the event in the outbox using the given db. When db is a
+// transaction, the event is only delivered if the transaction is committed.
+func (e *Empire) publishEvent(db *gorm.DB, event Event) error {
+	if err := e.audit.Record(db, event); err != nil {
+		return fmt.Errorf("error recording event: %v", err)
+	}
+	return nil
+}
+
+// AuditEvents returns the events in the audit log matching the query.
+func (e *Empire) AuditEvents(q AuditEventsQuery) ([]*AuditEvent, error) {
+	return auditEvents(e.db, q)
+}
+
+// AuditEventsVisible returns the events in the audit log matching the query,
+// for the apps that the user can view. The apps are filtered in the query, so
+// that paging returns full pages.
+func (e *Empire) AuditEventsVisible(user *User, q AuditEventsQuery) ([]*AuditEvent, error) {
+	p, err := e.Permissions(user)
+	if err != nil {
+		return nil, err
+	}
+
+	if !p.all {
+		as, err := apps(e.db, AppsQuery{})
+		if err != nil {
+			return nil, err
+		}
+
+		q.Apps = []string{}
+		for _, a := range as {
+			if p.Authorize(a.Name, RoleViewer) == nil {
+				q.Apps = append(q.Apps, a.Name)
+			}
+		}
+	}
+
+	return auditEvents(e.db, q)
+}
+
+// AuditEventsFind returns the first event in the audit log matching the query.
+func (e *Empire) AuditEventsFind(q AuditEventsQuery) (*AuditEvent, error) {
+	return auditEventsFind(e.db, q)
+}
+
 // CreateOpts are options that are provided when creating a new application.
 type CreateOpts struct {
 	// User performing the action.
@@ -146,6 +277,9 @@ func (opts CreateOpts) Event() CreateEvent {
 }
 
 func (opts CreateOpts) Validate(e *Empire) error {
+	if err := e.Authorize(opts.User, opts.Name, RoleAdmin); err != nil {
+		return err
+	}
 	return e.requireMessages(opts.Message)
 }
 
@@ -155,12 +289,20 @@ func (e *Empire) Create(ctx context.Context, opts CreateOpts) (*App, error) {
 		return nil, err
 	}
 
-	a, err := appsCreate(e.db, &App{Name: opts.Name})
+	tx := e.db.Begin()
+
+	a, err := appsCreate(tx, &App{Name: opts.Name})
 	if err != nil {
+		tx.Rollback()
+		return a, err
+	}
+
+	if err := e.publishEvent(tx, opts.Event()); err != nil {
+		tx.Rollback()
 		return a, err
 	}
 
-	return a, e.PublishEvent(opts.Event())
+	return a, tx.Commit().Error
 }
 
 // DestroyOpts are options provided when destroying an application.
@@ -184,6 +326,9 @@ func (opts DestroyOpts) Event() DestroyEvent {
 }
 
 func (opts DestroyOpts) Validate(e *Empire) error {
+	if err := e.Authorize(opts.User, opts.App.Name, RoleAdmin); err != nil {
+		return err
+	}
 	return e.requireMessages(opts.Message)
 }
 
@@ -200,11 +345,12 @@ func (e *Empire) Destroy(ctx context.Context, opts DestroyOpts) error {
 		return err
 	}
 
-	if err := tx.Commit().Error; err != nil {
+	if err := e.publishEvent(tx, opts.Event()); err != nil {
+		tx.Rollback()
 		return err
 	}
 
-	return e.PublishEvent(opts.Event())
+	return tx.Commit().Error
 }
 
 // Config returns the current Config for a given app.
@@ -224,6 +370,18 @@ func (e *Empire) Config(app *App) (*Config, error) {
 	return c, nil
 }
 
+// ConfigHistory returns the changes that were made to the config of an app in
+// each release matching the query.
+func (e *Empire) ConfigHistory(q ReleasesQuery) ([]*ConfigVersion, error) {
+	return configHistory(e.db, q)
+}
+
+// ConfigDiff returns the changes to the config of an app between two release
+// versions.
+func (e *Empire) ConfigDiff(app *App, from, to int) (ConfigDiff, error) {
+	return configDiff(e.db, app, from, to)
+}
+
 // SetOpts are options provided when setting new config vars on an app.
 type SetOpts struct {
 	// User performing the action.
@@ -235,6 +393,10 @@ type SetOpts struct {
 	// The new vars to merge into the old config.
 	Vars Vars
 
+	// If true, the values of Vars are stored in the SecretStore, and only
+	// references to them are stored in the config.
+	Secret bool
+
 	// Commit message
 	Message string
 }
@@ -255,6 +417,19 @@ func (opts SetOpts) Event() SetEvent {
 }
 
 func (opts SetOpts) Validate(e *Empire) error {
+	if err := e.Authorize(opts.User, opts.App.Name, RoleDeployer); err != nil {
+		return err
+	}
+	if opts.Secret && e.Secrets == nil {
+		return &ValidationError{Err: ErrNoSecretStore}
+	}
+	for k, v := range opts.Vars {
+		// References to secrets can only be created by the
+		// SecretStore.
+		if v != nil && isSecret(*v) {
+			return &ValidationError{Err: fmt.Errorf("invalid value for %s", k)}
+		}
+	}
 	return e.requireMessages(opts.Message)
 }
 
@@ -274,11 +449,12 @@ func (e *Empire) Set(ctx context.Context, opts SetOpts) (*Config, error) {
 		return c, err
 	}
 
-	if err := tx.Commit().Error; err != nil {
+	if err := e.publishEvent(tx, opts.Event()); err != nil {
+		tx.Rollback()
 		return c, err
 	}
 
-	return c, e.PublishEvent(opts.Event())
+	return c, tx.Commit().Error
 }
 
 // DomainsFind returns the first domain matching the query.
@@ -291,11 +467,31 @@ func (e *Empire) Domains(q DomainsQuery) ([]*Domain, error) {
 	return domains(e.db, q)
 }
 
+// DomainsCreateOpts are options provided when adding a domain to an app.
+type DomainsCreateOpts struct {
+	// User performing the action.
+	User *User
+
+	// The associated app.
+	App *App
+
+	// The domain to add.
+	Domain *Domain
+}
+
+func (opts DomainsCreateOpts) Validate(e *Empire) error {
+	return e.Authorize(opts.User, opts.App.Name, RoleAdmin)
+}
+
 // DomainsCreate adds a new Domain for an App.
-func (e *Empire) DomainsCreate(ctx context.Context, domain *Domain) (*Domain, error) {
+func (e *Empire) DomainsCreate(ctx context.Context, opts DomainsCreateOpts) (*Domain, error) {
+	if err := opts.Validate(e); err != nil {
+		return nil, err
+	}
+
 	tx := e.db.Begin()
 
-	d, err := e.domains.DomainsCreate(ctx, tx, domain)
+	d, err := e.domains.DomainsCreate(ctx, tx, opts.Domain)
 	if err != nil {
 		tx.Rollback()
 		return d, err
@@ -308,11 +504,31 @@ func (e *Empire) DomainsCreate(ctx context.Context, domain *Domain) (*Domain, er
 	return d, nil
 }
 
+// DomainsDestroyOpts are options provided when removing a domain from an app.
+type DomainsDestroyOpts struct {
+	// User performing the action.
+	User *User
+
+	// The associated app.
+	App *App
+
+	// The domain to remove.
+	Domain *Domain
+}
+
+func (opts DomainsDestroyOpts) Validate(e *Empire) error {
+	return e.Authorize(opts.User, opts.App.Name, RoleAdmin)
+}
+
 // DomainsDestroy removes a Domain for an App.
-func (e *Empire) DomainsDestroy(ctx context.Context, domain *Domain) error {
+func (e *Empire) DomainsDestroy(ctx context.Context, opts DomainsDestroyOpts) error {
+	if err := opts.Validate(e); err != nil {
+		return err
+	}
+
 	tx := e.db.Begin()
 
-	if err := e.domains.DomainsDestroy(ctx, tx, domain); err != nil {
+	if err := e.domains.DomainsDestroy(ctx, tx, opts.Domain); err != nil {
 		tx.Rollback()
 		return err
 	}
@@ -356,6 +572,9 @@ func (opts RestartOpts) Event() RestartEvent {
 }
 
 func (opts RestartOpts) Validate(e *Empire) error {
+	if err := e.Authorize(opts.User, opts.App.Name, RoleDeployer); err != nil {
+		return err
+	}
 	return e.requireMessages(opts.Message)
 }
 
@@ -418,6 +637,12 @@ func (opts RunOpts) Event() RunEvent {
 }
 
 func (opts RunOpts) Validate(e *Empire) error {
+	if err := e.Authorize(opts.User, opts.App.Name, RoleDeployer); err != nil {
+		return err
+	}
+	if err := e.validateConstraints(opts.Constraints); err != nil {
+		return err
+	}
 	return e.requireMessages(opts.Message)
 }
 
@@ -452,6 +677,11 @@ func (e *Empire) Run(ctx context.Context, opts RunOpts) error {
 	}
 
 	if err := e.runner.Run(ctx, opts); err != nil {
+		// The process still ran if it exited with a non-zero status,
+		// so the run is still published.
+		if _, ok := err.(*scheduler.ExitError); ok {
+			e.PublishEvent(event)
+		}
 		return err
 	}
 
@@ -481,6 +711,10 @@ type RollbackOpts struct {
 
 	// Commit message
 	Message string
+
+	// If provided, the reason that the rollback was performed automatically
+	// (e.g. because the deployment failed to stabilize).
+	Reason string
 }
 
 func (opts RollbackOpts) Event() RollbackEvent {
@@ -493,7 +727,21 @@ func (opts RollbackOpts) Event() RollbackEvent {
 	}
 }
 
+func (opts RollbackOpts) AutoRollbackEvent() AutoRollbackEvent {
+	return AutoRollbackEvent{
+		User:    opts.User.Name,
+		App:     opts.App.Name,
+		Version: opts.Version,
+		Message: opts.Message,
+		Reason:  opts.Reason,
+		app:     opts.App,
+	}
+}
+
 func (opts RollbackOpts) Validate(e *Empire) error {
+	if err := e.Authorize(opts.User, opts.App.Name, RoleDeployer); err != nil {
+		return err
+	}
 	return e.requireMessages(opts.Message)
 }
 
@@ -512,11 +760,22 @@ func (e *Empire) Rollback(ctx context.Context, opts RollbackOpts) (*Release, err
 		return r, err
 	}
 
-	if err := tx.Commit().Error; err != nil {
+	var event Event
+	if opts.Reason != "" {
+		ev := opts.AutoRollbackEvent()
+		ev.Release = r.Version
+		event = ev
+	} else {
+		ev := opts.Event()
+		ev.Release = r.Version
+		event = ev
+	}
+	if err := e.publishEvent(tx, event); err != nil {
+		tx.Rollback()
 		return r, err
 	}
 
-	return r, e.PublishEvent(opts.Event())
+	return r, tx.Commit().Error
 }
 
 // DeployOpts represents options that can be passed when deploying to
@@ -543,12 +802,21 @@ type DeployOpts struct {
 
 	// Stream boolean for whether or not a status stream should be created.
 	Stream bool
+
+	// Strategy controls how the new release is rolled out. By default, the
+	// new release replaces the current release.
+	Strategy DeployStrategy
+
+	// If true, nothing is deployed. Instead, a preview of the changes that
+	// the deploy would make is written to Output.
+	Plan bool
 }
 
 func (opts DeployOpts) Event() DeployEvent {
 	e := DeployEvent{
 		User:    opts.User.Name,
 		Image:   opts.Image.String(),
+		Canary:  opts.Strategy.Percent(),
 		Message: opts.Message,
 	}
 	if opts.App != nil {
@@ -560,30 +828,184 @@ func (opts DeployOpts) Event() DeployEvent {
 }
 
 func (opts DeployOpts) Validate(e *Empire) error {
+	if err := e.Authorize(opts.User, opts.appName(), RoleDeployer); err != nil {
+		return err
+	}
+	if err := opts.Strategy.Validate(); err != nil {
+		return err
+	}
+	if opts.Plan {
+		if opts.Strategy.Percent() > 0 {
+			return ErrPlanStrategy
+		}
+		// Nothing is changed, so a message isn't required.
+		return nil
+	}
 	return e.requireMessages(opts.Message)
 }
 
+// appName returns the name of the app being deployed to. If no app is given,
+// the app is found by the repository of the image.
+func (opts DeployOpts) appName() string {
+	if opts.App != nil {
+		return opts.App.Name
+	}
+	return appNameFromRepo(opts.Image.Repository)
+}
+
 // Deploy deploys an image and streams the output to w.
 func (e *Empire) Deploy(ctx context.Context, opts DeployOpts) (*Release, error) {
 	if err := opts.Validate(e); err != nil {
 		return nil, err
 	}
 
-	r, err := e.deployer.Deploy(ctx, opts)
+	// The DeployEvent is recorded in the same transaction as the new
+	// release.
+	return e.deployer.Deploy(ctx, opts)
+}
+
+// PlanOpts are options provided when previewing the changes that re-releasing
+// an app would make.
+type PlanOpts struct {
+	// User performing the action.
+	User *User
+
+	// The associated app.
+	App *App
+}
+
+func (opts PlanOpts) Validate(e *Empire) error {
+	return e.Authorize(opts.User, opts.App.Name, RoleDeployer)
+}
+
+// Plan returns a preview of the changes that re-releasing the current release
+// of the app would make, without making them. This is useful to see what a
+// change to Empire's templates, or to resources managed outside of Empire,
+// would do to the app.
+func (e *Empire) Plan(ctx context.Context, opts PlanOpts) (*scheduler.Plan, error) {
+	if err := opts.Validate(e); err != nil {
+		return nil, err
+	}
+
+	if err := canaryGuard(e.db, opts.App); err != nil {
+		return nil, err
+	}
+
+	release, err := releasesFind(e.db, ReleasesQuery{App: opts.App})
 	if err != nil {
+		if err == gorm.RecordNotFound {
+			return nil, ErrNoReleases
+		}
+		return nil, err
+	}
+
+	return e.releases.Plan(ctx, e.db, release)
+}
+
+// PromoteOpts are options provided when promoting a canary release.
+type PromoteOpts struct {
+	// User performing the action.
+	User *User
+
+	// The associated app.
+	App *App
+
+	// Commit message
+	Message string
+}
+
+func (opts PromoteOpts) Event() PromoteEvent {
+	return PromoteEvent{
+		User:    opts.User.Name,
+		App:     opts.App.Name,
+		Message: opts.Message,
+		app:     opts.App,
+	}
+}
+
+func (opts PromoteOpts) Validate(e *Empire) error {
+	if err := e.Authorize(opts.User, opts.App.Name, RoleDeployer); err != nil {
+		return err
+	}
+	return e.requireMessages(opts.Message)
+}
+
+// Promote promotes the canary release of an app, replacing the stable release.
+// Returns the promoted release.
+func (e *Empire) Promote(ctx context.Context, opts PromoteOpts) (*Release, error) {
+	if err := opts.Validate(e); err != nil {
+		return nil, err
+	}
+
+	tx := e.db.Begin()
+
+	r, err := e.canaries.Promote(ctx, tx, opts)
+	if err != nil {
+		tx.Rollback()
 		return r, err
 	}
 
 	event := opts.Event()
 	event.Release = r.Version
-	event.Environment = e.Environment
-	// Deals with new app creation on first deploy
-	if event.App == "" && r.App != nil {
-		event.App = r.App.Name
-		event.app = r.App
+	if err := e.publishEvent(tx, event); err != nil {
+		tx.Rollback()
+		return r, err
+	}
+
+	return r, tx.Commit().Error
+}
+
+// AbortOpts are options provided when aborting a canary release.
+type AbortOpts struct {
+	// User performing the action.
+	User *User
+
+	// The associated app.
+	App *App
+
+	// Commit message
+	Message string
+}
+
+func (opts AbortOpts) Event() AbortEvent {
+	return AbortEvent{
+		User:    opts.User.Name,
+		App:     opts.App.Name,
+		Message: opts.Message,
+		app:     opts.App,
 	}
+}
 
-	return r, e.PublishEvent(event)
+func (opts AbortOpts) Validate(e *Empire) error {
+	if err := e.Authorize(opts.User, opts.App.Name, RoleDeployer); err != nil {
+		return err
+	}
+	return e.requireMessages(opts.Message)
+}
+
+// Abort aborts the canary release of an app, and rolls back to the stable
+// release. Returns a new release.
+func (e *Empire) Abort(ctx context.Context, opts AbortOpts) (*Release, error) {
+	if err := opts.Validate(e); err != nil {
+		return nil, err
+	}
+
+	tx := e.db.Begin()
+
+	r, err := e.canaries.Abort(ctx, tx, opts)
+	if err != nil {
+		tx.Rollback()
+		return r, err
+	}
+
+	event := opts.Event()
+	event.Release = r.Version
+	if err := e.publishEvent(tx, event); err != nil {
+		tx.Rollback()
+		return r, err
+	}
+
+	return r, tx.Commit().Error
 }
 
 type ProcessUpdate struct {
@@ -635,7 +1057,48 @@ func (opts ScaleOpts) Event() ScaleEvent {
 }
 
 func (opts ScaleOpts) Validate(e *Empire) error {
-	return e.requireMessages(opts.Message)
+	if err := e.Authorize(opts.User, opts.App.Name, RoleDeployer); err != nil {
+		return err
+	}
+	for _, up := range opts.Updates {
+		if up.Process == releaseProcessType {
+			return ErrScaleRelease
+		}
+		if err := e.validateConstraints(up.Constraints); err != nil {
+			return err
+		}
+	}
+	if err := e.requireMessages(opts.Message); err != nil {
+		return err
+	}
+	return opts.checkQuotas(e)
+}
+
+// checkQuotas checks that the formation after scaling is within the quotas.
+func (opts ScaleOpts) checkQuotas(e *Empire) error {
+	current, err := currentFormation(e.db, opts.App)
+	if err != nil {
+		if err == gorm.RecordNotFound {
+			// Scale returns a more helpful error.
+			return nil
+		}
+		return err
+	}
+
+	desired := current.clone()
+	for _, up := range opts.Updates {
+		p, ok := desired[up.Process]
+		if !ok {
+			continue
+		}
+		p.Quantity = up.Quantity
+		if up.Constraints != nil {
+			p.SetConstraints(*up.Constraints)
+		}
+		desired[up.Process] = p
+	}
+
+	return e.checkQuotas(e.db, opts.App, current, desired)
 }
 
 // Scale scales an apps processes.
@@ -655,25 +1118,168 @@ func (e *Empire) Scale(ctx context.Context, opts ScaleOpts) ([]*Process, error)
 	return ps, tx.Commit().Error
 }
 
+// AutoscaleOpts are options provided when changing the autoscaling policy of a
+// process.
+type AutoscaleOpts struct {
+	// User that's performing the action.
+	User *User
+
+	// The associated app.
+	App *App
+
+	// The process to autoscale.
+	Process string
+
+	// The new autoscaling policy. If nil, autoscaling is disabled, and the
+	// process stays at its current quantity.
+	Autoscaling *Autoscaling
+
+	// Commit message
+	Message string
+}
+
+func (opts AutoscaleOpts) Event() AutoscaleEvent {
+	return AutoscaleEvent{
+		User:        opts.User.Name,
+		App:         opts.App.Name,
+		Process:     opts.Process,
+		Autoscaling: opts.Autoscaling,
+		Message:     opts.Message,
+		app:         opts.App,
+	}
+}
+
+func (opts AutoscaleOpts) Validate(e *Empire) error {
+	if err := e.Authorize(opts.User, opts.App.Name, RoleDeployer); err != nil {
+		return err
+	}
+	if opts.Process == releaseProcessType {
+		return ErrScaleRelease
+	}
+	if opts.Autoscaling != nil {
+		if err := opts.Autoscaling.Validate(); err != nil {
+			return &ValidationError{Err: err}
+		}
+	}
+	return e.requireMessages(opts.Message)
+}
+
+// Autoscale sets the autoscaling policy of a process. The scheduler scales the
+// process between the min and max instances of the policy.
+func (e *Empire) Autoscale(ctx context.Context, opts AutoscaleOpts) (*Process, error) {
+	if err := opts.Validate(e); err != nil {
+		return nil, err
+	}
+
+	tx := e.db.Begin()
+
+	p, err := e.apps.Autoscale(ctx, tx, opts)
+	if err != nil {
+		tx.Rollback()
+		return p, err
+	}
+
+	return p, tx.Commit().Error
+}
+
 // ListScale lists the current scale settings for a given App
 func (e *Empire) ListScale(ctx context.Context, app *App) (Formation, error) {
 	return currentFormation(e.db, app)
 }
 
+// StreamLogsOpts are options provided when streaming logs from an app.
+type StreamLogsOpts struct {
+	// User performing the action.
+	User *User
+
+	// The associated app.
+	App *App
+
+	// The writer to stream the logs to.
+	Output io.Writer
+
+	// How long to stream logs for. Zero streams until the connection is
+	// closed.
+	Duration time.Duration
+}
+
+func (opts StreamLogsOpts) Validate(e *Empire) error {
+	return e.Authorize(opts.User, opts.App.Name, RoleViewer)
+}
+
 // Streamlogs streams logs from an app.
-func (e *Empire) StreamLogs(app *App, w io.Writer, duration time.Duration) error {
-	if err := e.LogsStreamer.StreamLogs(app, w, duration); err != nil {
+func (e *Empire) StreamLogs(ctx context.Context, opts StreamLogsOpts) error {
+	if err := opts.Validate(e); err != nil {
+		return err
+	}
+
+	if err := e.LogsStreamer.StreamLogs(opts.App, opts.Output, opts.Duration); err != nil {
 		return fmt.Errorf("error streaming logs: %v", err)
 	}
 
 	return nil
 }
 
+// CertsAttachOpts are options provided when attaching a certificate to an app.
+type CertsAttachOpts struct {
+	// User performing the action.
+	User *User
+
+	// The associated app.
+	App *App
+
+	// The certificate to attach.
+	Cert string
+}
+
+func (opts CertsAttachOpts) Validate(e *Empire) error {
+	return e.Authorize(opts.User, opts.App.Name, RoleAdmin)
+}
+
 // CertsAttach attaches an SSL certificate to the app.
-func (e *Empire) CertsAttach(ctx context.Context, app *App, cert string) error {
+func (e *Empire) CertsAttach(ctx context.Context, opts CertsAttachOpts) error {
+	if err := opts.Validate(e); err != nil {
+		return err
+	}
+
+	tx := e.db.Begin()
+
+	if err := e.certs.CertsAttach(ctx, tx, opts.App, opts.Cert); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
+// SetAutoRollbackOpts are options provided when enabling or disabling
+// automatic rollbacks.
+type SetAutoRollbackOpts struct {
+	// User performing the action.
+	User *User
+
+	// The associated app.
+	App *App
+
+	// Whether automatic rollbacks should be enabled.
+	Enabled bool
+}
+
+func (opts SetAutoRollbackOpts) Validate(e *Empire) error {
+	return e.Authorize(opts.User, opts.App.Name, RoleAdmin)
+}
+
+// SetAutoRollback enables or disables automatic rollbacks for the app.
+func (e *Empire) SetAutoRollback(ctx context.Context, opts SetAutoRollbackOpts) error {
+	if err := opts.Validate(e); err != nil {
+		return err
+	}
+
 	tx := e.db.Begin()
 
-	if err := e.certs.CertsAttach(ctx, tx, app, cert); err != nil {
+	app := opts.App
+	app.AutoRollback = opts.Enabled
+	if err := appsUpdate(tx, app); err != nil {
 		tx.Rollback()
 		return err
 	}
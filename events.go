@@ -1,11 +1,14 @@
 package empire
 
 import (
+	"encoding/json"
 	"fmt"
 	"log"
 	"strings"
+	"time"
 
 	"github.com/hashicorp/go-multierror"
+	"github.com/remind101/pkg/timex"
 )
 
 func appendCommitMessage(main, commit string) string {
@@ -18,12 +21,12 @@ func appendCommitMessage(main, commit string) string {
 
 // RunEvent is triggered when a user starts a one off process.
 type RunEvent struct {
-	User     string
-	App      string
-	Command  Command
-	URL      string
-	Attached bool
-	Message  string
+	User     string  `json:"user"`
+	App      string  `json:"app"`
+	Command  Command `json:"command"`
+	URL      string  `json:"url,omitempty"`
+	Attached bool    `json:"attached"`
+	Message  string  `json:"message,omitempty"`
 
 	app *App
 }
@@ -50,10 +53,10 @@ func (e RunEvent) GetApp() *App {
 
 // RestartEvent is triggered when a user restarts an application.
 type RestartEvent struct {
-	User    string
-	App     string
-	PID     string
-	Message string
+	User    string `json:"user"`
+	App     string `json:"app"`
+	PID     string `json:"pid,omitempty"`
+	Message string `json:"message,omitempty"`
 
 	app *App
 }
@@ -84,14 +87,66 @@ type ScaleEventUpdate struct {
 	PreviousConstraints Constraints
 }
 
+// ScaleEventFormation is the JSON representation of the scale and size of a
+// process, before or after a ScaleEvent.
+type ScaleEventFormation struct {
+	Quantity int  `json:"quantity"`
+	CPUShare int  `json:"cpu_share"`
+	Memory   uint `json:"memory"`
+	Nproc    uint `json:"nproc"`
+}
+
+func newScaleEventFormation(quantity int, c Constraints) ScaleEventFormation {
+	return ScaleEventFormation{
+		Quantity: quantity,
+		CPUShare: int(c.CPUShare),
+		Memory:   uint(c.Memory),
+		Nproc:    uint(c.Nproc),
+	}
+}
+
+// newConstraints returns the constraints after the update. When no new
+// constraints were given, the previous constraints are kept.
+func (up *ScaleEventUpdate) newConstraints() Constraints {
+	c := up.Constraints
+	if c.CPUShare == 0 {
+		c.CPUShare = up.PreviousConstraints.CPUShare
+	}
+	if c.Memory == 0 {
+		c.Memory = up.PreviousConstraints.Memory
+	}
+	if c.Nproc == 0 {
+		c.Nproc = up.PreviousConstraints.Nproc
+	}
+	return c
+}
+
+// MarshalJSON implements the json.Marshaler interface, encoding the update as
+// the previous and new formation of the process.
+func (up *ScaleEventUpdate) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Process  string              `json:"process"`
+		Previous ScaleEventFormation `json:"previous"`
+		New      ScaleEventFormation `json:"new"`
+	}{
+		Process:  up.Process,
+		Previous: newScaleEventFormation(up.PreviousQuantity, up.PreviousConstraints),
+		New:      newScaleEventFormation(up.Quantity, up.newConstraints()),
+	})
+}
+
 // ScaleEvent is triggered when a manual scaling event happens.
 type ScaleEvent struct {
-	User    string
-	App     string
-	Updates []*ScaleEventUpdate
-	Message string
+	User    string              `json:"user"`
+	App     string              `json:"app"`
+	Updates []*ScaleEventUpdate `json:"updates"`
+	Message string              `json:"message,omitempty"`
 
 	app *App
+
+	// The dyno sizes that are used to name the constraints in the message.
+	// The default is DefaultDynoSizes.
+	sizes DynoSizes
 }
 
 func (e ScaleEvent) Event() string {
@@ -99,22 +154,15 @@ func (e ScaleEvent) Event() string {
 }
 
 func (e ScaleEvent) String() string {
+	sizes := e.sizes
+	if len(sizes) == 0 {
+		sizes = DefaultDynoSizes
+	}
+
 	var msg, sep string
 	for _, up := range e.Updates {
 		// Deal with no new constraints by copying previous constraint settings.
-		newConstraints := up.Constraints
-		previousConstraints := up.PreviousConstraints
-		if newConstraints.CPUShare == 0 {
-			newConstraints.CPUShare = previousConstraints.CPUShare
-		}
-
-		if newConstraints.Memory == 0 {
-			newConstraints.Memory = previousConstraints.Memory
-		}
-
-		if newConstraints.Nproc == 0 {
-			newConstraints.Nproc = previousConstraints.Nproc
-		}
+		newConstraints := up.newConstraints()
 
 		msg += fmt.Sprintf(
 			"%s%s scaled `%s` on %s from %d(%s) to %d(%s)",
@@ -123,9 +171,9 @@ func (e ScaleEvent) String() string {
 			up.Process,
 			e.App,
 			up.PreviousQuantity,
-			up.PreviousConstraints,
+			sizes.Format(up.PreviousConstraints),
 			up.Quantity,
-			newConstraints,
+			sizes.Format(newConstraints),
 		)
 		sep = "\n"
 	}
@@ -136,14 +184,49 @@ func (e ScaleEvent) GetApp() *App {
 	return e.app
 }
 
+// AutoscaleEvent is triggered when the autoscaling policy of a process is
+// changed.
+type AutoscaleEvent struct {
+	User        string       `json:"user"`
+	App         string       `json:"app"`
+	Process     string       `json:"process"`
+	Previous    *Autoscaling `json:"previous"`
+	Autoscaling *Autoscaling `json:"autoscaling"`
+	Message     string       `json:"message,omitempty"`
+
+	app *App
+}
+
+func (e AutoscaleEvent) Event() string {
+	return "autoscale"
+}
+
+func (e AutoscaleEvent) String() string {
+	var msg string
+	if e.Autoscaling == nil {
+		msg = fmt.Sprintf("%s disabled autoscaling of `%s` on %s", e.User, e.Process, e.App)
+	} else {
+		msg = fmt.Sprintf("%s autoscaled `%s` on %s with %s", e.User, e.Process, e.App, e.Autoscaling)
+	}
+	return appendCommitMessage(msg, e.Message)
+}
+
+func (e AutoscaleEvent) GetApp() *App {
+	return e.app
+}
+
 // DeployEvent is triggered when a user deploys a new image to an app.
 type DeployEvent struct {
-	User        string
-	App         string
-	Image       string
-	Environment string
-	Release     int
-	Message     string
+	User        string `json:"user"`
+	App         string `json:"app"`
+	Image       string `json:"image"`
+	Environment string `json:"environment,omitempty"`
+	Release     int    `json:"release"`
+	Message     string `json:"message,omitempty"`
+
+	// If non-zero, the release was deployed as a canary with this
+	// percentage of instances.
+	Canary int `json:"canary,omitempty"`
 
 	app *App
 }
@@ -159,6 +242,9 @@ func (e DeployEvent) String() string {
 	} else {
 		msg = fmt.Sprintf("%s deployed %s to %s %s (v%d)", e.User, e.Image, e.App, e.Environment, e.Release)
 	}
+	if e.Canary > 0 {
+		msg = fmt.Sprintf("%s as a canary (%d%%)", msg, e.Canary)
+	}
 	return appendCommitMessage(msg, e.Message)
 }
 
@@ -168,10 +254,13 @@ func (e DeployEvent) GetApp() *App {
 
 // RollbackEvent is triggered when a user rolls back to an old version.
 type RollbackEvent struct {
-	User    string
-	App     string
-	Version int
-	Message string
+	User    string `json:"user"`
+	App     string `json:"app"`
+	Version int    `json:"version"`
+	Message string `json:"message,omitempty"`
+
+	// The version of the new release that was created by the rollback.
+	Release int `json:"release"`
 
 	app *App
 }
@@ -189,13 +278,93 @@ func (e RollbackEvent) GetApp() *App {
 	return e.app
 }
 
+// AutoRollbackEvent is triggered when Empire automatically rolls an app back to
+// an old version, because a deploy failed to stabilize.
+type AutoRollbackEvent struct {
+	// The user that performed the deploy that failed.
+	User    string `json:"user"`
+	App     string `json:"app"`
+	Version int    `json:"version"`
+	Message string `json:"message,omitempty"`
+
+	// The version of the new release that was created by the rollback.
+	Release int `json:"release"`
+
+	// The reason that the rollback was performed.
+	Reason string `json:"reason"`
+
+	app *App
+}
+
+func (e AutoRollbackEvent) Event() string {
+	return "auto_rollback"
+}
+
+func (e AutoRollbackEvent) String() string {
+	msg := fmt.Sprintf("%s was automatically rolled back to v%d after a deploy by %s: %s", e.App, e.Version, e.User, e.Reason)
+	return appendCommitMessage(msg, e.Message)
+}
+
+func (e AutoRollbackEvent) GetApp() *App {
+	return e.app
+}
+
+// PromoteEvent is triggered when a user promotes a canary release.
+type PromoteEvent struct {
+	User    string `json:"user"`
+	App     string `json:"app"`
+	Release int    `json:"release"`
+	Message string `json:"message,omitempty"`
+
+	app *App
+}
+
+func (e PromoteEvent) Event() string {
+	return "promote"
+}
+
+func (e PromoteEvent) String() string {
+	msg := fmt.Sprintf("%s promoted the canary on %s (v%d)", e.User, e.App, e.Release)
+	return appendCommitMessage(msg, e.Message)
+}
+
+func (e PromoteEvent) GetApp() *App {
+	return e.app
+}
+
+// AbortEvent is triggered when a user aborts a canary release.
+type AbortEvent struct {
+	User    string `json:"user"`
+	App     string `json:"app"`
+	Message string `json:"message,omitempty"`
+
+	// The version of the new release that was created to replace the
+	// canary.
+	Release int `json:"release"`
+
+	app *App
+}
+
+func (e AbortEvent) Event() string {
+	return "abort"
+}
+
+func (e AbortEvent) String() string {
+	msg := fmt.Sprintf("%s aborted the canary on %s", e.User, e.App)
+	return appendCommitMessage(msg, e.Message)
+}
+
+func (e AbortEvent) GetApp() *App {
+	return e.app
+}
+
 // SetEvent is triggered when environment variables are changed on an
 // application.
 type SetEvent struct {
-	User    string
-	App     string
-	Changed []string
-	Message string
+	User    string   `json:"user"`
+	App     string   `json:"app"`
+	Changed []string `json:"changed"`
+	Message string   `json:"message,omitempty"`
 
 	app *App
 }
@@ -213,11 +382,88 @@ func (e SetEvent) GetApp() *App {
 	return e.app
 }
 
+// ApplyEvent is triggered when a user applies a manifest to an application.
+type ApplyEvent struct {
+	User    string   `json:"user"`
+	App     string   `json:"app"`
+	Changes []string `json:"changes"`
+	Message string   `json:"message,omitempty"`
+
+	// The version of the new release that was created, if any.
+	Release int `json:"release,omitempty"`
+
+	app *App
+}
+
+func (e ApplyEvent) Event() string {
+	return "apply"
+}
+
+func (e ApplyEvent) String() string {
+	msg := fmt.Sprintf("%s applied a manifest to %s (%s)", e.User, e.App, strings.Join(e.Changes, ", "))
+	return appendCommitMessage(msg, e.Message)
+}
+
+func (e ApplyEvent) GetApp() *App {
+	return e.app
+}
+
+// ReconcileEvent is triggered when the latest release of an app is re-submitted
+// to the scheduler, because what was running had drifted from it.
+type ReconcileEvent struct {
+	User    string `json:"user"`
+	App     string `json:"app"`
+	Release int    `json:"release"`
+
+	// The processes that had drifted.
+	Processes []string `json:"processes"`
+
+	Message string `json:"message,omitempty"`
+
+	app *App
+}
+
+func (e ReconcileEvent) Event() string {
+	return "reconcile"
+}
+
+func (e ReconcileEvent) String() string {
+	msg := fmt.Sprintf("%s reconciled %s with v%d (%s)", e.User, e.App, e.Release, strings.Join(e.Processes, ", "))
+	return appendCommitMessage(msg, e.Message)
+}
+
+func (e ReconcileEvent) GetApp() *App {
+	return e.app
+}
+
+// RenameEvent is triggered when a user renames an application.
+type RenameEvent struct {
+	User         string `json:"user"`
+	App          string `json:"app"`
+	PreviousName string `json:"previous_name"`
+	Message      string `json:"message,omitempty"`
+
+	app *App
+}
+
+func (e RenameEvent) Event() string {
+	return "rename"
+}
+
+func (e RenameEvent) String() string {
+	msg := fmt.Sprintf("%s renamed %s to %s", e.User, e.PreviousName, e.App)
+	return appendCommitMessage(msg, e.Message)
+}
+
+func (e RenameEvent) GetApp() *App {
+	return e.app
+}
+
 // CreateEvent is triggered when a user creates a new application.
 type CreateEvent struct {
-	User    string
-	Name    string
-	Message string
+	User    string `json:"user"`
+	Name    string `json:"app"`
+	Message string `json:"message,omitempty"`
 }
 
 func (e CreateEvent) Event() string {
@@ -231,9 +477,9 @@ func (e CreateEvent) String() string {
 
 // DestroyEvent is triggered when a user destroys an application.
 type DestroyEvent struct {
-	User    string
-	App     string
-	Message string
+	User    string `json:"user"`
+	App     string `json:"app"`
+	Message string `json:"message,omitempty"`
 }
 
 func (e DestroyEvent) Event() string {
@@ -260,6 +506,82 @@ type AppEvent interface {
 	GetApp() *App
 }
 
+// EventSchemaVersion is the version of the JSON schema that events are encoded
+// with by EncodeEvent. It's incremented whenever a backwards incompatible change
+// is made to the schema of an event.
+const EventSchemaVersion = 1
+
+// EventMessage is the structured JSON representation of an Event that's
+// published to event streams. The fields in Data are specific to the type of
+// event.
+type EventMessage struct {
+	// The version of the schema.
+	Version int `json:"version"`
+
+	// The type of event (e.g. deploy, scale).
+	Event string `json:"event"`
+
+	// The name of the user that triggered the event.
+	User string `json:"user"`
+
+	// The name of the app that the event relates to, if any.
+	App string `json:"app,omitempty"`
+
+	// A human readable description of the event.
+	Message string `json:"message"`
+
+	// The time that the event happened.
+	Timestamp time.Time `json:"timestamp"`
+
+	// The event.
+	Data json.RawMessage `json:"data"`
+}
+
+// NewEventMessage returns the EventMessage for the event.
+func NewEventMessage(event Event) (*EventMessage, error) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+
+	// All events have a user field, and most have an app field, so we
+	// pull them out of the data instead of requiring every event to expose
+	// them.
+	var fields struct {
+		User string `json:"user"`
+		App  string `json:"app"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	timestamp := timex.Now()
+	if e, ok := event.(interface {
+		Timestamp() time.Time
+	}); ok {
+		timestamp = e.Timestamp()
+	}
+
+	return &EventMessage{
+		Version:   EventSchemaVersion,
+		Event:     event.Event(),
+		User:      fields.User,
+		App:       fields.App,
+		Message:   event.String(),
+		Timestamp: timestamp.UTC(),
+		Data:      data,
+	}, nil
+}
+
+// EncodeEvent encodes the event as an EventMessage.
+func EncodeEvent(event Event) ([]byte, error) {
+	m, err := NewEventMessage(event)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(m)
+}
+
 // EventStream is an interface for publishing events that happen within
 // Empire.
 type EventStream interface {
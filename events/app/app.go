@@ -27,12 +27,19 @@ func NewEventStream(c client.ConfigProvider) *EventStream {
 	}
 }
 
+// PublishEvent puts the event, encoded as an empire.EventMessage, on the
+// kinesis stream for the app.
 func (s *EventStream) PublishEvent(event empire.Event) error {
 	if e, ok := event.(empire.AppEvent); ok {
+		raw, err := empire.EncodeEvent(e)
+		if err != nil {
+			return err
+		}
+
 		name := e.GetApp().ID
 		key := fmt.Sprintf("%s.events", e.GetApp().ID)
 		s.kinesis.PutRecord(&kinesis.PutRecordInput{
-			Data:         []byte(e.String()),
+			Data:         raw,
 			StreamName:   &name,
 			PartitionKey: &key,
 		})
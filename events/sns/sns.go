@@ -3,21 +3,12 @@
 package sns
 
 import (
-	"encoding/json"
-
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/client"
 	"github.com/aws/aws-sdk-go/service/sns"
 	"github.com/remind101/empire"
 )
 
-// Event represents the schema for an SNS payload.
-type Event struct {
-	Event   string
-	Message string
-	Data    interface{}
-}
-
 type snsClient interface {
 	Publish(*sns.PublishInput) (*sns.PublishOutput, error)
 }
@@ -37,12 +28,10 @@ func NewEventStream(c client.ConfigProvider) *EventStream {
 	}
 }
 
+// PublishEvent publishes the event to the SNS topic, encoded as an
+// empire.EventMessage.
 func (e *EventStream) PublishEvent(event empire.Event) error {
-	raw, err := json.Marshal(&Event{
-		Event:   event.Event(),
-		Message: event.String(),
-		Data:    event,
-	})
+	raw, err := empire.EncodeEvent(event)
 	if err != nil {
 		return err
 	}
@@ -3,13 +3,21 @@ package sns
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/remind101/pkg/timex"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
 
+func init() {
+	timex.Now = func() time.Time {
+		return time.Date(2016, time.January, 1, 0, 0, 0, 0, time.UTC)
+	}
+}
+
 func TestEvents_PublishEvent(t *testing.T) {
 	c := new(mockSNSClient)
 	e := &EventStream{
@@ -18,7 +26,7 @@ func TestEvents_PublishEvent(t *testing.T) {
 	}
 
 	c.On("Publish", &sns.PublishInput{
-		Message:  aws.String("{\"Event\":\"fake\",\"Message\":\"ejholmes did something\",\"Data\":{\"User\":\"ejholmes\"}}"),
+		Message:  aws.String(`{"version":1,"event":"fake","user":"ejholmes","message":"ejholmes did something","timestamp":"2016-01-01T00:00:00Z","data":{"user":"ejholmes"}}`),
 		TopicArn: aws.String("arn::sns/topic"),
 	}).Return(nil, nil)
 
@@ -29,7 +37,7 @@ func TestEvents_PublishEvent(t *testing.T) {
 }
 
 type fakeEvent struct {
-	User string
+	User string `json:"user"`
 }
 
 func (e fakeEvent) Event() string  { return "fake" }
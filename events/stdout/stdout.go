@@ -15,7 +15,13 @@ func NewEventStream(c client.ConfigProvider) *EventStream {
 	return &EventStream{}
 }
 
+// PublishEvent prints the event to stdout, encoded as an empire.EventMessage
+// on a single line.
 func (e *EventStream) PublishEvent(event empire.Event) error {
-	_, err := fmt.Println(event.String())
+	raw, err := empire.EncodeEvent(event)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Println(string(raw))
 	return err
 }
@@ -0,0 +1,263 @@
+// Package webhook provides an empire.EventStream implementation that POSTs
+// events to http endpoints.
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/remind101/empire"
+)
+
+const (
+	// EventHeader is the http header that contains the type of the event.
+	EventHeader = "X-Empire-Event"
+
+	// SignatureHeader is the http header that contains the HMAC-SHA256
+	// signature of the request body, as sha256=<hex digest>.
+	SignatureHeader = "X-Empire-Signature"
+)
+
+// DefaultMaxAttempts is the default number of times that delivery of an event
+// to an endpoint is attempted.
+const DefaultMaxAttempts = 5
+
+// DefaultTimeout is the default timeout for a single delivery attempt.
+const DefaultTimeout = 10 * time.Second
+
+// defaultClient is the http.Client used when one isn't provided. Unlike
+// http.DefaultClient, it has a timeout, so a slow endpoint can't hold up
+// delivery indefinitely.
+var defaultClient = &http.Client{Timeout: DefaultTimeout}
+
+// Endpoint is a url that events are delivered to.
+type Endpoint struct {
+	// The url to POST events to.
+	URL string
+
+	// If provided, only events of these types will be delivered.
+	Events []string
+}
+
+// ParseEndpoint parses an endpoint in the form of a url, with an optional
+// fragment containing a comma separated list of the event types to deliver
+// (e.g. https://example.com/hook#deploy,scale). The fragment is never sent to
+// the server, so it can't conflict with the url.
+func ParseEndpoint(s string) (*Endpoint, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("webhook url must be http or https: %s", s)
+	}
+
+	var events []string
+	for _, event := range strings.Split(u.Fragment, ",") {
+		if event = strings.TrimSpace(event); event != "" {
+			events = append(events, event)
+		}
+	}
+	u.Fragment = ""
+
+	return &Endpoint{
+		URL:    u.String(),
+		Events: events,
+	}, nil
+}
+
+// Accepts returns true if events of the given type should be delivered to this
+// endpoint.
+func (e *Endpoint) Accepts(event string) bool {
+	if len(e.Events) == 0 {
+		return true
+	}
+
+	for _, ev := range e.Events {
+		if ev == event {
+			return true
+		}
+	}
+
+	return false
+}
+
+// EventStream is an implementation of the empire.EventStream interface that
+// POSTs events to one or more endpoints. Failed deliveries are retried with an
+// exponential backoff.
+type EventStream struct {
+	// The endpoints to deliver events to.
+	Endpoints []*Endpoint
+
+	// If provided, requests are signed with this secret, so the receiver
+	// can verify that the request came from Empire.
+	Secret []byte
+
+	// The maximum number of delivery attempts for each endpoint. The
+	// default is DefaultMaxAttempts.
+	MaxAttempts int
+
+	// The http.Client to use to make requests. The default is a client
+	// with a timeout of DefaultTimeout.
+	Client *http.Client
+
+	// Returns how long to wait before the given retry attempt (starting
+	// at 1). The default is an exponential backoff starting at 1 second.
+	Backoff func(attempt int) time.Duration
+
+	// Used in tests to stub out time.Sleep.
+	sleep func(time.Duration)
+}
+
+// NewEventStream returns a new EventStream that delivers events to the
+// endpoints.
+func NewEventStream(endpoints []*Endpoint, secret []byte) *EventStream {
+	return &EventStream{
+		Endpoints: endpoints,
+		Secret:    secret,
+	}
+}
+
+// PublishEvent delivers the event, encoded as an empire.EventMessage, to all of
+// the endpoints that accept it. It blocks until each delivery succeeds, or fails
+// after all of the attempts.
+func (s *EventStream) PublishEvent(event empire.Event) error {
+	raw, err := empire.EncodeEvent(event)
+	if err != nil {
+		return err
+	}
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		result *multierror.Error
+	)
+
+	for _, endpoint := range s.Endpoints {
+		if !endpoint.Accepts(event.Event()) {
+			continue
+		}
+
+		wg.Add(1)
+		go func(endpoint *Endpoint) {
+			defer wg.Done()
+			if err := s.deliver(endpoint, event.Event(), raw); err != nil {
+				mu.Lock()
+				result = multierror.Append(result, err)
+				mu.Unlock()
+			}
+		}(endpoint)
+	}
+
+	wg.Wait()
+
+	return result.ErrorOrNil()
+}
+
+// deliver POSTs the payload to the endpoint, retrying when the request fails
+// or the endpoint returns a retryable status code.
+func (s *EventStream) deliver(endpoint *Endpoint, event string, raw []byte) error {
+	var err error
+	for attempt := 0; attempt < s.maxAttempts(); attempt++ {
+		if attempt > 0 {
+			s.wait(attempt)
+		}
+
+		var retry bool
+		retry, err = s.post(endpoint, event, raw)
+		if err == nil || !retry {
+			break
+		}
+	}
+
+	if err != nil {
+		return fmt.Errorf("webhook delivery of %s event to %s failed: %v", event, endpoint.URL, err)
+	}
+
+	return nil
+}
+
+// post makes a single delivery attempt. It returns true if the attempt can be
+// retried.
+func (s *EventStream) post(endpoint *Endpoint, event string, raw []byte) (bool, error) {
+	req, err := http.NewRequest("POST", endpoint.URL, bytes.NewReader(raw))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, event)
+	if s.Secret != nil {
+		req.Header.Set(SignatureHeader, Sign(s.Secret, raw))
+	}
+
+	client := s.Client
+	if client == nil {
+		client = defaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		// Server errors and rate limiting are retried. Anything else
+		// means that the endpoint rejected the event, so there's no
+		// point retrying.
+		retry := resp.StatusCode/100 == 5 || resp.StatusCode == http.StatusTooManyRequests
+		return retry, fmt.Errorf("unexpected response: %s", resp.Status)
+	}
+
+	return false, nil
+}
+
+func (s *EventStream) maxAttempts() int {
+	if s.MaxAttempts == 0 {
+		return DefaultMaxAttempts
+	}
+	return s.MaxAttempts
+}
+
+func (s *EventStream) wait(attempt int) {
+	backoff := s.Backoff
+	if backoff == nil {
+		backoff = ExponentialBackoff
+	}
+
+	sleep := s.sleep
+	if sleep == nil {
+		sleep = time.Sleep
+	}
+
+	sleep(backoff(attempt))
+}
+
+// ExponentialBackoff returns a backoff that starts at 1 second, and doubles
+// with each attempt.
+func ExponentialBackoff(attempt int) time.Duration {
+	return time.Second << uint(attempt-1)
+}
+
+// Sign returns the value of the SignatureHeader for the body.
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify returns true if the signature is a valid signature of the body.
+// Receivers can use this to verify that requests came from Empire.
+func Verify(secret, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, body)), []byte(signature))
+}
@@ -0,0 +1,146 @@
+package webhook
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/remind101/pkg/timex"
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	timex.Now = func() time.Time {
+		return time.Date(2016, time.January, 1, 0, 0, 0, 0, time.UTC)
+	}
+}
+
+func TestParseEndpoint(t *testing.T) {
+	tests := []struct {
+		in  string
+		out *Endpoint
+		err bool
+	}{
+		{"https://example.com/hook", &Endpoint{URL: "https://example.com/hook"}, false},
+		{"https://example.com/hook?a=b#deploy", &Endpoint{URL: "https://example.com/hook?a=b", Events: []string{"deploy"}}, false},
+		{"http://example.com/hook#deploy, scale", &Endpoint{URL: "http://example.com/hook", Events: []string{"deploy", "scale"}}, false},
+		{"example.com/hook", nil, true},
+	}
+
+	for _, tt := range tests {
+		e, err := ParseEndpoint(tt.in)
+		if tt.err {
+			assert.Error(t, err)
+			continue
+		}
+		assert.NoError(t, err)
+		assert.Equal(t, tt.out, e)
+	}
+}
+
+func TestEndpoint_Accepts(t *testing.T) {
+	e := &Endpoint{}
+	assert.True(t, e.Accepts("deploy"))
+
+	e = &Endpoint{Events: []string{"deploy", "scale"}}
+	assert.True(t, e.Accepts("scale"))
+	assert.False(t, e.Accepts("restart"))
+}
+
+func TestEventStream_PublishEvent(t *testing.T) {
+	secret := []byte("secret")
+	body := `{"version":1,"event":"fake","user":"ejholmes","message":"ejholmes did something","timestamp":"2016-01-01T00:00:00Z","data":{"user":"ejholmes"}}`
+
+	var called bool
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		raw, err := ioutil.ReadAll(r.Body)
+		assert.NoError(t, err)
+		assert.Equal(t, body, string(raw))
+		assert.Equal(t, "fake", r.Header.Get(EventHeader))
+		assert.True(t, Verify(secret, raw, r.Header.Get(SignatureHeader)))
+		w.WriteHeader(200)
+	}))
+	defer s.Close()
+
+	e := NewEventStream([]*Endpoint{{URL: s.URL}}, secret)
+	err := e.PublishEvent(fakeEvent{User: "ejholmes"})
+	assert.NoError(t, err)
+	assert.True(t, called)
+}
+
+func TestEventStream_PublishEvent_Filtered(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("unexpected request")
+	}))
+	defer s.Close()
+
+	e := NewEventStream([]*Endpoint{{URL: s.URL, Events: []string{"deploy"}}}, nil)
+	err := e.PublishEvent(fakeEvent{User: "ejholmes"})
+	assert.NoError(t, err)
+}
+
+func TestEventStream_PublishEvent_Retry(t *testing.T) {
+	var attempts int
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts < 3 {
+			w.WriteHeader(503)
+			return
+		}
+		w.WriteHeader(200)
+	}))
+	defer s.Close()
+
+	var waits []time.Duration
+	e := NewEventStream([]*Endpoint{{URL: s.URL}}, nil)
+	e.sleep = func(d time.Duration) { waits = append(waits, d) }
+
+	err := e.PublishEvent(fakeEvent{User: "ejholmes"})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, attempts)
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second}, waits)
+}
+
+func TestEventStream_PublishEvent_Failure(t *testing.T) {
+	var attempts int
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(500)
+	}))
+	defer s.Close()
+
+	e := NewEventStream([]*Endpoint{{URL: s.URL}}, nil)
+	e.MaxAttempts = 2
+	e.sleep = func(time.Duration) {}
+
+	err := e.PublishEvent(fakeEvent{User: "ejholmes"})
+	assert.Error(t, err)
+	assert.Equal(t, 2, attempts)
+}
+
+func TestEventStream_PublishEvent_Rejected(t *testing.T) {
+	var attempts int
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(400)
+	}))
+	defer s.Close()
+
+	e := NewEventStream([]*Endpoint{{URL: s.URL}}, nil)
+	e.sleep = func(time.Duration) {}
+
+	err := e.PublishEvent(fakeEvent{User: "ejholmes"})
+	assert.Error(t, err)
+	assert.Equal(t, 1, attempts)
+}
+
+type fakeEvent struct {
+	User string `json:"user"`
+}
+
+func (e fakeEvent) Event() string  { return "fake" }
+func (e fakeEvent) String() string { return fmt.Sprintf("%s did something", e.User) }
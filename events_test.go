@@ -2,7 +2,9 @@ package empire
 
 import (
 	"testing"
+	"time"
 
+	"github.com/remind101/pkg/timex"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -93,10 +95,23 @@ func TestEvents_String(t *testing.T) {
 		{DeployEvent{User: "ejholmes", App: "acme-inc", Image: "remind101/acme-inc:master", Environment: "production", Release: 32, Message: "commit message"}, "ejholmes deployed remind101/acme-inc:master to acme-inc production (v32): 'commit message'"},
 		{DeployEvent{User: "ejholmes", Image: "remind101/acme-inc:master", Message: "commit message"}, "ejholmes deployed remind101/acme-inc:master: 'commit message'"},
 
+		{DeployEvent{User: "ejholmes", App: "acme-inc", Image: "remind101/acme-inc:master", Environment: "production", Release: 32, Canary: 10}, "ejholmes deployed remind101/acme-inc:master to acme-inc production (v32) as a canary (10%)"},
+
+		// PromoteEvent
+		{PromoteEvent{User: "ejholmes", App: "acme-inc", Release: 32}, "ejholmes promoted the canary on acme-inc (v32)"},
+		{PromoteEvent{User: "ejholmes", App: "acme-inc", Release: 32, Message: "commit message"}, "ejholmes promoted the canary on acme-inc (v32): 'commit message'"},
+
+		// AbortEvent
+		{AbortEvent{User: "ejholmes", App: "acme-inc"}, "ejholmes aborted the canary on acme-inc"},
+		{AbortEvent{User: "ejholmes", App: "acme-inc", Message: "commit message"}, "ejholmes aborted the canary on acme-inc: 'commit message'"},
+
 		// RollbackEvent
 		{RollbackEvent{User: "ejholmes", App: "acme-inc", Version: 1}, "ejholmes rolled back acme-inc to v1"},
 		{RollbackEvent{User: "ejholmes", App: "acme-inc", Version: 1, Message: "commit message"}, "ejholmes rolled back acme-inc to v1: 'commit message'"},
 
+		// AutoRollbackEvent
+		{AutoRollbackEvent{User: "ejholmes", App: "acme-inc", Version: 1, Reason: "release failed to stabilize"}, "acme-inc was automatically rolled back to v1 after a deploy by ejholmes: release failed to stabilize"},
+
 		// SetEvent
 		{SetEvent{User: "ejholmes", App: "acme-inc", Changed: []string{"RAILS_ENV"}}, "ejholmes changed environment variables on acme-inc (RAILS_ENV)"},
 		{SetEvent{User: "ejholmes", App: "acme-inc", Changed: []string{"RAILS_ENV"}, Message: "commit message"}, "ejholmes changed environment variables on acme-inc (RAILS_ENV): 'commit message'"},
@@ -105,6 +120,13 @@ func TestEvents_String(t *testing.T) {
 		{CreateEvent{User: "ejholmes", Name: "acme-inc"}, "ejholmes created acme-inc"},
 		{CreateEvent{User: "ejholmes", Name: "acme-inc", Message: "commit message"}, "ejholmes created acme-inc: 'commit message'"},
 
+		// AutoscaleEvent
+		{AutoscaleEvent{User: "ejholmes", App: "acme-inc", Process: "web", Autoscaling: &Autoscaling{Min: 2, Max: 20, CPU: 60}}, "ejholmes autoscaled `web` on acme-inc with min=2 max=20 cpu=60"},
+		{AutoscaleEvent{User: "ejholmes", App: "acme-inc", Process: "web", Previous: &Autoscaling{Min: 2, Max: 20, CPU: 60}, Message: "commit message"}, "ejholmes disabled autoscaling of `web` on acme-inc: 'commit message'"},
+
+		// RenameEvent
+		{RenameEvent{User: "ejholmes", App: "acme", PreviousName: "acme-inc", Message: "commit message"}, "ejholmes renamed acme-inc to acme: 'commit message'"},
+
 		// DestroyEvent
 		{DestroyEvent{User: "ejholmes", App: "acme-inc", Message: "commit message"}, "ejholmes destroyed acme-inc: 'commit message'"},
 	}
@@ -114,3 +136,43 @@ func TestEvents_String(t *testing.T) {
 		assert.Equal(t, tt.out, out)
 	}
 }
+
+func TestEncodeEvent(t *testing.T) {
+	now := time.Date(2016, time.January, 1, 0, 0, 0, 0, time.UTC)
+	timex.Now = func() time.Time { return now }
+	defer func() { timex.Now = time.Now }()
+
+	tests := []struct {
+		event Event
+		out   string
+	}{
+		{
+			DeployEvent{User: "ejholmes", App: "acme-inc", Image: "remind101/acme-inc:master", Environment: "production", Release: 2},
+			`{"version":1,"event":"deploy","user":"ejholmes","app":"acme-inc","message":"ejholmes deployed remind101/acme-inc:master to acme-inc production (v2)","timestamp":"2016-01-01T00:00:00Z","data":{"user":"ejholmes","app":"acme-inc","image":"remind101/acme-inc:master","environment":"production","release":2}}`,
+		},
+		{
+			ScaleEvent{User: "ejholmes", App: "acme-inc", Updates: []*ScaleEventUpdate{
+				{Process: "web", Quantity: 10, PreviousQuantity: 5, PreviousConstraints: Constraints{CPUShare: 512, Memory: 1024, Nproc: 256}},
+			}},
+			`{"version":1,"event":"scale","user":"ejholmes","app":"acme-inc","message":"ejholmes scaled ` + "`web`" + ` on acme-inc from 5(512:1.00kb:nproc=256) to 10(512:1.00kb:nproc=256)","timestamp":"2016-01-01T00:00:00Z","data":{"user":"ejholmes","app":"acme-inc","updates":[{"process":"web","previous":{"quantity":5,"cpu_share":512,"memory":1024,"nproc":256},"new":{"quantity":10,"cpu_share":512,"memory":1024,"nproc":256}}]}}`,
+		},
+		{
+			SetEvent{User: "ejholmes", App: "acme-inc", Changed: []string{"RAILS_ENV"}},
+			`{"version":1,"event":"set","user":"ejholmes","app":"acme-inc","message":"ejholmes changed environment variables on acme-inc (RAILS_ENV)","timestamp":"2016-01-01T00:00:00Z","data":{"user":"ejholmes","app":"acme-inc","changed":["RAILS_ENV"]}}`,
+		},
+		{
+			CreateEvent{User: "ejholmes", Name: "acme-inc"},
+			`{"version":1,"event":"create","user":"ejholmes","app":"acme-inc","message":"ejholmes created acme-inc","timestamp":"2016-01-01T00:00:00Z","data":{"user":"ejholmes","app":"acme-inc"}}`,
+		},
+		{
+			&storedEvent{typ: "destroy", message: "ejholmes destroyed acme-inc", payload: []byte(`{"user":"ejholmes","app":"acme-inc"}`), timestamp: now.Add(-time.Hour)},
+			`{"version":1,"event":"destroy","user":"ejholmes","app":"acme-inc","message":"ejholmes destroyed acme-inc","timestamp":"2015-12-31T23:00:00Z","data":{"user":"ejholmes","app":"acme-inc"}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		raw, err := EncodeEvent(tt.event)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.out, string(raw))
+	}
+}
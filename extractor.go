@@ -241,9 +241,45 @@ func formationFromExtendedProcfile(p procfile.ExtendedProcfile) (Formation, erro
 			return nil, errors.New("unknown command format")
 		}
 
+		var healthCheck *HealthCheck
+		if hc := process.HealthCheck; hc != nil {
+			healthCheck = &HealthCheck{
+				Path:               hc.Path,
+				Interval:           hc.Interval,
+				Timeout:            hc.Timeout,
+				HealthyThreshold:   hc.HealthyThreshold,
+				UnhealthyThreshold: hc.UnhealthyThreshold,
+			}
+			if err := healthCheck.Validate(); err != nil {
+				return nil, fmt.Errorf("invalid health check for %s: %v", name, err)
+			}
+		}
+
+		var exposure *Exposure
+		if e := process.Expose; e != nil {
+			exposure = &Exposure{
+				Protocol: e.Protocol,
+				External: e.External,
+				Ports:    e.Ports,
+				Cert:     e.Cert,
+			}
+			if exposure.Protocol == "" {
+				exposure.Protocol = ProtocolHTTP
+			}
+			if err := exposure.Validate(); err != nil {
+				return nil, fmt.Errorf("invalid exposure for %s: %v", name, err)
+			}
+		}
+
+		if name == releaseProcessType && (process.Cron != nil || healthCheck != nil || exposure != nil) {
+			return nil, fmt.Errorf("the %s process can't have a cron, health check or exposure", name)
+		}
+
 		f[name] = Process{
-			Command: cmd,
-			Cron:    process.Cron,
+			Command:     cmd,
+			Cron:        process.Cron,
+			HealthCheck: healthCheck,
+			Exposure:    exposure,
 		}
 	}
 
@@ -11,6 +11,7 @@ import (
 	"github.com/remind101/empire/pkg/dockerutil"
 	"github.com/remind101/empire/pkg/httpmock"
 	"github.com/remind101/empire/pkg/image"
+	"github.com/remind101/empire/procfile"
 )
 
 func TestCMDExtractor(t *testing.T) {
@@ -218,3 +219,62 @@ func tarProcfile(t *testing.T) string {
 
 	return buf.String()
 }
+
+func TestFormationFromProcfile_HealthCheck(t *testing.T) {
+	f, err := formationFromProcfile(procfile.ExtendedProcfile{
+		"web": procfile.Process{
+			Command: "./bin/web",
+			HealthCheck: &procfile.HealthCheck{
+				Path:     "/health",
+				Interval: 10,
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &HealthCheck{Path: "/health", Interval: 10}
+	if got := f["web"].HealthCheck; !reflect.DeepEqual(got, want) {
+		t.Errorf("HealthCheck => %#v; want %#v", got, want)
+	}
+
+	_, err = formationFromProcfile(procfile.ExtendedProcfile{
+		"web": procfile.Process{
+			Command: "./bin/web",
+			HealthCheck: &procfile.HealthCheck{
+				Path: "health",
+			},
+		},
+	})
+	if err == nil {
+		t.Error("Expected an error for an invalid health check")
+	}
+}
+
+func TestFormationFromProcfile_Release(t *testing.T) {
+	f, err := formationFromProcfile(procfile.ExtendedProcfile{
+		"release": procfile.Process{
+			Command: "./bin/release",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := Command{"./bin/release"}
+	if got := f["release"].Command; !reflect.DeepEqual(got, want) {
+		t.Errorf("Command => %#v; want %#v", got, want)
+	}
+
+	cron := "* * * * * *"
+	_, err = formationFromProcfile(procfile.ExtendedProcfile{
+		"release": procfile.Process{
+			Command: "./bin/release",
+			Cron:    &cron,
+		},
+	})
+	if err == nil {
+		t.Error("Expected an error for a scheduled release process")
+	}
+}
@@ -0,0 +1,135 @@
+package empire
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/jinzhu/gorm"
+	"github.com/remind101/empire/scheduler"
+	"golang.org/x/net/context"
+)
+
+// gcService finds, and removes, resources that the scheduler created for apps
+// that no longer exist.
+type gcService struct {
+	*Empire
+
+	// Resources that were orphaned on the last collection, so that
+	// resources are only removed when they stay orphaned, and not while an
+	// app is still being created.
+	orphaned map[string]bool
+}
+
+// Orphans returns the resources that the scheduler created for apps that aren't
+// in the apps table.
+func (s *gcService) Orphans(ctx context.Context, db *gorm.DB) ([]*scheduler.Orphan, error) {
+	apps, err := apps(db, AppsQuery{})
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	for _, app := range apps {
+		ids = append(ids, app.ID)
+	}
+
+	return scheduler.Orphans(ctx, s.Scheduler, ids)
+}
+
+// Remove removes the orphaned resources. Failures don't stop the remaining
+// resources from being removed, and are returned together.
+func (s *gcService) Remove(ctx context.Context, orphans []*scheduler.Orphan) error {
+	var result *multierror.Error
+	for _, orphan := range orphans {
+		if err := scheduler.RemoveOrphan(ctx, s.Scheduler, orphan); err != nil {
+			result = multierror.Append(result, fmt.Errorf("error removing %s: %v", orphan, err))
+		}
+	}
+	return result.ErrorOrNil()
+}
+
+// Confirm looks for orphaned resources again, and returns the ones that are
+// also in previous, so that resources are only removed when they stay orphaned.
+func (s *gcService) Confirm(ctx context.Context, db *gorm.DB, previous []*scheduler.Orphan) ([]*scheduler.Orphan, error) {
+	orphans, err := s.Orphans(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	orphaned := make(map[string]bool)
+	for _, orphan := range previous {
+		orphaned[orphan.String()] = true
+	}
+
+	var confirmed []*scheduler.Orphan
+	for _, orphan := range orphans {
+		if orphaned[orphan.String()] {
+			confirmed = append(confirmed, orphan)
+		}
+	}
+
+	return confirmed, nil
+}
+
+// Collect logs the orphaned resources. When apply is true, resources that were
+// also orphaned on the previous collection are removed.
+func (s *gcService) Collect(ctx context.Context, apply bool) error {
+	orphans, err := s.Orphans(ctx, s.db)
+	if err != nil {
+		return err
+	}
+
+	orphaned := make(map[string]bool)
+	var remove []*scheduler.Orphan
+	for _, orphan := range orphans {
+		log.Printf("gc: found orphaned %s\n", orphan)
+		orphaned[orphan.String()] = true
+
+		if apply && s.orphaned[orphan.String()] {
+			log.Printf("gc: removing %s\n", orphan)
+			remove = append(remove, orphan)
+		}
+	}
+	s.orphaned = orphaned
+
+	return s.Remove(ctx, remove)
+}
+
+// Orphans returns the resources that the scheduler created for apps that no
+// longer exist.
+func (e *Empire) Orphans(ctx context.Context) ([]*scheduler.Orphan, error) {
+	return e.gc.Orphans(ctx, e.db)
+}
+
+// ConfirmOrphans looks for orphaned resources again, and returns the ones that
+// were also returned from a previous call to Orphans.
+func (e *Empire) ConfirmOrphans(ctx context.Context, orphans []*scheduler.Orphan) ([]*scheduler.Orphan, error) {
+	return e.gc.Confirm(ctx, e.db, orphans)
+}
+
+// RemoveOrphans removes resources that were returned from Orphans.
+func (e *Empire) RemoveOrphans(ctx context.Context, orphans []*scheduler.Orphan) error {
+	return e.gc.Remove(ctx, orphans)
+}
+
+// CollectGarbage looks for orphaned resources every interval, until the context
+// is canceled. Orphaned resources are logged and, when apply is true, resources
+// that stay orphaned for two consecutive collections are removed.
+func (e *Empire) CollectGarbage(ctx context.Context, interval time.Duration, apply bool) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := e.gc.Collect(ctx, apply); err != nil {
+			log.Printf("gc: error collecting garbage: %v\n", err)
+		}
+	}
+}
@@ -0,0 +1,597 @@
+package empire
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+
+	"github.com/jinzhu/gorm"
+	"github.com/remind101/empire/pkg/image"
+	"golang.org/x/net/context"
+)
+
+// Manifest describes the desired state of an app: the image that's deployed,
+// its config vars, the scale and size of its processes, its domains and how
+// it's exposed. Applying a manifest only changes what differs from the current
+// state of the app.
+//
+// Fields that are omitted are left unchanged. For example, a manifest without
+// config leaves the config vars alone, whereas an empty config removes them
+// all.
+type Manifest struct {
+	// The name of the app.
+	Name string
+
+	// The Docker image to deploy.
+	Image string
+
+	// The config vars for the app. Secrets are kept as they are when
+	// their value is MaskedSecret.
+	Config map[string]string
+
+	// The quantity and size of each process.
+	Formation map[string]ManifestProcess
+
+	// The domains that route to the app.
+	Domains []string
+
+	// Valid values are exposePrivate and exposePublic.
+	Exposure string
+
+	// The name of the SSL cert for the web process.
+	Cert string
+}
+
+// ManifestProcess is the desired quantity and size of a process.
+type ManifestProcess struct {
+	Quantity int
+
+	// The size of the process, either a named size (e.g. 1X) or
+	// constraints (e.g. 512:1GB). If empty, the size isn't changed.
+	Size string
+}
+
+// Validate checks that the manifest is valid, without looking at the current
+// state of the app. Sizes are checked against the dyno sizes that e is
+// configured with.
+func (m *Manifest) Validate(e *Empire) error {
+	if !NamePattern.MatchString(m.Name) {
+		return ErrInvalidName
+	}
+
+	if m.Image != "" {
+		if _, err := image.Decode(m.Image); err != nil {
+			return &ValidationError{Err: fmt.Errorf("invalid image %q: %v", m.Image, err)}
+		}
+	}
+
+	switch m.Exposure {
+	case "", exposePrivate, exposePublic:
+	default:
+		return &ValidationError{Err: fmt.Errorf("%q is not a valid exposure. Valid values are private and public.", m.Exposure)}
+	}
+
+	for name, p := range m.Formation {
+		if name == releaseProcessType {
+			return ErrScaleRelease
+		}
+		if p.Quantity < 0 {
+			return &ValidationError{Err: fmt.Errorf("invalid quantity for %s: %d", name, p.Quantity)}
+		}
+		c, err := e.DynoSizes().Parse(p.Size)
+		if err != nil {
+			return &ValidationError{Err: fmt.Errorf("invalid size for %s: %v", name, err)}
+		}
+		if err := e.validateConstraints(c); err != nil {
+			return err
+		}
+	}
+
+	for _, hostname := range m.Domains {
+		if err := (&Domain{Hostname: hostname}).Validate(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Types of ManifestChange.
+const (
+	ManifestCreate   = "create"
+	ManifestImage    = "image"
+	ManifestConfig   = "config"
+	ManifestScale    = "scale"
+	ManifestDomain   = "domain"
+	ManifestExposure = "exposure"
+	ManifestCert     = "cert"
+)
+
+// ManifestChange is a single change that's made to an app when a Manifest is
+// applied.
+type ManifestChange struct {
+	// The type of change (e.g. config, scale).
+	Type string
+
+	// A human readable description of the change.
+	Description string
+}
+
+// role returns the role required to make this change.
+func (c *ManifestChange) role() Role {
+	switch c.Type {
+	case ManifestCreate, ManifestDomain, ManifestExposure, ManifestCert:
+		return RoleAdmin
+	default:
+		return RoleDeployer
+	}
+}
+
+// changesRole returns the role required to make all of the changes.
+func changesRole(changes []*ManifestChange) Role {
+	role := RoleDeployer
+	for _, c := range changes {
+		if r := c.role(); r.Includes(role) {
+			role = r
+		}
+	}
+	return role
+}
+
+// changesOfType returns true if any of the changes are of the given types.
+func changesOfType(changes []*ManifestChange, types ...string) bool {
+	for _, c := range changes {
+		for _, t := range types {
+			if c.Type == t {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// diffManifest returns the changes required to go from the current state of an
+// app to the desired state.
+func diffManifest(sizes DynoSizes, current, desired *Manifest) []*ManifestChange {
+	var changes []*ManifestChange
+	add := func(typ, format string, args ...interface{}) {
+		changes = append(changes, &ManifestChange{Type: typ, Description: fmt.Sprintf(format, args...)})
+	}
+
+	if desired.Image != "" && desired.Image != current.Image {
+		add(ManifestImage, "deploy %s", desired.Image)
+	}
+
+	if desired.Config != nil {
+		for _, name := range sortedKeys(desired.Config) {
+			if v, ok := current.Config[name]; !ok || v != desired.Config[name] {
+				add(ManifestConfig, "set %s", name)
+			}
+		}
+		for _, name := range sortedKeys(current.Config) {
+			if _, ok := desired.Config[name]; !ok {
+				add(ManifestConfig, "unset %s", name)
+			}
+		}
+	}
+
+	var processes []string
+	for name := range desired.Formation {
+		processes = append(processes, name)
+	}
+	sort.Strings(processes)
+
+	for _, name := range processes {
+		p := desired.Formation[name]
+		c, ok := current.Formation[name]
+		if !ok {
+			add(ManifestScale, "scale %s to %d", name, p.Quantity)
+			continue
+		}
+
+		size := c.Size
+		if p.Size != "" {
+			// Compare the parsed size, so that equivalent sizes (e.g.
+			// 1X and 256:512MB:nproc=256) aren't considered a change.
+			if con, err := sizes.Parse(p.Size); err == nil && con != nil {
+				size = sizes.Format(*con)
+			}
+		}
+
+		if p.Quantity != c.Quantity || size != c.Size {
+			add(ManifestScale, "scale %s from %d(%s) to %d(%s)", name, c.Quantity, c.Size, p.Quantity, size)
+		}
+	}
+
+	if desired.Domains != nil {
+		want, have := stringSet(desired.Domains), stringSet(current.Domains)
+		for _, d := range sortedStrings(desired.Domains) {
+			if !have[d] {
+				add(ManifestDomain, "add domain %s", d)
+			}
+		}
+		for _, d := range sortedStrings(current.Domains) {
+			if !want[d] {
+				add(ManifestDomain, "remove domain %s", d)
+			}
+		}
+	}
+
+	if desired.Exposure != "" && desired.Exposure != current.Exposure {
+		add(ManifestExposure, "change exposure from %s to %s", current.Exposure, desired.Exposure)
+	}
+
+	if desired.Cert != "" && desired.Cert != current.Cert {
+		add(ManifestCert, "attach cert %s", desired.Cert)
+	}
+
+	return changes
+}
+
+// manifestsService applies manifests, and exports the current state of apps as
+// manifests.
+type manifestsService struct {
+	*Empire
+}
+
+// Manifest returns a Manifest describing the current state of the app. The
+// values of secrets are masked.
+func (s *manifestsService) Manifest(db *gorm.DB, app *App) (*Manifest, error) {
+	m := &Manifest{
+		Name:      app.Name,
+		Config:    make(map[string]string),
+		Formation: make(map[string]ManifestProcess),
+		Domains:   []string{},
+		Exposure:  app.Exposure,
+		Cert:      app.Cert,
+	}
+
+	ds, err := domains(db, DomainsQuery{App: app})
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range ds {
+		m.Domains = append(m.Domains, d.Hostname)
+	}
+	sort.Strings(m.Domains)
+
+	config, err := s.configs.Config(db, app)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range config.Vars.Masked() {
+		if v != nil {
+			m.Config[string(k)] = *v
+		}
+	}
+
+	release, err := releasesFind(db, ReleasesQuery{App: app})
+	if err != nil {
+		if err == gorm.RecordNotFound {
+			return m, nil
+		}
+		return nil, err
+	}
+
+	m.Image = release.Slug.Image.String()
+	for name, p := range release.Formation {
+		// The release process can't be scaled.
+		if name == releaseProcessType {
+			continue
+		}
+		m.Formation[name] = ManifestProcess{
+			Quantity: p.Quantity,
+			Size:     s.DynoSizes().Format(p.Constraints()),
+		}
+	}
+
+	return m, nil
+}
+
+// Apply applies the manifest, creating the app if it doesn't exist. When the
+// image, config or formation changes, a single new release is created and
+// submitted to the scheduler. The changes that were made are returned.
+func (s *manifestsService) Apply(ctx context.Context, db *gorm.DB, opts ApplyOpts) ([]*ManifestChange, error) {
+	m := opts.Manifest
+
+	app, err := appsFind(db, AppsQuery{Name: &m.Name})
+	if err != nil && err != gorm.RecordNotFound {
+		return nil, err
+	}
+
+	current := &Manifest{Name: m.Name}
+	var changes []*ManifestChange
+	if err == gorm.RecordNotFound {
+		app = nil
+		changes = append(changes, &ManifestChange{Type: ManifestCreate, Description: fmt.Sprintf("create %s", m.Name)})
+	} else {
+		current, err = s.Manifest(db, app)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	changes = append(changes, diffManifest(s.DynoSizes(), current, m)...)
+
+	if err := s.Authorize(opts.User, m.Name, changesRole(changes)); err != nil {
+		return nil, err
+	}
+
+	if len(changes) == 0 {
+		return nil, nil
+	}
+
+	// References to secrets can only be created by the SecretStore, and
+	// the masked value of a secret can only be used to keep the existing
+	// secret.
+	for k, v := range m.Config {
+		if isSecret(v) || (v == MaskedSecret && current.Config[k] != MaskedSecret) {
+			return nil, &ValidationError{Err: fmt.Errorf("invalid value for %s", k)}
+		}
+	}
+
+	if app == nil {
+		app, err = appsCreate(db, &App{Name: m.Name})
+		if err != nil {
+			return nil, err
+		}
+		if err := s.publishEvent(db, CreateEvent{User: opts.User.Name, Name: app.Name, Message: opts.Message}); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.applyDomains(ctx, db, app, current, m); err != nil {
+		return nil, err
+	}
+
+	if m.Exposure != "" || m.Cert != "" {
+		if m.Exposure != "" {
+			app.Exposure = m.Exposure
+		}
+		if m.Cert != "" {
+			app.Cert = m.Cert
+		}
+		if err := appsUpdate(db, app); err != nil {
+			return nil, err
+		}
+	}
+
+	event := ApplyEvent{
+		User:    opts.User.Name,
+		App:     app.Name,
+		Message: opts.Message,
+		app:     app,
+	}
+	for _, c := range changes {
+		event.Changes = append(event.Changes, c.Description)
+	}
+
+	if changesOfType(changes, ManifestImage, ManifestConfig, ManifestScale) {
+		r, err := s.applyRelease(ctx, db, app, current, opts)
+		if err != nil {
+			return nil, err
+		}
+		if r != nil {
+			event.Release = r.Version
+		}
+	} else if err := s.releases.ReleaseApp(ctx, db, app); err != nil && err != ErrNoReleases {
+		// The domains, exposure or cert of the app changed, so the
+		// current release is submitted again.
+		return nil, err
+	}
+
+	return changes, s.publishEvent(db, event)
+}
+
+// applyDomains adds and removes domains so that they match the manifest.
+func (s *manifestsService) applyDomains(ctx context.Context, db *gorm.DB, app *App, current, m *Manifest) error {
+	if m.Domains == nil {
+		return nil
+	}
+
+	want, have := stringSet(m.Domains), stringSet(current.Domains)
+
+	for _, hostname := range current.Domains {
+		if want[hostname] {
+			continue
+		}
+
+		d, err := domainsFind(db, DomainsQuery{Hostname: &hostname})
+		if err != nil {
+			return err
+		}
+		if err := s.domains.DomainsDestroy(ctx, db, d); err != nil {
+			return err
+		}
+	}
+
+	for _, hostname := range sortedStrings(m.Domains) {
+		if have[hostname] {
+			continue
+		}
+
+		if _, err := s.domains.DomainsCreate(ctx, db, &Domain{AppID: app.ID, Hostname: hostname}); err != nil {
+			return err
+		}
+	}
+
+	// Adding and removing domains can change the exposure of the app.
+	a, err := appsFind(db, AppsQuery{ID: &app.ID})
+	if err != nil {
+		return err
+	}
+	app.Exposure = a.Exposure
+
+	return nil
+}
+
+// applyRelease creates and submits a new release with the image, config and
+// formation from the manifest. If the app has no releases, and the manifest
+// doesn't include an image, only the config is stored.
+func (s *manifestsService) applyRelease(ctx context.Context, db *gorm.DB, app *App, current *Manifest, opts ApplyOpts) (*Release, error) {
+	m := opts.Manifest
+
+	config, err := s.configs.Config(db, app)
+	if err != nil {
+		return nil, err
+	}
+
+	if m.Config != nil {
+		vars := make(Vars)
+		for k, v := range m.Config {
+			// Secrets are masked, so they're left as they are.
+			if v == MaskedSecret {
+				continue
+			}
+			v := v
+			vars[Variable(k)] = &v
+		}
+		for k := range current.Config {
+			if _, ok := m.Config[k]; !ok {
+				vars[Variable(k)] = nil
+			}
+		}
+
+		config, err = configsCreate(db, newConfig(config, vars))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var slug *Slug
+	last, err := releasesFind(db, ReleasesQuery{App: app})
+	if err != nil && err != gorm.RecordNotFound {
+		return nil, err
+	}
+	if err == nil {
+		slug = last.Slug
+	}
+
+	deployed := m.Image != "" && m.Image != current.Image
+	if deployed {
+		img, err := image.Decode(m.Image)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := appsEnsureRepo(db, app, img.Repository); err != nil {
+			return nil, err
+		}
+
+		slug, err = s.slugs.Create(ctx, db, img, ioutil.Discard)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if slug == nil {
+		if len(m.Formation) > 0 {
+			return nil, &ValidationError{Err: fmt.Errorf("an image is required to scale %s, since it has no releases", app.Name)}
+		}
+		return nil, nil
+	}
+
+	r := &Release{
+		App:         app,
+		Config:      config,
+		Slug:        slug,
+		Description: appendMessageToDescription("Apply manifest", opts.User, opts.Message),
+	}
+
+	if err := buildFormation(db, r); err != nil {
+		return nil, err
+	}
+
+	for name, p := range m.Formation {
+		f, ok := r.Formation[name]
+		if !ok {
+			return nil, &ValidationError{Err: fmt.Errorf("no %s process type in release", name)}
+		}
+
+		f.Quantity = p.Quantity
+		if c, _ := s.DynoSizes().Parse(p.Size); c != nil {
+			f.SetConstraints(*c)
+		}
+		r.Formation[name] = f
+	}
+
+	r, err = s.releases.Create(ctx, db, r)
+	if err != nil {
+		return r, err
+	}
+
+	if deployed {
+		if err := s.deployer.runReleaseProcess(ctx, r, NewDeploymentStream(ioutil.Discard)); err != nil {
+			return r, err
+		}
+	}
+
+	return r, s.releases.Release(ctx, r, nil)
+}
+
+// ApplyOpts are options provided when applying a manifest.
+type ApplyOpts struct {
+	// User performing the action.
+	User *User
+
+	// The desired state of the app.
+	Manifest *Manifest
+
+	// Commit message
+	Message string
+}
+
+// Validate validates the manifest. The role that's required depends on what
+// changes, so authorization happens when the manifest is applied.
+func (opts ApplyOpts) Validate(e *Empire) error {
+	if err := opts.Manifest.Validate(e); err != nil {
+		return err
+	}
+	return e.requireMessages(opts.Message)
+}
+
+// Apply applies the manifest atomically; either all of the changes are made, or
+// none of them are.
+func (e *Empire) Apply(ctx context.Context, opts ApplyOpts) ([]*ManifestChange, error) {
+	if err := opts.Validate(e); err != nil {
+		return nil, err
+	}
+
+	tx := e.db.Begin()
+
+	changes, err := e.manifests.Apply(ctx, tx, opts)
+	if err != nil {
+		tx.Rollback()
+		return changes, err
+	}
+
+	return changes, tx.Commit().Error
+}
+
+// Manifest returns a Manifest describing the current state of the app.
+func (e *Empire) Manifest(app *App) (*Manifest, error) {
+	return e.manifests.Manifest(e.db, app)
+}
+
+func sortedKeys(m map[string]string) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedStrings(s []string) []string {
+	sorted := append([]string(nil), s...)
+	sort.Strings(sorted)
+	return sorted
+}
+
+func stringSet(s []string) map[string]bool {
+	set := make(map[string]bool, len(s))
+	for _, v := range s {
+		set[v] = true
+	}
+	return set
+}
@@ -0,0 +1,117 @@
+package empire
+
+import (
+	"testing"
+
+	. "github.com/remind101/empire/pkg/bytesize"
+	"github.com/remind101/empire/pkg/constraints"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManifest_Validate(t *testing.T) {
+	tests := []struct {
+		manifest Manifest
+		err      bool
+	}{
+		{Manifest{Name: "acme-inc"}, false},
+		{Manifest{Name: "acme-inc", Image: "remind101/acme-inc:latest", Exposure: "public"}, false},
+		{Manifest{Name: "a"}, true},
+		{Manifest{Name: "acme-inc", Exposure: "internet"}, true},
+		{Manifest{Name: "acme-inc", Formation: map[string]ManifestProcess{"web": {Quantity: -1}}}, true},
+		{Manifest{Name: "acme-inc", Formation: map[string]ManifestProcess{"web": {Quantity: 1, Size: "huge"}}}, true},
+		{Manifest{Name: "acme-inc", Formation: map[string]ManifestProcess{"web": {Quantity: 1, Size: "256:128MB"}}}, false},
+		{Manifest{Name: "acme-inc", Formation: map[string]ManifestProcess{"release": {Quantity: 1}}}, true},
+		{Manifest{Name: "acme-inc", Domains: []string{"*.example.com"}}, true},
+	}
+
+	for _, tt := range tests {
+		err := tt.manifest.Validate(&Empire{})
+		if tt.err {
+			assert.Error(t, err)
+		} else {
+			assert.NoError(t, err)
+		}
+	}
+
+	// Custom sizes are checked against the available dyno sizes.
+	e := &Empire{
+		AvailableDynoSizes: DynoSizes{{"4X", Constraints{CPUShare: 1024, Memory: constraints.Memory(4 * GB)}}},
+		RestrictDynoSizes:  true,
+	}
+	assert.NoError(t, (&Manifest{Name: "acme-inc", Formation: map[string]ManifestProcess{"web": {Quantity: 1, Size: "4x"}}}).Validate(e))
+	assert.Error(t, (&Manifest{Name: "acme-inc", Formation: map[string]ManifestProcess{"web": {Quantity: 1, Size: "1X"}}}).Validate(e))
+	assert.Equal(t, ErrDynoSize, (&Manifest{Name: "acme-inc", Formation: map[string]ManifestProcess{"web": {Quantity: 1, Size: "256:128MB"}}}).Validate(e))
+}
+
+func TestDiffManifest(t *testing.T) {
+	current := &Manifest{
+		Name:  "acme-inc",
+		Image: "remind101/acme-inc:v1",
+		Config: map[string]string{
+			"FOO": "bar",
+			"BAR": "baz",
+		},
+		Formation: map[string]ManifestProcess{
+			"web":    {Quantity: 1, Size: "1X"},
+			"worker": {Quantity: 0, Size: "1X"},
+		},
+		Domains:  []string{"acme-inc.example.com"},
+		Exposure: "public",
+	}
+
+	tests := []struct {
+		desired *Manifest
+		changes []*ManifestChange
+	}{
+		// Omitted fields are unchanged.
+		{&Manifest{Name: "acme-inc"}, nil},
+
+		// Applying the current state is a no-op.
+		{current, nil},
+
+		{
+			&Manifest{
+				Name:  "acme-inc",
+				Image: "remind101/acme-inc:v2",
+				Config: map[string]string{
+					"FOO": "qux",
+					"NEW": "1",
+				},
+				Formation: map[string]ManifestProcess{
+					"web":    {Quantity: 1, Size: "256:512MB:nproc=256"},
+					"worker": {Quantity: 2, Size: "2X"},
+				},
+				Domains:  []string{"acme.example.com"},
+				Exposure: "private",
+				Cert:     "acme-inc",
+			},
+			[]*ManifestChange{
+				{Type: ManifestImage, Description: "deploy remind101/acme-inc:v2"},
+				{Type: ManifestConfig, Description: "set FOO"},
+				{Type: ManifestConfig, Description: "set NEW"},
+				{Type: ManifestConfig, Description: "unset BAR"},
+				{Type: ManifestScale, Description: "scale worker from 0(1X) to 2(2X)"},
+				{Type: ManifestDomain, Description: "add domain acme.example.com"},
+				{Type: ManifestDomain, Description: "remove domain acme-inc.example.com"},
+				{Type: ManifestExposure, Description: "change exposure from public to private"},
+				{Type: ManifestCert, Description: "attach cert acme-inc"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.changes, diffManifest(DefaultDynoSizes, current, tt.desired))
+	}
+}
+
+func TestChangesRole(t *testing.T) {
+	assert.Equal(t, RoleDeployer, changesRole(nil))
+	assert.Equal(t, RoleDeployer, changesRole([]*ManifestChange{
+		{Type: ManifestImage},
+		{Type: ManifestScale},
+	}))
+	assert.Equal(t, RoleAdmin, changesRole([]*ManifestChange{
+		{Type: ManifestConfig},
+		{Type: ManifestDomain},
+	}))
+}
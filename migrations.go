@@ -560,6 +560,177 @@ ALTER TABLE apps ADD COLUMN exposure TEXT NOT NULL default 'private'`,
 			`DROP TABLE ecs_environment`,
 		}),
 	},
+
+	// This migration adds a table to track canary releases that are running
+	// alongside the stable release of an app.
+	{
+		ID: 19,
+		Up: migrate.Queries([]string{
+			`CREATE TABLE canaries (
+  app_id uuid NOT NULL references apps(id) ON DELETE CASCADE primary key,
+  release_id uuid NOT NULL references releases(id) ON DELETE CASCADE,
+  stable_release_id uuid NOT NULL references releases(id) ON DELETE CASCADE,
+  percent integer NOT NULL,
+  created_at timestamp without time zone default (now() at time zone 'utc')
+)`,
+		}),
+		Down: migrate.Queries([]string{
+			`DROP TABLE canaries`,
+		}),
+	},
+
+	// This migration adds a column to opt apps into automatic rollbacks
+	// when a deployment fails to stabilize.
+	{
+		ID: 20,
+		Up: migrate.Queries([]string{
+			`ALTER TABLE apps ADD COLUMN auto_rollback bool NOT NULL DEFAULT false`,
+		}),
+		Down: migrate.Queries([]string{
+			`ALTER TABLE apps DROP COLUMN auto_rollback`,
+		}),
+	},
+
+	// This migration adds a table to allocate priorities for Application
+	// Load Balancer listener rules.
+	{
+		ID: 21,
+		Up: migrate.Queries([]string{
+			`CREATE TABLE listener_rule_priorities (
+  priority integer NOT NULL primary key
+)`,
+		}),
+		Down: migrate.Queries([]string{
+			`DROP TABLE listener_rule_priorities`,
+		}),
+	},
+
+	// This migration adds a table to store the roles that are granted to
+	// users and teams on apps.
+	{
+		ID: 22,
+		Up: migrate.Queries([]string{
+			`CREATE TABLE access_grants (
+  id uuid NOT NULL DEFAULT uuid_generate_v4() primary key,
+  "user" text,
+  team text,
+  app text NOT NULL,
+  role text NOT NULL,
+  created_at timestamp without time zone default (now() at time zone 'utc')
+)`,
+			`CREATE UNIQUE INDEX index_access_grants_on_principal_and_app ON access_grants USING btree (coalesce("user", ''), coalesce(team, ''), app)`,
+		}),
+		Down: migrate.Queries([]string{
+			`DROP TABLE access_grants`,
+		}),
+	},
+
+	// This migration adds a table to store published events, as an audit
+	// log.
+	{
+		ID: 23,
+		Up: migrate.Queries([]string{
+			`CREATE TABLE events (
+  id uuid NOT NULL DEFAULT uuid_generate_v4() primary key,
+  type text NOT NULL,
+  "user" text,
+  app text,
+  message text,
+  payload json,
+  created_at timestamp without time zone default (now() at time zone 'utc')
+)`,
+			`CREATE INDEX index_events_on_created_at ON events USING btree (created_at)`,
+			`CREATE INDEX index_events_on_app ON events USING btree (app)`,
+		}),
+		Down: migrate.Queries([]string{
+			`DROP TABLE events`,
+		}),
+	},
+
+	// This migration tracks the delivery of events, so the events table
+	// can be used as an outbox.
+	{
+		ID: 24,
+		Up: migrate.Queries([]string{
+			`ALTER TABLE events ADD COLUMN attempts integer NOT NULL DEFAULT 0`,
+			`ALTER TABLE events ADD COLUMN last_error text NOT NULL DEFAULT ''`,
+			`ALTER TABLE events ADD COLUMN next_attempt_at timestamp without time zone default (now() at time zone 'utc')`,
+			`ALTER TABLE events ADD COLUMN delivered_at timestamp without time zone`,
+			`ALTER TABLE events ADD COLUMN dead_at timestamp without time zone`,
+			// Events that were stored before this migration were
+			// already published.
+			`UPDATE events SET delivered_at = created_at`,
+			`CREATE INDEX index_events_on_pending ON events USING btree (next_attempt_at) WHERE delivered_at IS NULL AND dead_at IS NULL`,
+		}),
+		Down: migrate.Queries([]string{
+			`DROP INDEX index_events_on_pending`,
+			`ALTER TABLE events DROP COLUMN attempts`,
+			`ALTER TABLE events DROP COLUMN last_error`,
+			`ALTER TABLE events DROP COLUMN next_attempt_at`,
+			`ALTER TABLE events DROP COLUMN delivered_at`,
+			`ALTER TABLE events DROP COLUMN dead_at`,
+		}),
+	},
+
+	// This migration adds a table to store the previous names of renamed
+	// apps.
+	{
+		ID: 25,
+		Up: migrate.Queries([]string{
+			`CREATE TABLE app_aliases (
+  name text NOT NULL primary key,
+  app_id uuid NOT NULL references apps(id) ON DELETE CASCADE,
+  expires_at timestamp without time zone NOT NULL
+)`,
+			`CREATE INDEX index_app_aliases_on_app_id ON app_aliases USING btree (app_id)`,
+		}),
+		Down: migrate.Queries([]string{
+			`DROP TABLE app_aliases`,
+		}),
+	},
+
+	// This migration adds a table to store time based scaling rules.
+	{
+		ID: 26,
+		Up: migrate.Queries([]string{
+			`CREATE TABLE scale_schedules (
+  id uuid NOT NULL DEFAULT uuid_generate_v4() primary key,
+  app_id uuid NOT NULL references apps(id) ON DELETE CASCADE,
+  schedule text NOT NULL,
+  formation json,
+  restore boolean NOT NULL DEFAULT false,
+  previous json,
+  next_run_at timestamp without time zone NOT NULL,
+  last_run_at timestamp without time zone,
+  created_at timestamp without time zone default (now() at time zone 'utc')
+)`,
+			`CREATE INDEX index_scale_schedules_on_app_id ON scale_schedules USING btree (app_id)`,
+			`CREATE INDEX index_scale_schedules_on_next_run_at ON scale_schedules USING btree (next_run_at)`,
+		}),
+		Down: migrate.Queries([]string{
+			`DROP TABLE scale_schedules`,
+		}),
+	},
+
+	// This migration adds a table to store the formation of each app over
+	// time, including the changes made by scaling, which update the
+	// formation of a release in place.
+	{
+		ID: 27,
+		Up: migrate.Queries([]string{
+			`CREATE TABLE formation_changes (
+  id uuid NOT NULL DEFAULT uuid_generate_v4() primary key,
+  app_id uuid NOT NULL references apps(id) ON DELETE CASCADE,
+  formation json NOT NULL,
+  created_at timestamp without time zone default (now() at time zone 'utc')
+)`,
+			`CREATE INDEX index_formation_changes_on_app_id_and_created_at ON formation_changes USING btree (app_id, created_at)`,
+			`INSERT INTO formation_changes (app_id, formation, created_at) (SELECT app_id, formation, created_at FROM releases)`,
+		}),
+		Down: migrate.Queries([]string{
+			`DROP TABLE formation_changes`,
+		}),
+	},
 }
 
 // latestSchema returns the schema version that this version of Empire should be
@@ -0,0 +1,284 @@
+package empire
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/remind101/pkg/timex"
+	"golang.org/x/net/context"
+)
+
+// DefaultEventMaxAttempts is the default number of attempts to deliver an event
+// before it's considered dead.
+const DefaultEventMaxAttempts = 10
+
+// DefaultEventDispatchInterval is the default interval that the outbox is
+// checked for pending events.
+const DefaultEventDispatchInterval = time.Second
+
+// The maximum number of events that are delivered in a single batch.
+const eventDispatchBatchSize = 100
+
+// The maximum amount of time to wait between delivery attempts.
+const maxEventBackoff = 10 * time.Minute
+
+// The advisory lock key that's held while claiming events, so that two Empire
+// processes don't claim the same batch.
+const eventDispatchLockKey = 0x656d7001
+
+// How long a claimed event is leased to the process that claimed it. If the
+// event hasn't been marked as delivered or failed by then (e.g. the process
+// crashed), it will be claimed again.
+const eventClaimTimeout = 30 * time.Minute
+
+// storedEvent is an Event that was loaded from the outbox. It marshals to the
+// same JSON as the original event, so EventStreams can't tell the difference.
+type storedEvent struct {
+	typ       string
+	message   string
+	payload   []byte
+	timestamp time.Time
+}
+
+func (e *storedEvent) Event() string        { return e.typ }
+func (e *storedEvent) String() string       { return e.message }
+func (e *storedEvent) Timestamp() time.Time { return e.timestamp }
+
+// MarshalJSON implements the json.Marshaler interface.
+func (e *storedEvent) MarshalJSON() ([]byte, error) {
+	if len(e.payload) == 0 {
+		return []byte("null"), nil
+	}
+	return e.payload, nil
+}
+
+// storedAppEvent is a storedEvent that relates to an App that still exists.
+type storedAppEvent struct {
+	*storedEvent
+	app *App
+}
+
+func (e *storedAppEvent) GetApp() *App { return e.app }
+
+// Ensure that the raw payload is marshalled, not the embedded struct.
+var _ json.Marshaler = &storedAppEvent{}
+
+// eventDispatcher delivers events from the outbox to the EventStream. Events
+// are delivered at least once; if the EventStream returns an error, delivery is
+// retried with an exponential backoff, and the event is marked as dead after
+// the maximum number of attempts.
+type eventDispatcher struct {
+	*Empire
+}
+
+// Dispatch delivers a single batch of pending events, and returns the number
+// of events that were successfully delivered. If another process is already
+// claiming events, this returns immediately.
+func (d *eventDispatcher) Dispatch(ctx context.Context) (int, error) {
+	events, err := d.claim()
+	if err != nil {
+		return 0, err
+	}
+
+	// Events are delivered outside of a transaction, so that slow
+	// EventStreams don't hold a connection or locks open.
+	var delivered int
+	for _, event := range events {
+		if err := d.deliver(d.db, event); err != nil {
+			return delivered, err
+		}
+		if event.DeliveredAt != nil {
+			delivered++
+		}
+	}
+
+	return delivered, nil
+}
+
+// claim leases a batch of pending events to this process, by pushing their
+// next attempt past the claim timeout.
+func (d *eventDispatcher) claim() ([]*AuditEvent, error) {
+	tx := d.db.Begin()
+
+	var locked bool
+	if err := tx.Raw(`SELECT pg_try_advisory_xact_lock(?)`, eventDispatchLockKey).Row().Scan(&locked); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if !locked {
+		tx.Rollback()
+		return nil, nil
+	}
+
+	now := timex.Now()
+
+	var events []*AuditEvent
+	if err := tx.Where("delivered_at IS NULL AND dead_at IS NULL AND next_attempt_at <= ?", now).Order("created_at").Limit(eventDispatchBatchSize).Find(&events).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if len(events) == 0 {
+		tx.Rollback()
+		return nil, nil
+	}
+
+	ids := make([]string, len(events))
+	for i, event := range events {
+		ids[i] = event.ID
+	}
+
+	lease := now.Add(eventClaimTimeout)
+	if err := tx.Exec(`UPDATE events SET next_attempt_at = ? WHERE id IN (?)`, lease, ids).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	return events, tx.Commit().Error
+}
+
+// deliver publishes a single event to the EventStream, and updates its delivery
+// status. An error is only returned if the status can't be updated.
+func (d *eventDispatcher) deliver(db *gorm.DB, event *AuditEvent) error {
+	err := d.publish(d.loadEvent(db, event))
+
+	now := timex.Now()
+	if err == nil {
+		event.DeliveredAt = &now
+		return auditEventsUpdate(db, event)
+	}
+
+	event.Attempts++
+	event.LastError = err.Error()
+
+	if event.Attempts >= d.maxAttempts() {
+		event.DeadAt = &now
+		log.Printf("event %s (%s) is dead after %d attempts: %v\n", event.ID, event.Type, event.Attempts, err)
+	} else {
+		next := now.Add(eventBackoff(event.Attempts))
+		event.NextAttemptAt = &next
+	}
+
+	return auditEventsUpdate(db, event)
+}
+
+// publish publishes the event to the EventStream, recovering from any panics.
+func (d *eventDispatcher) publish(event Event) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = fmt.Errorf("panic: %v", v)
+		}
+	}()
+	return d.EventStream.PublishEvent(event)
+}
+
+// loadEvent returns an Event for the AuditEvent. If the event relates to an
+// app that still exists, it will implement the AppEvent interface.
+func (d *eventDispatcher) loadEvent(db *gorm.DB, event *AuditEvent) Event {
+	e := &storedEvent{
+		typ:     event.Type,
+		message: event.Message,
+		payload: event.Payload,
+	}
+	if event.CreatedAt != nil {
+		e.timestamp = *event.CreatedAt
+	}
+
+	if event.App == "" {
+		return e
+	}
+
+	app, err := appsFind(db, AppsQuery{Name: &event.App})
+	if err != nil {
+		return e
+	}
+
+	return &storedAppEvent{storedEvent: e, app: app}
+}
+
+func (d *eventDispatcher) maxAttempts() int {
+	if d.EventMaxAttempts == 0 {
+		return DefaultEventMaxAttempts
+	}
+	return d.EventMaxAttempts
+}
+
+// eventBackoff returns how long to wait before the next delivery attempt,
+// given the number of failed attempts.
+func eventBackoff(attempts int) time.Duration {
+	if attempts > 10 {
+		return maxEventBackoff
+	}
+	d := time.Second << uint(attempts-1)
+	if d > maxEventBackoff {
+		return maxEventBackoff
+	}
+	return d
+}
+
+// Requeue resets the delivery status of an event, so that it's delivered
+// again.
+func (d *eventDispatcher) Requeue(db *gorm.DB, event *AuditEvent) error {
+	if event.DeliveredAt != nil {
+		return &ValidationError{Err: fmt.Errorf("event %s was already delivered", event.ID)}
+	}
+
+	now := timex.Now()
+	event.Attempts = 0
+	event.LastError = ""
+	event.DeadAt = nil
+	event.NextAttemptAt = &now
+	return auditEventsUpdate(db, event)
+}
+
+// DispatchEvents delivers pending events from the outbox to the EventStream
+// every interval, until the context is canceled.
+func (e *Empire) DispatchEvents(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := e.DispatchPendingEvents(ctx); err != nil {
+			log.Printf("event dispatch error: %v\n", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DispatchPendingEvents delivers a single batch of pending events from the
+// outbox, and returns the number of events that were delivered.
+func (e *Empire) DispatchPendingEvents(ctx context.Context) (int, error) {
+	return e.dispatcher.Dispatch(ctx)
+}
+
+// EventsRequeueOpts are options provided when requeueing a dead event.
+type EventsRequeueOpts struct {
+	// User performing the action.
+	User *User
+
+	// The event to requeue.
+	Event *AuditEvent
+}
+
+func (opts EventsRequeueOpts) Validate(e *Empire) error {
+	return e.Authorize(opts.User, opts.Event.App, RoleAdmin)
+}
+
+// EventsRequeue requeues an event that failed to be delivered, so that delivery
+// is attempted again.
+func (e *Empire) EventsRequeue(ctx context.Context, opts EventsRequeueOpts) error {
+	if err := opts.Validate(e); err != nil {
+		return err
+	}
+
+	return e.dispatcher.Requeue(e.db, opts.Event)
+}
@@ -0,0 +1,62 @@
+package empire
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStoredEvent_MarshalJSON(t *testing.T) {
+	e := &storedAppEvent{
+		storedEvent: &storedEvent{
+			typ:     "scale",
+			message: "ejholmes scaled `web` on acme-inc",
+			payload: []byte(`{"User":"ejholmes","App":"acme-inc"}`),
+		},
+		app: &App{Name: "acme-inc"},
+	}
+
+	raw, err := json.Marshal(struct {
+		Event   string
+		Message string
+		Data    interface{}
+	}{e.Event(), e.String(), e})
+	assert.NoError(t, err)
+	assert.Equal(t, "{\"Event\":\"scale\",\"Message\":\"ejholmes scaled `web` on acme-inc\",\"Data\":{\"User\":\"ejholmes\",\"App\":\"acme-inc\"}}", string(raw))
+	assert.Equal(t, "acme-inc", e.GetApp().Name)
+}
+
+func TestEventBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		backoff  time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{5, 16 * time.Second},
+		{10, 512 * time.Second},
+		{11, maxEventBackoff},
+		{100, maxEventBackoff},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.backoff, eventBackoff(tt.attempts))
+	}
+}
+
+func TestEventDispatcher_publish(t *testing.T) {
+	d := &eventDispatcher{Empire: &Empire{}}
+
+	d.EventStream = EventStreamFunc(func(event Event) error {
+		return errors.New("boom")
+	})
+	assert.EqualError(t, d.publish(&storedEvent{}), "boom")
+
+	d.EventStream = EventStreamFunc(func(event Event) error {
+		panic("boom")
+	})
+	assert.EqualError(t, d.publish(&storedEvent{}), "panic: boom")
+}
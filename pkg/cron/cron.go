@@ -0,0 +1,214 @@
+// Package cron parses cron expressions, and finds the times that match them.
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Expression is a parsed cron expression. Each field is a bitset of the
+// values that match.
+type Expression struct {
+	minute, hour, dom, month, dow uint64
+
+	// True if the day of month or day of week fields were a wildcard.
+	domWildcard, dowWildcard bool
+}
+
+// Bounds for each of the fields in a cron expression.
+type field struct {
+	min, max uint
+	names    map[string]uint
+}
+
+var (
+	minuteField = field{min: 0, max: 59}
+	hourField   = field{min: 0, max: 23}
+	domField    = field{min: 1, max: 31}
+	monthField  = field{min: 1, max: 12, names: map[string]uint{
+		"JAN": 1, "FEB": 2, "MAR": 3, "APR": 4, "MAY": 5, "JUN": 6,
+		"JUL": 7, "AUG": 8, "SEP": 9, "OCT": 10, "NOV": 11, "DEC": 12,
+	}}
+	dowField = field{min: 0, max: 6, names: map[string]uint{
+		"SUN": 0, "MON": 1, "TUE": 2, "WED": 3, "THU": 4, "FRI": 5, "SAT": 6,
+	}}
+)
+
+// Parse parses a cron expression. Both standard 5 field expressions, and
+// the 6 field expressions used by CloudWatch Events (which Empire uses for
+// scheduled processes) are supported.
+//
+// In the 6 field format, the day of week field is 1-7 (SUN-SAT) and the
+// trailing year field is ignored.
+func Parse(expr string) (*Expression, error) {
+	fields := strings.Fields(expr)
+
+	cloudwatch := len(fields) == 6
+	if cloudwatch {
+		fields = fields[:5]
+	}
+
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid cron expression %q: expected 5 or 6 fields", expr)
+	}
+
+	var (
+		c   Expression
+		err error
+	)
+
+	if c.minute, err = parseField(fields[0], minuteField, 0); err != nil {
+		return nil, err
+	}
+	if c.hour, err = parseField(fields[1], hourField, 0); err != nil {
+		return nil, err
+	}
+	if c.dom, err = parseField(fields[2], domField, 0); err != nil {
+		return nil, err
+	}
+	if c.month, err = parseField(fields[3], monthField, 0); err != nil {
+		return nil, err
+	}
+
+	var dowOffset uint
+	if cloudwatch {
+		dowOffset = 1
+	}
+	if c.dow, err = parseField(fields[4], dowField, dowOffset); err != nil {
+		return nil, err
+	}
+
+	c.domWildcard = isWildcard(fields[2])
+	c.dowWildcard = isWildcard(fields[4])
+
+	return &c, nil
+}
+
+// parseField parses a single field into a bitset. Numeric values are
+// shifted down by offset before they're validated.
+func parseField(expr string, f field, offset uint) (uint64, error) {
+	var bits uint64
+
+	for _, part := range strings.Split(expr, ",") {
+		step := uint(1)
+		if i := strings.Index(part, "/"); i >= 0 {
+			n, err := strconv.ParseUint(part[i+1:], 10, 0)
+			if err != nil || n == 0 {
+				return 0, fmt.Errorf("invalid step in cron field %q", expr)
+			}
+			step = uint(n)
+			part = part[:i]
+		}
+
+		var start, end uint
+		switch {
+		case isWildcard(part):
+			start, end = f.min, f.max
+		case strings.Contains(part, "-"):
+			bounds := strings.SplitN(part, "-", 2)
+			var err error
+			if start, err = f.parse(bounds[0], offset); err != nil {
+				return 0, err
+			}
+			if end, err = f.parse(bounds[1], offset); err != nil {
+				return 0, err
+			}
+		default:
+			v, err := f.parse(part, offset)
+			if err != nil {
+				return 0, err
+			}
+			start, end = v, v
+			if step > 1 {
+				end = f.max
+			}
+		}
+
+		if start > end {
+			return 0, fmt.Errorf("invalid range in cron field %q", expr)
+		}
+
+		for v := start; v <= end; v += step {
+			bits |= 1 << v
+		}
+	}
+
+	return bits, nil
+}
+
+// parse parses a single value within the field.
+func (f field) parse(s string, offset uint) (uint, error) {
+	if v, ok := f.names[strings.ToUpper(s)]; ok {
+		return v, nil
+	}
+
+	n, err := strconv.ParseUint(s, 10, 0)
+	if err != nil {
+		return 0, fmt.Errorf("invalid cron value %q", s)
+	}
+
+	v := uint(n)
+	if v < offset {
+		return 0, fmt.Errorf("cron value %q out of range", s)
+	}
+	v -= offset
+
+	if v < f.min || v > f.max {
+		return 0, fmt.Errorf("cron value %q out of range", s)
+	}
+
+	return v, nil
+}
+
+func isWildcard(s string) bool {
+	return s == "*" || s == "?"
+}
+
+// Next returns the next time, after t, that matches the expression. If there
+// is no matching time within the next 5 years, the zero time is returned.
+func (c *Expression) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if c.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+
+		if !c.matchDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+
+		if c.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+
+		if c.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+
+		return t
+	}
+
+	return time.Time{}
+}
+
+// matchDay returns true if the day of t matches the day of month and day of
+// week fields. Like cron, if both fields are restricted, the day matches if
+// either field matches.
+func (c *Expression) matchDay(t time.Time) bool {
+	dom := c.dom&(1<<uint(t.Day())) != 0
+	dow := c.dow&(1<<uint(t.Weekday())) != 0
+
+	if c.domWildcard || c.dowWildcard {
+		return dom && dow
+	}
+
+	return dom || dow
+}
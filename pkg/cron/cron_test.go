@@ -0,0 +1,56 @@
+package cron
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpression_Next(t *testing.T) {
+	now := time.Date(2016, time.November, 10, 11, 59, 30, 0, time.UTC) // Thursday
+
+	tests := []struct {
+		expr string
+		next time.Time
+	}{
+		{"* * * * *", time.Date(2016, time.November, 10, 12, 0, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2016, time.November, 10, 12, 0, 0, 0, time.UTC)},
+		{"5 * * * *", time.Date(2016, time.November, 10, 12, 5, 0, 0, time.UTC)},
+		{"0 9 * * *", time.Date(2016, time.November, 11, 9, 0, 0, 0, time.UTC)},
+		{"0 0 1 JAN *", time.Date(2017, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 9 * * MON-FRI", time.Date(2016, time.November, 11, 9, 0, 0, 0, time.UTC)},
+		{"0 9 * * 0", time.Date(2016, time.November, 13, 9, 0, 0, 0, time.UTC)},
+		{"30 8,20 * * *", time.Date(2016, time.November, 10, 20, 30, 0, 0, time.UTC)},
+
+		// CloudWatch Events format.
+		{"0 12 * * ? *", time.Date(2016, time.November, 10, 12, 0, 0, 0, time.UTC)},
+		{"0 9 ? * 1 *", time.Date(2016, time.November, 13, 9, 0, 0, 0, time.UTC)},
+		{"0 9 ? * MON *", time.Date(2016, time.November, 14, 9, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		expr, err := Parse(tt.expr)
+		if assert.NoError(t, err, tt.expr) {
+			assert.Equal(t, tt.next, expr.Next(now), tt.expr)
+		}
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	tests := []string{
+		"",
+		"* * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"0 9 ? * 0 *",
+		"0 9 L * ? *",
+	}
+
+	for _, expr := range tests {
+		_, err := Parse(expr)
+		assert.Error(t, err, expr)
+	}
+}
@@ -113,6 +113,10 @@ func (c *Client) AttachToContainer(ctx context.Context, opts docker.AttachToCont
 	return c.Client.AttachToContainer(opts)
 }
 
+func (c *Client) WaitContainer(ctx context.Context, id string) (int, error) {
+	return c.Client.WaitContainer(id)
+}
+
 func (c *Client) StopContainer(ctx context.Context, id string, timeout uint) error {
 	return c.Client.StopContainer(id, timeout)
 }
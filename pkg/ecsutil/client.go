@@ -174,6 +174,64 @@ func (c *Client) ListAppServices(ctx context.Context, appID string, input *ecs.L
 	}, nil
 }
 
+// ListAppTaskDefinitionFamilies lists the active task definition families that
+// were registered for apps, keyed by the app.
+func (c *Client) ListAppTaskDefinitionFamilies(ctx context.Context) (map[string][]string, error) {
+	families := make(map[string][]string)
+	if err := c.ListTaskDefinitionFamiliesPages(ctx, &ecs.ListTaskDefinitionFamiliesInput{
+		Status: aws.String("ACTIVE"),
+	}, func(resp *ecs.ListTaskDefinitionFamiliesOutput, lastPage bool) bool {
+		for _, family := range resp.Families {
+			app, other := c.split(family)
+			if other == nil {
+				continue
+			}
+			families[app] = append(families[app], *family)
+		}
+		return true
+	}); err != nil {
+		return nil, err
+	}
+
+	return families, nil
+}
+
+// DeregisterTaskDefinitionFamily deregisters all of the active revisions of the
+// task definition family.
+func (c *Client) DeregisterTaskDefinitionFamily(ctx context.Context, family string) error {
+	var taskDefinitionArns []*string
+	if err := c.ListTaskDefinitionsPages(ctx, &ecs.ListTaskDefinitionsInput{
+		FamilyPrefix: aws.String(family),
+		Status:       aws.String("ACTIVE"),
+	}, func(resp *ecs.ListTaskDefinitionsOutput, lastPage bool) bool {
+		taskDefinitionArns = append(taskDefinitionArns, resp.TaskDefinitionArns...)
+		return true
+	}); err != nil {
+		return err
+	}
+
+	for _, a := range taskDefinitionArns {
+		id, err := arn.ResourceID(*a)
+		if err != nil {
+			return err
+		}
+
+		// The family is a prefix, so it can also match other
+		// families (e.g. web and web2).
+		if i := strings.LastIndex(id, ":"); i < 0 || id[:i] != family {
+			continue
+		}
+
+		if _, err := c.DeregisterTaskDefinition(ctx, &ecs.DeregisterTaskDefinitionInput{
+			TaskDefinition: a,
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (c *Client) delimiter() string {
 	if c.Delimiter == "" {
 		return DefaultDelimiter
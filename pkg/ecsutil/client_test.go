@@ -3,6 +3,7 @@ package ecsutil
 import (
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"testing"
 
 	"golang.org/x/net/context"
@@ -202,6 +203,73 @@ func TestListAppTasks_Paginate(t *testing.T) {
 	}
 }
 
+func TestListAppTaskDefinitionFamilies(t *testing.T) {
+	h := awsutil.NewHandler([]awsutil.Cycle{
+		awsutil.Cycle{
+			Request: awsutil.Request{
+				RequestURI: "/",
+				Operation:  "AmazonEC2ContainerServiceV20141113.ListTaskDefinitionFamilies",
+				Body:       `{"status":"ACTIVE"}`,
+			},
+			Response: awsutil.Response{
+				StatusCode: 200,
+				Body:       `{"families":["ae69bb4c-3903-4844-82fe-548ac5b74570--web","ae69bb4c-3903-4844-82fe-548ac5b74570--worker","acme-inc-web"]}`,
+			},
+		},
+	})
+	m, s := newTestClient(h)
+	defer s.Close()
+
+	families, err := m.ListAppTaskDefinitionFamilies(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string][]string{
+		"ae69bb4c-3903-4844-82fe-548ac5b74570": []string{
+			"ae69bb4c-3903-4844-82fe-548ac5b74570--web",
+			"ae69bb4c-3903-4844-82fe-548ac5b74570--worker",
+		},
+	}
+	if !reflect.DeepEqual(families, expected) {
+		t.Fatalf("Expected %v; got %v", expected, families)
+	}
+}
+
+func TestDeregisterTaskDefinitionFamily(t *testing.T) {
+	h := awsutil.NewHandler([]awsutil.Cycle{
+		awsutil.Cycle{
+			Request: awsutil.Request{
+				RequestURI: "/",
+				Operation:  "AmazonEC2ContainerServiceV20141113.ListTaskDefinitions",
+				Body:       `{"familyPrefix":"1234--web","status":"ACTIVE"}`,
+			},
+			Response: awsutil.Response{
+				StatusCode: 200,
+				Body:       `{"taskDefinitionArns":["arn:aws:ecs:us-east-1:249285743859:task-definition/1234--web:1","arn:aws:ecs:us-east-1:249285743859:task-definition/1234--web2:1"]}`,
+			},
+		},
+
+		awsutil.Cycle{
+			Request: awsutil.Request{
+				RequestURI: "/",
+				Operation:  "AmazonEC2ContainerServiceV20141113.DeregisterTaskDefinition",
+				Body:       `{"taskDefinition":"arn:aws:ecs:us-east-1:249285743859:task-definition/1234--web:1"}`,
+			},
+			Response: awsutil.Response{
+				StatusCode: 200,
+				Body:       `{}`,
+			},
+		},
+	})
+	m, s := newTestClient(h)
+	defer s.Close()
+
+	if err := m.DeregisterTaskDefinitionFamily(context.Background(), "1234--web"); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func newTestClient(h http.Handler) (*Client, *httptest.Server) {
 	s := httptest.NewServer(h)
 
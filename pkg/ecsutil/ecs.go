@@ -17,6 +17,9 @@ type ECS interface {
 	// Task Definitions
 	RegisterTaskDefinition(context.Context, *ecs.RegisterTaskDefinitionInput) (*ecs.RegisterTaskDefinitionOutput, error)
 	DescribeTaskDefinition(context.Context, *ecs.DescribeTaskDefinitionInput) (*ecs.DescribeTaskDefinitionOutput, error)
+	DeregisterTaskDefinition(context.Context, *ecs.DeregisterTaskDefinitionInput) (*ecs.DeregisterTaskDefinitionOutput, error)
+	ListTaskDefinitionFamiliesPages(context.Context, *ecs.ListTaskDefinitionFamiliesInput, func(*ecs.ListTaskDefinitionFamiliesOutput, bool) bool) error
+	ListTaskDefinitionsPages(context.Context, *ecs.ListTaskDefinitionsInput, func(*ecs.ListTaskDefinitionsOutput, bool) bool) error
 
 	// Services
 	CreateService(context.Context, *ecs.CreateServiceInput) (*ecs.CreateServiceOutput, error)
@@ -91,6 +94,27 @@ func (c *ecsClient) DescribeTaskDefinition(ctx context.Context, input *ecs.Descr
 	return resp, err
 }
 
+func (c *ecsClient) DeregisterTaskDefinition(ctx context.Context, input *ecs.DeregisterTaskDefinitionInput) (*ecs.DeregisterTaskDefinitionOutput, error) {
+	ctx, done := trace.Trace(ctx)
+	resp, err := c.ECS.DeregisterTaskDefinition(input)
+	done(err, "DeregisterTaskDefinition", "task-definition", stringField(input.TaskDefinition))
+	return resp, err
+}
+
+func (c *ecsClient) ListTaskDefinitionFamiliesPages(ctx context.Context, input *ecs.ListTaskDefinitionFamiliesInput, fn func(*ecs.ListTaskDefinitionFamiliesOutput, bool) bool) error {
+	ctx, done := trace.Trace(ctx)
+	err := c.ECS.ListTaskDefinitionFamiliesPages(input, fn)
+	done(err, "ListTaskDefinitionFamiliesPages")
+	return err
+}
+
+func (c *ecsClient) ListTaskDefinitionsPages(ctx context.Context, input *ecs.ListTaskDefinitionsInput, fn func(*ecs.ListTaskDefinitionsOutput, bool) bool) error {
+	ctx, done := trace.Trace(ctx)
+	err := c.ECS.ListTaskDefinitionsPages(input, fn)
+	done(err, "ListTaskDefinitionsPages", "family-prefix", stringField(input.FamilyPrefix))
+	return err
+}
+
 func (c *ecsClient) ListServicesPages(ctx context.Context, input *ecs.ListServicesInput, fn func(*ecs.ListServicesOutput, bool) bool) error {
 	ctx, done := trace.Trace(ctx)
 	err := c.ECS.ListServicesPages(input, fn)
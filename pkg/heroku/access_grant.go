@@ -0,0 +1,61 @@
+package heroku
+
+import (
+	"time"
+)
+
+// An access grant gives a user, or a GitHub team, a role on the apps matching
+// a name pattern.
+type AccessGrant struct {
+	// when the access grant was created
+	CreatedAt time.Time `json:"created_at"`
+
+	// unique identifier of the access grant
+	Id string `json:"id"`
+
+	// name of the user that's granted access
+	User string `json:"user,omitempty"`
+
+	// GitHub team (as org/slug) that's granted access
+	Team string `json:"team,omitempty"`
+
+	// name of an app, or a pattern matching app names (e.g. acme-*)
+	App string `json:"app"`
+
+	// role that's granted: viewer, deployer or admin
+	Role string `json:"role"`
+}
+
+// AccessGrantCreateOpts are the options for granting access.
+type AccessGrantCreateOpts struct {
+	// name of the user to grant access to
+	User string `json:"user,omitempty"`
+
+	// GitHub team (as org/slug) to grant access to
+	Team string `json:"team,omitempty"`
+
+	// name of an app, or a pattern matching app names (e.g. acme-*)
+	App string `json:"app"`
+
+	// role to grant: viewer, deployer or admin
+	Role string `json:"role"`
+}
+
+// Grant a role to a user or team.
+func (c *Client) AccessGrantCreate(options AccessGrantCreateOpts) (*AccessGrant, error) {
+	var grantRes AccessGrant
+	return &grantRes, c.Post(&grantRes, "/access", options)
+}
+
+// Revoke an access grant.
+//
+// grantIdentity is the unique identifier of the AccessGrant.
+func (c *Client) AccessGrantDelete(grantIdentity string) error {
+	return c.Delete("/access/" + grantIdentity)
+}
+
+// List existing access grants.
+func (c *Client) AccessGrantList() ([]AccessGrant, error) {
+	var grantsRes []AccessGrant
+	return grantsRes, c.Get(&grantsRes, "/access")
+}
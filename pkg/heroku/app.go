@@ -67,6 +67,9 @@ type App struct {
 
 	// certificate for the app
 	Cert string `json:"cert,omitempty"`
+
+	// whether deploys that fail to stabilize are rolled back automatically
+	AutoRollback bool `json:"auto_rollback"`
 }
 
 // Create a new app.
@@ -130,6 +133,16 @@ func (c *Client) AppUpdate(appIdentity string, options *AppUpdateOpts) (*App, er
 	return &appRes, c.Patch(&appRes, "/apps/"+appIdentity, options)
 }
 
+// Rename an existing app.
+//
+// appIdentity is the unique identifier of the App. name is the new name for the
+// App.
+func (c *Client) AppRename(appIdentity, name, message string) (*App, error) {
+	var appRes App
+	rh := RequestHeaders{CommitMessage: message}
+	return &appRes, c.PatchWithHeaders(&appRes, "/apps/"+appIdentity, &AppUpdateOpts{Name: &name}, rh.Headers())
+}
+
 // AppUpdateOpts holds the optional parameters for AppUpdate
 type AppUpdateOpts struct {
 	// maintenance status of app
@@ -138,4 +151,6 @@ type AppUpdateOpts struct {
 	Name *string `json:"name,omitempty"`
 	// certificate for the app
 	Cert *string `json:"cert,omitempty"`
+	// whether deploys that fail to stabilize are rolled back automatically
+	AutoRollback *bool `json:"auto_rollback,omitempty"`
 }
@@ -4,6 +4,12 @@
 
 package heroku
 
+import (
+	"net/url"
+	"strconv"
+	"time"
+)
+
 // Get config-vars for app.
 //
 // appIdentity is the unique identifier of the ConfigVar's App.
@@ -22,3 +28,89 @@ func (c *Client) ConfigVarUpdate(appIdentity string, options map[string]*string,
 	var configVarRes map[string]string
 	return configVarRes, c.PatchWithHeaders(&configVarRes, "/apps/"+appIdentity+"/config-vars", options, rh.Headers())
 }
+
+// Update config-vars for app, storing the values in the secrets backend. Only
+// references to the values are stored in the config, and the values are masked
+// when config-vars are returned.
+//
+// appIdentity is the unique identifier of the ConfigVar's App. options is the
+// hash of config changes – update values or delete by seting it to nil.
+func (c *Client) ConfigVarUpdateSecret(appIdentity string, options map[string]*string, message string) (map[string]string, error) {
+	rh := RequestHeaders{CommitMessage: message, Secret: true}
+	var configVarRes map[string]string
+	return configVarRes, c.PatchWithHeaders(&configVarRes, "/apps/"+appIdentity+"/config-vars", options, rh.Headers())
+}
+
+// A ConfigVarChange represents a change to a single config var.
+type ConfigVarChange struct {
+	// name of the config var
+	Name string `json:"name"`
+
+	// how the config var changed: added, changed or removed
+	Change string `json:"change"`
+
+	// value of the config var before the change, omitted if masked
+	OldValue *string `json:"old_value,omitempty"`
+
+	// value of the config var after the change, omitted if masked
+	NewValue *string `json:"new_value,omitempty"`
+}
+
+// A ConfigVarHistory represents the changes made to config vars in a release.
+type ConfigVarHistory struct {
+	// release that the config vars were changed in
+	Release struct {
+		Id          string    `json:"id"`
+		Version     int       `json:"version"`
+		Description string    `json:"description"`
+		CreatedAt   time.Time `json:"created_at"`
+	} `json:"release"`
+
+	// changes from the config vars in the previous release
+	Changes []ConfigVarChange `json:"changes"`
+}
+
+// List the changes made to config-vars in each release of an app.
+//
+// appIdentity is the unique identifier of the ConfigVar's App. If masked is
+// true, the values of config-vars are omitted. lr is an optional ListRange that
+// sets the Range options for the paginated list of results.
+func (c *Client) ConfigVarHistory(appIdentity string, masked bool, lr *ListRange) ([]ConfigVarHistory, error) {
+	req, err := c.NewRequest("GET", "/apps/"+appIdentity+"/config-vars/history"+maskedQuery(masked, nil), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if lr != nil {
+		lr.SetHeader(req)
+	}
+
+	var historyRes []ConfigVarHistory
+	return historyRes, c.DoReq(req, &historyRes)
+}
+
+// Diff config-vars between two releases of an app.
+//
+// appIdentity is the unique identifier of the ConfigVar's App. from and to are
+// the versions of the releases to compare. If masked is true, the values of
+// config-vars are omitted.
+func (c *Client) ConfigVarDiff(appIdentity string, from, to int, masked bool) ([]ConfigVarChange, error) {
+	q := url.Values{}
+	q.Set("from", strconv.Itoa(from))
+	q.Set("to", strconv.Itoa(to))
+	var changesRes []ConfigVarChange
+	return changesRes, c.Get(&changesRes, "/apps/"+appIdentity+"/config-vars/diff"+maskedQuery(masked, q))
+}
+
+func maskedQuery(masked bool, q url.Values) string {
+	if q == nil {
+		q = url.Values{}
+	}
+	if masked {
+		q.Set("masked", "true")
+	}
+	if len(q) == 0 {
+		return ""
+	}
+	return "?" + q.Encode()
+}
@@ -0,0 +1,81 @@
+package heroku
+
+import (
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// An app cost is an estimate of how much an app costs to run, based on the
+// resources that its processes use.
+type AppCost struct {
+	// app that the cost is for
+	App struct {
+		Id   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"app"`
+
+	// estimated cost of running the current formation for a month
+	Monthly float64 `json:"monthly"`
+
+	// estimated cost of each process in the current formation
+	Processes []ProcessCost `json:"processes"`
+
+	// estimated cost of each month, oldest first, ending with the current
+	// month
+	History []MonthlyCost `json:"history"`
+}
+
+// ProcessCost is the estimated cost of a process type.
+type ProcessCost struct {
+	// the process type
+	Type string `json:"type"`
+
+	// number of instances
+	Quantity int `json:"quantity"`
+
+	// dyno size of each instance
+	Size string `json:"size"`
+
+	// whether the process is exposed with a load balancer
+	LoadBalancer bool `json:"load_balancer"`
+
+	// estimated cost of the process for a month
+	Monthly float64 `json:"monthly"`
+}
+
+// MonthlyCost is the estimated cost of an app over a calendar month.
+type MonthlyCost struct {
+	// first day of the month, in UTC
+	Month time.Time `json:"month"`
+
+	// estimated cost of the month. For the current month, this is the cost
+	// so far.
+	Cost float64 `json:"cost"`
+}
+
+// Info for the estimated cost of an app.
+//
+// appIdentity is the unique identifier of the AppCost's App. months is the
+// number of months of history to include, or 0 for the default.
+func (c *Client) AppCostInfo(appIdentity string, months int) (*AppCost, error) {
+	var costRes AppCost
+	return &costRes, c.Get(&costRes, "/apps/"+appIdentity+"/cost"+costQuery(months))
+}
+
+// List the estimated cost of every app, most expensive first.
+//
+// months is the number of months of history to include, or 0 for the default.
+func (c *Client) AppCostList(months int) ([]AppCost, error) {
+	var costsRes []AppCost
+	return costsRes, c.Get(&costsRes, "/cost"+costQuery(months))
+}
+
+func costQuery(months int) string {
+	if months <= 0 {
+		return ""
+	}
+	q := url.Values{}
+	q.Set("months", strconv.Itoa(months))
+	return "?" + q.Encode()
+}
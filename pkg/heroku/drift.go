@@ -0,0 +1,55 @@
+package heroku
+
+// Drift describes how an app that's running in the scheduler differs from its
+// latest release.
+type Drift struct {
+	// version of the latest release
+	Release int `json:"release"`
+
+	// whether any of the processes differ from the formation
+	Drifted bool `json:"drifted"`
+
+	// each process in the formation of the latest release
+	Processes []ProcessDrift `json:"processes"`
+}
+
+// How a process that's running in the scheduler differs from the formation.
+type ProcessDrift struct {
+	// process type
+	Type string `json:"type"`
+
+	// quantity in the formation of the latest release
+	Quantity int `json:"quantity"`
+
+	// number of instances the scheduler is configured to run, if known
+	Desired *int `json:"desired"`
+
+	// number of instances that are running, or starting
+	Running int `json:"running"`
+
+	// number of running instances that belong to a different release
+	Outdated int `json:"outdated"`
+
+	// whether the process differs from the formation
+	Drifted bool `json:"drifted"`
+}
+
+// Check an app for drift between its latest release and what's running in the
+// scheduler.
+//
+// appIdentity is the unique identifier of the App.
+func (c *Client) DriftInfo(appIdentity string) (*Drift, error) {
+	var drift Drift
+	return &drift, c.Get(&drift, "/apps/"+appIdentity+"/drift")
+}
+
+// Re-submit the latest release of an app to the scheduler, if it has drifted.
+// The drift that was detected is returned.
+//
+// appIdentity is the unique identifier of the App. message is an optional
+// commit message.
+func (c *Client) DriftReconcile(appIdentity string, message string) (*Drift, error) {
+	rh := RequestHeaders{CommitMessage: message}
+	var drift Drift
+	return &drift, c.PostWithHeaders(&drift, "/apps/"+appIdentity+"/drift", nil, rh.Headers())
+}
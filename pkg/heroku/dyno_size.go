@@ -0,0 +1,50 @@
+package heroku
+
+// Dyno sizes are the values and details of sizes that can be assigned to
+// dynos.
+type DynoSize struct {
+	// minimum vCPUs, non-dedicated may get more depending on load. In
+	// Empire, this is the number of CPU shares.
+	Compute int `json:"compute"`
+
+	// whether this dyno will be dedicated to one user
+	Dedicated bool `json:"dedicated"`
+
+	// unique identifier of this dyno size
+	Id string `json:"id"`
+
+	// amount of RAM in GB
+	Memory float64 `json:"memory"`
+
+	// the name of this dyno-size
+	Name string `json:"name"`
+
+	// the maximum number of processes, or 0 for no limit
+	Nproc int `json:"nproc"`
+}
+
+// Info for existing dyno size.
+//
+// dynoSizeIdentity is the unique identifier of the DynoSize.
+func (c *Client) DynoSizeInfo(dynoSizeIdentity string) (*DynoSize, error) {
+	var dynoSize DynoSize
+	return &dynoSize, c.Get(&dynoSize, "/dyno-sizes/"+dynoSizeIdentity)
+}
+
+// List existing dyno sizes.
+//
+// lr is an optional ListRange that sets the Range options for the paginated
+// list of results.
+func (c *Client) DynoSizeList(lr *ListRange) ([]DynoSize, error) {
+	req, err := c.NewRequest("GET", "/dyno-sizes", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if lr != nil {
+		lr.SetHeader(req)
+	}
+
+	var dynoSizesRes []DynoSize
+	return dynoSizesRes, c.DoReq(req, &dynoSizesRes)
+}
@@ -0,0 +1,113 @@
+package heroku
+
+import (
+	"encoding/json"
+	"net/url"
+	"time"
+)
+
+// An event is something that happened within Empire, like a deploy or a
+// scale, which is stored in the audit log.
+type Event struct {
+	// when the event happened
+	CreatedAt time.Time `json:"created_at"`
+
+	// unique identifier of the event
+	Id string `json:"id"`
+
+	// type of the event (e.g. deploy, scale)
+	Type string `json:"type"`
+
+	// name of the user that triggered the event
+	User string `json:"user"`
+
+	// name of the app that the event relates to
+	App string `json:"app"`
+
+	// human readable description of the event
+	Message string `json:"message"`
+
+	// the event, with fields specific to the type of the event
+	Payload json.RawMessage `json:"payload"`
+
+	// delivery status of the event (pending, delivered or dead)
+	Status string `json:"status"`
+
+	// number of failed attempts to deliver the event
+	Attempts int `json:"attempts"`
+
+	// error from the last failed attempt to deliver the event
+	LastError string `json:"last_error"`
+
+	// when the event was delivered
+	DeliveredAt *time.Time `json:"delivered_at"`
+}
+
+// EventListOpts are the filters for listing events.
+type EventListOpts struct {
+	// only list events for this app
+	App string
+
+	// only list events triggered by this user
+	User string
+
+	// only list events of this type
+	Type string
+
+	// only list events that happened at or after this time
+	Since *time.Time
+
+	// only list events with this delivery status (pending, delivered or
+	// dead)
+	Status string
+}
+
+// List events in the audit log, most recent first.
+//
+// options is the optional set of filters. lr is an optional ListRange that
+// sets the Range options for the paginated list of results.
+func (c *Client) EventList(options *EventListOpts, lr *ListRange) ([]Event, error) {
+	q := url.Values{}
+	if options != nil {
+		if options.App != "" {
+			q.Set("app", options.App)
+		}
+		if options.User != "" {
+			q.Set("user", options.User)
+		}
+		if options.Type != "" {
+			q.Set("type", options.Type)
+		}
+		if options.Status != "" {
+			q.Set("status", options.Status)
+		}
+		if options.Since != nil {
+			q.Set("since", options.Since.UTC().Format(time.RFC3339))
+		}
+	}
+
+	path := "/events"
+	if len(q) > 0 {
+		path += "?" + q.Encode()
+	}
+
+	req, err := c.NewRequest("GET", path, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if lr != nil {
+		lr.SetHeader(req)
+	}
+
+	var eventsRes []Event
+	return eventsRes, c.DoReq(req, &eventsRes)
+}
+
+// Requeue an event that failed to be delivered, so that delivery is attempted
+// again.
+//
+// eventId is the unique identifier of the event.
+func (c *Client) EventRequeue(eventId string) error {
+	return c.Post(nil, "/events/"+eventId+"/requeue", nil)
+}
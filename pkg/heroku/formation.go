@@ -33,6 +33,30 @@ type Formation struct {
 
 	// when dyno type was updated
 	UpdatedAt time.Time `json:"updated_at"`
+
+	// autoscaling policy, if the process is autoscaled
+	Autoscaling *FormationAutoscaling `json:"autoscaling,omitempty"`
+}
+
+// FormationAutoscaling is the autoscaling policy of a process type.
+type FormationAutoscaling struct {
+	// minimum number of processes
+	Min int `json:"min"`
+
+	// maximum number of processes
+	Max int `json:"max"`
+
+	// target average CPU utilization percentage
+	CPU int `json:"cpu,omitempty"`
+
+	// target average memory utilization percentage
+	Memory int `json:"memory,omitempty"`
+
+	// seconds to wait after scaling in before scaling in again
+	ScaleInCooldown int `json:"scale_in_cooldown,omitempty"`
+
+	// seconds to wait after scaling out before scaling out again
+	ScaleOutCooldown int `json:"scale_out_cooldown,omitempty"`
 }
 
 // Info for a process type
@@ -108,3 +132,23 @@ type FormationUpdateOpts struct {
 	// dyno size (default: "1X")
 	Size *string `json:"size,omitempty"`
 }
+
+// Set the autoscaling policy of a process type
+//
+// appIdentity is the unique identifier of the Formation's App.
+// formationIdentity is the unique identifier of the Formation. autoscaling is
+// the new autoscaling policy.
+func (c *Client) FormationAutoscale(appIdentity string, formationIdentity string, autoscaling *FormationAutoscaling, message string) (*Formation, error) {
+	rh := RequestHeaders{CommitMessage: message}
+	var formationRes Formation
+	return &formationRes, c.PutWithHeaders(&formationRes, "/apps/"+appIdentity+"/formation/"+formationIdentity+"/autoscaling", autoscaling, rh.Headers())
+}
+
+// Disable autoscaling of a process type
+//
+// appIdentity is the unique identifier of the Formation's App.
+// formationIdentity is the unique identifier of the Formation.
+func (c *Client) FormationAutoscaleDisable(appIdentity string, formationIdentity string, message string) error {
+	rh := RequestHeaders{CommitMessage: message}
+	return c.DeleteWithHeaders("/apps/"+appIdentity+"/formation/"+formationIdentity+"/autoscaling", rh.Headers())
+}
@@ -26,6 +26,7 @@ const (
 	DefaultAPIURL       = "https://api.heroku.com"
 	DefaultUserAgent    = "heroku-go/" + Version + " (" + runtime.GOOS + "; " + runtime.GOARCH + ")"
 	CommitMessageHeader = "Commit-Message"
+	SecretHeader        = "Secret"
 )
 
 // A Client is a Heroku API client. Its zero value is a usable client that uses
@@ -300,6 +301,7 @@ func (lr *ListRange) SetHeader(req *http.Request) {
 
 type RequestHeaders struct {
 	CommitMessage string
+	Secret        bool
 }
 
 func (r *RequestHeaders) Headers() http.Header {
@@ -307,5 +309,8 @@ func (r *RequestHeaders) Headers() http.Header {
 	if r.CommitMessage != "" {
 		headers.Set(CommitMessageHeader, r.CommitMessage)
 	}
+	if r.Secret {
+		headers.Set(SecretHeader, "true")
+	}
 	return headers
 }
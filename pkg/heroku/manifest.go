@@ -0,0 +1,64 @@
+package heroku
+
+// A manifest describes the desired state of an app. Fields that are omitted
+// are left unchanged when the manifest is applied.
+type Manifest struct {
+	// name of the app
+	Name string `json:"name" yaml:"name"`
+
+	// Docker image to deploy
+	Image string `json:"image,omitempty" yaml:"image,omitempty"`
+
+	// config vars for the app
+	Config map[string]string `json:"config" yaml:"config"`
+
+	// quantity and size of each process
+	Formation map[string]ManifestProcess `json:"formation" yaml:"formation"`
+
+	// domains that route to the app
+	Domains []string `json:"domains" yaml:"domains"`
+
+	// exposure of the app (private or public)
+	Exposure string `json:"exposure,omitempty" yaml:"exposure,omitempty"`
+
+	// name of the SSL cert for the web process
+	Cert string `json:"cert,omitempty" yaml:"cert,omitempty"`
+}
+
+// The desired quantity and size of a process in a manifest.
+type ManifestProcess struct {
+	// number of processes to run
+	Quantity int `json:"quantity" yaml:"quantity"`
+
+	// size of the process (e.g. 1X, or 512:1GB)
+	Size string `json:"size,omitempty" yaml:"size,omitempty"`
+}
+
+// A change that was made to an app when a manifest was applied.
+type ManifestChange struct {
+	// type of change (create, image, config, scale, domain, exposure or
+	// cert)
+	Type string `json:"type"`
+
+	// human readable description of the change
+	Description string `json:"description"`
+}
+
+// Get a manifest describing the current state of an app.
+//
+// appIdentity is the unique identifier of the App.
+func (c *Client) ManifestInfo(appIdentity string) (*Manifest, error) {
+	var manifest Manifest
+	return &manifest, c.Get(&manifest, "/apps/"+appIdentity+"/manifest")
+}
+
+// Apply a manifest to an app, creating the app if it doesn't exist. The
+// changes are applied atomically.
+//
+// appIdentity is the name of the App. manifest is the desired state of the
+// app. message is an optional commit message.
+func (c *Client) ManifestApply(appIdentity string, manifest *Manifest, message string) ([]ManifestChange, error) {
+	rh := RequestHeaders{CommitMessage: message}
+	var changes []ManifestChange
+	return changes, c.PutWithHeaders(&changes, "/apps/"+appIdentity+"/manifest", manifest, rh.Headers())
+}
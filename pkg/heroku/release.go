@@ -104,3 +104,24 @@ func (c *Client) ReleaseRollback(appIdentity, release, message string) (*Release
 	var releaseRes Release
 	return &releaseRes, c.PostWithHeaders(&releaseRes, "/apps/"+appIdentity+"/releases", params, rh.Headers())
 }
+
+// Promote the canary release of an existing app.
+//
+// appIdentity is the unique identifier of the Release's App. message is the
+// commit message for the action.
+func (c *Client) ReleasePromote(appIdentity, message string) (*Release, error) {
+	rh := RequestHeaders{CommitMessage: message}
+	var releaseRes Release
+	return &releaseRes, c.PostWithHeaders(&releaseRes, "/apps/"+appIdentity+"/canary/promote", nil, rh.Headers())
+}
+
+// Abort the canary release of an existing app, rolling back to the stable
+// release.
+//
+// appIdentity is the unique identifier of the Release's App. message is the
+// commit message for the action.
+func (c *Client) ReleaseAbort(appIdentity, message string) (*Release, error) {
+	rh := RequestHeaders{CommitMessage: message}
+	var releaseRes Release
+	return &releaseRes, c.PostWithHeaders(&releaseRes, "/apps/"+appIdentity+"/canary/abort", nil, rh.Headers())
+}
@@ -0,0 +1,36 @@
+package heroku
+
+// A resource plan is a preview of the changes that releasing an app would make
+// to the resources for the app.
+type ResourcePlan struct {
+	// resources that would be changed
+	Changes []ResourceChange `json:"changes"`
+}
+
+// A change to a single resource.
+type ResourceChange struct {
+	// action that would be performed (Add, Modify, Replace or Remove)
+	Action string `json:"action"`
+
+	// name of the resource within the app
+	Name string `json:"name"`
+
+	// type of resource (e.g. AWS::ElasticLoadBalancing::LoadBalancer)
+	Type string `json:"type"`
+
+	// identifier of the existing resource, if there is one
+	ID string `json:"id,omitempty"`
+
+	// whether the resource would only be replaced depending on values that
+	// can't be determined until the change is made
+	Conditional bool `json:"conditional,omitempty"`
+}
+
+// Preview the changes that re-releasing the current release of an app would
+// make, without making them.
+//
+// appIdentity is the unique identifier of the App.
+func (c *Client) ResourcePlanInfo(appIdentity string) (*ResourcePlan, error) {
+	var plan ResourcePlan
+	return &plan, c.Get(&plan, "/apps/"+appIdentity+"/plan")
+}
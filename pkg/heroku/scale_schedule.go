@@ -0,0 +1,68 @@
+package heroku
+
+import (
+	"time"
+)
+
+// A scale schedule scales the processes of an app at times matching a cron
+// expression.
+type ScaleSchedule struct {
+	// unique identifier of the scale schedule
+	Id string `json:"id"`
+
+	// cron expression, evaluated in UTC
+	Schedule string `json:"schedule"`
+
+	// quantity to scale each process type to. "*" applies to any process
+	// types that aren't listed
+	Formation map[string]int `json:"formation,omitempty"`
+
+	// whether the formation from before the last scale schedule ran is
+	// restored
+	Restore bool `json:"restore"`
+
+	// when the scale schedule next runs
+	NextRunAt time.Time `json:"next_run_at"`
+
+	// when the scale schedule last ran
+	LastRunAt *time.Time `json:"last_run_at"`
+
+	// when the scale schedule was created
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ScaleScheduleCreateOpts are the options for adding a scale schedule.
+type ScaleScheduleCreateOpts struct {
+	// cron expression, evaluated in UTC
+	Schedule string `json:"schedule"`
+
+	// quantity to scale each process type to
+	Formation map[string]int `json:"formation,omitempty"`
+
+	// restore the formation from before the last scale schedule ran
+	Restore bool `json:"restore,omitempty"`
+}
+
+// Add a scale schedule to an app.
+//
+// appIdentity is the unique identifier of the ScaleSchedule's App.
+func (c *Client) ScaleScheduleCreate(appIdentity string, options ScaleScheduleCreateOpts) (*ScaleSchedule, error) {
+	var scheduleRes ScaleSchedule
+	return &scheduleRes, c.Post(&scheduleRes, "/apps/"+appIdentity+"/scale-schedules", options)
+}
+
+// Remove a scale schedule from an app.
+//
+// appIdentity is the unique identifier of the ScaleSchedule's App.
+// scheduleIdentity is the unique identifier of the ScaleSchedule.
+func (c *Client) ScaleScheduleDelete(appIdentity string, scheduleIdentity string) error {
+	return c.Delete("/apps/" + appIdentity + "/scale-schedules/" + scheduleIdentity)
+}
+
+// List the scale schedules of an app.
+//
+// appIdentity is the unique identifier of the ScaleSchedule's App.
+func (c *Client) ScaleScheduleList(appIdentity string) ([]ScaleSchedule, error) {
+	var schedulesRes []ScaleSchedule
+	return schedulesRes, c.Get(&schedulesRes, "/apps/"+appIdentity+"/scale-schedules")
+}
@@ -1,5 +1,7 @@
 package troposphere
 
+import "fmt"
+
 // Ref provides a helper for the Ref function.
 func Ref(ref interface{}) interface{} {
 	switch v := ref.(type) {
@@ -27,3 +29,13 @@ func Equals(thing, value interface{}) interface{} {
 func Join(delimiter string, things ...interface{}) interface{} {
 	return map[string][]interface{}{"Fn::Join": []interface{}{delimiter, things}}
 }
+
+// Select is a helper for the Fn::Select function.
+func Select(index int, list interface{}) interface{} {
+	return map[string][]interface{}{"Fn::Select": []interface{}{fmt.Sprintf("%d", index), list}}
+}
+
+// Split is a helper for the Fn::Split function.
+func Split(delimiter string, source interface{}) interface{} {
+	return map[string][]interface{}{"Fn::Split": []interface{}{delimiter, source}}
+}
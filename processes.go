@@ -4,6 +4,7 @@ import (
 	"database/sql/driver"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"strings"
 
 	shellwords "github.com/mattn/go-shellwords"
@@ -85,6 +86,18 @@ type Process struct {
 	// A cron expression. If provided, the process will be run as a
 	// scheduled task.
 	Cron *string `json:"cron,omitempty"`
+
+	// If provided, configures how the health of this process is checked.
+	HealthCheck *HealthCheck `json:"HealthCheck,omitempty"`
+
+	// If provided, configures how this process is exposed. If not
+	// provided, only the web process is exposed, using the exposure and
+	// cert of the app.
+	Exposure *Exposure `json:"Exposure,omitempty"`
+
+	// If provided, the quantity of this process is scaled automatically,
+	// based on utilization.
+	Autoscaling *Autoscaling `json:"Autoscaling,omitempty"`
 }
 
 // Constraints returns a constraints.Constraints from this Process definition.
@@ -104,6 +117,240 @@ func (p *Process) SetConstraints(c Constraints) {
 	p.Nproc = c.Nproc
 }
 
+// DefaultHealthCheck holds the values that are used for any HealthCheck
+// attributes that aren't provided in the Procfile.
+var DefaultHealthCheck = HealthCheck{
+	Interval:           30,
+	Timeout:            5,
+	HealthyThreshold:   2,
+	UnhealthyThreshold: 2,
+}
+
+// HealthCheck configures how the health of a process is checked.
+type HealthCheck struct {
+	// The HTTP path to request. If empty, a TCP connection check is
+	// performed.
+	Path string `json:"Path,omitempty"`
+
+	// The number of seconds between checks.
+	Interval int `json:"Interval,omitempty"`
+
+	// The number of seconds to wait for a response.
+	Timeout int `json:"Timeout,omitempty"`
+
+	// The number of consecutive successful checks before an instance is
+	// considered healthy.
+	HealthyThreshold int `json:"HealthyThreshold,omitempty"`
+
+	// The number of consecutive failed checks before an instance is
+	// considered unhealthy.
+	UnhealthyThreshold int `json:"UnhealthyThreshold,omitempty"`
+}
+
+// Validate returns an error if the health check isn't valid.
+func (h *HealthCheck) Validate() error {
+	if h.Path != "" && !strings.HasPrefix(h.Path, "/") {
+		return fmt.Errorf("health check path must start with a /: %s", h.Path)
+	}
+	// These match the limits imposed by ELB.
+	if err := validateHealthCheckRange("interval", h.Interval, 5, 300); err != nil {
+		return err
+	}
+	if err := validateHealthCheckRange("timeout", h.Timeout, 2, 60); err != nil {
+		return err
+	}
+	if err := validateHealthCheckRange("healthy threshold", h.HealthyThreshold, 2, 10); err != nil {
+		return err
+	}
+	if err := validateHealthCheckRange("unhealthy threshold", h.UnhealthyThreshold, 2, 10); err != nil {
+		return err
+	}
+	// The timeout is compared after defaults are applied, since a timeout
+	// that's greater than the default interval would be rejected.
+	if d := h.WithDefaults(); d.Timeout >= d.Interval {
+		return errors.New("health check timeout must be less than the interval")
+	}
+	return nil
+}
+
+// validateHealthCheckRange returns an error if v is set, but isn't between min and max.
+func validateHealthCheckRange(name string, v, min, max int) error {
+	if v != 0 && (v < min || v > max) {
+		return fmt.Errorf("health check %s must be between %d and %d", name, min, max)
+	}
+	return nil
+}
+
+// WithDefaults returns a copy of the HealthCheck with DefaultHealthCheck values
+// used for anything that isn't set.
+func (h HealthCheck) WithDefaults() HealthCheck {
+	if h.Interval == 0 {
+		h.Interval = DefaultHealthCheck.Interval
+	}
+	if h.Timeout == 0 {
+		h.Timeout = DefaultHealthCheck.Timeout
+		if h.Timeout >= h.Interval {
+			h.Timeout = h.Interval - 1
+		}
+	}
+	if h.HealthyThreshold == 0 {
+		h.HealthyThreshold = DefaultHealthCheck.HealthyThreshold
+	}
+	if h.UnhealthyThreshold == 0 {
+		h.UnhealthyThreshold = DefaultHealthCheck.UnhealthyThreshold
+	}
+	return h
+}
+
+// DefaultAutoscaling holds the values that are used for any Autoscaling
+// attributes that aren't provided.
+var DefaultAutoscaling = Autoscaling{
+	ScaleInCooldown:  300,
+	ScaleOutCooldown: 60,
+}
+
+// Autoscaling configures how the quantity of a process is scaled automatically,
+// to keep the average utilization of its instances near a target.
+type Autoscaling struct {
+	// The minimum number of instances.
+	Min int `json:"Min"`
+
+	// The maximum number of instances.
+	Max int `json:"Max"`
+
+	// The target average CPU utilization, as a percentage of the CPU share
+	// of the process.
+	CPU int `json:"CPU,omitempty"`
+
+	// The target average memory utilization, as a percentage of the memory
+	// limit of the process.
+	Memory int `json:"Memory,omitempty"`
+
+	// The number of seconds to wait after scaling in before scaling in
+	// again.
+	ScaleInCooldown int `json:"ScaleInCooldown,omitempty"`
+
+	// The number of seconds to wait after scaling out before scaling out
+	// again.
+	ScaleOutCooldown int `json:"ScaleOutCooldown,omitempty"`
+}
+
+// Validate returns an error if the autoscaling policy isn't valid.
+func (a *Autoscaling) Validate() error {
+	if a.Min < 0 {
+		return errors.New("autoscaling min can't be negative")
+	}
+	if a.Max < 1 {
+		return errors.New("autoscaling max must be at least 1")
+	}
+	if a.Min > a.Max {
+		return errors.New("autoscaling min can't be greater than max")
+	}
+	if a.CPU == 0 && a.Memory == 0 {
+		return errors.New("a target cpu or memory utilization is required for autoscaling")
+	}
+	if a.CPU < 0 || a.CPU > 100 {
+		return errors.New("autoscaling cpu target must be between 1 and 100")
+	}
+	if a.Memory < 0 || a.Memory > 100 {
+		return errors.New("autoscaling memory target must be between 1 and 100")
+	}
+	if a.ScaleInCooldown < 0 || a.ScaleOutCooldown < 0 {
+		return errors.New("autoscaling cooldowns can't be negative")
+	}
+	return nil
+}
+
+// WithDefaults returns a copy of the Autoscaling with DefaultAutoscaling values
+// used for anything that isn't set.
+func (a Autoscaling) WithDefaults() Autoscaling {
+	if a.ScaleInCooldown == 0 {
+		a.ScaleInCooldown = DefaultAutoscaling.ScaleInCooldown
+	}
+	if a.ScaleOutCooldown == 0 {
+		a.ScaleOutCooldown = DefaultAutoscaling.ScaleOutCooldown
+	}
+	return a
+}
+
+// Bound returns the quantity, limited to the min and max instances.
+func (a *Autoscaling) Bound(quantity int) int {
+	if quantity < a.Min {
+		return a.Min
+	}
+	if quantity > a.Max {
+		return a.Max
+	}
+	return quantity
+}
+
+// String returns the autoscaling policy in the form that's accepted by `emp
+// autoscale` (e.g. "min=2 max=20 cpu=60").
+func (a Autoscaling) String() string {
+	s := fmt.Sprintf("min=%d max=%d", a.Min, a.Max)
+	if a.CPU != 0 {
+		s += fmt.Sprintf(" cpu=%d", a.CPU)
+	}
+	if a.Memory != 0 {
+		s += fmt.Sprintf(" memory=%d", a.Memory)
+	}
+	if a.ScaleInCooldown != 0 {
+		s += fmt.Sprintf(" scale-in-cooldown=%d", a.ScaleInCooldown)
+	}
+	if a.ScaleOutCooldown != 0 {
+		s += fmt.Sprintf(" scale-out-cooldown=%d", a.ScaleOutCooldown)
+	}
+	return s
+}
+
+// Valid protocols for an Exposure.
+const (
+	ProtocolHTTP  = "http"
+	ProtocolHTTPS = "https"
+	ProtocolTCP   = "tcp"
+	ProtocolSSL   = "ssl"
+)
+
+// Exposure configures how a process is exposed through a load balancer.
+type Exposure struct {
+	// One of ProtocolHTTP, ProtocolHTTPS, ProtocolTCP or ProtocolSSL.
+	Protocol string `json:"Protocol,omitempty"`
+
+	// If true, the process is exposed to the internet, instead of only
+	// internally.
+	External bool `json:"External,omitempty"`
+
+	// The ports that the load balancer listens on, for tcp and ssl. http
+	// and https always use port 80 and 443.
+	Ports []int `json:"Ports,omitempty"`
+
+	// The SSL certificate to use for https and ssl. If not provided, the
+	// cert of the app is used.
+	Cert string `json:"Cert,omitempty"`
+}
+
+// Validate returns an error if the exposure isn't valid.
+func (e *Exposure) Validate() error {
+	switch e.Protocol {
+	case ProtocolHTTP, ProtocolHTTPS:
+		if len(e.Ports) != 0 {
+			return fmt.Errorf("ports can't be specified for %s", e.Protocol)
+		}
+	case ProtocolTCP, ProtocolSSL:
+		if len(e.Ports) == 0 {
+			return fmt.Errorf("at least 1 port is required for %s", e.Protocol)
+		}
+		for _, port := range e.Ports {
+			if port < 1 || port > 65535 {
+				return fmt.Errorf("invalid port: %d", port)
+			}
+		}
+	default:
+		return fmt.Errorf("unknown protocol: %s", e.Protocol)
+	}
+	return nil
+}
+
 // Formation represents a collection of named processes and their configuration.
 type Formation map[string]Process
 
@@ -137,8 +384,17 @@ func (f Formation) Value() (driver.Value, error) {
 	return driver.Value(raw), nil
 }
 
-// Merge merges in the existing quantity and constraints from the old Formation
-// into this Formation.
+// clone returns a copy of the Formation.
+func (f Formation) clone() Formation {
+	c := make(Formation)
+	for name, p := range f {
+		c[name] = p
+	}
+	return c
+}
+
+// Merge merges in the existing quantity, constraints and autoscaling policy from
+// the old Formation into this Formation.
 func (f Formation) Merge(other Formation) Formation {
 	new := make(Formation)
 
@@ -149,6 +405,7 @@ func (f Formation) Merge(other Formation) Formation {
 			// instance count.
 			p.Quantity = existing.Quantity
 			p.SetConstraints(existing.Constraints())
+			p.Autoscaling = existing.Autoscaling
 		} else {
 			p.Quantity = DefaultQuantities[name]
 			p.SetConstraints(DefaultConstraints)
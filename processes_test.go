@@ -30,16 +30,16 @@ func TestNewFormation(t *testing.T) {
 				"web": Process{
 					Quantity: 1,
 					Command:  Command{"./bin/web"},
-					Memory:   NamedConstraints["1X"].Memory,
-					CPUShare: NamedConstraints["1X"].CPUShare,
-					Nproc:    NamedConstraints["1X"].Nproc,
+					Memory:   Constraints1X.Memory,
+					CPUShare: Constraints1X.CPUShare,
+					Nproc:    Constraints1X.Nproc,
 				},
 				"worker": Process{
 					Quantity: 0,
 					Command:  Command{"sidekiq"},
-					Memory:   NamedConstraints["1X"].Memory,
-					CPUShare: NamedConstraints["1X"].CPUShare,
-					Nproc:    NamedConstraints["1X"].Nproc,
+					Memory:   Constraints1X.Memory,
+					CPUShare: Constraints1X.CPUShare,
+					Nproc:    Constraints1X.Nproc,
 				},
 			},
 		},
@@ -56,18 +56,47 @@ func TestNewFormation(t *testing.T) {
 				"web": Process{
 					Command:  Command{"./bin/web"},
 					Quantity: 2,
-					Memory:   NamedConstraints["PX"].Memory,
-					CPUShare: NamedConstraints["PX"].CPUShare,
-					Nproc:    NamedConstraints["PX"].Nproc,
+					Memory:   ConstraintsPX.Memory,
+					CPUShare: ConstraintsPX.CPUShare,
+					Nproc:    ConstraintsPX.Nproc,
 				},
 			},
 			expected: Formation{
 				"web": Process{
 					Quantity: 2,
 					Command:  Command{"./bin/web"},
-					Memory:   NamedConstraints["PX"].Memory,
-					CPUShare: NamedConstraints["PX"].CPUShare,
-					Nproc:    NamedConstraints["PX"].Nproc,
+					Memory:   ConstraintsPX.Memory,
+					CPUShare: ConstraintsPX.CPUShare,
+					Nproc:    ConstraintsPX.Nproc,
+				},
+			},
+		},
+
+		// Check that the autoscaling policy is retained.
+		{
+			f: Formation{
+				"web": Process{
+					Command: Command{"./bin/web"},
+				},
+			},
+			other: Formation{
+				"web": Process{
+					Command:     Command{"./bin/web"},
+					Quantity:    2,
+					Memory:      Constraints1X.Memory,
+					CPUShare:    Constraints1X.CPUShare,
+					Nproc:       Constraints1X.Nproc,
+					Autoscaling: &Autoscaling{Min: 2, Max: 20, CPU: 60},
+				},
+			},
+			expected: Formation{
+				"web": Process{
+					Quantity:    2,
+					Command:     Command{"./bin/web"},
+					Memory:      Constraints1X.Memory,
+					CPUShare:    Constraints1X.CPUShare,
+					Nproc:       Constraints1X.Nproc,
+					Autoscaling: &Autoscaling{Min: 2, Max: 20, CPU: 60},
 				},
 			},
 		},
@@ -83,18 +112,18 @@ func TestNewFormation(t *testing.T) {
 				"worker": Process{
 					Command:  Command{"sidekiq"},
 					Quantity: 2,
-					Memory:   NamedConstraints["PX"].Memory,
-					CPUShare: NamedConstraints["PX"].CPUShare,
-					Nproc:    NamedConstraints["PX"].Nproc,
+					Memory:   ConstraintsPX.Memory,
+					CPUShare: ConstraintsPX.CPUShare,
+					Nproc:    ConstraintsPX.Nproc,
 				},
 			},
 			expected: Formation{
 				"web": Process{
 					Quantity: 1,
 					Command:  Command{"./bin/web"},
-					Memory:   NamedConstraints["1X"].Memory,
-					CPUShare: NamedConstraints["1X"].CPUShare,
-					Nproc:    NamedConstraints["1X"].Nproc,
+					Memory:   Constraints1X.Memory,
+					CPUShare: Constraints1X.CPUShare,
+					Nproc:    Constraints1X.Nproc,
 				},
 			},
 		},
@@ -106,6 +135,110 @@ func TestNewFormation(t *testing.T) {
 	}
 }
 
+func TestHealthCheck_Validate(t *testing.T) {
+	tests := []struct {
+		hc    HealthCheck
+		valid bool
+	}{
+		{HealthCheck{}, true},
+		{HealthCheck{Path: "/health"}, true},
+		{HealthCheck{Path: "/health", Interval: 10, Timeout: 5}, true},
+		{HealthCheck{HealthyThreshold: 3, UnhealthyThreshold: 5}, true},
+		{HealthCheck{Path: "health"}, false},
+		{HealthCheck{Interval: 1}, false},
+		{HealthCheck{Interval: 10, Timeout: 10}, false},
+		{HealthCheck{Interval: 5}, true},
+		{HealthCheck{Timeout: 20}, true},
+		{HealthCheck{Timeout: 40}, false},
+		{HealthCheck{UnhealthyThreshold: 11}, false},
+	}
+
+	for _, tt := range tests {
+		err := tt.hc.Validate()
+		if tt.valid {
+			assert.NoError(t, err)
+		} else {
+			assert.Error(t, err)
+		}
+	}
+}
+
+func TestHealthCheck_WithDefaults(t *testing.T) {
+	assert.Equal(t, HealthCheck{
+		Path:               "/health",
+		Interval:           30,
+		Timeout:            5,
+		HealthyThreshold:   2,
+		UnhealthyThreshold: 2,
+	}, HealthCheck{Path: "/health"}.WithDefaults())
+
+	// The default timeout should be less than the interval.
+	assert.Equal(t, 4, HealthCheck{Interval: 5}.WithDefaults().Timeout)
+}
+
+func TestExposure_Validate(t *testing.T) {
+	tests := []struct {
+		e     Exposure
+		valid bool
+	}{
+		{Exposure{Protocol: ProtocolHTTP}, true},
+		{Exposure{Protocol: ProtocolHTTPS, External: true}, true},
+		{Exposure{Protocol: ProtocolTCP, Ports: []int{50051}}, true},
+		{Exposure{Protocol: ProtocolSSL, Ports: []int{443, 8443}}, true},
+		{Exposure{Protocol: ProtocolHTTP, Ports: []int{8080}}, false},
+		{Exposure{Protocol: ProtocolTCP}, false},
+		{Exposure{Protocol: ProtocolTCP, Ports: []int{70000}}, false},
+		{Exposure{Protocol: "udp", Ports: []int{53}}, false},
+	}
+
+	for _, tt := range tests {
+		err := tt.e.Validate()
+		if tt.valid {
+			assert.NoError(t, err)
+		} else {
+			assert.Error(t, err)
+		}
+	}
+}
+
+func TestAutoscaling_Validate(t *testing.T) {
+	tests := []struct {
+		a     Autoscaling
+		valid bool
+	}{
+		{Autoscaling{Min: 2, Max: 20, CPU: 60}, true},
+		{Autoscaling{Min: 0, Max: 1, Memory: 80}, true},
+		{Autoscaling{Min: 1, Max: 5, CPU: 50, Memory: 75, ScaleInCooldown: 600}, true},
+		{Autoscaling{Min: 2, Max: 20}, false},
+		{Autoscaling{Min: 5, Max: 2, CPU: 60}, false},
+		{Autoscaling{Min: -1, Max: 2, CPU: 60}, false},
+		{Autoscaling{Max: 0, CPU: 60}, false},
+		{Autoscaling{Min: 1, Max: 2, CPU: 101}, false},
+		{Autoscaling{Min: 1, Max: 2, CPU: 60, ScaleOutCooldown: -1}, false},
+	}
+
+	for _, tt := range tests {
+		err := tt.a.Validate()
+		if tt.valid {
+			assert.NoError(t, err)
+		} else {
+			assert.Error(t, err)
+		}
+	}
+}
+
+func TestAutoscaling_Bound(t *testing.T) {
+	a := &Autoscaling{Min: 2, Max: 20, CPU: 60}
+	assert.Equal(t, 2, a.Bound(1))
+	assert.Equal(t, 5, a.Bound(5))
+	assert.Equal(t, 20, a.Bound(30))
+}
+
+func TestAutoscaling_String(t *testing.T) {
+	assert.Equal(t, "min=2 max=20 cpu=60", Autoscaling{Min: 2, Max: 20, CPU: 60}.String())
+	assert.Equal(t, "min=0 max=5 memory=80 scale-in-cooldown=600", Autoscaling{Max: 5, Memory: 80, ScaleInCooldown: 600}.String())
+}
+
 func ExampleCommand() {
 	cmd := Command{"/bin/ls", "-h"}
 	fmt.Println(cmd)
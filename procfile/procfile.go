@@ -22,8 +22,37 @@ func (e ExtendedProcfile) version() string {
 }
 
 type Process struct {
-	Command interface{} `yaml:"command"`
-	Cron    *string     `yaml:"cron,omitempty"`
+	Command     interface{}  `yaml:"command"`
+	Cron        *string      `yaml:"cron,omitempty"`
+	HealthCheck *HealthCheck `yaml:"healthcheck,omitempty"`
+	Expose      *Exposure    `yaml:"expose,omitempty"`
+}
+
+// Exposure configures how a process is exposed through a load balancer.
+type Exposure struct {
+	// One of http, https, tcp or ssl. Defaults to http.
+	Protocol string `yaml:"protocol,omitempty"`
+
+	// If true, the process is exposed to the internet. Defaults to
+	// internal.
+	External bool `yaml:"external,omitempty"`
+
+	// The ports that the load balancer should listen on, for tcp and ssl.
+	Ports []int `yaml:"ports,omitempty"`
+
+	// The SSL certificate to use for https and ssl.
+	Cert string `yaml:"cert,omitempty"`
+}
+
+// HealthCheck configures how the health of a process is checked. Intervals and
+// timeouts are in seconds.
+type HealthCheck struct {
+	// The HTTP path to request. If not provided, a TCP check is performed.
+	Path               string `yaml:"path,omitempty"`
+	Interval           int    `yaml:"interval,omitempty"`
+	Timeout            int    `yaml:"timeout,omitempty"`
+	HealthyThreshold   int    `yaml:"healthy_threshold,omitempty"`
+	UnhealthyThreshold int    `yaml:"unhealthy_threshold,omitempty"`
 }
 
 // StandardProcfile represents a standard Procfile.
@@ -51,6 +51,53 @@ web:
 			},
 		},
 	},
+
+	// Extended Procfile with a health check.
+	{
+		strings.NewReader(`---
+web:
+  command: ./bin/web
+  healthcheck:
+    path: /health
+    interval: 10
+    timeout: 2
+    healthy_threshold: 3
+    unhealthy_threshold: 4`),
+		ExtendedProcfile{
+			"web": Process{
+				Command: "./bin/web",
+				HealthCheck: &HealthCheck{
+					Path:               "/health",
+					Interval:           10,
+					Timeout:            2,
+					HealthyThreshold:   3,
+					UnhealthyThreshold: 4,
+				},
+			},
+		},
+	},
+
+	// Extended Procfile with an exposed process.
+	{
+		strings.NewReader(`---
+grpc:
+  command: ./bin/grpc
+  expose:
+    protocol: tcp
+    external: true
+    ports:
+      - 50051`),
+		ExtendedProcfile{
+			"grpc": Process{
+				Command: "./bin/grpc",
+				Expose: &Exposure{
+					Protocol: "tcp",
+					External: true,
+					Ports:    []int{50051},
+				},
+			},
+		},
+	},
 }
 
 func TestParse(t *testing.T) {
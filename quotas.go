@@ -0,0 +1,194 @@
+package empire
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/jinzhu/gorm"
+	"github.com/remind101/empire/pkg/constraints"
+	"github.com/remind101/empire/scheduler"
+	"golang.org/x/net/context"
+)
+
+// Resources are the number of instances, and the total memory and CPU shares,
+// that processes use, or are allowed to use.
+type Resources struct {
+	Instances int
+	Memory    constraints.Memory
+	CPUShare  int
+}
+
+// Add returns the sum of r and other.
+func (r Resources) Add(other Resources) Resources {
+	return Resources{
+		Instances: r.Instances + other.Instances,
+		Memory:    r.Memory + other.Memory,
+		CPUShare:  r.CPUShare + other.CPUShare,
+	}
+}
+
+// Resources returns the resources that the processes in the formation can
+// use. Autoscaled processes count as their maximum number of instances.
+func (f Formation) Resources() Resources {
+	var r Resources
+	for _, p := range f {
+		n := p.Quantity
+		if p.Autoscaling != nil {
+			n = p.Autoscaling.Max
+		}
+
+		r.Instances += n
+		r.Memory += constraints.Memory(n) * p.Memory
+		r.CPUShare += n * int(p.CPUShare)
+	}
+	return r
+}
+
+// checkQuota returns a ValidationError if the desired resources are over the
+// quota. Zero values in the quota are unlimited. Going from current to desired
+// is always allowed if it doesn't use more of the resource that's over quota,
+// so that apps that are already over a quota can still be scaled down.
+func checkQuota(quota, current, desired Resources, subject string) error {
+	if quota.Instances > 0 && desired.Instances > quota.Instances && desired.Instances > current.Instances {
+		return &ValidationError{Err: fmt.Errorf("%s would run %d instances, which is more than the quota of %d.", subject, desired.Instances, quota.Instances)}
+	}
+	if quota.Memory > 0 && desired.Memory > quota.Memory && desired.Memory > current.Memory {
+		return &ValidationError{Err: fmt.Errorf("%s would use %s of memory, which is more than the quota of %s.", subject, desired.Memory, quota.Memory)}
+	}
+	if quota.CPUShare > 0 && desired.CPUShare > quota.CPUShare && desired.CPUShare > current.CPUShare {
+		return &ValidationError{Err: fmt.Errorf("%s would use %d CPU shares, which is more than the quota of %d.", subject, desired.CPUShare, quota.CPUShare)}
+	}
+	return nil
+}
+
+// checkQuotas returns a ValidationError if changing the formation of app from
+// current to desired would go over the AppQuota, or the Quota for all apps.
+func (e *Empire) checkQuotas(db *gorm.DB, app *App, current, desired Formation) error {
+	before, after := current.Resources(), desired.Resources()
+
+	if err := checkQuota(e.AppQuota, before, after, app.Name); err != nil {
+		return err
+	}
+
+	if e.Quota == (Resources{}) {
+		return nil
+	}
+
+	others, err := otherAppsResources(db, app)
+	if err != nil {
+		return err
+	}
+
+	return checkQuota(e.Quota, others.Add(before), others.Add(after), "All apps together")
+}
+
+// otherAppsResources returns the resources used by the current formation of
+// every app, other than app.
+func otherAppsResources(db *gorm.DB, app *App) (Resources, error) {
+	var r Resources
+
+	rows, err := db.Raw(`SELECT DISTINCT ON (releases.app_id) releases.formation FROM releases JOIN apps ON apps.id = releases.app_id WHERE releases.app_id != ? ORDER BY releases.app_id, releases.version DESC`, app.ID).Rows()
+	if err != nil {
+		return r, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var f Formation
+		if err := rows.Scan(&f); err != nil {
+			return r, err
+		}
+		r = r.Add(f.Resources())
+	}
+
+	return r, rows.Err()
+}
+
+// checkCapacity returns a ValidationError if the instances that going from
+// current to desired adds can't be placed on the hosts in the cluster. It's
+// only checked when CheckCapacity is enabled, and the Scheduler reports its
+// hosts.
+func (e *Empire) checkCapacity(ctx context.Context, current, desired Formation) error {
+	if !e.CheckCapacity {
+		return nil
+	}
+
+	instances := newInstances(current, desired)
+	if len(instances) == 0 {
+		return nil
+	}
+
+	hosts, err := scheduler.Hosts(ctx, e.Scheduler)
+	if err != nil {
+		return err
+	}
+	if hosts == nil {
+		return nil
+	}
+
+	if !fits(hosts, instances) {
+		return &ValidationError{Err: fmt.Errorf("There isn't enough CPU or memory left in the cluster to run %d more instances.", len(instances))}
+	}
+
+	return nil
+}
+
+// newInstances returns the constraints of each instance that needs to be
+// placed to go from current to desired. When the constraints of a process
+// change, all of its instances are replaced.
+func newInstances(current, desired Formation) []Constraints {
+	var instances []Constraints
+	for name, p := range desired {
+		n := p.Quantity
+		if existing, ok := current[name]; ok && existing.Constraints() == p.Constraints() {
+			n -= existing.Quantity
+		}
+
+		for i := 0; i < n; i++ {
+			instances = append(instances, p.Constraints())
+		}
+	}
+	return instances
+}
+
+// fits returns true if each of the instances can be placed on one of the
+// hosts. Instances are placed largest first, on the first host with enough CPU
+// and memory left.
+func fits(hosts []*scheduler.Host, instances []Constraints) bool {
+	left := make([]scheduler.Host, len(hosts))
+	for i, h := range hosts {
+		left[i] = *h
+	}
+
+	sort.Sort(sort.Reverse(byMemory(instances)))
+
+	for _, c := range instances {
+		placed := false
+		for i := range left {
+			h := &left[i]
+			if h.CPUShares >= uint(c.CPUShare) && h.MemoryLimit >= uint(c.Memory) {
+				h.CPUShares -= uint(c.CPUShare)
+				h.MemoryLimit -= uint(c.Memory)
+				placed = true
+				break
+			}
+		}
+		if !placed {
+			return false
+		}
+	}
+
+	return true
+}
+
+// byMemory sorts constraints by memory, then CPU shares.
+type byMemory []Constraints
+
+func (s byMemory) Len() int      { return len(s) }
+func (s byMemory) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s byMemory) Less(i, j int) bool {
+	if s[i].Memory == s[j].Memory {
+		return s[i].CPUShare < s[j].CPUShare
+	}
+	return s[i].Memory < s[j].Memory
+}
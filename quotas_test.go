@@ -0,0 +1,125 @@
+package empire
+
+import (
+	"testing"
+
+	. "github.com/remind101/empire/pkg/bytesize"
+	"github.com/remind101/empire/pkg/constraints"
+	"github.com/remind101/empire/scheduler"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestFormation_Resources(t *testing.T) {
+	f := Formation{
+		"web":    Process{Quantity: 2, CPUShare: 256, Memory: constraints.Memory(512 * MB)},
+		"worker": Process{Quantity: 1, CPUShare: 1024, Memory: constraints.Memory(1 * GB), Autoscaling: &Autoscaling{Min: 1, Max: 4}},
+		"cron":   Process{Quantity: 0, CPUShare: 256, Memory: constraints.Memory(512 * MB)},
+	}
+
+	assert.Equal(t, Resources{
+		Instances: 6,
+		Memory:    constraints.Memory(5 * GB),
+		CPUShare:  4608,
+	}, f.Resources())
+}
+
+func TestCheckQuota(t *testing.T) {
+	quota := Resources{Instances: 10, Memory: constraints.Memory(4 * GB), CPUShare: 2048}
+
+	tests := []struct {
+		current, desired Resources
+		err              string
+	}{
+		{Resources{}, Resources{Instances: 10, Memory: constraints.Memory(4 * GB), CPUShare: 2048}, ""},
+		{Resources{}, Resources{Instances: 11}, "acme-inc would run 11 instances, which is more than the quota of 10."},
+		{Resources{}, Resources{Memory: constraints.Memory(8 * GB)}, "acme-inc would use 8.00gb of memory, which is more than the quota of 4.00gb."},
+		{Resources{}, Resources{CPUShare: 4096}, "acme-inc would use 4096 CPU shares, which is more than the quota of 2048."},
+
+		// Apps that are already over quota can be scaled down.
+		{Resources{Instances: 20}, Resources{Instances: 15}, ""},
+		{Resources{Instances: 20}, Resources{Instances: 20}, ""},
+	}
+
+	for i, tt := range tests {
+		err := checkQuota(quota, tt.current, tt.desired, "acme-inc")
+		if tt.err == "" {
+			assert.NoError(t, err, "#%d", i)
+			continue
+		}
+		if assert.IsType(t, &ValidationError{}, err, "#%d", i) {
+			assert.EqualError(t, err, tt.err, "#%d", i)
+		}
+	}
+
+	assert.NoError(t, checkQuota(Resources{}, Resources{}, Resources{Instances: 1000}, "acme-inc"))
+}
+
+func TestNewInstances(t *testing.T) {
+	current := Formation{
+		"web":    Process{Quantity: 2, CPUShare: 256, Memory: constraints.Memory(512 * MB)},
+		"worker": Process{Quantity: 3, CPUShare: 256, Memory: constraints.Memory(512 * MB)},
+	}
+	desired := Formation{
+		"web":    Process{Quantity: 3, CPUShare: 256, Memory: constraints.Memory(512 * MB)},
+		"worker": Process{Quantity: 1, CPUShare: 1024, Memory: constraints.Memory(6 * GB)},
+		"other":  Process{Quantity: 0, CPUShare: 256, Memory: constraints.Memory(512 * MB)},
+	}
+
+	instances := newInstances(current, desired)
+	assert.Equal(t, 2, len(instances))
+	assert.Contains(t, instances, Constraints{256, constraints.Memory(512 * MB), 0})
+	assert.Contains(t, instances, Constraints{1024, constraints.Memory(6 * GB), 0})
+
+	assert.Equal(t, 0, len(newInstances(current, current)))
+}
+
+func TestFits(t *testing.T) {
+	hosts := []*scheduler.Host{
+		{ID: "a", CPUShares: 1024, MemoryLimit: 2 * GB},
+		{ID: "b", CPUShares: 512, MemoryLimit: 6 * GB},
+	}
+	small := Constraints{256, constraints.Memory(512 * MB), 0}
+	large := Constraints{512, constraints.Memory(4 * GB), 0}
+
+	assert.True(t, fits(hosts, nil))
+	assert.True(t, fits(hosts, []Constraints{small, small, small, small, large}))
+	assert.False(t, fits(hosts, []Constraints{small, small, small, small, small, large}))
+	assert.False(t, fits(hosts, []Constraints{large, large}))
+
+	// The hosts aren't modified.
+	assert.Equal(t, uint(1024), hosts[0].CPUShares)
+}
+
+func TestEmpire_CheckCapacity(t *testing.T) {
+	s := &capacityScheduler{
+		hosts: []*scheduler.Host{{ID: "a", CPUShares: 1024, MemoryLimit: 2 * GB}},
+	}
+	e := &Empire{Scheduler: s}
+
+	current := Formation{"web": Process{Quantity: 1, CPUShare: 256, Memory: constraints.Memory(512 * MB)}}
+	desired := Formation{"web": Process{Quantity: 10, CPUShare: 256, Memory: constraints.Memory(512 * MB)}}
+
+	assert.NoError(t, e.checkCapacity(context.Background(), current, desired))
+
+	e.CheckCapacity = true
+	err := e.checkCapacity(context.Background(), current, desired)
+	assert.IsType(t, &ValidationError{}, err)
+
+	desired["web"] = Process{Quantity: 4, CPUShare: 256, Memory: constraints.Memory(512 * MB)}
+	assert.NoError(t, e.checkCapacity(context.Background(), current, desired))
+
+	// Schedulers that don't report their hosts aren't checked.
+	e.Scheduler = scheduler.NewFakeScheduler()
+	desired["web"] = Process{Quantity: 100, CPUShare: 256, Memory: constraints.Memory(512 * MB)}
+	assert.NoError(t, e.checkCapacity(context.Background(), current, desired))
+}
+
+type capacityScheduler struct {
+	scheduler.Scheduler
+	hosts []*scheduler.Host
+}
+
+func (s *capacityScheduler) Hosts(ctx context.Context) ([]*scheduler.Host, error) {
+	return s.hosts, nil
+}
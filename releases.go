@@ -115,6 +115,12 @@ func (s *releasesService) CreateAndRelease(ctx context.Context, db *gorm.DB, r *
 
 // Create creates a new release.
 func (s *releasesService) Create(ctx context.Context, db *gorm.DB, r *Release) (*Release, error) {
+	// New releases can't be created until the canary has been promoted or
+	// aborted.
+	if err := canaryGuard(db, r.App); err != nil {
+		return r, err
+	}
+
 	// Lock all releases for the given application to ensure that the
 	// release version is updated automically.
 	if err := db.Exec(`select 1 from releases where app_id = ? for update`, r.App.ID).Error; err != nil {
@@ -131,6 +137,19 @@ func (s *releasesService) Create(ctx context.Context, db *gorm.DB, r *Release) (
 		}
 	}
 
+	current, err := currentFormation(db, r.App)
+	if err != nil && err != gorm.RecordNotFound {
+		return r, err
+	}
+
+	if err := s.checkQuotas(db, r.App, current, r.Formation); err != nil {
+		return r, err
+	}
+
+	if err := s.checkCapacity(ctx, current, r.Formation); err != nil {
+		return r, err
+	}
+
 	return releasesCreate(db, r)
 }
 
@@ -143,6 +162,9 @@ func (s *releasesService) Rollback(ctx context.Context, db *gorm.DB, opts Rollba
 	}
 
 	desc := fmt.Sprintf("Rollback to v%d", version)
+	if opts.Reason != "" {
+		desc = fmt.Sprintf("%s (automatic: %s)", desc, opts.Reason)
+	}
 	desc = appendMessageToDescription(desc, opts.User, opts.Message)
 	return s.CreateAndRelease(ctx, db, &Release{
 		App:         app,
@@ -155,12 +177,56 @@ func (s *releasesService) Rollback(ctx context.Context, db *gorm.DB, opts Rollba
 
 // Release submits a release to the scheduler.
 func (s *releasesService) Release(ctx context.Context, release *Release, ss scheduler.StatusStream) error {
-	a := newSchedulerApp(release)
+	return s.release(ctx, s.db, release, ss, false)
+}
+
+// release submits a release to the scheduler, with the domains and aliases of
+// the app in db. If restart is true, all of the app's processes are restarted.
+func (s *releasesService) release(ctx context.Context, db *gorm.DB, release *Release, ss scheduler.StatusStream, restart bool) error {
+	a, err := newRoutedSchedulerApp(db, release)
+	if err != nil {
+		return err
+	}
+
+	if restart {
+		return scheduler.Restart(ctx, s.Scheduler, a, ss)
+	}
 	return s.Scheduler.Submit(ctx, a, ss)
 }
 
+// Plan returns a preview of the changes that releasing the release would make,
+// without making them.
+func (s *releasesService) Plan(ctx context.Context, db *gorm.DB, release *Release) (*scheduler.Plan, error) {
+	a, err := newRoutedSchedulerApp(db, release)
+	if err != nil {
+		return nil, err
+	}
+
+	plan, err := scheduler.PlanSubmit(ctx, s.Scheduler, a)
+	if err == scheduler.ErrPlanNotSupported {
+		return nil, &ValidationError{Err: err}
+	}
+	return plan, err
+}
+
 // ReleaseApp will find the last release for an app and release it.
 func (s *releasesService) ReleaseApp(ctx context.Context, db *gorm.DB, app *App) error {
+	return s.releaseApp(ctx, db, app, false)
+}
+
+// RestartApp will find the last release for an app and release it, restarting
+// all of its processes.
+func (s *releasesService) RestartApp(ctx context.Context, db *gorm.DB, app *App) error {
+	return s.releaseApp(ctx, db, app, true)
+}
+
+func (s *releasesService) releaseApp(ctx context.Context, db *gorm.DB, app *App, restart bool) error {
+	// The latest release is the canary, so re-releasing it would replace
+	// the stable release.
+	if err := canaryGuard(db, app); err != nil {
+		return err
+	}
+
 	release, err := releasesFind(db, ReleasesQuery{App: app})
 	if err != nil {
 		if err == gorm.RecordNotFound {
@@ -174,7 +240,7 @@ func (s *releasesService) ReleaseApp(ctx context.Context, db *gorm.DB, app *App)
 		return nil
 	}
 
-	return s.Release(ctx, release, nil)
+	return s.release(ctx, db, release, nil, restart)
 }
 
 // These associations are always available on a Release.
@@ -200,7 +266,11 @@ func releases(db *gorm.DB, scope scope) ([]*Release, error) {
 }
 
 func releasesUpdate(db *gorm.DB, release *Release) error {
-	return db.Save(release).Error
+	if err := db.Save(release).Error; err != nil {
+		return err
+	}
+
+	return formationChangesCreate(db, release.App, release.Formation)
 }
 
 func buildFormation(db *gorm.DB, release *Release) error {
@@ -269,17 +339,42 @@ func releasesCreate(db *gorm.DB, release *Release) (*Release, error) {
 		return release, err
 	}
 
-	return release, nil
+	return release, formationChangesCreate(db, release.App, release.Formation)
+}
+
+// newRoutedSchedulerApp returns a scheduler.App for the release, with the
+// domains and aliases of the app, which schedulers route to it.
+func newRoutedSchedulerApp(db *gorm.DB, release *Release) (*scheduler.App, error) {
+	a := newSchedulerApp(release)
+
+	domains, err := schedulerDomains(db, release.App)
+	if err != nil {
+		return nil, err
+	}
+	a.Domains = domains
+
+	aliases, err := schedulerAliases(db, release.App)
+	if err != nil {
+		return nil, err
+	}
+	a.Aliases = aliases
+
+	return a, nil
 }
 
 func newSchedulerApp(release *Release) *scheduler.App {
 	var processes []*scheduler.Process
 
 	for name, p := range release.Formation {
+		// The release process is only run when deploying, it's never
+		// scheduled.
+		if name == releaseProcessType {
+			continue
+		}
 		processes = append(processes, newSchedulerProcess(release, name, p))
 	}
 
-	env := environment(release.Config.Vars)
+	env, secrets := environment(release.Config.Vars)
 	env["EMPIRE_APPID"] = release.App.ID
 	env["EMPIRE_APPNAME"] = release.App.Name
 	env["EMPIRE_RELEASE"] = fmt.Sprintf("v%d", release.Version)
@@ -296,6 +391,7 @@ func newSchedulerApp(release *Release) *scheduler.App {
 		Release:   fmt.Sprintf("v%d", release.Version),
 		Processes: processes,
 		Env:       env,
+		Secrets:   secrets,
 		Labels:    labels,
 	}
 }
@@ -320,24 +416,40 @@ func newSchedulerProcess(release *Release, name string, p Process) *scheduler.Pr
 		MemoryLimit: uint(p.Memory),
 		CPUShares:   uint(p.CPUShare),
 		Nproc:       uint(p.Nproc),
-		Exposure:    processExposure(release.App, name),
+		Exposure:    processExposure(release.App, name, p),
 		Schedule:    processSchedule(name, p),
+		HealthCheck: processHealthCheck(p),
+		Autoscaling: processAutoscaling(p),
 	}
 }
 
-// environment coerces a Vars into a map[string]string.
-func environment(vars Vars) map[string]string {
-	env := make(map[string]string)
+// environment coerces a Vars into a map[string]string. Secrets are returned
+// separately, as references that the scheduler resolves when instances are
+// launched.
+func environment(vars Vars) (env map[string]string, secrets map[string]string) {
+	env = make(map[string]string)
 
 	for k, v := range vars {
+		if isSecret(*v) {
+			if secrets == nil {
+				secrets = make(map[string]string)
+			}
+			secrets[string(k)] = secretRef(*v)
+			continue
+		}
 		env[string(k)] = string(*v)
 	}
 
-	return env
+	return env, secrets
 }
 
-func processExposure(app *App, process string) *scheduler.Exposure {
-	// For now, only the `web` process can be exposed.
+func processExposure(app *App, process string, p Process) *scheduler.Exposure {
+	if p.Exposure != nil {
+		return newSchedulerExposure(app, p.Exposure)
+	}
+
+	// Without an exposure in the Procfile, only the `web` process is
+	// exposed.
 	if process != webProcessType {
 		return nil
 	}
@@ -358,6 +470,72 @@ func processExposure(app *App, process string) *scheduler.Exposure {
 	return exposure
 }
 
+// newSchedulerExposure returns the scheduler.Exposure for an exposure that was
+// declared in the Procfile. https and ssl fall back to the cert of the app, and
+// are downgraded to http and tcp when there's no cert.
+func newSchedulerExposure(app *App, e *Exposure) *scheduler.Exposure {
+	exposure := &scheduler.Exposure{
+		External: e.External,
+	}
+
+	cert := e.Cert
+	if cert == "" {
+		cert = app.Cert
+	}
+
+	switch e.Protocol {
+	case ProtocolHTTPS:
+		if cert == "" {
+			exposure.Type = &scheduler.HTTPExposure{}
+		} else {
+			exposure.Type = &scheduler.HTTPSExposure{Cert: cert}
+		}
+	case ProtocolTCP:
+		exposure.Type = &scheduler.TCPExposure{Ports: e.Ports}
+	case ProtocolSSL:
+		if cert == "" {
+			exposure.Type = &scheduler.TCPExposure{Ports: e.Ports}
+		} else {
+			exposure.Type = &scheduler.SSLExposure{Ports: e.Ports, Cert: cert}
+		}
+	default:
+		exposure.Type = &scheduler.HTTPExposure{}
+	}
+
+	return exposure
+}
+
+func processHealthCheck(p Process) *scheduler.HealthCheck {
+	if p.HealthCheck == nil {
+		return nil
+	}
+
+	hc := p.HealthCheck.WithDefaults()
+	return &scheduler.HealthCheck{
+		Path:               hc.Path,
+		Interval:           time.Duration(hc.Interval) * time.Second,
+		Timeout:            time.Duration(hc.Timeout) * time.Second,
+		HealthyThreshold:   hc.HealthyThreshold,
+		UnhealthyThreshold: hc.UnhealthyThreshold,
+	}
+}
+
+func processAutoscaling(p Process) *scheduler.Autoscaling {
+	if p.Autoscaling == nil {
+		return nil
+	}
+
+	a := p.Autoscaling.WithDefaults()
+	return &scheduler.Autoscaling{
+		MinInstances:            uint(a.Min),
+		MaxInstances:            uint(a.Max),
+		TargetCPUUtilization:    a.CPU,
+		TargetMemoryUtilization: a.Memory,
+		ScaleInCooldown:         time.Duration(a.ScaleInCooldown) * time.Second,
+		ScaleOutCooldown:        time.Duration(a.ScaleOutCooldown) * time.Second,
+	}
+}
+
 func processSchedule(name string, p Process) scheduler.Schedule {
 	if p.Cron != nil {
 		return scheduler.CRONSchedule(*p.Cron)
@@ -2,8 +2,11 @@ package empire
 
 import (
 	"testing"
+	"time"
 
 	"github.com/remind101/empire/pkg/headerutil"
+	"github.com/remind101/empire/scheduler"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestReleasesQuery(t *testing.T) {
@@ -31,3 +34,43 @@ func TestReleasesQuery(t *testing.T) {
 
 	tests.Run(t)
 }
+
+func TestProcessExposure(t *testing.T) {
+	app := &App{Name: "acme-inc", Exposure: exposePublic, Cert: "AcmeIncDotCom"}
+
+	tests := []struct {
+		process  string
+		p        Process
+		exposure *scheduler.Exposure
+	}{
+		// Without an exposure in the Procfile, only web is exposed,
+		// using the app settings.
+		{"web", Process{}, &scheduler.Exposure{External: true, Type: &scheduler.HTTPSExposure{Cert: "AcmeIncDotCom"}}},
+		{"worker", Process{}, nil},
+
+		{"grpc", Process{Exposure: &Exposure{Protocol: ProtocolTCP, Ports: []int{50051}}}, &scheduler.Exposure{Type: &scheduler.TCPExposure{Ports: []int{50051}}}},
+		{"admin", Process{Exposure: &Exposure{Protocol: ProtocolSSL, Ports: []int{443}, Cert: "Admin"}}, &scheduler.Exposure{Type: &scheduler.SSLExposure{Ports: []int{443}, Cert: "Admin"}}},
+		{"admin", Process{Exposure: &Exposure{Protocol: ProtocolHTTPS, External: true}}, &scheduler.Exposure{External: true, Type: &scheduler.HTTPSExposure{Cert: "AcmeIncDotCom"}}},
+		{"web", Process{Exposure: &Exposure{Protocol: ProtocolHTTP}}, &scheduler.Exposure{Type: &scheduler.HTTPExposure{}}},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.exposure, processExposure(app, tt.process, tt.p))
+	}
+
+	// Without a cert, https and ssl are downgraded.
+	app = &App{Name: "acme-inc"}
+	assert.Equal(t, &scheduler.HTTPExposure{}, processExposure(app, "web", Process{Exposure: &Exposure{Protocol: ProtocolHTTPS}}).Type)
+	assert.Equal(t, &scheduler.TCPExposure{Ports: []int{443}}, processExposure(app, "admin", Process{Exposure: &Exposure{Protocol: ProtocolSSL, Ports: []int{443}}}).Type)
+}
+
+func TestProcessAutoscaling(t *testing.T) {
+	assert.Nil(t, processAutoscaling(Process{}))
+	assert.Equal(t, &scheduler.Autoscaling{
+		MinInstances:         2,
+		MaxInstances:         20,
+		TargetCPUUtilization: 60,
+		ScaleInCooldown:      300 * time.Second,
+		ScaleOutCooldown:     60 * time.Second,
+	}, processAutoscaling(Process{Autoscaling: &Autoscaling{Min: 2, Max: 20, CPU: 60}}))
+}
@@ -0,0 +1,195 @@
+package empire
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/remind101/pkg/timex"
+	"golang.org/x/net/context"
+)
+
+// DefaultAppAliasTTL is the default amount of time that the previous name of a
+// renamed app can still be used to find it.
+const DefaultAppAliasTTL = 7 * 24 * time.Hour
+
+// AppAlias is a previous name of an app, which can still be used to find the
+// app until it expires.
+type AppAlias struct {
+	// The previous name of the app.
+	Name string `gorm:"primary_key"`
+
+	// The id of the app that was renamed.
+	AppID string
+
+	// The time that the alias stops resolving to the app.
+	ExpiresAt time.Time
+}
+
+// TableName implements the gorm tabler interface.
+func (AppAlias) TableName() string {
+	return "app_aliases"
+}
+
+// appAliasesCreate inserts the alias into the database.
+func appAliasesCreate(db *gorm.DB, alias *AppAlias) (*AppAlias, error) {
+	return alias, db.Create(alias).Error
+}
+
+// appAliasesDestroy removes the alias with the given name, if there is one.
+func appAliasesDestroy(db *gorm.DB, name string) error {
+	return db.Where("name = ?", name).Delete(AppAlias{}).Error
+}
+
+// appsFindByAlias finds the app that has an unexpired alias with the given
+// name.
+func appsFindByAlias(db *gorm.DB, name string) (*App, error) {
+	var alias AppAlias
+	if err := db.Where("name = ? AND expires_at > ?", name, timex.Now()).First(&alias).Error; err != nil {
+		return nil, err
+	}
+
+	return appsFind(db, AppsQuery{ID: &alias.AppID})
+}
+
+// schedulerAliases returns the unexpired previous names of the app, to pass
+// along to the scheduler.
+func schedulerAliases(db *gorm.DB, app *App) ([]string, error) {
+	var aliases []*AppAlias
+	if err := db.Where("app_id = ? AND expires_at > ?", app.ID, timex.Now()).Order("name").Find(&aliases).Error; err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, alias := range aliases {
+		names = append(names, alias.Name)
+	}
+	return names, nil
+}
+
+// renamesService renames apps.
+type renamesService struct {
+	*Empire
+}
+
+// Rename changes the name of the app, and re-submits the latest release to the
+// scheduler, so that anything that's named after the app (e.g. DNS records) is
+// updated. The previous name is kept as an alias for the app until AppAliasTTL
+// has passed, and schedulers keep DNS records for it until then. Stack names
+// aren't changed, because that would mean replacing the stack.
+func (s *renamesService) Rename(ctx context.Context, db *gorm.DB, app *App, name string) error {
+	// The latest release is the canary, so re-releasing it would replace
+	// the stable release.
+	if err := canaryGuard(db, app); err != nil {
+		return err
+	}
+
+	_, err := appsFind(db, AppsQuery{Name: &name})
+	if err == nil {
+		return &ValidationError{Err: fmt.Errorf("An app named %s already exists.", name)}
+	}
+	if err != gorm.RecordNotFound {
+		return err
+	}
+
+	previous := app.Name
+	app.Name = name
+	if err := app.IsValid(); err != nil {
+		return err
+	}
+
+	if err := appsUpdate(db, app); err != nil {
+		return err
+	}
+
+	// The new name belongs to this app now, even if it was an alias for
+	// another app.
+	if err := appAliasesDestroy(db, name); err != nil {
+		return err
+	}
+
+	if _, err := appAliasesCreate(db, &AppAlias{
+		Name:      previous,
+		AppID:     app.ID,
+		ExpiresAt: timex.Now().Add(s.appAliasTTL()),
+	}); err != nil {
+		return err
+	}
+
+	// Access grants refer to apps by name, so they're moved to the new
+	// name. Grants that use a pattern are left alone. Audit events are a
+	// record of what happened, so they keep the previous name.
+	if err := db.Exec(`UPDATE access_grants SET app = ? WHERE app = ?`, name, previous).Error; err != nil {
+		return err
+	}
+
+	if err := s.releases.ReleaseApp(ctx, db, app); err != nil && err != ErrNoReleases {
+		return err
+	}
+
+	return nil
+}
+
+func (s *renamesService) appAliasTTL() time.Duration {
+	if s.AppAliasTTL == 0 {
+		return DefaultAppAliasTTL
+	}
+	return s.AppAliasTTL
+}
+
+// RenameOpts are options provided when renaming an app.
+type RenameOpts struct {
+	// User performing the action.
+	User *User
+
+	// The app to rename.
+	App *App
+
+	// The new name for the app.
+	Name string
+
+	// Commit message
+	Message string
+}
+
+func (opts RenameOpts) Validate(e *Empire) error {
+	if err := e.Authorize(opts.User, opts.App.Name, RoleAdmin); err != nil {
+		return err
+	}
+	if opts.Name == opts.App.Name {
+		return &ValidationError{Err: fmt.Errorf("%s is already named %s.", opts.App.Name, opts.Name)}
+	}
+	return e.requireMessages(opts.Message)
+}
+
+// Rename changes the name of an app. The previous name can still be used to
+// find the app until AppAliasTTL has passed.
+func (e *Empire) Rename(ctx context.Context, opts RenameOpts) error {
+	if err := opts.Validate(e); err != nil {
+		return err
+	}
+
+	previous := opts.App.Name
+
+	tx := e.db.Begin()
+
+	if err := e.renames.Rename(ctx, tx, opts.App, opts.Name); err != nil {
+		tx.Rollback()
+		opts.App.Name = previous
+		return err
+	}
+
+	if err := e.publishEvent(tx, RenameEvent{
+		User:         opts.User.Name,
+		App:          opts.App.Name,
+		PreviousName: previous,
+		Message:      opts.Message,
+		app:          opts.App,
+	}); err != nil {
+		tx.Rollback()
+		opts.App.Name = previous
+		return err
+	}
+
+	return tx.Commit().Error
+}
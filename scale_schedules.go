@@ -0,0 +1,442 @@
+package empire
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/remind101/empire/pkg/cron"
+	"github.com/remind101/pkg/timex"
+	"golang.org/x/net/context"
+)
+
+// DefaultScaleScheduleInterval is the default interval at which scale schedules
+// are checked.
+const DefaultScaleScheduleInterval = time.Minute
+
+// The advisory lock key that's held while applying scale schedules, so that
+// only one Empire process applies them.
+const scaleScheduleLockKey = 0x656d7002
+
+// AllProcesses can be used as the process type in the formation of a
+// ScaleSchedule, to scale all processes that aren't listed explicitly.
+const AllProcesses = "*"
+
+// ErrScaleScheduleFormation is returned when a ScaleSchedule has both, or
+// neither, of a formation and restore.
+var ErrScaleScheduleFormation = &ValidationError{Err: errors.New("A scale schedule needs either a formation, or to restore the previous formation.")}
+
+// Quantities maps process types to a quantity.
+type Quantities map[string]int
+
+// Scan implements the sql.Scanner interface.
+func (q *Quantities) Scan(src interface{}) error {
+	if src == nil {
+		*q = nil
+		return nil
+	}
+
+	bytes, ok := src.([]byte)
+	if !ok {
+		return error(errors.New("Scan source was not []bytes"))
+	}
+
+	quantities := make(Quantities)
+	if err := json.Unmarshal(bytes, &quantities); err != nil {
+		return err
+	}
+	*q = quantities
+
+	return nil
+}
+
+// Value implements the driver.Value interface.
+func (q Quantities) Value() (driver.Value, error) {
+	if q == nil {
+		return nil, nil
+	}
+
+	raw, err := json.Marshal(q)
+	if err != nil {
+		return nil, err
+	}
+
+	return driver.Value(raw), nil
+}
+
+// ScaleSchedule scales the processes of an app at times matching a cron
+// expression. For example, a staging app can be scaled down every evening,
+// and restored every morning.
+type ScaleSchedule struct {
+	ID string
+
+	AppID string
+	App   *App
+
+	// A cron expression, evaluated in UTC.
+	Schedule string
+
+	// The quantity to scale each process to. AllProcesses applies to any
+	// processes that aren't listed.
+	Formation Quantities
+
+	// If true, the quantities from before the last scale schedule of the
+	// app ran are restored, instead of scaling to a formation.
+	Restore bool
+
+	// The quantities of the processes before this schedule last ran, which
+	// are used to restore them.
+	Previous Quantities
+
+	// The next time that the schedule runs.
+	NextRunAt time.Time
+
+	// The last time that the schedule ran.
+	LastRunAt *time.Time
+
+	CreatedAt *time.Time
+}
+
+// BeforeCreate sets created_at, and the time of the first run, before
+// inserting.
+func (s *ScaleSchedule) BeforeCreate() error {
+	t := timex.Now()
+	s.CreatedAt = &t
+	return s.schedule(t)
+}
+
+// schedule sets the next run to the first time after t that matches the
+// schedule.
+func (s *ScaleSchedule) schedule(t time.Time) error {
+	expr, err := cron.Parse(s.Schedule)
+	if err != nil {
+		return err
+	}
+	s.NextRunAt = expr.Next(t.UTC())
+	return nil
+}
+
+// Validate checks that the schedule is a valid cron expression, and that the
+// schedule has either a formation or restores the previous formation.
+func (s *ScaleSchedule) Validate() error {
+	if _, err := cron.Parse(s.Schedule); err != nil {
+		return &ValidationError{Err: err}
+	}
+
+	if s.Restore == (len(s.Formation) > 0) {
+		return ErrScaleScheduleFormation
+	}
+
+	for process, quantity := range s.Formation {
+		if quantity < 0 {
+			return &ValidationError{Err: fmt.Errorf("%d is not a valid quantity for %s", quantity, process)}
+		}
+	}
+
+	return nil
+}
+
+// ScaleSchedulesQuery is a scope implementation for common things to filter
+// scale schedules by.
+type ScaleSchedulesQuery struct {
+	// If provided, finds the scale schedule with the given id.
+	ID *string
+
+	// If provided, filters scale schedules belonging to the given app.
+	App *App
+}
+
+// scope implements the scope interface.
+func (q ScaleSchedulesQuery) scope(db *gorm.DB) *gorm.DB {
+	var scope composedScope
+
+	if q.ID != nil {
+		scope = append(scope, idEquals(*q.ID))
+	}
+
+	if q.App != nil {
+		scope = append(scope, forApp(q.App))
+	}
+
+	scope = append(scope, order("created_at"))
+
+	return scope.scope(db)
+}
+
+// scaleSchedulesFind returns the first matching scale schedule.
+func scaleSchedulesFind(db *gorm.DB, scope scope) (*ScaleSchedule, error) {
+	var schedule ScaleSchedule
+	return &schedule, first(db, scope, &schedule)
+}
+
+// scaleSchedules returns all scale schedules matching the scope.
+func scaleSchedules(db *gorm.DB, scope scope) ([]*ScaleSchedule, error) {
+	var schedules []*ScaleSchedule
+	return schedules, find(db, scope, &schedules)
+}
+
+// scaleSchedulesCreate inserts a ScaleSchedule into the database.
+func scaleSchedulesCreate(db *gorm.DB, schedule *ScaleSchedule) (*ScaleSchedule, error) {
+	return schedule, db.Create(schedule).Error
+}
+
+// scaleSchedulesUpdate updates the run times, and previous quantities, of an
+// existing ScaleSchedule.
+func scaleSchedulesUpdate(db *gorm.DB, schedule *ScaleSchedule) error {
+	return db.Exec(`UPDATE scale_schedules SET previous = ?, next_run_at = ?, last_run_at = ? WHERE id = ?`, schedule.Previous, schedule.NextRunAt, schedule.LastRunAt, schedule.ID).Error
+}
+
+// scaleSchedulesDestroy removes a ScaleSchedule from the database.
+func scaleSchedulesDestroy(db *gorm.DB, schedule *ScaleSchedule) error {
+	return db.Delete(schedule).Error
+}
+
+// scaleSchedulesService applies scale schedules when they're due.
+type scaleSchedulesService struct {
+	*Empire
+}
+
+// Create validates the schedule against the formation of the latest release of
+// the app, and inserts it.
+func (s *scaleSchedulesService) Create(ctx context.Context, db *gorm.DB, schedule *ScaleSchedule) (*ScaleSchedule, error) {
+	if err := schedule.Validate(); err != nil {
+		return schedule, err
+	}
+
+	formation, err := currentFormation(db, schedule.App)
+	if err != nil {
+		return schedule, err
+	}
+
+	for process := range schedule.Formation {
+		if process == AllProcesses {
+			continue
+		}
+		if process == releaseProcessType {
+			return schedule, ErrScaleRelease
+		}
+		if _, ok := formation[process]; !ok {
+			return schedule, &ValidationError{Err: fmt.Errorf("no %s process type in release", process)}
+		}
+	}
+
+	schedule.AppID = schedule.App.ID
+	return scaleSchedulesCreate(db, schedule)
+}
+
+// Run applies all of the scale schedules that are due. If another process is
+// already applying scale schedules, this returns immediately.
+func (s *scaleSchedulesService) Run(ctx context.Context) error {
+	tx := s.db.Begin()
+
+	var locked bool
+	if err := tx.Raw(`SELECT pg_try_advisory_xact_lock(?)`, scaleScheduleLockKey).Row().Scan(&locked); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if !locked {
+		tx.Rollback()
+		return nil
+	}
+
+	now := timex.Now()
+
+	var due []*ScaleSchedule
+	if err := tx.Preload("App").Where("next_run_at <= ?", now).Order("next_run_at").Find(&due).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	for _, schedule := range due {
+		if err := s.apply(ctx, tx, schedule); err != nil {
+			log.Printf("scale schedules: error applying %s to %s: %v\n", schedule.Schedule, schedule.App.Name, err)
+		}
+
+		schedule.LastRunAt = &now
+		if err := schedule.schedule(now); err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		if err := scaleSchedulesUpdate(tx, schedule); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit().Error
+}
+
+// apply scales the app to the formation of the schedule, or restores the
+// formation from before the last schedule ran. The quantities from before the
+// schedule ran are stored on the schedule.
+func (s *scaleSchedulesService) apply(ctx context.Context, db *gorm.DB, schedule *ScaleSchedule) error {
+	app := schedule.App
+
+	formation, err := currentFormation(db, app)
+	if err != nil {
+		return err
+	}
+
+	target := schedule.Formation
+	if schedule.Restore {
+		target, err = s.previous(db, app)
+		if err != nil {
+			return err
+		}
+	}
+
+	var types []string
+	for name := range formation {
+		types = append(types, name)
+	}
+	sort.Strings(types)
+
+	previous := make(Quantities)
+	var updates []*ProcessUpdate
+	for _, name := range types {
+		p := formation[name]
+		if name == releaseProcessType {
+			continue
+		}
+
+		quantity, ok := target[name]
+		if !ok {
+			if quantity, ok = target[AllProcesses]; !ok || schedule.Restore {
+				continue
+			}
+		}
+
+		if quantity == p.Quantity {
+			continue
+		}
+
+		// The autoscaling policy controls the quantity of autoscaled
+		// processes.
+		if p.Autoscaling != nil {
+			log.Printf("scale schedules: not scaling %s on %s, since it's autoscaled\n", name, app.Name)
+			continue
+		}
+
+		previous[name] = p.Quantity
+		updates = append(updates, &ProcessUpdate{
+			Process:  name,
+			Quantity: quantity,
+		})
+	}
+
+	// If nothing changed (e.g. the app is already asleep), the quantities
+	// from the last run are kept, so that they can still be restored.
+	if !schedule.Restore && len(previous) > 0 {
+		schedule.Previous = previous
+	}
+
+	if len(updates) == 0 {
+		return nil
+	}
+
+	_, err = s.Scale(ctx, ScaleOpts{
+		User:    systemUser(),
+		App:     app,
+		Updates: updates,
+		Message: fmt.Sprintf("Scale schedule %s (%s)", schedule.ID, schedule.Schedule),
+	})
+	return err
+}
+
+// previous returns the quantities from before the last scale schedule of the
+// app, that wasn't a restore, ran.
+func (s *scaleSchedulesService) previous(db *gorm.DB, app *App) (Quantities, error) {
+	var last ScaleSchedule
+	err := forApp(app).scope(db).Where("restore = ? AND last_run_at IS NOT NULL", false).Order("last_run_at desc").First(&last).Error
+	if err == gorm.RecordNotFound {
+		return nil, nil
+	}
+	return last.Previous, err
+}
+
+// ScaleSchedules returns the scale schedules of an app.
+func (e *Empire) ScaleSchedules(q ScaleSchedulesQuery) ([]*ScaleSchedule, error) {
+	return scaleSchedules(e.db, q)
+}
+
+// ScaleSchedulesFind returns the first scale schedule matching the query.
+func (e *Empire) ScaleSchedulesFind(q ScaleSchedulesQuery) (*ScaleSchedule, error) {
+	return scaleSchedulesFind(e.db, q)
+}
+
+// ScaleSchedulesCreateOpts are options provided when adding a scale schedule to
+// an app.
+type ScaleSchedulesCreateOpts struct {
+	// User performing the action.
+	User *User
+
+	// The schedule to add. Its App is the app that it's added to.
+	Schedule *ScaleSchedule
+}
+
+func (opts ScaleSchedulesCreateOpts) Validate(e *Empire) error {
+	return e.Authorize(opts.User, opts.Schedule.App.Name, RoleDeployer)
+}
+
+// ScaleSchedulesCreate adds a scale schedule to an app.
+func (e *Empire) ScaleSchedulesCreate(ctx context.Context, opts ScaleSchedulesCreateOpts) (*ScaleSchedule, error) {
+	if err := opts.Validate(e); err != nil {
+		return nil, err
+	}
+
+	return e.scaleSchedules.Create(ctx, e.db, opts.Schedule)
+}
+
+// ScaleSchedulesDestroyOpts are options provided when removing a scale schedule
+// from an app.
+type ScaleSchedulesDestroyOpts struct {
+	// User performing the action.
+	User *User
+
+	// The schedule to remove.
+	Schedule *ScaleSchedule
+}
+
+func (opts ScaleSchedulesDestroyOpts) Validate(e *Empire) error {
+	return e.Authorize(opts.User, opts.Schedule.App.Name, RoleDeployer)
+}
+
+// ScaleSchedulesDestroy removes a scale schedule from an app.
+func (e *Empire) ScaleSchedulesDestroy(ctx context.Context, opts ScaleSchedulesDestroyOpts) error {
+	if err := opts.Validate(e); err != nil {
+		return err
+	}
+
+	return scaleSchedulesDestroy(e.db, opts.Schedule)
+}
+
+// RunScaleSchedules applies the scale schedules that are due every interval,
+// until the context is canceled.
+func (e *Empire) RunScaleSchedules(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := e.scaleSchedules.Run(ctx); err != nil {
+			log.Printf("scale schedules: error running scale schedules: %v\n", err)
+		}
+	}
+}
+
+// ApplyScaleSchedules applies the scale schedules that are due.
+func (e *Empire) ApplyScaleSchedules(ctx context.Context) error {
+	return e.scaleSchedules.Run(ctx)
+}
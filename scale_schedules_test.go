@@ -0,0 +1,52 @@
+package empire
+
+import (
+	"testing"
+	"time"
+
+	"github.com/remind101/pkg/timex"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScaleSchedule_Validate(t *testing.T) {
+	tests := []struct {
+		s   ScaleSchedule
+		err error
+	}{
+		{ScaleSchedule{Schedule: "0 20 * * *", Formation: Quantities{"*": 0}}, nil},
+		{ScaleSchedule{Schedule: "0 7 * * MON-FRI", Restore: true}, nil},
+		{ScaleSchedule{Schedule: "0 7 * * MON-FRI"}, ErrScaleScheduleFormation},
+		{ScaleSchedule{Schedule: "0 7 * * *", Formation: Quantities{"web": 1}, Restore: true}, ErrScaleScheduleFormation},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.err, tt.s.Validate())
+	}
+
+	assert.Error(t, (&ScaleSchedule{Schedule: "0 25 * * *", Formation: Quantities{"*": 0}}).Validate())
+	assert.Error(t, (&ScaleSchedule{Schedule: "0 20 * * *", Formation: Quantities{"web": -1}}).Validate())
+}
+
+func TestScaleSchedule_BeforeCreate(t *testing.T) {
+	now := time.Date(2016, time.November, 10, 21, 0, 0, 0, time.UTC)
+	timex.Now = func() time.Time { return now }
+	defer func() { timex.Now = time.Now }()
+
+	s := &ScaleSchedule{Schedule: "0 20 * * *", Formation: Quantities{"*": 0}}
+	assert.NoError(t, s.BeforeCreate())
+	assert.Equal(t, time.Date(2016, time.November, 11, 20, 0, 0, 0, time.UTC), s.NextRunAt)
+	assert.Equal(t, now, *s.CreatedAt)
+}
+
+func TestQuantities_Scan(t *testing.T) {
+	var q Quantities
+	assert.NoError(t, q.Scan([]byte(`{"web":2,"*":0}`)))
+	assert.Equal(t, Quantities{"web": 2, "*": 0}, q)
+
+	assert.NoError(t, q.Scan(nil))
+	assert.Nil(t, q)
+
+	v, err := Quantities{"web": 2}.Value()
+	assert.NoError(t, err)
+	assert.Equal(t, []byte(`{"web":2}`), v)
+}
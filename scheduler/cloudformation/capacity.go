@@ -0,0 +1,65 @@
+package cloudformation
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecs"
+	"github.com/remind101/empire/pkg/bytesize"
+	"github.com/remind101/empire/scheduler"
+	"golang.org/x/net/context"
+)
+
+// The maximum number of container instances that can be described at once.
+const describeContainerInstancesLimit = 100
+
+// Hosts returns the active container instances in the ECS cluster, with the
+// CPU and memory that's left on each of them.
+func (s *Scheduler) Hosts(ctx context.Context) ([]*scheduler.Host, error) {
+	var arns []*string
+	if err := s.ecs.ListContainerInstancesPages(&ecs.ListContainerInstancesInput{
+		Cluster: aws.String(s.Cluster),
+	}, func(p *ecs.ListContainerInstancesOutput, lastPage bool) bool {
+		arns = append(arns, p.ContainerInstanceArns...)
+		return true
+	}); err != nil {
+		return nil, err
+	}
+
+	var hosts []*scheduler.Host
+	for len(arns) > 0 {
+		n := len(arns)
+		if n > describeContainerInstancesLimit {
+			n = describeContainerInstancesLimit
+		}
+
+		resp, err := s.ecs.DescribeContainerInstances(&ecs.DescribeContainerInstancesInput{
+			Cluster:            aws.String(s.Cluster),
+			ContainerInstances: arns[:n],
+		})
+		if err != nil {
+			return nil, err
+		}
+		arns = arns[n:]
+
+		for _, ci := range resp.ContainerInstances {
+			// Tasks won't be placed on instances that are
+			// draining, or that lost their agent.
+			if aws.StringValue(ci.Status) != "ACTIVE" || !aws.BoolValue(ci.AgentConnected) {
+				continue
+			}
+
+			h := &scheduler.Host{ID: aws.StringValue(ci.ContainerInstanceArn)}
+			for _, r := range ci.RemainingResources {
+				switch aws.StringValue(r.Name) {
+				case "CPU":
+					h.CPUShares = uint(aws.Int64Value(r.IntegerValue))
+				case "MEMORY":
+					// ECS reports memory in MiB.
+					h.MemoryLimit = uint(aws.Int64Value(r.IntegerValue)) * bytesize.MB
+				}
+			}
+			hosts = append(hosts, h)
+		}
+	}
+
+	return hosts, nil
+}
@@ -0,0 +1,53 @@
+package cloudformation
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecs"
+	"github.com/remind101/empire/pkg/bytesize"
+	"github.com/remind101/empire/scheduler"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestScheduler_Hosts(t *testing.T) {
+	e := new(mockECSClient)
+	s := &Scheduler{
+		Cluster: "cluster",
+		ecs:     e,
+	}
+
+	e.On("ListContainerInstancesPages", &ecs.ListContainerInstancesInput{
+		Cluster: aws.String("cluster"),
+	}).Return(&ecs.ListContainerInstancesOutput{
+		ContainerInstanceArns: []*string{aws.String("i-a"), aws.String("i-b"), aws.String("i-c")},
+	}, nil)
+
+	remaining := func(cpu, memory int64) []*ecs.Resource {
+		return []*ecs.Resource{
+			{Name: aws.String("CPU"), IntegerValue: aws.Int64(cpu)},
+			{Name: aws.String("MEMORY"), IntegerValue: aws.Int64(memory)},
+			{Name: aws.String("PORTS"), StringSetValue: []*string{aws.String("22")}},
+		}
+	}
+
+	e.On("DescribeContainerInstances", &ecs.DescribeContainerInstancesInput{
+		Cluster:            aws.String("cluster"),
+		ContainerInstances: []*string{aws.String("i-a"), aws.String("i-b"), aws.String("i-c")},
+	}).Return(&ecs.DescribeContainerInstancesOutput{
+		ContainerInstances: []*ecs.ContainerInstance{
+			{ContainerInstanceArn: aws.String("i-a"), Status: aws.String("ACTIVE"), AgentConnected: aws.Bool(true), RemainingResources: remaining(1024, 2048)},
+			{ContainerInstanceArn: aws.String("i-b"), Status: aws.String("DRAINING"), AgentConnected: aws.Bool(true), RemainingResources: remaining(2048, 4096)},
+			{ContainerInstanceArn: aws.String("i-c"), Status: aws.String("ACTIVE"), AgentConnected: aws.Bool(false), RemainingResources: remaining(2048, 4096)},
+		},
+	}, nil)
+
+	hosts, err := s.Hosts(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []*scheduler.Host{
+		{ID: "i-a", CPUShares: 1024, MemoryLimit: 2048 * bytesize.MB},
+	}, hosts)
+
+	e.AssertExpectations(t)
+}
@@ -11,6 +11,7 @@ import (
 	"hash/crc32"
 	"html/template"
 	"io"
+	"sort"
 	"strings"
 	"time"
 
@@ -59,6 +60,18 @@ var (
 	// Controls how long we'll wait between requests to describe services when
 	// waiting for a deployment to stabilize
 	pollServicesWait = 20 * time.Second
+
+	// Controls the maximum amount of time we'll wait for a deployment to
+	// stabilize before considering it failed.
+	stabilizeTimeout = 20 * time.Minute
+
+	// Controls how long we'll wait between requests to describe a change
+	// set when waiting for it to be created.
+	pollChangeSetWait = 2 * time.Second
+
+	// Controls the maximum amount of time we'll wait for a change set to be
+	// created.
+	changeSetTimeout = 5 * time.Minute
 )
 
 // CloudFormation limits
@@ -82,6 +95,10 @@ var DefaultStackNameTemplate = template.Must(template.New("stack_name").Parse("{
 // app.
 var errNoStack = errors.New("no stack for app found")
 
+// ErrDetachedSecrets is returned by Run when a detached process is run for an
+// app with secrets.
+var ErrDetachedSecrets = errors.New("detached processes can't be run for apps with secrets; run the process attached instead (without -d)")
+
 // cloudformationClient duck types the cloudformation.CloudFormation interface
 // that we use.
 type cloudformationClient interface {
@@ -94,6 +111,9 @@ type cloudformationClient interface {
 	WaitUntilStackCreateComplete(*cloudformation.DescribeStacksInput) error
 	WaitUntilStackUpdateComplete(*cloudformation.DescribeStacksInput) error
 	ValidateTemplate(*cloudformation.ValidateTemplateInput) (*cloudformation.ValidateTemplateOutput, error)
+	CreateChangeSet(*cloudformation.CreateChangeSetInput) (*cloudformation.CreateChangeSetOutput, error)
+	DescribeChangeSet(*cloudformation.DescribeChangeSetInput) (*cloudformation.DescribeChangeSetOutput, error)
+	DeleteChangeSet(*cloudformation.DeleteChangeSetInput) (*cloudformation.DeleteChangeSetOutput, error)
 }
 
 // ecsClient duck types the ecs.ECS interface that we use.
@@ -106,6 +126,8 @@ type ecsClient interface {
 	StopTask(*ecs.StopTaskInput) (*ecs.StopTaskOutput, error)
 	UpdateService(*ecs.UpdateServiceInput) (*ecs.UpdateServiceOutput, error)
 	DescribeServices(*ecs.DescribeServicesInput) (*ecs.DescribeServicesOutput, error)
+	ListContainerInstancesPages(*ecs.ListContainerInstancesInput, func(*ecs.ListContainerInstancesOutput, bool) bool) error
+	DescribeContainerInstances(*ecs.DescribeContainerInstancesInput) (*ecs.DescribeContainerInstancesOutput, error)
 }
 
 // s3Client duck types the s3.S3 interface that we use.
@@ -184,17 +206,37 @@ func (s *Scheduler) SubmitWithOptions(ctx context.Context, app *scheduler.App, s
 		return err
 	}
 
-	err = s.submit(ctx, tx, app, ss, opts)
+	stack, err := s.submit(ctx, tx, app, ss, opts)
 	if err != nil {
 		tx.Rollback()
 		return err
 	}
-	return tx.Commit()
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	// If a status stream was provided, wait for the new deployments to
+	// stabilize. This happens outside of the transaction, since it can
+	// take a while.
+	if stack != nil {
+		if err := s.waitUntilStable(ctx, stack, ss); err != nil {
+			if _, ok := err.(*scheduler.UnstableError); ok {
+				return err
+			}
+			logger.Warn(ctx, fmt.Sprintf("error waiting for submit to stabilize: %v", err))
+		}
+	}
+
+	return nil
 }
 
-// Submit creates (or updates) the CloudFormation stack for the app.
-func (s *Scheduler) submit(ctx context.Context, tx *sql.Tx, app *scheduler.App, ss scheduler.StatusStream, opts SubmitOptions) error {
+// Submit creates (or updates) the CloudFormation stack for the app. If a
+// StatusStream is provided, it waits for the stack operation to complete and
+// returns the stack.
+func (s *Scheduler) submit(ctx context.Context, tx *sql.Tx, app *scheduler.App, ss scheduler.StatusStream, opts SubmitOptions) (*cloudformation.Stack, error) {
 	stackName, err := s.stackName(app.ID)
+	exists := err == nil
 	if err == errNoStack {
 		t := s.StackNameTemplate
 		if t == nil {
@@ -202,19 +244,19 @@ func (s *Scheduler) submit(ctx context.Context, tx *sql.Tx, app *scheduler.App,
 		}
 		buf := new(bytes.Buffer)
 		if err := t.Execute(buf, app); err != nil {
-			return fmt.Errorf("error generating stack name: %v", err)
+			return nil, fmt.Errorf("error generating stack name: %v", err)
 		}
 		stackName = buf.String()
 		if _, err := tx.Exec(`INSERT INTO stacks (app_id, stack_name) VALUES ($1, $2)`, app.ID, stackName); err != nil {
-			return err
+			return nil, err
 		}
 	} else if err != nil {
-		return err
+		return nil, err
 	}
 
 	t, err := s.createTemplate(ctx, app)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	scheduler.Publish(ctx, ss, fmt.Sprintf("Created cloudformation template: %v (%d/%d bytes)", *t.URL, t.Size, MaxTemplateSize))
@@ -224,28 +266,17 @@ func (s *Scheduler) submit(ctx context.Context, tx *sql.Tx, app *scheduler.App,
 		&cloudformation.Tag{Key: aws.String("empire.app.name"), Value: aws.String(app.Name)},
 	)
 
-	// Build parameters for the stack.
-	parameters := []*cloudformation.Parameter{
-		// FIXME: Remove this in favor of a Restart method.
-		{
-			ParameterKey:   aws.String(restartParameter),
-			ParameterValue: aws.String(newUUID()),
-		},
-	}
-
-	if opts.NoDNS != nil {
-		parameters = append(parameters, &cloudformation.Parameter{
-			ParameterKey:   aws.String("DNS"),
-			ParameterValue: aws.String(fmt.Sprintf("%t", *opts.NoDNS)),
-		})
+	// Autoscaling changes the desired count of services outside of
+	// CloudFormation, so the current desired counts are kept.
+	var desired map[string]uint
+	if exists && autoscaled(app) {
+		desired, err = s.DesiredCounts(ctx, app.ID)
+		if err != nil {
+			return nil, fmt.Errorf("error getting desired counts: %v", err)
+		}
 	}
 
-	for _, p := range app.Processes {
-		parameters = append(parameters, &cloudformation.Parameter{
-			ParameterKey:   aws.String(scaleParameter(p.Type)),
-			ParameterValue: aws.String(fmt.Sprintf("%d", p.Instances)),
-		})
-	}
+	parameters := stackParameters(app, opts, desired)
 
 	output := make(chan stackOperationOutput, 1)
 	_, err = s.cloudformation.DescribeStacks(&cloudformation.DescribeStacksInput{
@@ -258,7 +289,7 @@ func (s *Scheduler) submit(ctx context.Context, tx *sql.Tx, app *scheduler.App,
 			Tags:       tags,
 			Parameters: parameters,
 		}, output, ss); err != nil {
-			return fmt.Errorf("error creating stack: %v", err)
+			return nil, fmt.Errorf("error creating stack: %v", err)
 		}
 	} else if err == nil {
 		if err := s.updateStack(ctx, &updateStackInput{
@@ -268,22 +299,110 @@ func (s *Scheduler) submit(ctx context.Context, tx *sql.Tx, app *scheduler.App,
 			// TODO: Update Go client
 			// Tags:         tags,
 		}, output, ss); err != nil {
-			return err
+			return nil, err
 		}
 	} else {
-		return fmt.Errorf("error describing stack: %v", err)
+		return nil, fmt.Errorf("error describing stack: %v", err)
 	}
 
 	if ss != nil {
 		o := <-output
-		if o.err != nil || o.stack == nil {
-			return o.err
+		return o.stack, o.err
+	}
+	return nil, nil
+}
+
+// stackParameters builds the parameters to provide to the stack for the app.
+// desired is the current desired count of each process, which is used instead
+// of the number of instances for autoscaled processes.
+func stackParameters(app *scheduler.App, opts SubmitOptions, desired map[string]uint) []*cloudformation.Parameter {
+	parameters := []*cloudformation.Parameter{
+		// FIXME: Remove this in favor of a Restart method.
+		{
+			ParameterKey:   aws.String(restartParameter),
+			ParameterValue: aws.String(newUUID()),
+		},
+	}
+
+	// When starting a canary, we don't want to restart the stable
+	// processes, so we keep the existing restart key.
+	if app.Stable != nil {
+		parameters[0] = &cloudformation.Parameter{
+			ParameterKey:     aws.String(restartParameter),
+			UsePreviousValue: aws.Bool(true),
 		}
-		if err := s.waitUntilStable(ctx, o.stack, ss); err != nil {
-			logger.Warn(ctx, fmt.Sprintf("error waiting for submit to stabilize: %v", err))
+	}
+
+	if opts.NoDNS != nil {
+		parameters = append(parameters, &cloudformation.Parameter{
+			ParameterKey:   aws.String("DNS"),
+			ParameterValue: aws.String(fmt.Sprintf("%t", *opts.NoDNS)),
+		})
+	}
+
+	if app.Stable != nil {
+		for _, p := range app.Stable.Processes {
+			parameters = append(parameters, &cloudformation.Parameter{
+				ParameterKey:   aws.String(scaleParameter(p.Type)),
+				ParameterValue: aws.String(fmt.Sprintf("%d", instances(p, desired))),
+			})
+		}
+
+		for _, p := range app.Processes {
+			if p.Schedule != nil {
+				continue
+			}
+
+			parameters = append(parameters, &cloudformation.Parameter{
+				ParameterKey:   aws.String(canaryScaleParameter(p.Type)),
+				ParameterValue: aws.String(fmt.Sprintf("%d", p.Instances)),
+			})
+		}
+	} else {
+		for _, p := range app.Processes {
+			parameters = append(parameters, &cloudformation.Parameter{
+				ParameterKey:   aws.String(scaleParameter(p.Type)),
+				ParameterValue: aws.String(fmt.Sprintf("%d", instances(p, desired))),
+			})
 		}
 	}
-	return nil
+
+	return parameters
+}
+
+// instances returns the number of instances of the process to run. For
+// autoscaled processes, the desired count of the existing service is kept, as
+// long as it's within the bounds of the autoscaling policy.
+func instances(p *scheduler.Process, desired map[string]uint) uint {
+	a := p.Autoscaling
+	if a == nil {
+		return p.Instances
+	}
+
+	n, ok := desired[p.Type]
+	if !ok {
+		n = p.Instances
+	}
+	if n < a.MinInstances {
+		return a.MinInstances
+	}
+	if n > a.MaxInstances {
+		return a.MaxInstances
+	}
+	return n
+}
+
+// autoscaled returns true if any of the processes of the app are autoscaled.
+func autoscaled(app *scheduler.App) bool {
+	for _, p := range app.Processes {
+		if p.Autoscaling != nil {
+			return true
+		}
+	}
+	if app.Stable != nil {
+		return autoscaled(app.Stable)
+	}
+	return false
 }
 
 func (s *Scheduler) waitUntilStable(ctx context.Context, stack *cloudformation.Stack, ss scheduler.StatusStream) error {
@@ -291,12 +410,11 @@ func (s *Scheduler) waitUntilStable(ctx context.Context, stack *cloudformation.S
 	if err != nil {
 		return err
 	}
-	deploymentStatuses := s.waitForDeploymentsToStabilize(ctx, deployments)
+	deploymentStatuses, errc := s.waitForDeploymentsToStabilize(ctx, deployments)
 	for status := range deploymentStatuses {
 		scheduler.Publish(ctx, ss, fmt.Sprintf("Service %s became %s", status.deployment.process, status))
 	}
-	// TODO publish notification to empire
-	return nil
+	return <-errc
 }
 
 type deploymentStatus struct {
@@ -308,8 +426,13 @@ func (d *deploymentStatus) String() string {
 	return d.status
 }
 
-func (s *Scheduler) waitForDeploymentsToStabilize(ctx context.Context, deployments map[string]*ecsDeployment) <-chan *deploymentStatus {
+// waitForDeploymentsToStabilize polls the ECS services until the deployments
+// have stabilized, sending a deploymentStatus as each one changes. If the
+// deployments don't stabilize within stabilizeTimeout, an UnstableError is sent
+// on the returned error channel.
+func (s *Scheduler) waitForDeploymentsToStabilize(ctx context.Context, deployments map[string]*ecsDeployment) (<-chan *deploymentStatus, <-chan error) {
 	ch := make(chan *deploymentStatus)
+	errc := make(chan error, 1)
 
 	wait := func(deployments map[string]*ecsDeployment) (bool, error) {
 		arns := make([]*string, 0, len(deployments))
@@ -325,7 +448,7 @@ func (s *Scheduler) waitForDeploymentsToStabilize(ctx context.Context, deploymen
 		for _, service := range services {
 			d, ok := deployments[*service.ServiceArn]
 			if !ok {
-				return false, fmt.Errorf("missing deployment for: %s", service.ServiceArn)
+				return false, fmt.Errorf("missing deployment for: %s", *service.ServiceArn)
 			}
 			primary := false
 			stable := len(service.Deployments) == 1
@@ -349,6 +472,10 @@ func (s *Scheduler) waitForDeploymentsToStabilize(ctx context.Context, deploymen
 	}
 
 	go func(deployments map[string]*ecsDeployment) {
+		defer close(errc)
+		defer close(ch)
+
+		timeout := s.after(stabilizeTimeout)
 		keepWaiting := true
 		var err error
 		for keepWaiting && len(deployments) > 0 {
@@ -358,13 +485,24 @@ func (s *Scheduler) waitForDeploymentsToStabilize(ctx context.Context, deploymen
 				break
 			}
 			if keepWaiting {
-				<-s.after(pollServicesWait)
+				select {
+				case <-timeout:
+					var processes []string
+					for _, d := range deployments {
+						processes = append(processes, d.process)
+					}
+					sort.Strings(processes)
+					errc <- &scheduler.UnstableError{
+						Err: fmt.Errorf("timed out after %v waiting for %s to stabilize", stabilizeTimeout, strings.Join(processes, ", ")),
+					}
+					return
+				case <-s.after(pollServicesWait):
+				}
 			}
 		}
-		close(ch)
 	}(deployments)
 
-	return ch
+	return ch, errc
 }
 
 // createTemplate takes a scheduler.App, and returns a validated cloudformation
@@ -730,6 +868,37 @@ func (s *Scheduler) Instances(ctx context.Context, app string) ([]*scheduler.Ins
 	return instances, nil
 }
 
+// DesiredCounts implements the scheduler.DesiredCounter interface. It returns
+// the desired count of the ECS service for each process, which reflects any
+// changes that were made outside of CloudFormation.
+func (s *Scheduler) DesiredCounts(ctx context.Context, app string) (map[string]uint, error) {
+	processes, err := s.Services(app)
+	if err != nil {
+		return nil, err
+	}
+
+	var arns []*string
+	for _, arn := range processes {
+		arns = append(arns, aws.String(arn))
+	}
+
+	services, err := s.services(arns)
+	if err != nil {
+		return nil, err
+	}
+
+	desired := make(map[string]uint)
+	for process, arn := range processes {
+		for _, service := range services {
+			if *service.ServiceArn == arn {
+				desired[process] = uint(*service.DesiredCount)
+			}
+		}
+	}
+
+	return desired, nil
+}
+
 func (s *Scheduler) services(arns []*string) ([]*ecs.Service, error) {
 	var services []*ecs.Service
 	for _, chunk := range chunkStrings(arns, MaxDescribeServices) {
@@ -847,6 +1016,15 @@ func (m *Scheduler) Run(ctx context.Context, app *scheduler.App, process *schedu
 		return errors.New("running an attached process is not implemented by the ECS manager.")
 	}
 
+	// Stacks reference secrets in their task definitions, but the version
+	// of aws-sdk-go that's vendored doesn't have the Secrets and
+	// ExecutionRoleArn fields for RegisterTaskDefinition, so ECS would
+	// have no way to resolve them here. Attached runs resolve secrets
+	// themselves.
+	if len(app.Secrets) > 0 {
+		return ErrDetachedSecrets
+	}
+
 	t, ok := m.Template.(interface {
 		ContainerDefinition(*scheduler.App, *scheduler.Process) *ecs.ContainerDefinition
 	})
@@ -894,7 +1072,10 @@ func extractProcessData(value string) map[string]string {
 	pairs := strings.Split(value, ",")
 
 	for _, p := range pairs {
-		parts := strings.Split(p, "=")
+		parts := strings.SplitN(p, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
 		data[parts[0]] = parts[1]
 	}
 
@@ -1089,15 +1270,16 @@ func deploymentsToWatch(stack *cloudformation.Stack) (map[string]*ecsDeployment,
 	if len(arns) == 0 {
 		return nil, fmt.Errorf("no services found in output")
 	}
-	if len(deploymentIDs) == 0 {
-		return nil, fmt.Errorf("no deploymentIDs found in output")
-	}
 
 	ecsDeployments := make(map[string]*ecsDeployment)
 	for p, a := range arns {
 		deploymentID, ok := deploymentIDs[p]
 		if !ok {
-			return nil, fmt.Errorf("deployment id not found for process: %v", p)
+			// AWS::ECS::Service resources (used for processes
+			// attached to an Application Load Balancer) don't
+			// expose a deployment, but the stack update already
+			// waits for them to stabilize.
+			continue
 		}
 		ecsDeployments[a] = &ecsDeployment{
 			process: p,
@@ -336,6 +336,113 @@ func TestScheduler_Submit_Superseded(t *testing.T) {
 	x.AssertExpectations(t)
 }
 
+func TestScheduler_Submit_StabilizeTimeout(t *testing.T) {
+	db := newDB(t)
+	defer db.Close()
+
+	x := new(mockS3Client)
+	c := new(mockCloudFormationClient)
+	e := new(mockECSClient)
+	s := &Scheduler{
+		Template:       template.Must(template.New("t").Parse("{}")),
+		Bucket:         "bucket",
+		Cluster:        "cluster",
+		cloudformation: c,
+		ecs:            e,
+		s3:             x,
+		db:             db,
+		after: func(d time.Duration) <-chan time.Time {
+			// Never poll again, and resolve the stabilize timeout
+			// immediately.
+			switch d {
+			case pollServicesWait:
+				return nil
+			case stabilizeTimeout:
+				ch := make(chan time.Time)
+				close(ch)
+				return ch
+			}
+			return fakeAfter(d)
+		},
+	}
+
+	x.On("PutObject", &s3.PutObjectInput{
+		Bucket:      aws.String("bucket"),
+		Body:        bytes.NewReader([]byte("{}")),
+		Key:         aws.String("/acme-inc/c9366591-ab68-4d49-a333-95ce5a23df68/bf21a9e8fbc5a3846fb05b4fa0859e0917b2202f"),
+		ContentType: aws.String("application/json"),
+	}).Return(&s3.PutObjectOutput{}, nil)
+
+	c.On("ValidateTemplate", &cloudformation.ValidateTemplateInput{
+		TemplateURL: aws.String("https://bucket.s3.amazonaws.com/acme-inc/c9366591-ab68-4d49-a333-95ce5a23df68/bf21a9e8fbc5a3846fb05b4fa0859e0917b2202f"),
+	}).Return(&cloudformation.ValidateTemplateOutput{}, nil)
+
+	c.On("DescribeStacks", &cloudformation.DescribeStacksInput{
+		StackName: aws.String("acme-inc"),
+	}).Return(&cloudformation.DescribeStacksOutput{
+		Stacks: []*cloudformation.Stack{
+			{StackStatus: aws.String("CREATE_COMPLETE")},
+		},
+	}, nil).Once()
+
+	c.On("UpdateStack", &cloudformation.UpdateStackInput{
+		StackName:   aws.String("acme-inc"),
+		TemplateURL: aws.String("https://bucket.s3.amazonaws.com/acme-inc/c9366591-ab68-4d49-a333-95ce5a23df68/bf21a9e8fbc5a3846fb05b4fa0859e0917b2202f"),
+		Parameters: []*cloudformation.Parameter{
+			{ParameterKey: aws.String("RestartKey"), ParameterValue: aws.String("uuid")},
+		},
+	}).Return(&cloudformation.UpdateStackOutput{}, nil)
+
+	c.On("WaitUntilStackUpdateComplete", &cloudformation.DescribeStacksInput{
+		StackName: aws.String("acme-inc"),
+	}).Return(nil)
+
+	c.On("DescribeStacks", &cloudformation.DescribeStacksInput{
+		StackName: aws.String("acme-inc"),
+	}).Return(&cloudformation.DescribeStacksOutput{
+		Stacks: []*cloudformation.Stack{
+			{
+				StackStatus: aws.String("CREATE_COMPLETE"),
+				Outputs: []*cloudformation.Output{
+					{
+						OutputKey:   aws.String("Services"),
+						OutputValue: aws.String("web=arn:aws:ecs:us-east-1:012345678910:service/acme-inc-web"),
+					},
+					{
+						OutputKey:   aws.String("Deployments"),
+						OutputValue: aws.String("web=1"),
+					},
+				},
+			},
+		},
+	}, nil)
+
+	e.On("DescribeServices", &ecs.DescribeServicesInput{
+		Cluster:  aws.String("cluster"),
+		Services: []*string{aws.String("arn:aws:ecs:us-east-1:012345678910:service/acme-inc-web")},
+	}).Return(&ecs.DescribeServicesOutput{
+		Services: []*ecs.Service{
+			{
+				ServiceArn: aws.String("arn:aws:ecs:us-east-1:012345678910:service/acme-inc-web"),
+				Deployments: []*ecs.Deployment{
+					&ecs.Deployment{Id: aws.String("1"), Status: aws.String("PRIMARY")},
+					&ecs.Deployment{Id: aws.String("0"), Status: aws.String("ACTIVE")},
+				},
+			},
+		},
+	}, nil)
+
+	stream := &storedStatusStream{}
+	err := s.Submit(context.Background(), &scheduler.App{
+		ID:   "c9366591-ab68-4d49-a333-95ce5a23df68",
+		Name: "acme-inc",
+	}, stream)
+	assert.IsType(t, &scheduler.UnstableError{}, err)
+
+	c.AssertExpectations(t)
+	x.AssertExpectations(t)
+}
+
 func TestScheduler_Submit_LockWaitTimeout(t *testing.T) {
 	db := newDB(t)
 	defer db.Close()
@@ -1405,6 +1512,21 @@ func (m *mockCloudFormationClient) ValidateTemplate(input *cloudformation.Valida
 	return args.Get(0).(*cloudformation.ValidateTemplateOutput), args.Error(1)
 }
 
+func (m *mockCloudFormationClient) CreateChangeSet(input *cloudformation.CreateChangeSetInput) (*cloudformation.CreateChangeSetOutput, error) {
+	args := m.Called(input)
+	return args.Get(0).(*cloudformation.CreateChangeSetOutput), args.Error(1)
+}
+
+func (m *mockCloudFormationClient) DescribeChangeSet(input *cloudformation.DescribeChangeSetInput) (*cloudformation.DescribeChangeSetOutput, error) {
+	args := m.Called(input)
+	return args.Get(0).(*cloudformation.DescribeChangeSetOutput), args.Error(1)
+}
+
+func (m *mockCloudFormationClient) DeleteChangeSet(input *cloudformation.DeleteChangeSetInput) (*cloudformation.DeleteChangeSetOutput, error) {
+	args := m.Called(input)
+	return args.Get(0).(*cloudformation.DeleteChangeSetOutput), args.Error(1)
+}
+
 type mockS3Client struct {
 	mock.Mock
 }
@@ -1440,13 +1562,116 @@ func (m *mockECSClient) DescribeServices(input *ecs.DescribeServicesInput) (*ecs
 	return args.Get(0).(*ecs.DescribeServicesOutput), args.Error(1)
 }
 
+func (m *mockECSClient) ListContainerInstancesPages(input *ecs.ListContainerInstancesInput, fn func(p *ecs.ListContainerInstancesOutput, lastPage bool) (shouldContinue bool)) error {
+	args := m.Called(input)
+	fn(args.Get(0).(*ecs.ListContainerInstancesOutput), true)
+	return args.Error(1)
+}
+
+func (m *mockECSClient) DescribeContainerInstances(input *ecs.DescribeContainerInstancesInput) (*ecs.DescribeContainerInstancesOutput, error) {
+	args := m.Called(input)
+	return args.Get(0).(*ecs.DescribeContainerInstancesOutput), args.Error(1)
+}
+
 // fakeAfter is a helper function that will resolve immediately
 // except in cases where a lockWait is specified.
 func fakeAfter(d time.Duration) <-chan time.Time {
-	if d == lockWait || d == stackOperationTimeout {
+	if d == lockWait || d == stackOperationTimeout || d == stabilizeTimeout || d == changeSetTimeout {
 		return nil
 	}
 	ch := make(chan time.Time)
 	close(ch)
 	return ch
 }
+
+func TestScheduler_DesiredCounts(t *testing.T) {
+	db := newDB(t)
+	defer db.Close()
+
+	c := new(mockCloudFormationClient)
+	e := new(mockECSClient)
+	s := &Scheduler{
+		Cluster:        "cluster",
+		cloudformation: c,
+		ecs:            e,
+		db:             db,
+	}
+
+	_, err := db.Exec(`INSERT INTO stacks (app_id, stack_name) VALUES ($1, $2)`, "c9366591-ab68-4d49-a333-95ce5a23df68", "acme-inc")
+	assert.NoError(t, err)
+
+	c.On("DescribeStacks", &cloudformation.DescribeStacksInput{
+		StackName: aws.String("acme-inc"),
+	}).Return(&cloudformation.DescribeStacksOutput{
+		Stacks: []*cloudformation.Stack{
+			{
+				StackStatus: aws.String("UPDATE_COMPLETE"),
+				Outputs: []*cloudformation.Output{
+					{
+						OutputKey:   aws.String("Services"),
+						OutputValue: aws.String("web=arn:aws:ecs:us-east-1:012345678910:service/acme-inc-web"),
+					},
+				},
+			},
+		},
+	}, nil)
+
+	e.On("DescribeServices", &ecs.DescribeServicesInput{
+		Cluster:  aws.String("cluster"),
+		Services: []*string{aws.String("arn:aws:ecs:us-east-1:012345678910:service/acme-inc-web")},
+	}).Return(&ecs.DescribeServicesOutput{
+		Services: []*ecs.Service{
+			{
+				ServiceArn:   aws.String("arn:aws:ecs:us-east-1:012345678910:service/acme-inc-web"),
+				DesiredCount: aws.Int64(3),
+			},
+		},
+	}, nil)
+
+	desired, err := s.DesiredCounts(context.Background(), "c9366591-ab68-4d49-a333-95ce5a23df68")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]uint{"web": 3}, desired)
+
+	c.AssertExpectations(t)
+	e.AssertExpectations(t)
+}
+
+func TestInstances(t *testing.T) {
+	autoscaling := &scheduler.Autoscaling{MinInstances: 2, MaxInstances: 10}
+	desired := map[string]uint{"web": 5, "worker": 12}
+
+	tests := []struct {
+		p        *scheduler.Process
+		desired  map[string]uint
+		expected uint
+	}{
+		{&scheduler.Process{Type: "web", Instances: 1}, desired, 1},
+		{&scheduler.Process{Type: "web", Instances: 3, Autoscaling: autoscaling}, nil, 3},
+		{&scheduler.Process{Type: "web", Instances: 3, Autoscaling: autoscaling}, desired, 5},
+		{&scheduler.Process{Type: "worker", Instances: 3, Autoscaling: autoscaling}, desired, 10},
+		{&scheduler.Process{Type: "api", Instances: 1, Autoscaling: autoscaling}, desired, 2},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, instances(tt.p, tt.desired))
+	}
+}
+
+func TestScheduler_Run_DetachedSecrets(t *testing.T) {
+	e := new(mockECSClient)
+	s := &Scheduler{
+		Template: newTemplate(),
+		ecs:      e,
+	}
+
+	app := &scheduler.App{
+		ID:      "c9366591-ab68-4d49-a333-95ce5a23df68",
+		Name:    "acme-inc",
+		Secrets: map[string]string{"DATABASE_URL": "ssm:/empire/acme-inc/DATABASE_URL/1"},
+	}
+
+	err := s.Run(context.Background(), app, &scheduler.Process{Type: "run"}, nil, nil)
+	assert.Equal(t, ErrDetachedSecrets, err)
+
+	e.AssertExpectations(t)
+}
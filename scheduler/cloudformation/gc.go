@@ -0,0 +1,109 @@
+package cloudformation
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/remind101/empire/scheduler"
+	"golang.org/x/net/context"
+)
+
+// OrphanStack is the type of resource for CloudFormation stacks that belong to
+// apps that no longer exist.
+const OrphanStack = "AWS::CloudFormation::Stack"
+
+// The type of the custom resource that allocates instance ports for load
+// balancers. The physical id of the resource is the port.
+const instancePortResource = "Custom::InstancePort"
+
+// Orphans returns the stacks that were created for apps that aren't in apps.
+// Only stacks that are recorded in the stacks table are considered, so that
+// stacks belonging to other Empire installations are left alone.
+func (s *Scheduler) Orphans(ctx context.Context, apps []string) ([]*scheduler.Orphan, error) {
+	known := make(map[string]bool)
+	for _, app := range apps {
+		known[app] = true
+	}
+
+	rows, err := s.db.Query(`SELECT app_id, stack_name FROM stacks ORDER BY stack_name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var orphans []*scheduler.Orphan
+	for rows.Next() {
+		var appID, stackName string
+		if err := rows.Scan(&appID, &stackName); err != nil {
+			return nil, err
+		}
+
+		if known[appID] {
+			continue
+		}
+
+		orphans = append(orphans, &scheduler.Orphan{
+			Type: OrphanStack,
+			ID:   stackName,
+			App:  appID,
+		})
+	}
+
+	return orphans, rows.Err()
+}
+
+// InstancePorts returns the instance ports that are allocated to the stacks in
+// the stacks table. These are allocated from the same pool of ports as the
+// ECS scheduler's load balancers.
+func (s *Scheduler) InstancePorts(ctx context.Context) (map[string]bool, error) {
+	rows, err := s.db.Query(`SELECT stack_name FROM stacks ORDER BY stack_name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stackNames []string
+	for rows.Next() {
+		var stackName string
+		if err := rows.Scan(&stackName); err != nil {
+			return nil, err
+		}
+		stackNames = append(stackNames, stackName)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	ports := make(map[string]bool)
+	for _, stackName := range stackNames {
+		err := s.cloudformation.ListStackResourcesPages(&cloudformation.ListStackResourcesInput{
+			StackName: aws.String(stackName),
+		}, func(p *cloudformation.ListStackResourcesOutput, lastPage bool) bool {
+			for _, r := range p.StackResourceSummaries {
+				if aws.StringValue(r.ResourceType) == instancePortResource && r.PhysicalResourceId != nil {
+					ports[*r.PhysicalResourceId] = true
+				}
+			}
+			return true
+		})
+		if err, ok := err.(awserr.Error); ok && err.Message() == fmt.Sprintf("Stack with id %s does not exist", stackName) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return ports, nil
+}
+
+// RemoveOrphan removes a stack that was returned from Orphans.
+func (s *Scheduler) RemoveOrphan(ctx context.Context, orphan *scheduler.Orphan) error {
+	if orphan.Type != OrphanStack {
+		return fmt.Errorf("unknown resource type: %s", orphan.Type)
+	}
+
+	return s.Remove(ctx, orphan.App)
+}
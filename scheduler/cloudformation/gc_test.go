@@ -0,0 +1,91 @@
+package cloudformation
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/remind101/empire/scheduler"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestScheduler_Orphans(t *testing.T) {
+	db := newDB(t)
+	defer db.Close()
+
+	c := new(mockCloudFormationClient)
+	s := &Scheduler{
+		cloudformation: c,
+		db:             db,
+		after:          fakeAfter,
+	}
+
+	_, err := db.Exec(`INSERT INTO stacks (app_id, stack_name) VALUES ($1, $2), ($3, $4)`,
+		"c9366591-ab68-4d49-a333-95ce5a23df68", "acme-inc",
+		"ae69bb4c-3903-4844-82fe-548ac5b74570", "old-app",
+	)
+	assert.NoError(t, err)
+
+	orphans, err := s.Orphans(context.Background(), []string{"c9366591-ab68-4d49-a333-95ce5a23df68"})
+	assert.NoError(t, err)
+	assert.Equal(t, []*scheduler.Orphan{
+		{Type: OrphanStack, ID: "old-app", App: "ae69bb4c-3903-4844-82fe-548ac5b74570"},
+	}, orphans)
+
+	c.On("DescribeStacks", &cloudformation.DescribeStacksInput{
+		StackName: aws.String("old-app"),
+	}).Return(&cloudformation.DescribeStacksOutput{
+		Stacks: []*cloudformation.Stack{
+			{StackName: aws.String("old-app")},
+		},
+	}, nil)
+	c.On("DeleteStack", &cloudformation.DeleteStackInput{
+		StackName: aws.String("old-app"),
+	}).Return(&cloudformation.DeleteStackOutput{}, nil)
+
+	err = s.RemoveOrphan(context.Background(), orphans[0])
+	assert.NoError(t, err)
+
+	orphans, err = s.Orphans(context.Background(), []string{"c9366591-ab68-4d49-a333-95ce5a23df68"})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(orphans))
+
+	c.AssertExpectations(t)
+}
+
+func TestScheduler_InstancePorts(t *testing.T) {
+	db := newDB(t)
+	defer db.Close()
+
+	c := new(mockCloudFormationClient)
+	s := &Scheduler{
+		cloudformation: c,
+		db:             db,
+	}
+
+	_, err := db.Exec(`INSERT INTO stacks (app_id, stack_name) VALUES ($1, $2), ($3, $4)`,
+		"c9366591-ab68-4d49-a333-95ce5a23df68", "acme-inc",
+		"ae69bb4c-3903-4844-82fe-548ac5b74570", "deleted-app",
+	)
+	assert.NoError(t, err)
+
+	c.On("ListStackResourcesPages", &cloudformation.ListStackResourcesInput{
+		StackName: aws.String("acme-inc"),
+	}).Return(&cloudformation.ListStackResourcesOutput{
+		StackResourceSummaries: []*cloudformation.StackResourceSummary{
+			{ResourceType: aws.String("Custom::InstancePort"), PhysicalResourceId: aws.String("9001")},
+			{ResourceType: aws.String("AWS::ElasticLoadBalancing::LoadBalancer"), PhysicalResourceId: aws.String("acme-inc")},
+		},
+	}, nil)
+	c.On("ListStackResourcesPages", &cloudformation.ListStackResourcesInput{
+		StackName: aws.String("deleted-app"),
+	}).Return(&cloudformation.ListStackResourcesOutput{}, awserr.New("ValidationError", "Stack with id deleted-app does not exist", nil))
+
+	ports, err := s.InstancePorts(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]bool{"9001": true}, ports)
+
+	c.AssertExpectations(t)
+}
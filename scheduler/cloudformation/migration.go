@@ -41,6 +41,7 @@ type MigrationScheduler struct {
 	cloudformation interface {
 		scheduler.Scheduler
 		SubmitWithOptions(context.Context, *scheduler.App, scheduler.StatusStream, SubmitOptions) error
+		InstancePorts(context.Context) (map[string]bool, error)
 	}
 
 	// The scheduler we're migrating from.
@@ -191,6 +192,60 @@ func (s *MigrationScheduler) Instances(ctx context.Context, appID string) ([]*sc
 	return b.Instances(ctx, appID)
 }
 
+func (s *MigrationScheduler) Plan(ctx context.Context, app *scheduler.App) (*scheduler.Plan, error) {
+	b, err := s.Backend(app.ID)
+	if err != nil {
+		return nil, err
+	}
+	return scheduler.PlanSubmit(ctx, b, app)
+}
+
+func (s *MigrationScheduler) DesiredCounts(ctx context.Context, appID string) (map[string]uint, error) {
+	b, err := s.Backend(appID)
+	if err != nil {
+		return nil, err
+	}
+	return scheduler.DesiredCounts(ctx, b, appID)
+}
+
+// Orphans returns the resources from both schedulers that belong to apps that
+// aren't in apps.
+func (s *MigrationScheduler) Orphans(ctx context.Context, apps []string) ([]*scheduler.Orphan, error) {
+	orphans, err := scheduler.Orphans(ctx, s.cloudformation, apps)
+	if err != nil {
+		return nil, err
+	}
+
+	ecsOrphans, err := scheduler.Orphans(ctx, s.ecs, apps)
+	if err != nil {
+		return nil, err
+	}
+
+	// The ECS scheduler only knows about the instance ports of its own
+	// load balancers, but stacks allocate theirs from the same pool.
+	ports, err := s.cloudformation.InstancePorts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, orphan := range ecsOrphans {
+		if orphan.Type == ecs.OrphanPort && ports[orphan.ID] {
+			continue
+		}
+		orphans = append(orphans, orphan)
+	}
+
+	return orphans, nil
+}
+
+// RemoveOrphan removes the resource using the scheduler that created it.
+func (s *MigrationScheduler) RemoveOrphan(ctx context.Context, orphan *scheduler.Orphan) error {
+	if orphan.Type == OrphanStack {
+		return scheduler.RemoveOrphan(ctx, s.cloudformation, orphan)
+	}
+	return scheduler.RemoveOrphan(ctx, s.ecs, orphan)
+}
+
 func (s *MigrationScheduler) Run(ctx context.Context, app *scheduler.App, process *scheduler.Process, in io.Reader, out io.Writer) error {
 	b, err := s.Backend(app.ID)
 	if err != nil {
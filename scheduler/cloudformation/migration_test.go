@@ -231,6 +231,40 @@ func TestMigrationScheduler_Migrate_InvalidStateTransitions(t *testing.T) {
 	c.AssertExpectations(t)
 }
 
+// Instance ports that are allocated to stacks aren't leaked, even though the
+// ECS scheduler doesn't know about them.
+func TestMigrationScheduler_Orphans(t *testing.T) {
+	e := new(mockECSScheduler)
+	c := new(mockCloudFormationScheduler)
+	s := &MigrationScheduler{
+		ecs:            e,
+		cloudformation: c,
+	}
+
+	apps := []string{"c9366591-ab68-4d49-a333-95ce5a23df68"}
+
+	c.On("Orphans", apps).Return([]*scheduler.Orphan{
+		{Type: OrphanStack, ID: "old-app", App: "ae69bb4c-3903-4844-82fe-548ac5b74570"},
+	}, nil)
+	c.On("InstancePorts").Return(map[string]bool{"9001": true}, nil)
+	e.On("Orphans", apps).Return([]*scheduler.Orphan{
+		{Type: ecs.OrphanTaskDefinition, ID: "ae69bb4c-3903-4844-82fe-548ac5b74570--web", App: "ae69bb4c-3903-4844-82fe-548ac5b74570"},
+		{Type: ecs.OrphanPort, ID: "9001"},
+		{Type: ecs.OrphanPort, ID: "9002"},
+	}, nil)
+
+	orphans, err := s.Orphans(context.Background(), apps)
+	assert.NoError(t, err)
+	assert.Equal(t, []*scheduler.Orphan{
+		{Type: OrphanStack, ID: "old-app", App: "ae69bb4c-3903-4844-82fe-548ac5b74570"},
+		{Type: ecs.OrphanTaskDefinition, ID: "ae69bb4c-3903-4844-82fe-548ac5b74570--web", App: "ae69bb4c-3903-4844-82fe-548ac5b74570"},
+		{Type: ecs.OrphanPort, ID: "9002"},
+	}, orphans)
+
+	e.AssertExpectations(t)
+	c.AssertExpectations(t)
+}
+
 type mockScheduler struct {
 	scheduler.Scheduler
 	mock.Mock
@@ -241,6 +275,16 @@ func (m *mockScheduler) Submit(_ context.Context, app *scheduler.App, ss schedul
 	return args.Error(0)
 }
 
+func (m *mockScheduler) Orphans(_ context.Context, apps []string) ([]*scheduler.Orphan, error) {
+	args := m.Called(apps)
+	return args.Get(0).([]*scheduler.Orphan), args.Error(1)
+}
+
+func (m *mockScheduler) RemoveOrphan(_ context.Context, orphan *scheduler.Orphan) error {
+	args := m.Called(orphan)
+	return args.Error(0)
+}
+
 type mockECSScheduler struct {
 	mockScheduler
 }
@@ -258,3 +302,8 @@ func (m *mockCloudFormationScheduler) SubmitWithOptions(_ context.Context, app *
 	args := m.Called(app, opts)
 	return args.Error(0)
 }
+
+func (m *mockCloudFormationScheduler) InstancePorts(_ context.Context) (map[string]bool, error) {
+	args := m.Called()
+	return args.Get(0).(map[string]bool), args.Error(1)
+}
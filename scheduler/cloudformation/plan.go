@@ -0,0 +1,207 @@
+package cloudformation
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/remind101/empire/scheduler"
+	"github.com/remind101/pkg/logger"
+	"golang.org/x/net/context"
+)
+
+// The StatusReason of a failed change set when the stack is already up to
+// date.
+var noChangesReasons = []string{
+	"didn't contain changes",
+	"No updates are to be performed",
+}
+
+// Plan implements the scheduler.Planner interface. It renders the template for
+// the app, then creates a CloudFormation change set to determine which
+// resources would be added, modified, replaced or removed by Submit. The change
+// set is deleted afterwards, and is never executed.
+//
+// The RestartKey parameter is left unchanged, so that processes aren't
+// reported as modified just because Submit would restart them.
+func (s *Scheduler) Plan(ctx context.Context, app *scheduler.App) (*scheduler.Plan, error) {
+	stackName, err := s.stackName(app.ID)
+	if err == errNoStack {
+		return s.planNewStack(app)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	stack, err := s.stack(aws.String(stackName))
+	if err, ok := err.(awserr.Error); ok && err.Message() == fmt.Sprintf("Stack with id %s does not exist", stackName) {
+		return s.planNewStack(app)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error describing stack: %v", err)
+	}
+
+	t, err := s.createTemplate(ctx, app)
+	if err != nil {
+		return nil, err
+	}
+
+	parameters := stackParameters(app, SubmitOptions{}, nil)
+	parameters[0] = &cloudformation.Parameter{
+		ParameterKey:     aws.String(restartParameter),
+		UsePreviousValue: aws.Bool(true),
+	}
+
+	changeSetName := aws.String(fmt.Sprintf("plan-%s", newUUID()))
+	if _, err := s.cloudformation.CreateChangeSet(&cloudformation.CreateChangeSetInput{
+		ChangeSetName: changeSetName,
+		StackName:     aws.String(stackName),
+		TemplateURL:   t.URL,
+		Parameters:    updateParameters(parameters, stack, t),
+	}); err != nil {
+		return nil, fmt.Errorf("error creating change set: %v", err)
+	}
+
+	defer func() {
+		if _, err := s.cloudformation.DeleteChangeSet(&cloudformation.DeleteChangeSetInput{
+			ChangeSetName: changeSetName,
+			StackName:     aws.String(stackName),
+		}); err != nil {
+			logger.Warn(ctx, fmt.Sprintf("error deleting change set: %v", err))
+		}
+	}()
+
+	changes, err := s.waitForChangeSet(changeSetName, aws.String(stackName))
+	if err != nil {
+		return nil, err
+	}
+
+	plan := new(scheduler.Plan)
+	for _, c := range changes {
+		if c.ResourceChange == nil {
+			continue
+		}
+		plan.Changes = append(plan.Changes, resourceChange(c.ResourceChange))
+	}
+
+	return plan, nil
+}
+
+// waitForChangeSet waits until the change set has been created, then returns
+// all of the changes in it.
+func (s *Scheduler) waitForChangeSet(changeSetName, stackName *string) ([]*cloudformation.Change, error) {
+	timeout := s.after(changeSetTimeout)
+
+	for {
+		resp, err := s.cloudformation.DescribeChangeSet(&cloudformation.DescribeChangeSetInput{
+			ChangeSetName: changeSetName,
+			StackName:     stackName,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error describing change set: %v", err)
+		}
+
+		switch aws.StringValue(resp.Status) {
+		case cloudformation.ChangeSetStatusCreateComplete:
+			return s.changeSetChanges(changeSetName, stackName, resp)
+		case cloudformation.ChangeSetStatusFailed:
+			reason := aws.StringValue(resp.StatusReason)
+			for _, r := range noChangesReasons {
+				if strings.Contains(reason, r) {
+					return nil, nil
+				}
+			}
+			return nil, fmt.Errorf("error creating change set: %s", reason)
+		}
+
+		select {
+		case <-timeout:
+			return nil, errors.New("timed out waiting for change set to be created")
+		case <-s.after(pollChangeSetWait):
+		}
+	}
+}
+
+// changeSetChanges returns all of the changes in a change set, starting with
+// the first page of results.
+func (s *Scheduler) changeSetChanges(changeSetName, stackName *string, resp *cloudformation.DescribeChangeSetOutput) ([]*cloudformation.Change, error) {
+	changes := resp.Changes
+	for resp.NextToken != nil {
+		var err error
+		resp, err = s.cloudformation.DescribeChangeSet(&cloudformation.DescribeChangeSetInput{
+			ChangeSetName: changeSetName,
+			StackName:     stackName,
+			NextToken:     resp.NextToken,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error describing change set: %v", err)
+		}
+		changes = append(changes, resp.Changes...)
+	}
+	return changes, nil
+}
+
+// planNewStack returns a plan for an app that doesn't have a stack yet. Change
+// sets can only be created for existing stacks, so every resource in the
+// rendered template is reported as added.
+func (s *Scheduler) planNewStack(app *scheduler.App) (*scheduler.Plan, error) {
+	buf := new(bytes.Buffer)
+	if err := s.Template.Execute(buf, app); err != nil {
+		return nil, err
+	}
+
+	var t struct {
+		Resources map[string]struct {
+			Type string
+		}
+	}
+	if err := json.Unmarshal(buf.Bytes(), &t); err != nil {
+		return nil, fmt.Errorf("error parsing stack template: %v", err)
+	}
+
+	var names []string
+	for name := range t.Resources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	plan := new(scheduler.Plan)
+	for _, name := range names {
+		plan.Changes = append(plan.Changes, &scheduler.ResourceChange{
+			Action: scheduler.ActionAdd,
+			Name:   name,
+			Type:   t.Resources[name].Type,
+		})
+	}
+
+	return plan, nil
+}
+
+// resourceChange converts a cloudformation.ResourceChange to a
+// scheduler.ResourceChange.
+func resourceChange(c *cloudformation.ResourceChange) *scheduler.ResourceChange {
+	change := &scheduler.ResourceChange{
+		Action: aws.StringValue(c.Action),
+		Name:   aws.StringValue(c.LogicalResourceId),
+		Type:   aws.StringValue(c.ResourceType),
+		ID:     aws.StringValue(c.PhysicalResourceId),
+	}
+
+	if change.Action == cloudformation.ChangeActionModify {
+		switch aws.StringValue(c.Replacement) {
+		case cloudformation.ReplacementTrue:
+			change.Action = scheduler.ActionReplace
+		case cloudformation.ReplacementConditional:
+			change.Action = scheduler.ActionReplace
+			change.Conditional = true
+		}
+	}
+
+	return change
+}
@@ -0,0 +1,239 @@
+package cloudformation
+
+import (
+	"bytes"
+	"html/template"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/remind101/empire/scheduler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/net/context"
+)
+
+func TestScheduler_Plan(t *testing.T) {
+	db := newDB(t)
+	defer db.Close()
+
+	x := new(mockS3Client)
+	c := new(mockCloudFormationClient)
+	s := &Scheduler{
+		Template:       template.Must(template.New("t").Parse("{}")),
+		Bucket:         "bucket",
+		cloudformation: c,
+		s3:             x,
+		db:             db,
+		after:          fakeAfter,
+	}
+
+	_, err := db.Exec(`INSERT INTO stacks (app_id, stack_name) VALUES ($1, $2)`, "c9366591-ab68-4d49-a333-95ce5a23df68", "acme-inc")
+	assert.NoError(t, err)
+
+	x.On("PutObject", &s3.PutObjectInput{
+		Bucket:      aws.String("bucket"),
+		Body:        bytes.NewReader([]byte("{}")),
+		Key:         aws.String("/acme-inc/c9366591-ab68-4d49-a333-95ce5a23df68/bf21a9e8fbc5a3846fb05b4fa0859e0917b2202f"),
+		ContentType: aws.String("application/json"),
+	}).Return(&s3.PutObjectOutput{}, nil)
+
+	c.On("ValidateTemplate", &cloudformation.ValidateTemplateInput{
+		TemplateURL: aws.String("https://bucket.s3.amazonaws.com/acme-inc/c9366591-ab68-4d49-a333-95ce5a23df68/bf21a9e8fbc5a3846fb05b4fa0859e0917b2202f"),
+	}).Return(&cloudformation.ValidateTemplateOutput{
+		Parameters: []*cloudformation.TemplateParameter{
+			{ParameterKey: aws.String("RestartKey")},
+			{ParameterKey: aws.String("webScale")},
+		},
+	}, nil)
+
+	c.On("DescribeStacks", &cloudformation.DescribeStacksInput{
+		StackName: aws.String("acme-inc"),
+	}).Return(&cloudformation.DescribeStacksOutput{
+		Stacks: []*cloudformation.Stack{
+			{
+				StackStatus: aws.String("UPDATE_COMPLETE"),
+				Parameters: []*cloudformation.Parameter{
+					{ParameterKey: aws.String("RestartKey"), ParameterValue: aws.String("uuid")},
+					{ParameterKey: aws.String("webScale"), ParameterValue: aws.String("1")},
+				},
+			},
+		},
+	}, nil)
+
+	c.On("CreateChangeSet", &cloudformation.CreateChangeSetInput{
+		ChangeSetName: aws.String("plan-uuid"),
+		StackName:     aws.String("acme-inc"),
+		TemplateURL:   aws.String("https://bucket.s3.amazonaws.com/acme-inc/c9366591-ab68-4d49-a333-95ce5a23df68/bf21a9e8fbc5a3846fb05b4fa0859e0917b2202f"),
+		Parameters: []*cloudformation.Parameter{
+			{ParameterKey: aws.String("RestartKey"), UsePreviousValue: aws.Bool(true)},
+			{ParameterKey: aws.String("webScale"), ParameterValue: aws.String("2")},
+		},
+	}).Return(&cloudformation.CreateChangeSetOutput{}, nil)
+
+	c.On("DescribeChangeSet", &cloudformation.DescribeChangeSetInput{
+		ChangeSetName: aws.String("plan-uuid"),
+		StackName:     aws.String("acme-inc"),
+	}).Return(&cloudformation.DescribeChangeSetOutput{
+		Status: aws.String("CREATE_PENDING"),
+	}, nil).Once()
+
+	c.On("DescribeChangeSet", &cloudformation.DescribeChangeSetInput{
+		ChangeSetName: aws.String("plan-uuid"),
+		StackName:     aws.String("acme-inc"),
+	}).Return(&cloudformation.DescribeChangeSetOutput{
+		Status: aws.String("CREATE_COMPLETE"),
+		Changes: []*cloudformation.Change{
+			{ResourceChange: &cloudformation.ResourceChange{
+				Action:             aws.String("Modify"),
+				LogicalResourceId:  aws.String("webLoadBalancer"),
+				PhysicalResourceId: aws.String("acme-inc-web"),
+				ResourceType:       aws.String("AWS::ElasticLoadBalancing::LoadBalancer"),
+				Replacement:        aws.String("True"),
+			}},
+		},
+		NextToken: aws.String("next"),
+	}, nil).Once()
+
+	c.On("DescribeChangeSet", &cloudformation.DescribeChangeSetInput{
+		ChangeSetName: aws.String("plan-uuid"),
+		StackName:     aws.String("acme-inc"),
+		NextToken:     aws.String("next"),
+	}).Return(&cloudformation.DescribeChangeSetOutput{
+		Status: aws.String("CREATE_COMPLETE"),
+		Changes: []*cloudformation.Change{
+			{ResourceChange: &cloudformation.ResourceChange{
+				Action:             aws.String("Modify"),
+				LogicalResourceId:  aws.String("web"),
+				PhysicalResourceId: aws.String("arn:aws:ecs:us-east-1:012345678910:service/acme-inc-web"),
+				ResourceType:       aws.String("AWS::ECS::Service"),
+				Replacement:        aws.String("False"),
+			}},
+		},
+	}, nil).Once()
+
+	c.On("DeleteChangeSet", &cloudformation.DeleteChangeSetInput{
+		ChangeSetName: aws.String("plan-uuid"),
+		StackName:     aws.String("acme-inc"),
+	}).Return(&cloudformation.DeleteChangeSetOutput{}, nil)
+
+	plan, err := s.Plan(context.Background(), &scheduler.App{
+		ID:   "c9366591-ab68-4d49-a333-95ce5a23df68",
+		Name: "acme-inc",
+		Processes: []*scheduler.Process{
+			{Type: "web", Instances: 2},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, &scheduler.Plan{
+		Changes: []*scheduler.ResourceChange{
+			{Action: "Replace", Name: "webLoadBalancer", Type: "AWS::ElasticLoadBalancing::LoadBalancer", ID: "acme-inc-web"},
+			{Action: "Modify", Name: "web", Type: "AWS::ECS::Service", ID: "arn:aws:ecs:us-east-1:012345678910:service/acme-inc-web"},
+		},
+	}, plan)
+
+	c.AssertExpectations(t)
+	x.AssertExpectations(t)
+}
+
+func TestScheduler_Plan_NoChanges(t *testing.T) {
+	db := newDB(t)
+	defer db.Close()
+
+	x := new(mockS3Client)
+	c := new(mockCloudFormationClient)
+	s := &Scheduler{
+		Template:       template.Must(template.New("t").Parse("{}")),
+		Bucket:         "bucket",
+		cloudformation: c,
+		s3:             x,
+		db:             db,
+		after:          fakeAfter,
+	}
+
+	_, err := db.Exec(`INSERT INTO stacks (app_id, stack_name) VALUES ($1, $2)`, "c9366591-ab68-4d49-a333-95ce5a23df68", "acme-inc")
+	assert.NoError(t, err)
+
+	x.On("PutObject", mock.Anything).Return(&s3.PutObjectOutput{}, nil)
+	c.On("ValidateTemplate", mock.Anything).Return(&cloudformation.ValidateTemplateOutput{}, nil)
+	c.On("DescribeStacks", mock.Anything).Return(&cloudformation.DescribeStacksOutput{
+		Stacks: []*cloudformation.Stack{
+			{StackStatus: aws.String("UPDATE_COMPLETE")},
+		},
+	}, nil)
+	c.On("CreateChangeSet", mock.Anything).Return(&cloudformation.CreateChangeSetOutput{}, nil)
+	c.On("DescribeChangeSet", mock.Anything).Return(&cloudformation.DescribeChangeSetOutput{
+		Status:       aws.String("FAILED"),
+		StatusReason: aws.String("The submitted information didn't contain changes. Submit different information to create a change set."),
+	}, nil)
+	c.On("DeleteChangeSet", mock.Anything).Return(&cloudformation.DeleteChangeSetOutput{}, nil)
+
+	plan, err := s.Plan(context.Background(), &scheduler.App{
+		ID:   "c9366591-ab68-4d49-a333-95ce5a23df68",
+		Name: "acme-inc",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, &scheduler.Plan{}, plan)
+
+	c.AssertExpectations(t)
+}
+
+func TestScheduler_Plan_NewStack(t *testing.T) {
+	db := newDB(t)
+	defer db.Close()
+
+	c := new(mockCloudFormationClient)
+	s := &Scheduler{
+		Template:       template.Must(template.New("t").Parse(`{"Resources":{"web":{"Type":"AWS::ECS::Service"},"webLoadBalancer":{"Type":"AWS::ElasticLoadBalancing::LoadBalancer"}}}`)),
+		cloudformation: c,
+		db:             db,
+		after:          fakeAfter,
+	}
+
+	plan, err := s.Plan(context.Background(), &scheduler.App{
+		ID:   "c9366591-ab68-4d49-a333-95ce5a23df68",
+		Name: "acme-inc",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, &scheduler.Plan{
+		Changes: []*scheduler.ResourceChange{
+			{Action: "Add", Name: "web", Type: "AWS::ECS::Service"},
+			{Action: "Add", Name: "webLoadBalancer", Type: "AWS::ElasticLoadBalancing::LoadBalancer"},
+		},
+	}, plan)
+
+	c.AssertExpectations(t)
+}
+
+func TestResourceChange(t *testing.T) {
+	tests := []struct {
+		in  *cloudformation.ResourceChange
+		out *scheduler.ResourceChange
+	}{
+		{
+			&cloudformation.ResourceChange{Action: aws.String("Add"), LogicalResourceId: aws.String("web"), ResourceType: aws.String("AWS::ECS::Service")},
+			&scheduler.ResourceChange{Action: "Add", Name: "web", Type: "AWS::ECS::Service"},
+		},
+		{
+			&cloudformation.ResourceChange{Action: aws.String("Modify"), LogicalResourceId: aws.String("web"), PhysicalResourceId: aws.String("arn"), Replacement: aws.String("False")},
+			&scheduler.ResourceChange{Action: "Modify", Name: "web", ID: "arn"},
+		},
+		{
+			&cloudformation.ResourceChange{Action: aws.String("Modify"), LogicalResourceId: aws.String("webLoadBalancer"), Replacement: aws.String("True")},
+			&scheduler.ResourceChange{Action: "Replace", Name: "webLoadBalancer"},
+		},
+		{
+			&cloudformation.ResourceChange{Action: aws.String("Modify"), LogicalResourceId: aws.String("webLoadBalancer"), Replacement: aws.String("Conditional")},
+			&scheduler.ResourceChange{Action: "Replace", Name: "webLoadBalancer", Conditional: true},
+		},
+		{
+			&cloudformation.ResourceChange{Action: aws.String("Remove"), LogicalResourceId: aws.String("worker")},
+			&scheduler.ResourceChange{Action: "Remove", Name: "worker"},
+		},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.out, resourceChange(tt.in))
+	}
+}
@@ -17,10 +17,17 @@ type ContainerDefinitionProperties struct {
 	PortMappings     []*PortMappingProperties `json:",omitempty"`
 	Ulimits          interface{}              `json:",omitempty"`
 	LogConfiguration interface{}              `json:",omitempty"`
+	Secrets          []*SecretProperties      `json:",omitempty"`
+}
+
+type SecretProperties struct {
+	Name      string
+	ValueFrom string
 }
 
 type TaskDefinitionProperties struct {
 	ContainerDefinitions []*ContainerDefinitionProperties `json:",omitempty"`
+	ExecutionRoleArn     interface{}                      `json:",omitempty"`
 	Volumes              []interface{}
 }
 
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"hash/fnv"
 	"io"
 	"regexp"
 	"sort"
@@ -19,6 +20,7 @@ import (
 	"github.com/remind101/empire/pkg/bytesize"
 	"github.com/remind101/empire/pkg/troposphere"
 	"github.com/remind101/empire/scheduler"
+	"github.com/remind101/empire/secrets/ssm"
 )
 
 var (
@@ -26,6 +28,8 @@ var (
 	GetAtt = troposphere.GetAtt
 	Equals = troposphere.Equals
 	Join   = troposphere.Join
+	Select = troposphere.Select
+	Split  = troposphere.Split
 )
 
 const (
@@ -45,6 +49,10 @@ const (
 
 	appEnvironment = "AppEnvironment"
 
+	// Appended to the names of resources for the canary release of a
+	// process.
+	canarySuffix = "Canary"
+
 	restartLabel = "cloudformation.restart-key"
 )
 
@@ -81,8 +89,24 @@ type EmpireTemplate struct {
 	// The ARN of the SNS topic to provision instance ports.
 	CustomResourcesTopic string
 
+	// If provided, http and https processes are routed through these
+	// shared Application Load Balancers, using host and path based routing,
+	// instead of getting a classic ELB of their own. tcp and ssl processes
+	// always get their own ELB.
+	InternalALB *ApplicationLoadBalancer
+	ExternalALB *ApplicationLoadBalancer
+
+	// The ID of the VPC to create target groups in. Required when an
+	// Application Load Balancer is provided.
+	VpcID string
+
 	LogConfiguration *ecs.LogConfiguration
 
+	// The ARN of the IAM role that ECS assumes to fetch the app's secrets
+	// from SSM Parameter Store when tasks are launched. Required when an
+	// app has secrets.
+	ExecutionRoleArn string
+
 	// Any extra outputs to attach to the template.
 	ExtraOutputs map[string]troposphere.Output
 }
@@ -117,10 +141,55 @@ func (t *EmpireTemplate) Validate() error {
 	if t.CustomResourcesTopic == "" {
 		return r("CustomResourcesTopic")
 	}
+	for _, alb := range []*ApplicationLoadBalancer{t.InternalALB, t.ExternalALB} {
+		if alb == nil {
+			continue
+		}
+		if t.VpcID == "" {
+			return r("VpcID")
+		}
+		if alb.DNSName == "" {
+			return r("ApplicationLoadBalancer.DNSName")
+		}
+		if alb.HTTPListenerArn == "" {
+			return r("ApplicationLoadBalancer.HTTPListenerArn")
+		}
+	}
 
 	return nil
 }
 
+// ApplicationLoadBalancer represents an existing Application Load Balancer that
+// is shared by all apps. Each exposed process gets a target group, and listener
+// rules that route requests to it by host and path.
+type ApplicationLoadBalancer struct {
+	// The DNS name of the load balancer, which CNAME's will point to.
+	DNSName string
+
+	// The ARN of the HTTP listener.
+	HTTPListenerArn string
+
+	// The ARN of the HTTPS listener. If not provided, https processes are
+	// only routed through the HTTP listener.
+	HTTPSListenerArn string
+}
+
+// applicationLoadBalancer returns the Application Load Balancer that the
+// exposure should be routed through, or nil if the process should get a
+// classic ELB.
+func (t *EmpireTemplate) applicationLoadBalancer(e *scheduler.Exposure) *ApplicationLoadBalancer {
+	switch e.Type.(type) {
+	case *scheduler.HTTPExposure, *scheduler.HTTPSExposure:
+	default:
+		return nil
+	}
+
+	if e.External {
+		return t.ExternalALB
+	}
+	return t.InternalALB
+}
+
 // Execute builds the template, and writes it to w.
 func (t *EmpireTemplate) Execute(w io.Writer, data interface{}) error {
 	v, err := t.Build(data.(*scheduler.App))
@@ -163,17 +232,31 @@ func (t *EmpireTemplate) Build(app *scheduler.App) (*troposphere.Template, error
 	deploymentMappings := []interface{}{}
 	scheduledProcesses := map[string]string{}
 
-	if taskDefinitionResourceType(app) == "Custom::ECSTaskDefinition" {
+	// When the app is a canary, the existing resources are built from the
+	// stable release, so that they're left as is, and the canary processes
+	// are added as a separate set of services.
+	stable := app
+	if app.Stable != nil {
+		stable = app.Stable
+	}
+
+	for _, a := range []*scheduler.App{stable, app} {
+		if err := t.validateSecrets(a); err != nil {
+			return nil, err
+		}
+	}
+
+	if taskDefinitionResourceType(stable) == "Custom::ECSTaskDefinition" {
 		tmpl.Resources[appEnvironment] = troposphere.Resource{
 			Type: "Custom::ECSEnvironment",
 			Properties: map[string]interface{}{
 				"ServiceToken": t.CustomResourcesTopic,
-				"Environment":  sortedEnvironment(app.Env),
+				"Environment":  sortedEnvironment(stable.Env),
 			},
 		}
 	}
 
-	for _, p := range app.Processes {
+	for _, p := range stable.Processes {
 		if p.Env == nil {
 			p.Env = make(map[string]string)
 		}
@@ -187,13 +270,49 @@ func (t *EmpireTemplate) Build(app *scheduler.App) (*troposphere.Template, error
 			// To save space in the template, avoid adding the
 			// resources if the process is scaled down.
 			if p.Instances > 0 {
-				taskDefinition := t.addScheduledTask(tmpl, app, p)
+				taskDefinition := t.addScheduledTask(tmpl, stable, p)
 				scheduledProcesses[p.Type] = taskDefinition.Name
 			}
 		default:
-			service := t.addService(tmpl, app, p)
+			service := t.addService(tmpl, stable, p)
 			serviceMappings = append(serviceMappings, Join("=", p.Type, Ref(service)))
-			deploymentMappings = append(deploymentMappings, Join("=", p.Type, GetAtt(service, "DeploymentId")))
+			if hasDeployment(tmpl, service) {
+				deploymentMappings = append(deploymentMappings, Join("=", p.Type, GetAtt(service, "DeploymentId")))
+			}
+		}
+	}
+
+	if app.Stable != nil {
+		if taskDefinitionResourceType(app) == "Custom::ECSTaskDefinition" {
+			tmpl.Resources[appEnvironment+canarySuffix] = troposphere.Resource{
+				Type: "Custom::ECSEnvironment",
+				Properties: map[string]interface{}{
+					"ServiceToken": t.CustomResourcesTopic,
+					"Environment":  sortedEnvironment(app.Env),
+				},
+			}
+		}
+
+		for _, p := range app.Processes {
+			// Scheduled processes continue to run from the stable
+			// release until the canary is promoted.
+			if p.Schedule != nil {
+				continue
+			}
+
+			if p.Env == nil {
+				p.Env = make(map[string]string)
+			}
+
+			tmpl.Parameters[canaryScaleParameter(p.Type)] = troposphere.Parameter{
+				Type: "String",
+			}
+
+			service := t.addCanaryService(tmpl, app, p)
+			serviceMappings = append(serviceMappings, Join("=", canaryProcessType(p.Type), Ref(service)))
+			if hasDeployment(tmpl, service) {
+				deploymentMappings = append(deploymentMappings, Join("=", canaryProcessType(p.Type), GetAtt(service, "DeploymentId")))
+			}
 		}
 	}
 
@@ -208,8 +327,11 @@ func (t *EmpireTemplate) Build(app *scheduler.App) (*troposphere.Template, error
 	return tmpl, nil
 }
 
-func (t *EmpireTemplate) addTaskDefinition(tmpl *troposphere.Template, app *scheduler.App, p *scheduler.Process) (troposphere.NamedResource, *ContainerDefinitionProperties) {
-	key := processResourceName(p.Type)
+// addTaskDefinition adds the task definition for the process to the template.
+// The suffix is appended to the names of the resources, which allows multiple
+// task definitions to exist for the same process (e.g. for canaries).
+func (t *EmpireTemplate) addTaskDefinition(tmpl *troposphere.Template, app *scheduler.App, p *scheduler.Process, suffix string) (troposphere.NamedResource, *ContainerDefinitionProperties) {
+	key := processResourceName(p.Type) + suffix
 	// The task definition that will be used to run the ECS task.
 	taskDefinition := troposphere.NamedResource{
 		Name: fmt.Sprintf("%sTaskDefinition", key),
@@ -233,7 +355,7 @@ func (t *EmpireTemplate) addTaskDefinition(tmpl *troposphere.Template, app *sche
 		}
 
 		containerDefinition.Environment = []interface{}{
-			Ref(appEnvironment),
+			Ref(appEnvironment + suffix),
 			Ref(processEnvironment),
 		}
 		taskDefinitionProperties = &CustomTaskDefinitionProperties{
@@ -246,12 +368,17 @@ func (t *EmpireTemplate) addTaskDefinition(tmpl *troposphere.Template, app *sche
 		}
 	} else {
 		containerDefinition.Environment = cd.Environment
-		taskDefinitionProperties = &TaskDefinitionProperties{
+		containerDefinition.Secrets = containerSecrets(app, p)
+		properties := &TaskDefinitionProperties{
 			Volumes: []interface{}{},
 			ContainerDefinitions: []*ContainerDefinitionProperties{
 				containerDefinition,
 			},
 		}
+		if len(containerDefinition.Secrets) > 0 {
+			properties.ExecutionRoleArn = t.ExecutionRoleArn
+		}
+		taskDefinitionProperties = properties
 	}
 
 	taskDefinition.Resource = troposphere.Resource{
@@ -263,10 +390,33 @@ func (t *EmpireTemplate) addTaskDefinition(tmpl *troposphere.Template, app *sche
 	return taskDefinition, containerDefinition
 }
 
+// validateSecrets checks that ECS will be able to resolve the app's secrets when
+// tasks are launched. ECS only resolves secrets from SSM Parameter Store, and
+// only for task definitions that it manages itself.
+func (t *EmpireTemplate) validateSecrets(app *scheduler.App) error {
+	if len(app.Secrets) == 0 {
+		return nil
+	}
+
+	if t.ExecutionRoleArn == "" {
+		return errors.New("an ECS task execution role is required to use secrets")
+	}
+	if taskDefinitionResourceType(app) == "Custom::ECSTaskDefinition" {
+		return errors.New("secrets are not supported with ECS_TASK_DEFINITION=custom")
+	}
+	for k, ref := range app.Secrets {
+		if _, ok := ssm.ParameterName(ref); !ok {
+			return fmt.Errorf("unable to use secret %s: ECS can only resolve secrets from the ssm secrets backend", k)
+		}
+	}
+
+	return nil
+}
+
 func (t *EmpireTemplate) addScheduledTask(tmpl *troposphere.Template, app *scheduler.App, p *scheduler.Process) troposphere.NamedResource {
 	key := processResourceName(p.Type)
 
-	taskDefinition, _ := t.addTaskDefinition(tmpl, app, p)
+	taskDefinition, _ := t.addTaskDefinition(tmpl, app, p, "")
 
 	schedule := fmt.Sprintf("%sTrigger", key)
 	tmpl.Resources[schedule] = troposphere.Resource{
@@ -314,117 +464,518 @@ func (t *EmpireTemplate) addService(tmpl *troposphere.Template, app *scheduler.A
 	// resources intead, which does not wait for the service to stabilize
 	// after updating.
 	ecsServiceType := "Custom::ECSService"
+	service := fmt.Sprintf("%sService", key)
 
 	var portMappings []*PortMappingProperties
+	var dependsOn []string
 
 	loadBalancers := []map[string]interface{}{}
 	if p.Exposure != nil {
-		scheme := schemeInternal
-		sg := t.InternalSecurityGroupID
-		subnets := t.InternalSubnetIDs
+		var dnsName interface{}
+
+		if alb := t.applicationLoadBalancer(p.Exposure); alb != nil {
+			targetGroup, rules := t.addTargetGroup(tmpl, app, p, alb)
+
+			// Custom::ECSService doesn't support target groups, so
+			// processes behind an Application Load Balancer use
+			// the standard AWS::ECS::Service resource. The type of
+			// a resource can't be changed, so it also gets a
+			// different name.
+			ecsServiceType = "AWS::ECS::Service"
+			service = fmt.Sprintf("%sALBService", key)
+
+			// Target groups support dynamic host ports, so ECS
+			// can pick the host port.
+			portMappings = append(portMappings, &PortMappingProperties{
+				ContainerPort: ContainerPort,
+			})
+			loadBalancers = append(loadBalancers, map[string]interface{}{
+				"ContainerName":  p.Type,
+				"ContainerPort":  ContainerPort,
+				"TargetGroupArn": Ref(targetGroup),
+			})
+
+			// The target group needs to be attached to the load
+			// balancer before the service can be created.
+			dependsOn = rules
+			dnsName = alb.DNSName
+		} else {
+			loadBalancer, instancePort := t.addLoadBalancer(tmpl, p)
 
-		if p.Exposure.External {
-			scheme = schemeExternal
-			sg = t.ExternalSecurityGroupID
-			subnets = t.ExternalSubnetIDs
+			portMappings = append(portMappings, &PortMappingProperties{
+				ContainerPort: ContainerPort,
+				HostPort:      GetAtt(instancePort, "InstancePort"),
+			})
+			loadBalancers = append(loadBalancers, map[string]interface{}{
+				"ContainerName":    p.Type,
+				"ContainerPort":    ContainerPort,
+				"LoadBalancerName": Ref(loadBalancer),
+			})
+			dnsName = GetAtt(loadBalancer, "DNSName")
 		}
+		p.Env["PORT"] = fmt.Sprintf("%d", ContainerPort)
 
-		instancePort := fmt.Sprintf("%s%dInstancePort", key, ContainerPort)
-		tmpl.Resources[instancePort] = troposphere.Resource{
-			Type:    "Custom::InstancePort",
-			Version: "1.0",
+		// The web process gets <app>.<zone>, and other exposed
+		// processes get <process>.<app>.<zone>.
+		cname, name := fmt.Sprintf("%sCNAME", key), processHostname(app.Name, p, t.HostedZone)
+		if p.Type == "web" {
+			cname = "CNAME"
+		}
+		tmpl.Resources[cname] = t.cnameRecord(name, dnsName)
+
+		// Records for the previous names of a renamed app are kept
+		// until the aliases expire.
+		for _, alias := range app.Aliases {
+			tmpl.Resources[aliasResourceName(cname, alias)] = t.cnameRecord(processHostname(alias, p, t.HostedZone), dnsName)
+		}
+	}
+
+	taskDefinition, containerDefinition := t.addTaskDefinition(tmpl, app, p, "")
+
+	containerDefinition.DockerLabels[restartLabel] = Ref(restartParameter)
+	containerDefinition.PortMappings = portMappings
+
+	serviceProperties := map[string]interface{}{
+		"Cluster":        t.Cluster,
+		"DesiredCount":   Ref(scaleParameter(p.Type)),
+		"LoadBalancers":  loadBalancers,
+		"TaskDefinition": Ref(taskDefinition),
+	}
+	if ecsServiceType == "Custom::ECSService" {
+		serviceProperties["ServiceName"] = fmt.Sprintf("%s-%s", app.Name, p.Type)
+		serviceProperties["ServiceToken"] = t.CustomResourcesTopic
+	}
+	if len(loadBalancers) > 0 {
+		serviceProperties["Role"] = t.ServiceRole
+	}
+	resource := troposphere.Resource{
+		Type:       ecsServiceType,
+		Properties: serviceProperties,
+	}
+	if len(dependsOn) > 0 {
+		resource.DependsOn = dependsOn
+	}
+	tmpl.Resources[service] = resource
+
+	if p.Autoscaling != nil {
+		t.addAutoscaling(tmpl, app, p, service)
+	}
+
+	return service
+}
+
+// addAutoscaling adds an Application Auto Scaling target for the service, and
+// target tracking policies for the CPU and memory utilization targets.
+func (t *EmpireTemplate) addAutoscaling(tmpl *troposphere.Template, app *scheduler.App, p *scheduler.Process, service string) {
+	key := processResourceName(p.Type)
+	a := p.Autoscaling
+
+	// The ARN of an ECS service ends with service/<name>.
+	serviceName := Select(1, Split("/", Ref(service)))
+
+	target := fmt.Sprintf("%sScalableTarget", key)
+	tmpl.Resources[target] = troposphere.Resource{
+		Type: "AWS::ApplicationAutoScaling::ScalableTarget",
+		Properties: map[string]interface{}{
+			"MinCapacity":       a.MinInstances,
+			"MaxCapacity":       a.MaxInstances,
+			"ResourceId":        Join("/", "service", t.clusterName(), serviceName),
+			"RoleARN":           t.serviceRoleArn(),
+			"ScalableDimension": "ecs:service:DesiredCount",
+			"ServiceNamespace":  "ecs",
+		},
+	}
+
+	policies := []struct {
+		name   string
+		metric string
+		target int
+	}{
+		{"CPU", "ECSServiceAverageCPUUtilization", a.TargetCPUUtilization},
+		{"Memory", "ECSServiceAverageMemoryUtilization", a.TargetMemoryUtilization},
+	}
+	for _, policy := range policies {
+		if policy.target == 0 {
+			continue
+		}
+
+		tmpl.Resources[fmt.Sprintf("%s%sScalingPolicy", key, policy.name)] = troposphere.Resource{
+			Type: "AWS::ApplicationAutoScaling::ScalingPolicy",
 			Properties: map[string]interface{}{
-				"ServiceToken": t.CustomResourcesTopic,
+				"PolicyName":      fmt.Sprintf("%s-%s-%s", app.Name, p.Type, strings.ToLower(policy.name)),
+				"PolicyType":      "TargetTrackingScaling",
+				"ScalingTargetId": Ref(target),
+				"TargetTrackingScalingPolicyConfiguration": map[string]interface{}{
+					"TargetValue":      policy.target,
+					"ScaleInCooldown":  int(a.ScaleInCooldown.Seconds()),
+					"ScaleOutCooldown": int(a.ScaleOutCooldown.Seconds()),
+					"PredefinedMetricSpecification": map[string]interface{}{
+						"PredefinedMetricType": policy.metric,
+					},
+				},
 			},
 		}
+	}
+}
+
+// clusterName returns the name of the ECS cluster, when the Cluster option is
+// an ARN.
+func (t *EmpireTemplate) clusterName() string {
+	if id, err := arn.ResourceID(t.Cluster); err == nil {
+		return id
+	}
+	return t.Cluster
+}
 
-		listeners := []map[string]interface{}{
-			map[string]interface{}{
-				"LoadBalancerPort": 80,
-				"Protocol":         "http",
+// addLoadBalancer adds a classic ELB for the process, and the instance port
+// that the ELB forwards to.
+func (t *EmpireTemplate) addLoadBalancer(tmpl *troposphere.Template, p *scheduler.Process) (loadBalancer, instancePort string) {
+	key := processResourceName(p.Type)
+
+	scheme := schemeInternal
+	sg := t.InternalSecurityGroupID
+	subnets := t.InternalSubnetIDs
+
+	if p.Exposure.External {
+		scheme = schemeExternal
+		sg = t.ExternalSecurityGroupID
+		subnets = t.ExternalSubnetIDs
+	}
+
+	instancePort = fmt.Sprintf("%s%dInstancePort", key, ContainerPort)
+	tmpl.Resources[instancePort] = troposphere.Resource{
+		Type:    "Custom::InstancePort",
+		Version: "1.0",
+		Properties: map[string]interface{}{
+			"ServiceToken": t.CustomResourcesTopic,
+		},
+	}
+
+	var listeners []map[string]interface{}
+	switch e := p.Exposure.Type.(type) {
+	case *scheduler.TCPExposure:
+		for _, port := range e.Ports {
+			listeners = append(listeners, map[string]interface{}{
+				"LoadBalancerPort": port,
+				"Protocol":         "tcp",
 				"InstancePort":     GetAtt(instancePort, "InstancePort"),
-				"InstanceProtocol": "http",
-			},
+				"InstanceProtocol": "tcp",
+			})
 		}
+	case *scheduler.SSLExposure:
+		for _, port := range e.Ports {
+			listeners = append(listeners, map[string]interface{}{
+				"LoadBalancerPort": port,
+				"Protocol":         "ssl",
+				"InstancePort":     GetAtt(instancePort, "InstancePort"),
+				"SSLCertificateId": certificateId(e.Cert),
+				"InstanceProtocol": "tcp",
+			})
+		}
+	default:
+		listeners = append(listeners, map[string]interface{}{
+			"LoadBalancerPort": 80,
+			"Protocol":         "http",
+			"InstancePort":     GetAtt(instancePort, "InstancePort"),
+			"InstanceProtocol": "http",
+		})
 
-		if e, ok := p.Exposure.Type.(*scheduler.HTTPSExposure); ok {
-			var cert interface{}
-			if _, err := arn.Parse(e.Cert); err == nil {
-				cert = e.Cert
-			} else {
-				cert = Join("", "arn:aws:iam::", Ref("AWS::AccountId"), ":server-certificate/", e.Cert)
-			}
-
+		if e, ok := e.(*scheduler.HTTPSExposure); ok {
 			listeners = append(listeners, map[string]interface{}{
 				"LoadBalancerPort": 443,
 				"Protocol":         "https",
 				"InstancePort":     GetAtt(instancePort, "InstancePort"),
-				"SSLCertificateId": cert,
+				"SSLCertificateId": certificateId(e.Cert),
 				"InstanceProtocol": "http",
 			})
 		}
+	}
+
+	loadBalancer = fmt.Sprintf("%sLoadBalancer", key)
+	loadBalancerProperties := map[string]interface{}{
+		"Scheme":         scheme,
+		"SecurityGroups": []string{sg},
+		"Subnets":        subnets,
+		"Listeners":      listeners,
+		"CrossZone":      true,
+		"Tags": []map[string]string{
+			map[string]string{
+				"Key":   "empire.app.process",
+				"Value": p.Type,
+			},
+		},
+		"ConnectionDrainingPolicy": map[string]interface{}{
+			"Enabled": true,
+			"Timeout": defaultConnectionDrainingTimeout,
+		},
+	}
+	if p.HealthCheck != nil {
+		loadBalancerProperties["HealthCheck"] = healthCheck(p.HealthCheck, GetAtt(instancePort, "InstancePort"))
+	}
+	tmpl.Resources[loadBalancer] = troposphere.Resource{
+		Type:       "AWS::ElasticLoadBalancing::LoadBalancer",
+		Properties: loadBalancerProperties,
+	}
+
+	return loadBalancer, instancePort
+}
+
+// addTargetGroup adds a target group for the process, and the listener rules
+// that route requests for its hostnames to it. The web process gets
+// <app>.<zone>, along with the domains of the app, and other processes get
+// <process>.<app>.<zone>. It returns the name of the target group and the
+// names of the listener rules.
+func (t *EmpireTemplate) addTargetGroup(tmpl *troposphere.Template, app *scheduler.App, p *scheduler.Process, alb *ApplicationLoadBalancer) (targetGroup string, rules []string) {
+	key := processResourceName(p.Type)
+
+	targetGroup = fmt.Sprintf("%sTargetGroup", key)
+	targetGroupProperties := map[string]interface{}{
+		// Targets are registered with the host port that ECS picks,
+		// so this is only a default.
+		"Port":     80,
+		"Protocol": "HTTP",
+		"VpcId":    t.VpcID,
+		"TargetGroupAttributes": []map[string]interface{}{
+			{
+				"Key":   "deregistration_delay.timeout_seconds",
+				"Value": fmt.Sprintf("%d", defaultConnectionDrainingTimeout),
+			},
+		},
+		"Tags": []map[string]string{
+			map[string]string{
+				"Key":   "empire.app.process",
+				"Value": p.Type,
+			},
+		},
+	}
+	if hc := p.HealthCheck; hc != nil {
+		path := hc.Path
+		if path == "" {
+			path = "/"
+		}
+		targetGroupProperties["HealthCheckPath"] = path
+		targetGroupProperties["HealthCheckIntervalSeconds"] = int(hc.Interval.Seconds())
+		targetGroupProperties["HealthCheckTimeoutSeconds"] = int(hc.Timeout.Seconds())
+		targetGroupProperties["HealthyThresholdCount"] = hc.HealthyThreshold
+		targetGroupProperties["UnhealthyThresholdCount"] = hc.UnhealthyThreshold
+	}
+	tmpl.Resources[targetGroup] = troposphere.Resource{
+		Type:       "AWS::ElasticLoadBalancingV2::TargetGroup",
+		Properties: targetGroupProperties,
+	}
+
+	listeners := map[string]string{"HTTP": alb.HTTPListenerArn}
+	if e, ok := p.Exposure.Type.(*scheduler.HTTPSExposure); ok && alb.HTTPSListenerArn != "" {
+		listeners["HTTPS"] = alb.HTTPSListenerArn
+
+		tmpl.Resources[fmt.Sprintf("%sListenerCertificate", key)] = troposphere.Resource{
+			Type: "AWS::ElasticLoadBalancingV2::ListenerCertificate",
+			Properties: map[string]interface{}{
+				"ListenerArn": alb.HTTPSListenerArn,
+				"Certificates": []map[string]interface{}{
+					{"CertificateArn": certificateId(e.Cert)},
+				},
+			},
+		}
+	}
+
+	domains := []scheduler.Domain{
+		{Hostname: strings.TrimSuffix(processHostname(app.Name, p, t.HostedZone), ".")},
+	}
+	for _, alias := range app.Aliases {
+		domains = append(domains, scheduler.Domain{Hostname: strings.TrimSuffix(processHostname(alias, p, t.HostedZone), ".")})
+	}
+	if p.Type == "web" {
+		domains = append(domains, app.Domains...)
+	}
+
+	for _, domain := range domains {
+		id := domainResourceName(domain)
+
+		// Listener rules need a priority that's unique within the
+		// listener, which is allocated from the database.
+		priority := fmt.Sprintf("%s%sPriority", key, id)
+		tmpl.Resources[priority] = troposphere.Resource{
+			Type: "Custom::ListenerRulePriority",
+			Properties: map[string]interface{}{
+				"ServiceToken": t.CustomResourcesTopic,
+				"Path":         domain.Path,
+			},
+		}
+
+		conditions := []map[string]interface{}{
+			{"Field": "host-header", "Values": []string{domain.Hostname}},
+		}
+		if domain.Path != "" {
+			// Match the path itself, and anything nested under it,
+			// but not other paths that share the prefix (e.g. /v2
+			// shouldn't match /v2beta).
+			path := strings.TrimSuffix(domain.Path, "/")
+			conditions = append(conditions, map[string]interface{}{
+				"Field": "path-pattern",
+				"PathPatternConfig": map[string]interface{}{
+					"Values": []string{path, path + "/*"},
+				},
+			})
+		}
+
+		for _, protocol := range []string{"HTTP", "HTTPS"} {
+			listener, ok := listeners[protocol]
+			if !ok {
+				continue
+			}
+
+			rule := fmt.Sprintf("%s%s%sRule", key, id, protocol)
+			tmpl.Resources[rule] = troposphere.Resource{
+				Type: "AWS::ElasticLoadBalancingV2::ListenerRule",
+				Properties: map[string]interface{}{
+					"ListenerArn": listener,
+					"Priority":    GetAtt(priority, "Priority"),
+					"Conditions":  conditions,
+					"Actions": []map[string]interface{}{
+						{"Type": "forward", "TargetGroupArn": Ref(targetGroup)},
+					},
+				},
+			}
+			rules = append(rules, rule)
+		}
+	}
+
+	return targetGroup, rules
+}
+
+// cnameRecord returns a CNAME record in the hosted zone, which points name at
+// dnsName.
+func (t *EmpireTemplate) cnameRecord(name string, dnsName interface{}) troposphere.Resource {
+	return troposphere.Resource{
+		Type:      "AWS::Route53::RecordSet",
+		Condition: "DNSCondition",
+		Properties: map[string]interface{}{
+			"HostedZoneId":    *t.HostedZone.Id,
+			"Name":            name,
+			"Type":            "CNAME",
+			"TTL":             defaultCNAMETTL,
+			"ResourceRecords": []interface{}{dnsName},
+		},
+	}
+}
+
+// processHostname returns the hostname of the CNAME for an exposed process of
+// the app with the given name.
+func processHostname(app string, p *scheduler.Process, zone *route53.HostedZone) string {
+	if p.Type == "web" {
+		return fmt.Sprintf("%s.%s", app, *zone.Name)
+	}
+	return fmt.Sprintf("%s.%s.%s", p.Type, app, *zone.Name)
+}
+
+// aliasResourceName returns a stable identifier for the CNAME record of a
+// previous name of the app.
+func aliasResourceName(cname, alias string) string {
+	h := fnv.New32a()
+	h.Write([]byte(alias))
+	return fmt.Sprintf("%sAlias%08x", cname, h.Sum32())
+}
+
+// domainResourceName returns a stable identifier for a domain that can be used
+// in resource names.
+func domainResourceName(d scheduler.Domain) string {
+	h := fnv.New32a()
+	h.Write([]byte(d.Hostname + d.Path))
+	return fmt.Sprintf("Domain%08x", h.Sum32())
+}
+
+// hasDeployment returns true if the service resource exposes a DeploymentId.
+// AWS::ECS::Service resources don't, because CloudFormation already waits for
+// them to stabilize.
+func hasDeployment(tmpl *troposphere.Template, service string) bool {
+	return tmpl.Resources[service].Type == "Custom::ECSService"
+}
+
+// certificateId returns the SSLCertificateId for a listener. cert can either be
+// the ARN of a certificate, or the name of an IAM server certificate.
+func certificateId(cert string) interface{} {
+	if _, err := arn.Parse(cert); err == nil {
+		return cert
+	}
+	return Join("", "arn:aws:iam::", Ref("AWS::AccountId"), ":server-certificate/", cert)
+}
+
+// healthCheck returns the HealthCheck property for an ELB that checks the
+// health of instances on the given port.
+func healthCheck(hc *scheduler.HealthCheck, port interface{}) map[string]interface{} {
+	target := Join("", "TCP:", port)
+	if hc.Path != "" {
+		target = Join("", "HTTP:", port, hc.Path)
+	}
+
+	return map[string]interface{}{
+		"Target":             target,
+		"Interval":           fmt.Sprintf("%d", int(hc.Interval.Seconds())),
+		"Timeout":            fmt.Sprintf("%d", int(hc.Timeout.Seconds())),
+		"HealthyThreshold":   fmt.Sprintf("%d", hc.HealthyThreshold),
+		"UnhealthyThreshold": fmt.Sprintf("%d", hc.UnhealthyThreshold),
+	}
+}
+
+// addCanaryService adds an ECS service that runs the canary release of a
+// process. If the stable release of the process is attached to a load
+// balancer (or target group), the canary service is attached to the same one, so that
+// it receives a share of the traffic relative to the number of instances.
+func (t *EmpireTemplate) addCanaryService(tmpl *troposphere.Template, app *scheduler.App, p *scheduler.Process) (serviceName string) {
+	key := processResourceName(p.Type)
+
+	var portMappings []*PortMappingProperties
+
+	ecsServiceType := "Custom::ECSService"
+	service := fmt.Sprintf("%s%sService", key, canarySuffix)
+
+	loadBalancers := []map[string]interface{}{}
+	loadBalancer := fmt.Sprintf("%sLoadBalancer", key)
+	targetGroup := fmt.Sprintf("%sTargetGroup", key)
+	if _, ok := tmpl.Resources[targetGroup]; ok && p.Exposure != nil {
+		ecsServiceType = "AWS::ECS::Service"
+		service = fmt.Sprintf("%s%sALBService", key, canarySuffix)
+
+		portMappings = append(portMappings, &PortMappingProperties{
+			ContainerPort: ContainerPort,
+		})
+		p.Env["PORT"] = fmt.Sprintf("%d", ContainerPort)
 
+		loadBalancers = append(loadBalancers, map[string]interface{}{
+			"ContainerName":  p.Type,
+			"ContainerPort":  ContainerPort,
+			"TargetGroupArn": Ref(targetGroup),
+		})
+	} else if _, ok := tmpl.Resources[loadBalancer]; ok && p.Exposure != nil {
+		instancePort := fmt.Sprintf("%s%dInstancePort", key, ContainerPort)
 		portMappings = append(portMappings, &PortMappingProperties{
 			ContainerPort: ContainerPort,
 			HostPort:      GetAtt(instancePort, "InstancePort"),
 		})
 		p.Env["PORT"] = fmt.Sprintf("%d", ContainerPort)
 
-		loadBalancer := fmt.Sprintf("%sLoadBalancer", key)
 		loadBalancers = append(loadBalancers, map[string]interface{}{
 			"ContainerName":    p.Type,
 			"ContainerPort":    ContainerPort,
 			"LoadBalancerName": Ref(loadBalancer),
 		})
-		tmpl.Resources[loadBalancer] = troposphere.Resource{
-			Type: "AWS::ElasticLoadBalancing::LoadBalancer",
-			Properties: map[string]interface{}{
-				"Scheme":         scheme,
-				"SecurityGroups": []string{sg},
-				"Subnets":        subnets,
-				"Listeners":      listeners,
-				"CrossZone":      true,
-				"Tags": []map[string]string{
-					map[string]string{
-						"Key":   "empire.app.process",
-						"Value": p.Type,
-					},
-				},
-				"ConnectionDrainingPolicy": map[string]interface{}{
-					"Enabled": true,
-					"Timeout": defaultConnectionDrainingTimeout,
-				},
-			},
-		}
-
-		if p.Type == "web" {
-			tmpl.Resources["CNAME"] = troposphere.Resource{
-				Type:      "AWS::Route53::RecordSet",
-				Condition: "DNSCondition",
-				Properties: map[string]interface{}{
-					"HostedZoneId":    *t.HostedZone.Id,
-					"Name":            fmt.Sprintf("%s.%s", app.Name, *t.HostedZone.Name),
-					"Type":            "CNAME",
-					"TTL":             defaultCNAMETTL,
-					"ResourceRecords": []interface{}{GetAtt(loadBalancer, "DNSName")},
-				},
-			}
-		}
 	}
 
-	taskDefinition, containerDefinition := t.addTaskDefinition(tmpl, app, p)
+	taskDefinition, containerDefinition := t.addTaskDefinition(tmpl, app, p, canarySuffix)
 
 	containerDefinition.DockerLabels[restartLabel] = Ref(restartParameter)
 	containerDefinition.PortMappings = portMappings
 
-	service := fmt.Sprintf("%sService", key)
 	serviceProperties := map[string]interface{}{
 		"Cluster":        t.Cluster,
-		"DesiredCount":   Ref(scaleParameter(p.Type)),
+		"DesiredCount":   Ref(canaryScaleParameter(p.Type)),
 		"LoadBalancers":  loadBalancers,
 		"TaskDefinition": Ref(taskDefinition),
-		"ServiceName":    fmt.Sprintf("%s-%s", app.Name, p.Type),
-		"ServiceToken":   t.CustomResourcesTopic,
+	}
+	if ecsServiceType == "Custom::ECSService" {
+		serviceProperties["ServiceName"] = fmt.Sprintf("%s-%s", app.Name, canaryProcessType(p.Type))
+		serviceProperties["ServiceToken"] = t.CustomResourcesTopic
 	}
 	if len(loadBalancers) > 0 {
 		serviceProperties["Role"] = t.ServiceRole
@@ -528,6 +1079,18 @@ func scaleParameter(process string) string {
 	return fmt.Sprintf("%sScale", processResourceName(process))
 }
 
+// canaryScaleParameter returns the name of the parameter used to control the
+// scale of the canary release of a process.
+func canaryScaleParameter(process string) string {
+	return fmt.Sprintf("%s%sScale", processResourceName(process), canarySuffix)
+}
+
+// canaryProcessType returns the name that's used to identify the canary
+// release of a process in the stack outputs.
+func canaryProcessType(process string) string {
+	return fmt.Sprintf("%s-canary", process)
+}
+
 // cloudformationContainerDefinition returns the CloudFormation representation
 // of a ecs.ContainerDefinition.
 func cloudformationContainerDefinition(cd *ecs.ContainerDefinition) *ContainerDefinitionProperties {
@@ -553,6 +1116,28 @@ func cloudformationContainerDefinition(cd *ecs.ContainerDefinition) *ContainerDe
 	return c
 }
 
+// containerSecrets returns the secrets for the container, which reference the
+// SSM parameters that ECS resolves them from, sorted by name.
+func containerSecrets(app *scheduler.App, p *scheduler.Process) []*SecretProperties {
+	secrets := scheduler.Secrets(app, p)
+
+	var names []string
+	for k := range secrets {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	var properties []*SecretProperties
+	for _, k := range names {
+		name, _ := ssm.ParameterName(secrets[k])
+		properties = append(properties, &SecretProperties{
+			Name:      k,
+			ValueFrom: name,
+		})
+	}
+	return properties
+}
+
 // sortedEnvironment takes a map[string]string and returns a sorted slice of
 // ecs.KeyValuePair.
 func sortedEnvironment(environment map[string]string) []*ecs.KeyValuePair {
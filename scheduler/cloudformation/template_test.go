@@ -177,6 +177,189 @@ func TestEmpireTemplate(t *testing.T) {
 				},
 			},
 		},
+
+		{
+			"canary.json",
+			&scheduler.App{
+				ID:      "1234",
+				Release: "v2",
+				Name:    "acme-inc",
+				Processes: []*scheduler.Process{
+					{
+						Type:    "web",
+						Image:   image.Image{Repository: "remind101/acme-inc", Tag: "v2"},
+						Command: []string{"./bin/web"},
+						Exposure: &scheduler.Exposure{
+							Type: &scheduler.HTTPExposure{},
+						},
+						Labels: map[string]string{
+							"empire.app.process": "web",
+						},
+						MemoryLimit: 128 * bytesize.MB,
+						CPUShares:   256,
+						Instances:   1,
+						Nproc:       256,
+					},
+					{
+						Type:      "vacuum",
+						Image:     image.Image{Repository: "remind101/acme-inc", Tag: "v2"},
+						Command:   []string{"./bin/vacuum"},
+						Schedule:  scheduler.CRONSchedule("* * * * *"),
+						Instances: 1,
+						Labels: map[string]string{
+							"empire.app.process": "vacuum",
+						},
+						MemoryLimit: 128 * bytesize.MB,
+						CPUShares:   256,
+						Nproc:       256,
+					},
+				},
+				Stable: &scheduler.App{
+					ID:      "1234",
+					Release: "v1",
+					Name:    "acme-inc",
+					Processes: []*scheduler.Process{
+						{
+							Type:    "web",
+							Image:   image.Image{Repository: "remind101/acme-inc", Tag: "v1"},
+							Command: []string{"./bin/web"},
+							Exposure: &scheduler.Exposure{
+								Type: &scheduler.HTTPExposure{},
+							},
+							Labels: map[string]string{
+								"empire.app.process": "web",
+							},
+							MemoryLimit: 128 * bytesize.MB,
+							CPUShares:   256,
+							Instances:   9,
+							Nproc:       256,
+						},
+						{
+							Type:      "vacuum",
+							Image:     image.Image{Repository: "remind101/acme-inc", Tag: "v1"},
+							Command:   []string{"./bin/vacuum"},
+							Schedule:  scheduler.CRONSchedule("* * * * *"),
+							Instances: 1,
+							Labels: map[string]string{
+								"empire.app.process": "vacuum",
+							},
+							MemoryLimit: 128 * bytesize.MB,
+							CPUShares:   256,
+							Nproc:       256,
+						},
+					},
+				},
+			},
+		},
+
+		{
+			"healthcheck.json",
+			&scheduler.App{
+				ID:      "1234",
+				Release: "v1",
+				Name:    "acme-inc",
+				Processes: []*scheduler.Process{
+					{
+						Type:    "web",
+						Image:   image.Image{Repository: "remind101/acme-inc", Tag: "latest"},
+						Command: []string{"./bin/web"},
+						Exposure: &scheduler.Exposure{
+							Type: &scheduler.HTTPExposure{},
+						},
+						Labels: map[string]string{
+							"empire.app.process": "web",
+						},
+						MemoryLimit: 128 * bytesize.MB,
+						CPUShares:   256,
+						Instances:   1,
+						Nproc:       256,
+						HealthCheck: &scheduler.HealthCheck{
+							Path:               "/health",
+							Interval:           10 * time.Second,
+							Timeout:            2 * time.Second,
+							HealthyThreshold:   2,
+							UnhealthyThreshold: 3,
+						},
+					},
+				},
+			},
+		},
+
+		{
+			"autoscaling.json",
+			&scheduler.App{
+				ID:      "1234",
+				Release: "v1",
+				Name:    "acme-inc",
+				Processes: []*scheduler.Process{
+					{
+						Type:    "web",
+						Image:   image.Image{Repository: "remind101/acme-inc", Tag: "latest"},
+						Command: []string{"./bin/web"},
+						Exposure: &scheduler.Exposure{
+							Type: &scheduler.HTTPExposure{},
+						},
+						Labels: map[string]string{
+							"empire.app.process": "web",
+						},
+						MemoryLimit: 128 * bytesize.MB,
+						CPUShares:   256,
+						Instances:   2,
+						Nproc:       256,
+						Autoscaling: &scheduler.Autoscaling{
+							MinInstances:            2,
+							MaxInstances:            20,
+							TargetCPUUtilization:    60,
+							TargetMemoryUtilization: 80,
+							ScaleInCooldown:         5 * time.Minute,
+							ScaleOutCooldown:        time.Minute,
+						},
+					},
+				},
+			},
+		},
+
+		{
+			"tcp.json",
+			&scheduler.App{
+				ID:      "1234",
+				Release: "v1",
+				Name:    "acme-inc",
+				Processes: []*scheduler.Process{
+					{
+						Type:    "grpc",
+						Image:   image.Image{Repository: "remind101/acme-inc", Tag: "latest"},
+						Command: []string{"./bin/grpc"},
+						Exposure: &scheduler.Exposure{
+							External: true,
+							Type:     &scheduler.TCPExposure{Ports: []int{50051}},
+						},
+						Labels: map[string]string{
+							"empire.app.process": "grpc",
+						},
+						MemoryLimit: 128 * bytesize.MB,
+						CPUShares:   256,
+						Instances:   1,
+						Nproc:       256,
+					},
+					{
+						Type:    "admin",
+						Image:   image.Image{Repository: "remind101/acme-inc", Tag: "latest"},
+						Command: []string{"./bin/admin"},
+						Exposure: &scheduler.Exposure{
+							Type: &scheduler.SSLExposure{Ports: []int{443, 8443}, Cert: "AcmeIncDotCom"},
+						},
+						Labels: map[string]string{
+							"empire.app.process": "admin",
+						},
+						MemoryLimit: 128 * bytesize.MB,
+						CPUShares:   256,
+						Instances:   1,
+						Nproc:       256,
+					},
+				},
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -196,6 +379,195 @@ func TestEmpireTemplate(t *testing.T) {
 	}
 }
 
+func TestEmpireTemplate_ALB(t *testing.T) {
+	tmpl := newTemplate()
+	tmpl.NoCompress = true
+	tmpl.VpcID = "vpc-6a2b3c4d"
+	tmpl.InternalALB = &ApplicationLoadBalancer{
+		DNSName:         "internal-empire-1234.us-east-1.elb.amazonaws.com",
+		HTTPListenerArn: "arn:aws:elasticloadbalancing:us-east-1:012345678901:listener/app/internal/1234/80",
+	}
+	tmpl.ExternalALB = &ApplicationLoadBalancer{
+		DNSName:          "empire-5678.us-east-1.elb.amazonaws.com",
+		HTTPListenerArn:  "arn:aws:elasticloadbalancing:us-east-1:012345678901:listener/app/external/5678/80",
+		HTTPSListenerArn: "arn:aws:elasticloadbalancing:us-east-1:012345678901:listener/app/external/5678/443",
+	}
+	assert.NoError(t, tmpl.Validate())
+
+	app := &scheduler.App{
+		ID:      "1234",
+		Release: "v1",
+		Name:    "acme-inc",
+		Domains: []scheduler.Domain{
+			{Hostname: "api.acme-inc.com"},
+			{Hostname: "api.acme-inc.com", Path: "/v2"},
+		},
+		Processes: []*scheduler.Process{
+			{
+				Type:    "web",
+				Image:   image.Image{Repository: "remind101/acme-inc", Tag: "latest"},
+				Command: []string{"./bin/web"},
+				Exposure: &scheduler.Exposure{
+					External: true,
+					Type: &scheduler.HTTPSExposure{
+						Cert: "arn:aws:iam::012345678901:server-certificate/AcmeIncDotCom",
+					},
+				},
+				HealthCheck: &scheduler.HealthCheck{
+					Path:               "/health",
+					Interval:           10 * time.Second,
+					Timeout:            5 * time.Second,
+					HealthyThreshold:   2,
+					UnhealthyThreshold: 3,
+				},
+				Labels: map[string]string{
+					"empire.app.process": "web",
+				},
+				MemoryLimit: 128 * bytesize.MB,
+				CPUShares:   256,
+				Instances:   1,
+				Nproc:       256,
+			},
+			{
+				Type:    "admin",
+				Image:   image.Image{Repository: "remind101/acme-inc", Tag: "latest"},
+				Command: []string{"./bin/admin"},
+				Exposure: &scheduler.Exposure{
+					Type: &scheduler.HTTPExposure{},
+				},
+				Labels: map[string]string{
+					"empire.app.process": "admin",
+				},
+				MemoryLimit: 128 * bytesize.MB,
+				CPUShares:   256,
+				Instances:   1,
+				Nproc:       256,
+			},
+			{
+				Type:    "worker",
+				Image:   image.Image{Repository: "remind101/acme-inc", Tag: "latest"},
+				Command: []string{"./bin/worker"},
+				Labels: map[string]string{
+					"empire.app.process": "worker",
+				},
+				MemoryLimit: 128 * bytesize.MB,
+				CPUShares:   256,
+				Instances:   1,
+				Nproc:       256,
+			},
+		},
+	}
+
+	buf := new(bytes.Buffer)
+	filename := "templates/alb.json"
+	err := tmpl.Execute(buf, app)
+	assert.NoError(t, err)
+
+	expected, err := ioutil.ReadFile(filename)
+	assert.NoError(t, err)
+
+	assert.Equal(t, string(expected), buf.String())
+	ioutil.WriteFile(filename, buf.Bytes(), 0660)
+}
+
+func TestEmpireTemplate_Validate_ALB(t *testing.T) {
+	tmpl := newTemplate()
+	tmpl.ExternalALB = &ApplicationLoadBalancer{
+		DNSName:         "empire-5678.us-east-1.elb.amazonaws.com",
+		HTTPListenerArn: "arn:aws:elasticloadbalancing:us-east-1:012345678901:listener/app/external/5678/80",
+	}
+	assert.EqualError(t, tmpl.Validate(), "VpcID is required")
+
+	tmpl.VpcID = "vpc-6a2b3c4d"
+	assert.NoError(t, tmpl.Validate())
+
+	tmpl.ExternalALB.HTTPListenerArn = ""
+	assert.EqualError(t, tmpl.Validate(), "ApplicationLoadBalancer.HTTPListenerArn is required")
+}
+
+func TestEmpireTemplate_Secrets(t *testing.T) {
+	app := &scheduler.App{
+		ID:      "1234",
+		Release: "v1",
+		Name:    "acme-inc",
+		Env:     map[string]string{"RAILS_ENV": "production"},
+		Secrets: map[string]string{
+			"DATABASE_URL": "ssm:/empire/acme-inc/DATABASE_URL/1",
+			"REDIS_URL":    "ssm:/empire/acme-inc/REDIS_URL/1",
+		},
+		Processes: []*scheduler.Process{
+			{
+				Type:      "worker",
+				Image:     image.Image{Repository: "remind101/acme-inc", Tag: "latest"},
+				Command:   []string{"./bin/worker"},
+				Env:       map[string]string{"REDIS_URL": "redis://localhost"},
+				Instances: 1,
+			},
+		},
+	}
+
+	tmpl := newTemplate()
+	_, err := tmpl.Build(app)
+	assert.EqualError(t, err, "an ECS task execution role is required to use secrets")
+
+	tmpl.ExecutionRoleArn = "arn:aws:iam::012345678901:role/ecsTaskExecutionRole"
+	v, err := tmpl.Build(app)
+	assert.NoError(t, err)
+
+	td := v.Resources["workerTaskDefinition"].Properties.(*TaskDefinitionProperties)
+	assert.Equal(t, "arn:aws:iam::012345678901:role/ecsTaskExecutionRole", td.ExecutionRoleArn)
+	assert.Equal(t, []*SecretProperties{
+		{Name: "DATABASE_URL", ValueFrom: "/empire/acme-inc/DATABASE_URL/1"},
+	}, td.ContainerDefinitions[0].Secrets)
+
+	app.Secrets["DATABASE_URL"] = "vault:secret/empire/acme-inc/DATABASE_URL/1"
+	_, err = tmpl.Build(app)
+	assert.EqualError(t, err, "unable to use secret DATABASE_URL: ECS can only resolve secrets from the ssm secrets backend")
+
+	app.Secrets = map[string]string{"DATABASE_URL": "ssm:/empire/acme-inc/DATABASE_URL/1"}
+	app.Env["ECS_TASK_DEFINITION"] = "custom"
+	_, err = tmpl.Build(app)
+	assert.EqualError(t, err, "secrets are not supported with ECS_TASK_DEFINITION=custom")
+}
+
+func TestEmpireTemplate_Aliases(t *testing.T) {
+	app := &scheduler.App{
+		ID:      "1234",
+		Release: "v1",
+		Name:    "acme",
+		Aliases: []string{"acme-inc"},
+		Processes: []*scheduler.Process{
+			{
+				Type:    "web",
+				Image:   image.Image{Repository: "remind101/acme-inc", Tag: "latest"},
+				Command: []string{"./bin/web"},
+				Exposure: &scheduler.Exposure{
+					Type: &scheduler.HTTPExposure{},
+				},
+				Instances: 1,
+			},
+		},
+	}
+
+	tmpl := newTemplate()
+	v, err := tmpl.Build(app)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "acme.empire", v.Resources["CNAME"].Properties.(map[string]interface{})["Name"])
+
+	alias := v.Resources[aliasResourceName("CNAME", "acme-inc")]
+	assert.Equal(t, "AWS::Route53::RecordSet", alias.Type)
+	assert.Equal(t, "acme-inc.empire", alias.Properties.(map[string]interface{})["Name"])
+	assert.Equal(t, v.Resources["CNAME"].Properties.(map[string]interface{})["ResourceRecords"], alias.Properties.(map[string]interface{})["ResourceRecords"])
+
+	// Once the alias expires, the record is removed.
+	app.Aliases = nil
+	v, err = tmpl.Build(app)
+	assert.NoError(t, err)
+	_, ok := v.Resources[aliasResourceName("CNAME", "acme-inc")]
+	assert.False(t, ok)
+}
+
 func TestEmpireTemplate_Large(t *testing.T) {
 	labels := make(map[string]string)
 	env := make(map[string]string)
@@ -0,0 +1,40 @@
+package docker
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/remind101/empire/pkg/cron"
+	"github.com/remind101/empire/scheduler"
+)
+
+// schedule tracks when a scheduled process should next run.
+type schedule struct {
+	next func(time.Time) time.Time
+
+	// The next time that the process should run.
+	at time.Time
+}
+
+// newSchedule returns a schedule for the scheduler.Schedule, relative to now.
+func newSchedule(s scheduler.Schedule, now time.Time) (*schedule, error) {
+	var next func(time.Time) time.Time
+
+	switch v := s.(type) {
+	case scheduler.CRONSchedule:
+		expr, err := cron.Parse(string(v))
+		if err != nil {
+			return nil, err
+		}
+		next = expr.Next
+	case time.Duration:
+		if v <= 0 {
+			return nil, fmt.Errorf("invalid schedule interval: %v", v)
+		}
+		next = func(t time.Time) time.Time { return t.Add(v) }
+	default:
+		return nil, fmt.Errorf("unknown schedule: %v", s)
+	}
+
+	return &schedule{next: next, at: next(now)}, nil
+}
@@ -1,12 +1,17 @@
 // Package docker implements the Scheduler interface backed by the Docker API.
+//
+// The Scheduler can be used on it's own, to run all of the processes for an
+// app on a single Docker host, or wrapped around another Scheduler (with
+// RunAttachedWithDocker) to only run attached processes.
 package docker
 
 import (
 	"bytes"
-	"errors"
 	"fmt"
 	"io"
 	"strings"
+	"sync"
+	"time"
 
 	"code.google.com/p/go-uuid/uuid"
 
@@ -29,6 +34,7 @@ type dockerClient interface {
 	StartContainer(context.Context, string, *docker.HostConfig) error
 	StopContainer(context.Context, string, uint) error
 	AttachToContainer(context.Context, docker.AttachToContainerOptions) error
+	WaitContainer(context.Context, string) (int, error)
 }
 
 const (
@@ -41,6 +47,12 @@ const (
 
 	// Label that determines what the name of the process is.
 	processLabel = "empire.app.process"
+
+	// Label that holds a hash of the configuration of a long running
+	// process. This is used to determine when a container needs to be
+	// replaced, and to distinguish containers for long running processes
+	// from one-off runs.
+	hashLabel = "empire.app.process.hash"
 )
 
 // Values for `runLabel`.
@@ -58,6 +70,9 @@ type AttachedScheduler struct {
 	// processes interact with a single Docker daemon.
 	ShowAttached bool
 
+	// Used to resolve the secrets of apps for attached runs.
+	Secrets scheduler.SecretResolver
+
 	scheduler.Scheduler
 	dockerScheduler *Scheduler
 }
@@ -78,12 +93,42 @@ func (s *AttachedScheduler) Run(ctx context.Context, app *scheduler.App, process
 	attached := out != nil || in != nil
 
 	if attached {
-		return s.dockerScheduler.Run(ctx, app, process, in, out)
+		return s.dockerScheduler.runAttached(ctx, s.Secrets, app, process, in, out)
 	} else {
 		return s.Scheduler.Run(ctx, app, process, in, out)
 	}
 }
 
+// Plan previews changes using the wrapped scheduler.
+func (s *AttachedScheduler) Plan(ctx context.Context, app *scheduler.App) (*scheduler.Plan, error) {
+	return scheduler.PlanSubmit(ctx, s.Scheduler, app)
+}
+
+// Restart restarts the app using the wrapped scheduler.
+func (s *AttachedScheduler) Restart(ctx context.Context, app *scheduler.App, ss scheduler.StatusStream) error {
+	return scheduler.Restart(ctx, s.Scheduler, app, ss)
+}
+
+// DesiredCounts returns the desired counts from the wrapped scheduler.
+func (s *AttachedScheduler) DesiredCounts(ctx context.Context, app string) (map[string]uint, error) {
+	return scheduler.DesiredCounts(ctx, s.Scheduler, app)
+}
+
+// Orphans returns the orphaned resources from the wrapped scheduler.
+func (s *AttachedScheduler) Orphans(ctx context.Context, apps []string) ([]*scheduler.Orphan, error) {
+	return scheduler.Orphans(ctx, s.Scheduler, apps)
+}
+
+// Hosts returns the hosts from the wrapped scheduler.
+func (s *AttachedScheduler) Hosts(ctx context.Context) ([]*scheduler.Host, error) {
+	return scheduler.Hosts(ctx, s.Scheduler)
+}
+
+// RemoveOrphan removes an orphaned resource using the wrapped scheduler.
+func (s *AttachedScheduler) RemoveOrphan(ctx context.Context, orphan *scheduler.Orphan) error {
+	return scheduler.RemoveOrphan(ctx, s.Scheduler, orphan)
+}
+
 // Instances returns a combination of instances from the wrapped scheduler, as
 // well as instances from attached runs.
 func (s *AttachedScheduler) Instances(ctx context.Context, app string) ([]*scheduler.Instance, error) {
@@ -136,7 +181,34 @@ func (s *AttachedScheduler) Stop(ctx context.Context, maybeContainerID string) e
 // Scheduler provides an implementation of the scheduler.Scheduler interface
 // backed by Docker.
 type Scheduler struct {
+	// Used to resolve the secrets of apps when containers are started.
+	Secrets scheduler.SecretResolver
+
 	docker dockerClient
+
+	// Ensures that only one reconciliation happens at a time, so that
+	// containers aren't started twice.
+	reconcileMu sync.Mutex
+
+	// Protects apps and schedules.
+	mu sync.Mutex
+
+	// The apps that have been submitted to this scheduler, keyed by id.
+	// These are periodically reconciled by Start.
+	apps map[string]*scheduler.App
+
+	// The next time that each scheduled process should run.
+	schedules map[string]*schedule
+
+	// The health check state of the containers for each app, keyed by app
+	// id, then container id. Protected by reconcileMu.
+	health map[string]map[string]*healthState
+
+	// Performs a health check against a published address. Defaults to
+	// checkAddr.
+	check func(context.Context, string, *scheduler.HealthCheck) error
+
+	now func() time.Time
 }
 
 // NewScheduler returns a new Scheduler instance that uses the given client to
@@ -151,7 +223,17 @@ func (s *Scheduler) Run(ctx context.Context, app *scheduler.App, p *scheduler.Pr
 	attached := out != nil || in != nil
 
 	if !attached {
-		return errors.New("cannot run detached processes with Docker scheduler")
+		return s.runDetached(ctx, app, p)
+	}
+
+	return s.runAttached(ctx, s.Secrets, app, p, in, out)
+}
+
+// runAttached runs the process in a new container, and attaches to it.
+func (s *Scheduler) runAttached(ctx context.Context, secrets scheduler.SecretResolver, app *scheduler.App, p *scheduler.Process, in io.Reader, out io.Writer) error {
+	env, err := scheduler.ResolveEnv(ctx, secrets, app, p)
+	if err != nil {
+		return err
 	}
 
 	labels := scheduler.Labels(app, p)
@@ -178,7 +260,7 @@ func (s *Scheduler) Run(ctx context.Context, app *scheduler.App, p *scheduler.Pr
 			CPUShares:    int64(p.CPUShares),
 			Image:        p.Image.String(),
 			Cmd:          p.Command,
-			Env:          envKeys(scheduler.Env(app, p)),
+			Env:          envKeys(env),
 			Labels:       labels,
 		},
 		HostConfig: &docker.HostConfig{
@@ -216,11 +298,22 @@ func (s *Scheduler) Run(ctx context.Context, app *scheduler.App, p *scheduler.Pr
 		return fmt.Errorf("error attaching to container: %v", err)
 	}
 
+	code, err := s.docker.WaitContainer(ctx, container.ID)
+	if err != nil {
+		return fmt.Errorf("error waiting for container: %v", err)
+	}
+
+	if code != 0 {
+		return &scheduler.ExitError{Code: code}
+	}
+
 	return nil
 }
 
+// Instances returns the running containers for the app, including
+// containers for long running processes, as well as one-off runs.
 func (s *Scheduler) Instances(ctx context.Context, app string) ([]*scheduler.Instance, error) {
-	return s.InstancesFromAttachedRuns(ctx, app)
+	return s.instances(ctx, app)
 }
 
 // InstancesFromAttachedRuns returns Instances that were started from attached
@@ -282,7 +375,7 @@ func (s *Scheduler) Stop(ctx context.Context, containerID string) error {
 	// Some extra protection around stopping containers. We don't want to
 	// allow users to stop containers that may have been started outside of
 	// Empire.
-	if _, ok := container.Config.Labels[runLabel]; !ok {
+	if !isEmpireContainer(container.Config.Labels) {
 		return &docker.NoSuchContainer{
 			ID: containerID,
 		}
@@ -295,6 +388,16 @@ func (s *Scheduler) Stop(ctx context.Context, containerID string) error {
 	return nil
 }
 
+// isEmpireContainer returns true if the labels indicate that the container was
+// started by Empire, either as a one-off run, or as a long running process.
+func isEmpireContainer(labels map[string]string) bool {
+	if _, ok := labels[runLabel]; ok {
+		return true
+	}
+	_, ok := labels[hashLabel]
+	return ok
+}
+
 func parseEnv(env []string) map[string]string {
 	m := make(map[string]string)
 	for _, e := range env {
@@ -1,6 +1,7 @@
 package docker
 
 import (
+	"io/ioutil"
 	"testing"
 	"time"
 
@@ -8,6 +9,7 @@ import (
 
 	"github.com/fsouza/go-dockerclient"
 	"github.com/remind101/empire/pkg/bytesize"
+	"github.com/remind101/empire/pkg/image"
 	"github.com/remind101/empire/scheduler"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -74,6 +76,66 @@ func TestScheduler_InstancesFromAttachedRuns(t *testing.T) {
 	d.AssertExpectations(t)
 }
 
+func TestScheduler_Run_ExitError(t *testing.T) {
+	d := new(mockDockerClient)
+	s := Scheduler{
+		docker: d,
+	}
+
+	app := &scheduler.App{ID: "2cdc4941-e36d-4855-a0ec-51525db4a500", Name: "acme-inc"}
+	process := &scheduler.Process{
+		Type:    "release",
+		Image:   image.Image{Repository: "remind101/acme-inc", Tag: "latest"},
+		Command: []string{"./bin/release"},
+	}
+
+	d.On("PullImage", "remind101/acme-inc", "latest").Return(nil)
+	d.On("CreateContainer", mock.Anything).Return(&docker.Container{ID: "container_id"}, nil)
+	d.On("StartContainer", "container_id").Return(nil)
+	d.On("AttachToContainer", "container_id").Return(nil)
+	d.On("WaitContainer", "container_id").Return(1, nil)
+	d.On("RemoveContainer", "container_id").Return(nil)
+
+	err := s.Run(ctx, app, process, nil, ioutil.Discard)
+	assert.Equal(t, &scheduler.ExitError{Code: 1}, err)
+
+	d.AssertExpectations(t)
+}
+
+func TestAttachedScheduler_Run_Secrets(t *testing.T) {
+	d := new(mockDockerClient)
+	s := &AttachedScheduler{
+		Secrets:         secretResolverFunc(func(ref string) (string, error) { return "postgres://localhost", nil }),
+		dockerScheduler: &Scheduler{docker: d},
+	}
+
+	app := &scheduler.App{
+		ID:      "2cdc4941-e36d-4855-a0ec-51525db4a500",
+		Name:    "acme-inc",
+		Secrets: map[string]string{"DATABASE_URL": "fake:acme-inc/DATABASE_URL"},
+	}
+	process := &scheduler.Process{
+		Type:    "run",
+		Image:   image.Image{Repository: "remind101/acme-inc", Tag: "latest"},
+		Command: []string{"/bin/sh"},
+	}
+
+	d.On("PullImage", "remind101/acme-inc", "latest").Return(nil)
+	d.On("CreateContainer", mock.Anything).Return(&docker.Container{ID: "container_id"}, nil).Run(func(args mock.Arguments) {
+		opts := args.Get(0).(docker.CreateContainerOptions)
+		assert.Equal(t, []string{"DATABASE_URL=postgres://localhost"}, opts.Config.Env)
+	})
+	d.On("StartContainer", "container_id").Return(nil)
+	d.On("AttachToContainer", "container_id").Return(nil)
+	d.On("WaitContainer", "container_id").Return(0, nil)
+	d.On("RemoveContainer", "container_id").Return(nil)
+
+	err := s.Run(ctx, app, process, nil, ioutil.Discard)
+	assert.NoError(t, err)
+
+	d.AssertExpectations(t)
+}
+
 func TestScheduler_Stop(t *testing.T) {
 	d := new(mockDockerClient)
 	s := Scheduler{
@@ -143,6 +205,62 @@ func TestAttachedScheduler_Stop_ContainerNotFound(t *testing.T) {
 	w.AssertExpectations(t)
 }
 
+func TestAttachedScheduler_Plan(t *testing.T) {
+	w := new(mockPlanner)
+	s := &AttachedScheduler{
+		Scheduler: w,
+	}
+
+	app := &scheduler.App{ID: "1"}
+	plan := &scheduler.Plan{}
+	w.On("Plan", app).Return(plan, nil)
+
+	p, err := s.Plan(ctx, app)
+	assert.NoError(t, err)
+	assert.Equal(t, plan, p)
+
+	w.AssertExpectations(t)
+}
+
+func TestAttachedScheduler_Plan_NotSupported(t *testing.T) {
+	s := &AttachedScheduler{
+		Scheduler: new(mockScheduler),
+	}
+
+	_, err := s.Plan(ctx, &scheduler.App{ID: "1"})
+	assert.Equal(t, scheduler.ErrPlanNotSupported, err)
+}
+
+func TestAttachedScheduler_Restart(t *testing.T) {
+	w := new(mockRestarter)
+	s := &AttachedScheduler{
+		Scheduler: w,
+	}
+
+	app := &scheduler.App{ID: "1"}
+	w.On("Restart", app).Return(nil)
+
+	err := s.Restart(ctx, app, nil)
+	assert.NoError(t, err)
+
+	w.AssertExpectations(t)
+}
+
+func TestAttachedScheduler_Restart_NotSupported(t *testing.T) {
+	w := new(mockScheduler)
+	s := &AttachedScheduler{
+		Scheduler: w,
+	}
+
+	app := &scheduler.App{ID: "1"}
+	w.On("Submit", app).Return(nil)
+
+	err := s.Restart(ctx, app, nil)
+	assert.NoError(t, err)
+
+	w.AssertExpectations(t)
+}
+
 func TestParseEnv(t *testing.T) {
 	tests := []struct {
 		in  []string
@@ -176,6 +294,40 @@ func (m *mockDockerClient) InspectContainer(id string) (*docker.Container, error
 	return container, args.Error(1)
 }
 
+func (m *mockDockerClient) PullImage(ctx context.Context, opts docker.PullImageOptions) error {
+	args := m.Called(opts.Repository, opts.Tag)
+	return args.Error(0)
+}
+
+func (m *mockDockerClient) CreateContainer(ctx context.Context, opts docker.CreateContainerOptions) (*docker.Container, error) {
+	args := m.Called(opts)
+	var container *docker.Container
+	if v := args.Get(0); v != nil {
+		container = v.(*docker.Container)
+	}
+	return container, args.Error(1)
+}
+
+func (m *mockDockerClient) StartContainer(ctx context.Context, id string, config *docker.HostConfig) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *mockDockerClient) RemoveContainer(ctx context.Context, opts docker.RemoveContainerOptions) error {
+	args := m.Called(opts.ID)
+	return args.Error(0)
+}
+
+func (m *mockDockerClient) AttachToContainer(ctx context.Context, opts docker.AttachToContainerOptions) error {
+	args := m.Called(opts.Container)
+	return args.Error(0)
+}
+
+func (m *mockDockerClient) WaitContainer(ctx context.Context, id string) (int, error) {
+	args := m.Called(id)
+	return args.Int(0), args.Error(1)
+}
+
 func (m *mockDockerClient) StopContainer(ctx context.Context, id string, timeout uint) error {
 	args := m.Called(id, timeout)
 	return args.Error(0)
@@ -190,3 +342,32 @@ func (m *mockScheduler) Stop(ctx context.Context, id string) error {
 	args := m.Called(id)
 	return args.Error(0)
 }
+
+func (m *mockScheduler) Submit(ctx context.Context, app *scheduler.App, ss scheduler.StatusStream) error {
+	args := m.Called(app)
+	return args.Error(0)
+}
+
+type mockPlanner struct {
+	mockScheduler
+}
+
+func (m *mockPlanner) Plan(ctx context.Context, app *scheduler.App) (*scheduler.Plan, error) {
+	args := m.Called(app)
+	return args.Get(0).(*scheduler.Plan), args.Error(1)
+}
+
+type mockRestarter struct {
+	mockScheduler
+}
+
+func (m *mockRestarter) Restart(ctx context.Context, app *scheduler.App, ss scheduler.StatusStream) error {
+	args := m.Called(app)
+	return args.Error(0)
+}
+
+type secretResolverFunc func(string) (string, error)
+
+func (fn secretResolverFunc) Get(ctx context.Context, ref string) (string, error) {
+	return fn(ref)
+}
@@ -0,0 +1,144 @@
+package docker
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/fsouza/go-dockerclient"
+	"github.com/remind101/empire/scheduler"
+	"golang.org/x/net/context"
+)
+
+// The host that is used to reach published ports when Docker reports that
+// they're bound to all interfaces.
+const defaultHealthCheckHost = "127.0.0.1"
+
+// healthState tracks the results of health checks against a single container.
+type healthState struct {
+	// The last time that the container was checked.
+	checkedAt time.Time
+
+	// The number of consecutive failed checks.
+	failures int
+}
+
+// healthy performs a health check against the container, if the process has a
+// health check and a check is due, and returns false if the container has
+// failed UnhealthyThreshold consecutive checks. Health checks are performed
+// during reconciliation, so the effective interval is never less than
+// reconcileInterval.
+func (s *Scheduler) healthy(ctx context.Context, app *scheduler.App, p *scheduler.Process, c docker.APIContainers) bool {
+	hc := p.HealthCheck
+	if hc == nil || p.Exposure == nil || c.State != "running" {
+		return true
+	}
+
+	now := s.clock()
+
+	states := s.health[app.ID]
+	state, ok := states[c.ID]
+	if !ok {
+		// Give the container an interval to boot before the first
+		// check.
+		states[c.ID] = &healthState{checkedAt: now}
+		return true
+	}
+
+	if now.Sub(state.checkedAt) < hc.Interval {
+		return state.failures < hc.UnhealthyThreshold
+	}
+	state.checkedAt = now
+
+	if err := s.checkHealth(ctx, c, hc); err != nil {
+		state.failures++
+		log.Printf("health check failed for %s.%s (%s): %v", app.Name, p.Type, c.ID, err)
+	} else {
+		state.failures = 0
+	}
+
+	return state.failures < hc.UnhealthyThreshold
+}
+
+// trackHealth drops the health state for containers that no longer exist.
+func (s *Scheduler) trackHealth(appID string, containers []docker.APIContainers) {
+	if s.health == nil {
+		s.health = make(map[string]map[string]*healthState)
+	}
+
+	prev := s.health[appID]
+	states := make(map[string]*healthState)
+	for _, c := range containers {
+		if state, ok := prev[c.ID]; ok {
+			states[c.ID] = state
+		}
+	}
+	s.health[appID] = states
+}
+
+func (s *Scheduler) checkHealth(ctx context.Context, c docker.APIContainers, hc *scheduler.HealthCheck) error {
+	addr, err := publishedAddr(c)
+	if err != nil {
+		return err
+	}
+
+	check := s.check
+	if check == nil {
+		check = checkAddr
+	}
+
+	return check(ctx, addr, hc)
+}
+
+// checkAddr performs an HTTP request against the health check path, or opens a
+// TCP connection if there's no path. For HTTP checks, any 2xx or 3xx response
+// is considered healthy.
+func checkAddr(ctx context.Context, addr string, hc *scheduler.HealthCheck) error {
+	if hc.Path == "" {
+		conn, err := net.DialTimeout("tcp", addr, hc.Timeout)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+
+	client := &http.Client{
+		Timeout: hc.Timeout,
+		// Redirects are considered healthy, so don't follow them.
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	resp, err := client.Get(fmt.Sprintf("http://%s%s", addr, hc.Path))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		return fmt.Errorf("unhealthy status code: %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// publishedAddr returns the address that ContainerPort is published to on the
+// Docker host.
+func publishedAddr(c docker.APIContainers) (string, error) {
+	for _, p := range c.Ports {
+		if p.PrivatePort != ContainerPort || p.PublicPort == 0 {
+			continue
+		}
+
+		ip := p.IP
+		if ip == "" || ip == "0.0.0.0" {
+			ip = defaultHealthCheckHost
+		}
+		return net.JoinHostPort(ip, fmt.Sprintf("%d", p.PublicPort)), nil
+	}
+
+	return "", fmt.Errorf("port %d is not published", ContainerPort)
+}
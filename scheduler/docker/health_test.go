@@ -0,0 +1,144 @@
+package docker
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fsouza/go-dockerclient"
+	"github.com/remind101/empire/scheduler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/net/context"
+)
+
+func TestScheduler_Submit_Unhealthy(t *testing.T) {
+	d := new(mockDockerClient)
+	now := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+	var checked []string
+	s := &Scheduler{
+		docker: d,
+		now:    func() time.Time { return now },
+		check: func(ctx context.Context, addr string, hc *scheduler.HealthCheck) error {
+			checked = append(checked, addr)
+			return errors.New("connection refused")
+		},
+	}
+
+	app := testApp()
+	app.Processes = app.Processes[:1]
+	app.Processes[0].Instances = 1
+	app.Processes[0].HealthCheck = &scheduler.HealthCheck{
+		Path:               "/health",
+		Interval:           10 * time.Second,
+		Timeout:            2 * time.Second,
+		HealthyThreshold:   2,
+		UnhealthyThreshold: 2,
+	}
+
+	hash, err := processHash(app, app.Processes[0])
+	assert.NoError(t, err)
+
+	d.On("ListContainers", mock.AnythingOfType("docker.ListContainersOptions")).Return([]docker.APIContainers{
+		{
+			ID:     "current",
+			State:  "running",
+			Labels: map[string]string{"empire.app.process": "web", "empire.app.process.hash": hash},
+			Ports:  []docker.APIPort{{PrivatePort: 8080, PublicPort: 32768, IP: "0.0.0.0"}},
+		},
+	}, nil)
+
+	var statuses []string
+	ss := scheduler.StatusStreamFunc(func(status scheduler.Status) error {
+		statuses = append(statuses, status.Message)
+		return nil
+	})
+
+	// The first reconciliation gives the container time to boot.
+	err = s.Submit(ctx, app, ss)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(checked))
+
+	// The first failure doesn't exceed the threshold.
+	now = now.Add(10 * time.Second)
+	err = s.Submit(ctx, app, ss)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"127.0.0.1:32768"}, checked)
+
+	// Not due for a check yet.
+	now = now.Add(5 * time.Second)
+	err = s.Submit(ctx, app, ss)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(checked))
+
+	d.On("PullImage", "remind101/acme-inc", "latest").Return(nil)
+	d.On("CreateContainer", mock.AnythingOfType("docker.CreateContainerOptions")).Return(&docker.Container{
+		ID: "new",
+	}, nil).Once()
+	d.On("StartContainer", "new").Return(nil)
+	d.On("RemoveContainer", "current").Return(nil)
+
+	now = now.Add(5 * time.Second)
+	err = s.Submit(ctx, app, ss)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(checked))
+	assert.Equal(t, []string{
+		"Replacing 1 unhealthy web containers",
+		"Started 1 web containers",
+		"Removed 1 web containers",
+	}, statuses)
+
+	d.AssertExpectations(t)
+}
+
+func TestCheckAddr(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/health":
+			w.WriteHeader(200)
+		case "/redirect":
+			http.Redirect(w, r, "/health", 302)
+		default:
+			w.WriteHeader(503)
+		}
+	}))
+	defer s.Close()
+
+	addr := strings.TrimPrefix(s.URL, "http://")
+	check := func(path string) error {
+		return checkAddr(ctx, addr, &scheduler.HealthCheck{Path: path, Timeout: time.Second})
+	}
+
+	assert.NoError(t, check("/health"))
+	assert.NoError(t, check("/redirect"))
+	assert.Error(t, check("/down"))
+
+	// TCP check.
+	assert.NoError(t, check(""))
+}
+
+func TestPublishedAddr(t *testing.T) {
+	tests := []struct {
+		ports []docker.APIPort
+		addr  string
+		err   bool
+	}{
+		{[]docker.APIPort{{PrivatePort: 8080, PublicPort: 32768, IP: "0.0.0.0"}}, "127.0.0.1:32768", false},
+		{[]docker.APIPort{{PrivatePort: 8080, PublicPort: 32768, IP: "10.0.0.1"}}, "10.0.0.1:32768", false},
+		{[]docker.APIPort{{PrivatePort: 8080}}, "", true},
+		{nil, "", true},
+	}
+
+	for _, tt := range tests {
+		addr, err := publishedAddr(docker.APIContainers{Ports: tt.ports})
+		if tt.err {
+			assert.Error(t, err)
+		} else {
+			assert.NoError(t, err)
+			assert.Equal(t, tt.addr, addr)
+		}
+	}
+}
@@ -0,0 +1,511 @@
+package docker
+
+import (
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"strings"
+	"time"
+
+	"code.google.com/p/go-uuid/uuid"
+
+	"github.com/fsouza/go-dockerclient"
+	"github.com/remind101/empire/scheduler"
+	"golang.org/x/net/context"
+)
+
+// For exposed processes, this is the port that processes within the container
+// should bind to. The port is published to a random port on the Docker host.
+const ContainerPort = 8080
+
+// The interval that Start will reconcile submitted apps, and check for
+// scheduled processes to run. This needs to be less than a minute, since
+// that's the resolution of cron expressions.
+var reconcileInterval = 10 * time.Second
+
+// Submit reconciles the containers on the Docker host with the processes in
+// the app. Containers are started or removed until there are the desired
+// number of instances for each process, and containers running an old
+// configuration are replaced.
+//
+// The app is also tracked, so that Start can restart crashed containers and run
+// scheduled processes. Scheduled processes are only tracked in memory, so
+// they will only run while this Empire process is running.
+func (s *Scheduler) Submit(ctx context.Context, app *scheduler.App, ss scheduler.StatusStream) error {
+	if app.Stable != nil {
+		return scheduler.ErrCanaryNotSupported
+	}
+
+	s.reconcileMu.Lock()
+	defer s.reconcileMu.Unlock()
+
+	if err := s.track(app); err != nil {
+		return err
+	}
+
+	return s.reconcile(ctx, app, ss)
+}
+
+// Remove removes all of the containers for the app.
+func (s *Scheduler) Remove(ctx context.Context, appID string) error {
+	s.reconcileMu.Lock()
+	defer s.reconcileMu.Unlock()
+
+	delete(s.health, appID)
+
+	s.mu.Lock()
+	delete(s.apps, appID)
+	for k := range s.schedules {
+		if strings.HasPrefix(k, appID+"/") {
+			delete(s.schedules, k)
+		}
+	}
+	s.mu.Unlock()
+
+	containers, err := s.docker.ListContainers(docker.ListContainersOptions{
+		All: true,
+		Filters: map[string][]string{
+			"label": []string{fmt.Sprintf("%s=%s", appLabel, appID)},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("error listing containers: %v", err)
+	}
+
+	for _, c := range containers {
+		// Leave attached runs alone, since there's somebody on the
+		// other end.
+		if c.Labels[runLabel] == Attached {
+			continue
+		}
+
+		if err := s.removeContainer(ctx, c.ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Start periodically reconciles all of the apps that have been submitted, and
+// runs any scheduled processes that are due. This method blocks forever.
+func (s *Scheduler) Start() {
+	ctx := context.Background()
+	for range time.Tick(reconcileInterval) {
+		s.tick(ctx)
+	}
+}
+
+// tick performs a single pass of reconciliation.
+func (s *Scheduler) tick(ctx context.Context) {
+	now := s.clock()
+
+	s.mu.Lock()
+	var ids []string
+	for id := range s.apps {
+		ids = append(ids, id)
+	}
+	s.mu.Unlock()
+
+	for _, id := range ids {
+		s.tickApp(ctx, id, now)
+	}
+}
+
+// tickApp reconciles a single app, and runs any scheduled processes that are
+// due.
+func (s *Scheduler) tickApp(ctx context.Context, appID string, now time.Time) {
+	s.reconcileMu.Lock()
+	defer s.reconcileMu.Unlock()
+
+	// The app may have been re-submitted, or removed, since the tick
+	// started.
+	s.mu.Lock()
+	app, ok := s.apps[appID]
+	s.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	if err := s.reconcile(ctx, app, nil); err != nil {
+		log.Printf("error reconciling %s: %v", app.Name, err)
+	}
+
+	for _, p := range app.Processes {
+		if p.Schedule == nil || !s.due(app, p, now) {
+			continue
+		}
+
+		if err := s.runScheduled(ctx, app, p); err != nil {
+			log.Printf("error running scheduled process %s.%s: %v", app.Name, p.Type, err)
+		}
+	}
+}
+
+// track stores the app, and computes the next run time for any new, or
+// changed, scheduled processes.
+func (s *Scheduler) track(app *scheduler.App) error {
+	now := s.clock()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.apps == nil {
+		s.apps = make(map[string]*scheduler.App)
+	}
+	if s.schedules == nil {
+		s.schedules = make(map[string]*schedule)
+	}
+
+	prev := s.apps[app.ID]
+	schedules := make(map[string]*schedule)
+	for _, p := range app.Processes {
+		if p.Schedule == nil {
+			continue
+		}
+
+		key := scheduleKey(app, p)
+
+		// Preserve the next run time if the schedule didn't change.
+		if existing, ok := s.schedules[key]; ok && prev != nil && sameSchedule(prev, p) {
+			schedules[key] = existing
+			continue
+		}
+
+		sched, err := newSchedule(p.Schedule, now)
+		if err != nil {
+			return fmt.Errorf("error parsing schedule for %s: %v", p.Type, err)
+		}
+		schedules[key] = sched
+	}
+
+	for k := range s.schedules {
+		if strings.HasPrefix(k, app.ID+"/") {
+			delete(s.schedules, k)
+		}
+	}
+	for k, v := range schedules {
+		s.schedules[k] = v
+	}
+	s.apps[app.ID] = app
+
+	return nil
+}
+
+// due returns true if the scheduled process should be run now, and advances
+// the schedule.
+func (s *Scheduler) due(app *scheduler.App, p *scheduler.Process, now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sched, ok := s.schedules[scheduleKey(app, p)]
+	if !ok || sched.at.IsZero() || now.Before(sched.at) {
+		return false
+	}
+
+	sched.at = sched.next(now)
+	return true
+}
+
+// runScheduled runs the desired number of instances of a scheduled process,
+// after cleaning up containers from the previous run.
+func (s *Scheduler) runScheduled(ctx context.Context, app *scheduler.App, p *scheduler.Process) error {
+	containers, err := s.docker.ListContainers(docker.ListContainersOptions{
+		All: true,
+		Filters: map[string][]string{
+			"label": []string{
+				fmt.Sprintf("%s=%s", appLabel, app.ID),
+				fmt.Sprintf("%s=%s", processLabel, p.Type),
+				fmt.Sprintf("%s=%s", runLabel, Detached),
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("error listing containers: %v", err)
+	}
+
+	for _, c := range containers {
+		if c.State == "running" {
+			// The previous run hasn't finished yet. Like ECS, we'll
+			// still start a new one.
+			continue
+		}
+		if err := s.removeContainer(ctx, c.ID); err != nil {
+			return err
+		}
+	}
+
+	for i := uint(0); i < p.Instances; i++ {
+		if err := s.runDetached(ctx, app, p); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// runDetached runs a process in the background. The container is left around
+// after it exits, so that the logs can be inspected.
+func (s *Scheduler) runDetached(ctx context.Context, app *scheduler.App, p *scheduler.Process) error {
+	labels := scheduler.Labels(app, p)
+	labels[runLabel] = Detached
+
+	if err := s.pullImage(ctx, p); err != nil {
+		return err
+	}
+
+	return s.startContainer(ctx, uuid.New(), app, p, labels, docker.NeverRestart())
+}
+
+// reconcile makes the containers on the Docker host match the long running
+// processes in the app.
+func (s *Scheduler) reconcile(ctx context.Context, app *scheduler.App, ss scheduler.StatusStream) error {
+	containers, err := s.docker.ListContainers(docker.ListContainersOptions{
+		All: true,
+		Filters: map[string][]string{
+			"label": []string{
+				fmt.Sprintf("%s=%s", appLabel, app.ID),
+				hashLabel,
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("error listing containers: %v", err)
+	}
+
+	s.trackHealth(app.ID, containers)
+
+	existing := make(map[string][]docker.APIContainers)
+	for _, c := range containers {
+		existing[c.Labels[processLabel]] = append(existing[c.Labels[processLabel]], c)
+	}
+
+	for _, p := range app.Processes {
+		if p.Schedule != nil {
+			continue
+		}
+
+		if err := s.reconcileProcess(ctx, app, p, existing[p.Type], ss); err != nil {
+			return fmt.Errorf("error reconciling %s: %v", p.Type, err)
+		}
+		delete(existing, p.Type)
+	}
+
+	// Anything left over belongs to processes that were removed, or are
+	// now scheduled.
+	for process, containers := range existing {
+		for _, c := range containers {
+			if err := s.removeContainer(ctx, c.ID); err != nil {
+				return err
+			}
+		}
+		scheduler.Publish(ctx, ss, fmt.Sprintf("Removed %d %s containers", len(containers), process))
+	}
+
+	return nil
+}
+
+// reconcileProcess starts or removes containers for a single process. New
+// containers are started before old containers are removed.
+func (s *Scheduler) reconcileProcess(ctx context.Context, app *scheduler.App, p *scheduler.Process, containers []docker.APIContainers, ss scheduler.StatusStream) error {
+	hash, err := processHash(app, p)
+	if err != nil {
+		return err
+	}
+
+	var current, stale []docker.APIContainers
+	var unhealthy int
+	for _, c := range containers {
+		if c.Labels[hashLabel] != hash || !isAlive(c) {
+			stale = append(stale, c)
+			continue
+		}
+		if !s.healthy(ctx, app, p, c) {
+			unhealthy++
+			stale = append(stale, c)
+			continue
+		}
+		current = append(current, c)
+	}
+	if unhealthy > 0 {
+		scheduler.Publish(ctx, ss, fmt.Sprintf("Replacing %d unhealthy %s containers", unhealthy, p.Type))
+	}
+
+	desired := int(p.Instances)
+	if len(current) > desired {
+		stale = append(stale, current[desired:]...)
+		current = current[:desired]
+	}
+
+	if missing := desired - len(current); missing > 0 {
+		if err := s.pullImage(ctx, p); err != nil {
+			return err
+		}
+
+		labels := scheduler.Labels(app, p)
+		labels[hashLabel] = hash
+
+		for i := 0; i < missing; i++ {
+			name := fmt.Sprintf("%s.%s.%s", app.Name, p.Type, strings.Split(uuid.New(), "-")[0])
+			if err := s.startContainer(ctx, name, app, p, labels, docker.AlwaysRestart()); err != nil {
+				return err
+			}
+		}
+		scheduler.Publish(ctx, ss, fmt.Sprintf("Started %d %s containers", missing, p.Type))
+	}
+
+	for _, c := range stale {
+		if err := s.removeContainer(ctx, c.ID); err != nil {
+			return err
+		}
+	}
+	if len(stale) > 0 {
+		scheduler.Publish(ctx, ss, fmt.Sprintf("Removed %d %s containers", len(stale), p.Type))
+	}
+
+	return nil
+}
+
+// startContainer creates and starts a container for the process.
+func (s *Scheduler) startContainer(ctx context.Context, name string, app *scheduler.App, p *scheduler.Process, labels map[string]string, restart docker.RestartPolicy) error {
+	env, err := scheduler.ResolveEnv(ctx, s.Secrets, app, p)
+	if err != nil {
+		return err
+	}
+
+	// These are normally provided by Empire, but we depend on them for
+	// reconciliation, so we make sure that they're set.
+	labels[appLabel] = app.ID
+	labels[processLabel] = p.Type
+
+	config := &docker.Config{
+		Image:  p.Image.String(),
+		Cmd:    p.Command,
+		Labels: labels,
+	}
+
+	hostConfig := &docker.HostConfig{
+		Memory:        int64(p.MemoryLimit),
+		CPUShares:     int64(p.CPUShares),
+		RestartPolicy: restart,
+		LogConfig: docker.LogConfig{
+			Type: "json-file",
+		},
+	}
+
+	if p.Nproc != 0 {
+		hostConfig.Ulimits = []docker.ULimit{
+			{Name: "nproc", Soft: int64(p.Nproc), Hard: int64(p.Nproc)},
+		}
+	}
+
+	if p.Exposure != nil {
+		port := docker.Port(fmt.Sprintf("%d/tcp", ContainerPort))
+		env["PORT"] = fmt.Sprintf("%d", ContainerPort)
+		config.ExposedPorts = map[docker.Port]struct{}{port: struct{}{}}
+		hostConfig.PublishAllPorts = true
+	}
+
+	config.Env = envKeys(env)
+
+	container, err := s.docker.CreateContainer(ctx, docker.CreateContainerOptions{
+		Name:       name,
+		Config:     config,
+		HostConfig: hostConfig,
+	})
+	if err != nil {
+		return fmt.Errorf("error creating container: %v", err)
+	}
+
+	if err := s.docker.StartContainer(ctx, container.ID, nil); err != nil {
+		return fmt.Errorf("error starting container: %v", err)
+	}
+
+	return nil
+}
+
+func (s *Scheduler) pullImage(ctx context.Context, p *scheduler.Process) error {
+	if err := s.docker.PullImage(ctx, docker.PullImageOptions{
+		Registry:     p.Image.Registry,
+		Repository:   p.Image.Repository,
+		Tag:          p.Image.Tag,
+		OutputStream: ioutil.Discard,
+	}); err != nil {
+		return fmt.Errorf("error pulling image: %v", err)
+	}
+	return nil
+}
+
+func (s *Scheduler) removeContainer(ctx context.Context, id string) error {
+	if err := s.docker.RemoveContainer(ctx, docker.RemoveContainerOptions{
+		ID:            id,
+		RemoveVolumes: true,
+		Force:         true,
+	}); err != nil {
+		return fmt.Errorf("error removing container %s: %v", id, err)
+	}
+	return nil
+}
+
+func (s *Scheduler) clock() time.Time {
+	if s.now == nil {
+		return time.Now()
+	}
+	return s.now()
+}
+
+// isAlive returns true if the container is running, or is being restarted by
+// Docker. Containers that have exited (e.g. were stopped) will be replaced.
+func isAlive(c docker.APIContainers) bool {
+	switch c.State {
+	case "running", "restarting", "":
+		return true
+	default:
+		return false
+	}
+}
+
+// processHash returns a hash of the configuration of the process, which is
+// used to determine if a container needs to be replaced.
+func processHash(app *scheduler.App, p *scheduler.Process) (string, error) {
+	raw, err := json.Marshal(struct {
+		Image       string
+		Command     []string
+		Env         map[string]string
+		Secrets     map[string]string `json:",omitempty"`
+		MemoryLimit uint
+		CPUShares   uint
+		Nproc       uint
+		Exposed     bool
+	}{
+		Image:       p.Image.String(),
+		Command:     p.Command,
+		Env:         scheduler.Env(app, p),
+		Secrets:     scheduler.Secrets(app, p),
+		MemoryLimit: p.MemoryLimit,
+		CPUShares:   p.CPUShares,
+		Nproc:       p.Nproc,
+		Exposed:     p.Exposure != nil,
+	})
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", sha1.Sum(raw)), nil
+}
+
+func scheduleKey(app *scheduler.App, p *scheduler.Process) string {
+	return fmt.Sprintf("%s/%s", app.ID, p.Type)
+}
+
+// sameSchedule returns true if the previous version of the app had the same
+// schedule for the process.
+func sameSchedule(prev *scheduler.App, p *scheduler.Process) bool {
+	for _, pp := range prev.Processes {
+		if pp.Type == p.Type {
+			return pp.Schedule == p.Schedule
+		}
+	}
+	return false
+}
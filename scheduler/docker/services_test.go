@@ -0,0 +1,282 @@
+package docker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fsouza/go-dockerclient"
+	"github.com/remind101/empire/pkg/image"
+	"github.com/remind101/empire/scheduler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestScheduler_Submit(t *testing.T) {
+	d := new(mockDockerClient)
+	s := &Scheduler{
+		docker: d,
+	}
+
+	app := testApp()
+
+	d.On("ListContainers", docker.ListContainersOptions{
+		All: true,
+		Filters: map[string][]string{
+			"label": []string{
+				"empire.app.id=c9366591-ab68-4d49-a333-95ce5a23df68",
+				"empire.app.process.hash",
+			},
+		},
+	}).Return([]docker.APIContainers{}, nil)
+
+	d.On("PullImage", "remind101/acme-inc", "latest").Return(nil)
+
+	var created []docker.CreateContainerOptions
+	d.On("CreateContainer", mock.AnythingOfType("docker.CreateContainerOptions")).Return(&docker.Container{
+		ID: "65311c2cc20d671d43118b7d42b3f02df6b48a6bb65b1c5939007214e7587b24",
+	}, nil).Run(func(args mock.Arguments) {
+		created = append(created, args.Get(0).(docker.CreateContainerOptions))
+	})
+
+	d.On("StartContainer", "65311c2cc20d671d43118b7d42b3f02df6b48a6bb65b1c5939007214e7587b24").Return(nil)
+
+	err := s.Submit(ctx, app, nil)
+	assert.NoError(t, err)
+
+	// 2 web processes + 1 worker process
+	assert.Equal(t, 3, len(created))
+
+	web := created[0]
+	assert.Equal(t, "remind101/acme-inc:latest", web.Config.Image)
+	assert.Equal(t, []string{"./bin/web"}, web.Config.Cmd)
+	assert.Contains(t, web.Config.Env, "PORT=8080")
+	assert.Contains(t, web.Config.Env, "FOO=bar")
+	assert.Equal(t, map[docker.Port]struct{}{"8080/tcp": struct{}{}}, web.Config.ExposedPorts)
+	assert.Equal(t, "web", web.Config.Labels["empire.app.process"])
+	assert.NotEqual(t, "", web.Config.Labels["empire.app.process.hash"])
+	assert.Equal(t, int64(128*1024*1024), web.HostConfig.Memory)
+	assert.Equal(t, int64(256), web.HostConfig.CPUShares)
+	assert.Equal(t, docker.AlwaysRestart(), web.HostConfig.RestartPolicy)
+	assert.True(t, web.HostConfig.PublishAllPorts)
+
+	worker := created[2]
+	assert.Equal(t, []string{"./bin/worker"}, worker.Config.Cmd)
+	assert.Nil(t, worker.Config.ExposedPorts)
+	assert.Equal(t, []docker.ULimit{{Name: "nproc", Soft: 512, Hard: 512}}, worker.HostConfig.Ulimits)
+
+	d.AssertExpectations(t)
+}
+
+func TestScheduler_Submit_Replace(t *testing.T) {
+	d := new(mockDockerClient)
+	s := &Scheduler{
+		docker: d,
+	}
+
+	app := testApp()
+	app.Processes[0].Instances = 1
+	app.Processes = app.Processes[:1]
+
+	hash, err := processHash(app, app.Processes[0])
+	assert.NoError(t, err)
+
+	d.On("ListContainers", docker.ListContainersOptions{
+		All: true,
+		Filters: map[string][]string{
+			"label": []string{
+				"empire.app.id=c9366591-ab68-4d49-a333-95ce5a23df68",
+				"empire.app.process.hash",
+			},
+		},
+	}).Return([]docker.APIContainers{
+		// Running an old version.
+		{ID: "old", State: "running", Labels: map[string]string{"empire.app.process": "web", "empire.app.process.hash": "abcd"}},
+		// Stopped.
+		{ID: "stopped", State: "exited", Labels: map[string]string{"empire.app.process": "web", "empire.app.process.hash": hash}},
+		// A process that was removed.
+		{ID: "removed", State: "running", Labels: map[string]string{"empire.app.process": "worker", "empire.app.process.hash": "abcd"}},
+	}, nil)
+
+	d.On("PullImage", "remind101/acme-inc", "latest").Return(nil)
+	d.On("CreateContainer", mock.AnythingOfType("docker.CreateContainerOptions")).Return(&docker.Container{
+		ID: "new",
+	}, nil).Once()
+	d.On("StartContainer", "new").Return(nil)
+	d.On("RemoveContainer", "old").Return(nil)
+	d.On("RemoveContainer", "stopped").Return(nil)
+	d.On("RemoveContainer", "removed").Return(nil)
+
+	var statuses []string
+	ss := scheduler.StatusStreamFunc(func(status scheduler.Status) error {
+		statuses = append(statuses, status.Message)
+		return nil
+	})
+
+	err = s.Submit(ctx, app, ss)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"Started 1 web containers",
+		"Removed 2 web containers",
+		"Removed 1 worker containers",
+	}, statuses)
+
+	d.AssertExpectations(t)
+}
+
+func TestScheduler_Submit_UpToDate(t *testing.T) {
+	d := new(mockDockerClient)
+	s := &Scheduler{
+		docker: d,
+	}
+
+	app := testApp()
+	app.Processes = app.Processes[:1]
+	app.Processes[0].Instances = 1
+
+	hash, err := processHash(app, app.Processes[0])
+	assert.NoError(t, err)
+
+	d.On("ListContainers", mock.AnythingOfType("docker.ListContainersOptions")).Return([]docker.APIContainers{
+		{ID: "current", State: "running", Labels: map[string]string{"empire.app.process": "web", "empire.app.process.hash": hash}},
+	}, nil)
+
+	err = s.Submit(ctx, app, nil)
+	assert.NoError(t, err)
+
+	d.AssertExpectations(t)
+}
+
+func TestScheduler_Remove(t *testing.T) {
+	d := new(mockDockerClient)
+	s := &Scheduler{
+		docker: d,
+	}
+
+	d.On("ListContainers", docker.ListContainersOptions{
+		All: true,
+		Filters: map[string][]string{
+			"label": []string{"empire.app.id=c9366591-ab68-4d49-a333-95ce5a23df68"},
+		},
+	}).Return([]docker.APIContainers{
+		{ID: "web", Labels: map[string]string{"empire.app.process.hash": "abcd"}},
+		{ID: "attached", Labels: map[string]string{"run": "attached"}},
+	}, nil)
+	d.On("RemoveContainer", "web").Return(nil)
+
+	err := s.Remove(ctx, "c9366591-ab68-4d49-a333-95ce5a23df68")
+	assert.NoError(t, err)
+
+	d.AssertExpectations(t)
+}
+
+func TestScheduler_Tick_Scheduled(t *testing.T) {
+	d := new(mockDockerClient)
+	now := time.Date(2016, time.November, 10, 11, 59, 30, 0, time.UTC)
+	s := &Scheduler{
+		docker: d,
+		now:    func() time.Time { return now },
+	}
+
+	app := testApp()
+	app.Processes = app.Processes[2:]
+
+	d.On("ListContainers", docker.ListContainersOptions{
+		All: true,
+		Filters: map[string][]string{
+			"label": []string{
+				"empire.app.id=c9366591-ab68-4d49-a333-95ce5a23df68",
+				"empire.app.process.hash",
+			},
+		},
+	}).Return([]docker.APIContainers{}, nil)
+
+	err := s.Submit(ctx, app, nil)
+	assert.NoError(t, err)
+
+	// Not due yet.
+	s.tick(ctx)
+
+	d.On("ListContainers", docker.ListContainersOptions{
+		All: true,
+		Filters: map[string][]string{
+			"label": []string{
+				"empire.app.id=c9366591-ab68-4d49-a333-95ce5a23df68",
+				"empire.app.process=scheduled",
+				"run=detached",
+			},
+		},
+	}).Return([]docker.APIContainers{
+		{ID: "previous", State: "exited"},
+	}, nil).Once()
+	d.On("RemoveContainer", "previous").Return(nil)
+	d.On("PullImage", "remind101/acme-inc", "latest").Return(nil)
+	var created docker.CreateContainerOptions
+	d.On("CreateContainer", mock.AnythingOfType("docker.CreateContainerOptions")).Return(&docker.Container{
+		ID: "scheduled",
+	}, nil).Once().Run(func(args mock.Arguments) {
+		created = args.Get(0).(docker.CreateContainerOptions)
+	})
+	d.On("StartContainer", "scheduled").Return(nil).Once()
+
+	now = now.Add(time.Minute)
+	s.tick(ctx)
+
+	// Already ran.
+	s.tick(ctx)
+
+	assert.Equal(t, "detached", created.Config.Labels["run"])
+	assert.Equal(t, docker.NeverRestart(), created.HostConfig.RestartPolicy)
+
+	d.AssertExpectations(t)
+}
+
+func TestProcessHash(t *testing.T) {
+	app := testApp()
+
+	a, err := processHash(app, app.Processes[0])
+	assert.NoError(t, err)
+	b, err := processHash(app, app.Processes[0])
+	assert.NoError(t, err)
+	assert.Equal(t, a, b)
+
+	app.Env["FOO"] = "baz"
+	c, err := processHash(app, app.Processes[0])
+	assert.NoError(t, err)
+	assert.NotEqual(t, a, c)
+}
+
+func testApp() *scheduler.App {
+	return &scheduler.App{
+		ID:      "c9366591-ab68-4d49-a333-95ce5a23df68",
+		Name:    "acme-inc",
+		Release: "v1",
+		Env:     map[string]string{"FOO": "bar"},
+		Processes: []*scheduler.Process{
+			{
+				Type:        "web",
+				Image:       image.Image{Repository: "remind101/acme-inc", Tag: "latest"},
+				Command:     []string{"./bin/web"},
+				Instances:   2,
+				MemoryLimit: 128 * 1024 * 1024,
+				CPUShares:   256,
+				Exposure: &scheduler.Exposure{
+					Type: &scheduler.HTTPExposure{},
+				},
+			},
+			{
+				Type:      "worker",
+				Image:     image.Image{Repository: "remind101/acme-inc", Tag: "latest"},
+				Command:   []string{"./bin/worker"},
+				Instances: 1,
+				Nproc:     512,
+			},
+			{
+				Type:      "scheduled",
+				Image:     image.Image{Repository: "remind101/acme-inc", Tag: "latest"},
+				Command:   []string{"./bin/scheduled"},
+				Instances: 1,
+				Schedule:  scheduler.CRONSchedule("0 12 * * ? *"),
+			},
+		},
+	}
+}
@@ -42,6 +42,12 @@ type Scheduler struct {
 	ecs              *ecsutil.Client
 	logConfiguration *ecs.LogConfiguration
 	lb               lbManager
+
+	// The pool of instance ports that are allocated to load balancers.
+	ports portPool
+
+	// The subnets that load balancers are created in.
+	subnets []string
 }
 
 // Config holds configuration for generating a new ECS backed Scheduler
@@ -103,24 +109,28 @@ func NewScheduler(config Config) (*Scheduler, error) {
 // * Creates a CNAME record in route53 under the internal TLD.
 // * Allocates ports from the ports table.
 func NewLoadBalancedScheduler(db *sql.DB, config Config) (*Scheduler, error) {
-	lb, err := newLBManager(db, config)
+	ports := lb.NewDBPortAllocator(db)
+
+	lb, err := newLBManager(ports, config)
 	if err != nil {
 		return nil, err
 	}
 
 	s := newScheduler(config)
 	s.lb = lb
+	s.ports = ports
+	s.subnets = append(append([]string{}, config.InternalSubnetIDs...), config.ExternalSubnetIDs...)
 	return s, nil
 }
 
-func newLBManager(db *sql.DB, config Config) (lbManager, error) {
+func newLBManager(ports lb.PortAllocator, config Config) (lbManager, error) {
 	if err := validateLoadBalancedConfig(config); err != nil {
 		return nil, err
 	}
 
 	// Create the ELB Manager
 	elb := lb.NewELBManager(config.AWS)
-	elb.Ports = lb.NewDBPortAllocator(db)
+	elb.Ports = ports
 	elb.InternalSecurityGroupID = config.InternalSecurityGroupID
 	elb.ExternalSecurityGroupID = config.ExternalSecurityGroupID
 	elb.InternalSubnetIDs = config.InternalSubnetIDs
@@ -187,6 +197,14 @@ func (m *Scheduler) RemoveCNAMEs(ctx context.Context, appID string) error {
 // `web` and `worker` process, then submit an app with the `web` process, the
 // ECS service for the old `worker` process will be removed.
 func (m *Scheduler) Submit(ctx context.Context, app *scheduler.App, ss scheduler.StatusStream) error {
+	if app.Stable != nil {
+		return scheduler.ErrCanaryNotSupported
+	}
+
+	if len(app.Secrets) > 0 {
+		return scheduler.ErrSecretsNotSupported
+	}
+
 	processes, err := m.Processes(ctx, app.ID)
 	if err != nil {
 		return err
@@ -345,6 +363,10 @@ func (m *Scheduler) Run(ctx context.Context, app *scheduler.App, process *schedu
 		return errors.New("running an attached process is not implemented by the ECS manager.")
 	}
 
+	if len(app.Secrets) > 0 {
+		return scheduler.ErrSecretsNotSupported
+	}
+
 	td, err := m.createTaskDefinition(ctx, app, process, nil)
 	if err != nil {
 		return err
@@ -405,6 +427,7 @@ func (m *Scheduler) taskDefinitionInput(app *scheduler.App, p *scheduler.Process
 	for k, v := range scheduler.Labels(app, p) {
 		labels[k] = aws.String(v)
 	}
+	labels[clusterLabel] = aws.String(m.cluster)
 
 	var ulimits []*ecs.Ulimit
 	if p.Nproc != 0 {
@@ -508,6 +531,11 @@ func (m *Scheduler) loadBalancer(ctx context.Context, app *scheduler.App, p *sch
 		return nil, nil
 	}
 
+	switch p.Exposure.Type.(type) {
+	case *scheduler.TCPExposure, *scheduler.SSLExposure:
+		return nil, fmt.Errorf("%s exposure is not supported by the ecs scheduler", p.Exposure.Type.Protocol())
+	}
+
 	// Attempt to find an existing load balancer for this app.
 	l, err := m.findLoadBalancer(ctx, app.ID, p.Type)
 	if err != nil {
@@ -0,0 +1,231 @@
+package ecs
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"code.google.com/p/go-uuid/uuid"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecs"
+	"github.com/remind101/empire/scheduler"
+	"github.com/remind101/empire/scheduler/ecs/lb"
+	"golang.org/x/net/context"
+)
+
+// The types of resources that the Scheduler can garbage collect.
+const (
+	OrphanLoadBalancer   = "AWS::ElasticLoadBalancing::LoadBalancer"
+	OrphanTaskDefinition = "AWS::ECS::TaskDefinition"
+	OrphanPort           = "Port"
+)
+
+// The docker label that's added to task definitions, so that task definitions
+// registered by other Empire installations can be told apart.
+const clusterLabel = "empire.ecs.cluster"
+
+// portPool is the interface used to find, and release, instance ports that
+// were leaked.
+type portPool interface {
+	lb.PortAllocator
+
+	// Taken returns all of the ports that are currently allocated.
+	Taken() ([]int64, error)
+}
+
+// Orphans returns the task definitions and load balancers that were created
+// for apps that aren't in apps, as well as instance ports that were allocated
+// but aren't used by any load balancer.
+func (m *Scheduler) Orphans(ctx context.Context, apps []string) ([]*scheduler.Orphan, error) {
+	known := make(map[string]bool)
+	for _, app := range apps {
+		known[app] = true
+	}
+
+	orphans, err := m.orphanedTaskDefinitions(ctx, known)
+	if err != nil {
+		return nil, err
+	}
+
+	if m.lb == nil {
+		return orphans, nil
+	}
+
+	lbs, err := m.lb.LoadBalancers(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	orphans = append(orphans, m.orphanedLoadBalancers(lbs, known)...)
+
+	ports, err := m.leakedPorts(lbs)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(orphans, ports...), nil
+}
+
+// orphanedTaskDefinitions returns the task definition families that were
+// registered for apps that aren't known. Families that aren't prefixed with an
+// app id weren't registered by the Scheduler, and are ignored. Task definitions
+// aren't scoped to a cluster, so only families whose latest revision is
+// labeled with the Scheduler's cluster are considered.
+func (m *Scheduler) orphanedTaskDefinitions(ctx context.Context, known map[string]bool) ([]*scheduler.Orphan, error) {
+	families, err := m.ecs.ListAppTaskDefinitionFamilies(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var apps []string
+	for app := range families {
+		if uuid.Parse(app) == nil || known[app] {
+			continue
+		}
+		apps = append(apps, app)
+	}
+	sort.Strings(apps)
+
+	var orphans []*scheduler.Orphan
+	for _, app := range apps {
+		for _, family := range families[app] {
+			ours, err := m.registeredTaskDefinition(ctx, family)
+			if err != nil {
+				return nil, err
+			}
+			if !ours {
+				continue
+			}
+
+			orphans = append(orphans, &scheduler.Orphan{
+				Type: OrphanTaskDefinition,
+				ID:   family,
+				App:  app,
+			})
+		}
+	}
+
+	return orphans, nil
+}
+
+// registeredTaskDefinition returns true if the latest revision of the task
+// definition family was registered by this Scheduler.
+func (m *Scheduler) registeredTaskDefinition(ctx context.Context, family string) (bool, error) {
+	resp, err := m.ecs.DescribeTaskDefinition(ctx, &ecs.DescribeTaskDefinitionInput{
+		TaskDefinition: aws.String(family),
+	})
+	if err != nil {
+		return false, err
+	}
+
+	if resp.TaskDefinition == nil {
+		return false, nil
+	}
+
+	for _, c := range resp.TaskDefinition.ContainerDefinitions {
+		if cluster, ok := c.DockerLabels[clusterLabel]; ok && cluster != nil && *cluster == m.cluster {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// orphanedLoadBalancers returns the load balancers that were created for apps
+// that aren't known. Only load balancers in the subnets that the Scheduler
+// creates them in are considered, so that load balancers belonging to other
+// Empire installations are left alone.
+func (m *Scheduler) orphanedLoadBalancers(lbs []*lb.LoadBalancer, known map[string]bool) []*scheduler.Orphan {
+	subnets := make(map[string]bool)
+	for _, subnet := range m.subnets {
+		subnets[subnet] = true
+	}
+
+	var orphans []*scheduler.Orphan
+	for _, l := range lbs {
+		app, ok := l.Tags["AppID"]
+		if !ok || known[app] {
+			continue
+		}
+
+		var inSubnet bool
+		for _, subnet := range l.Subnets {
+			if subnets[subnet] {
+				inSubnet = true
+			}
+		}
+		if !inSubnet {
+			continue
+		}
+
+		orphans = append(orphans, &scheduler.Orphan{
+			Type: OrphanLoadBalancer,
+			ID:   l.Name,
+			App:  app,
+		})
+	}
+
+	return orphans
+}
+
+// leakedPorts returns the instance ports that are allocated, but not used by
+// any of the load balancers.
+func (m *Scheduler) leakedPorts(lbs []*lb.LoadBalancer) ([]*scheduler.Orphan, error) {
+	if m.ports == nil {
+		return nil, nil
+	}
+
+	taken, err := m.ports.Taken()
+	if err != nil {
+		return nil, err
+	}
+
+	used := make(map[int64]bool)
+	for _, l := range lbs {
+		used[l.InstancePort] = true
+	}
+
+	var orphans []*scheduler.Orphan
+	for _, port := range taken {
+		if used[port] {
+			continue
+		}
+
+		orphans = append(orphans, &scheduler.Orphan{
+			Type: OrphanPort,
+			ID:   strconv.FormatInt(port, 10),
+		})
+	}
+
+	return orphans, nil
+}
+
+// RemoveOrphan removes a resource that was returned from Orphans.
+func (m *Scheduler) RemoveOrphan(ctx context.Context, orphan *scheduler.Orphan) error {
+	switch orphan.Type {
+	case OrphanTaskDefinition:
+		return m.ecs.DeregisterTaskDefinitionFamily(ctx, orphan.ID)
+	case OrphanLoadBalancer:
+		lbs, err := m.lb.LoadBalancers(ctx, map[string]string{"AppID": orphan.App})
+		if err != nil {
+			return err
+		}
+
+		for _, l := range lbs {
+			if l.Name == orphan.ID {
+				return m.lb.DestroyLoadBalancer(ctx, l)
+			}
+		}
+
+		return nil
+	case OrphanPort:
+		port, err := strconv.ParseInt(orphan.ID, 10, 64)
+		if err != nil {
+			return err
+		}
+
+		return m.ports.Put(port)
+	default:
+		return fmt.Errorf("unknown resource type: %s", orphan.Type)
+	}
+}
@@ -0,0 +1,126 @@
+package ecs
+
+import (
+	"testing"
+
+	"github.com/remind101/empire/pkg/awsutil"
+	"github.com/remind101/empire/scheduler"
+	"github.com/remind101/empire/scheduler/ecs/lb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/net/context"
+)
+
+func TestScheduler_Orphans(t *testing.T) {
+	h := awsutil.NewHandler([]awsutil.Cycle{
+		awsutil.Cycle{
+			Request: awsutil.Request{
+				RequestURI: "/",
+				Operation:  "AmazonEC2ContainerServiceV20141113.ListTaskDefinitionFamilies",
+				Body:       `{"status":"ACTIVE"}`,
+			},
+			Response: awsutil.Response{
+				StatusCode: 200,
+				Body:       `{"families":["ae69bb4c-3903-4844-82fe-548ac5b74570--web","c9366591-ab68-4d49-a333-95ce5a23df68--web","e2b7a3b9-5f0b-4c1e-8d3a-4f7b1c2d9e10--web","acme-inc-web","other--web"]}`,
+			},
+		},
+
+		awsutil.Cycle{
+			Request: awsutil.Request{
+				RequestURI: "/",
+				Operation:  "AmazonEC2ContainerServiceV20141113.DescribeTaskDefinition",
+				Body:       `{"taskDefinition":"c9366591-ab68-4d49-a333-95ce5a23df68--web"}`,
+			},
+			Response: awsutil.Response{
+				StatusCode: 200,
+				Body:       `{"taskDefinition":{"containerDefinitions":[{"name":"web","dockerLabels":{"empire.ecs.cluster":"empire"}}]}}`,
+			},
+		},
+
+		// Registered by an Empire installation using a different
+		// cluster.
+		awsutil.Cycle{
+			Request: awsutil.Request{
+				RequestURI: "/",
+				Operation:  "AmazonEC2ContainerServiceV20141113.DescribeTaskDefinition",
+				Body:       `{"taskDefinition":"e2b7a3b9-5f0b-4c1e-8d3a-4f7b1c2d9e10--web"}`,
+			},
+			Response: awsutil.Response{
+				StatusCode: 200,
+				Body:       `{"taskDefinition":{"containerDefinitions":[{"name":"web","dockerLabels":{"empire.ecs.cluster":"other"}}]}}`,
+			},
+		},
+	})
+	m, s := newTestScheduler(h)
+	defer s.Close()
+
+	l := new(mockLBManager)
+	p := new(mockPortPool)
+	m.lb = l
+	m.ports = p
+	m.subnets = []string{"subnet-a", "subnet-b"}
+
+	l.On("LoadBalancers", map[string]string(nil)).Return([]*lb.LoadBalancer{
+		{Name: "a", InstancePort: 9000, Subnets: []string{"subnet-a"}, Tags: map[string]string{"AppID": "ae69bb4c-3903-4844-82fe-548ac5b74570"}},
+		{Name: "b", InstancePort: 9001, Subnets: []string{"subnet-b"}, Tags: map[string]string{"AppID": "c9366591-ab68-4d49-a333-95ce5a23df68"}},
+		{Name: "c", InstancePort: 9002, Subnets: []string{"subnet-c"}, Tags: map[string]string{"AppID": "c9366591-ab68-4d49-a333-95ce5a23df68"}},
+		{Name: "d", InstancePort: 9003, Subnets: []string{"subnet-a"}},
+	}, nil)
+	p.On("Taken").Return([]int64{9000, 9001, 9002, 9003, 9004}, nil)
+
+	orphans, err := m.Orphans(context.Background(), []string{"ae69bb4c-3903-4844-82fe-548ac5b74570"})
+	assert.NoError(t, err)
+	assert.Equal(t, []*scheduler.Orphan{
+		{Type: OrphanTaskDefinition, ID: "c9366591-ab68-4d49-a333-95ce5a23df68--web", App: "c9366591-ab68-4d49-a333-95ce5a23df68"},
+		{Type: OrphanLoadBalancer, ID: "b", App: "c9366591-ab68-4d49-a333-95ce5a23df68"},
+		{Type: OrphanPort, ID: "9004"},
+	}, orphans)
+
+	l.AssertExpectations(t)
+	p.AssertExpectations(t)
+}
+
+func TestScheduler_RemoveOrphan(t *testing.T) {
+	l := new(mockLBManager)
+	p := new(mockPortPool)
+	m := &Scheduler{
+		lb:    l,
+		ports: p,
+	}
+
+	b := &lb.LoadBalancer{Name: "b", Tags: map[string]string{"AppID": "c9366591-ab68-4d49-a333-95ce5a23df68"}}
+	l.On("LoadBalancers", map[string]string{"AppID": "c9366591-ab68-4d49-a333-95ce5a23df68"}).Return([]*lb.LoadBalancer{b}, nil)
+	l.On("DestroyLoadBalancer", b).Return(nil)
+	p.On("Put", int64(9004)).Return(nil)
+
+	err := m.RemoveOrphan(context.Background(), &scheduler.Orphan{Type: OrphanLoadBalancer, ID: "b", App: "c9366591-ab68-4d49-a333-95ce5a23df68"})
+	assert.NoError(t, err)
+
+	err = m.RemoveOrphan(context.Background(), &scheduler.Orphan{Type: OrphanPort, ID: "9004"})
+	assert.NoError(t, err)
+
+	err = m.RemoveOrphan(context.Background(), &scheduler.Orphan{Type: "AWS::S3::Bucket", ID: "bucket"})
+	assert.EqualError(t, err, "unknown resource type: AWS::S3::Bucket")
+
+	l.AssertExpectations(t)
+	p.AssertExpectations(t)
+}
+
+type mockPortPool struct {
+	mock.Mock
+}
+
+func (m *mockPortPool) Get() (int64, error) {
+	args := m.Called()
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *mockPortPool) Put(port int64) error {
+	args := m.Called(port)
+	return args.Error(0)
+}
+
+func (m *mockPortPool) Taken() ([]int64, error) {
+	args := m.Called()
+	return args.Get(0).([]int64), args.Error(1)
+}
@@ -258,6 +258,7 @@ func loadBalancerFromDescription(elb *elb.LoadBalancerDescription) *LoadBalancer
 		External:     *elb.Scheme == schemeExternal,
 		SSLCert:      sslCert,
 		InstancePort: instancePort,
+		Subnets:      aws.StringValueSlice(elb.Subnets),
 	}
 }
 
@@ -249,8 +249,8 @@ func TestELB_LoadBalancers(t *testing.T) {
 	assert.Equal(t, 2, len(lbs))
 
 	expected := []*LoadBalancer{
-		{Name: "foo", DNSName: "foo.us-east-1.elb.amazonaws.com", InstancePort: 9000, Tags: map[string]string{"AppName": "foo", "ProcessType": "web"}},
-		{Name: "bar", DNSName: "bar.us-east-1.elb.amazonaws.com", External: true, InstancePort: 9001, Tags: map[string]string{"AppName": "bar", "ProcessType": "web"}},
+		{Name: "foo", DNSName: "foo.us-east-1.elb.amazonaws.com", InstancePort: 9000, Subnets: []string{"subnet-1a"}, Tags: map[string]string{"AppName": "foo", "ProcessType": "web"}},
+		{Name: "bar", DNSName: "bar.us-east-1.elb.amazonaws.com", External: true, InstancePort: 9001, Subnets: []string{"subnet-1a"}, Tags: map[string]string{"AppName": "bar", "ProcessType": "web"}},
 	}
 
 	for i := range expected {
@@ -47,6 +47,9 @@ type LoadBalancer struct {
 	// on the host.
 	InstancePort int64
 
+	// The subnets that the load balancer is attached to.
+	Subnets []string
+
 	// Tags contain the tags attached to the LoadBalancer
 	Tags map[string]string
 }
@@ -41,3 +41,23 @@ func (a *DBPortAllocator) Put(port int64) error {
 	_, err := a.db.Exec(sql, port)
 	return err
 }
+
+// Taken returns all of the ports that are currently allocated.
+func (a *DBPortAllocator) Taken() ([]int64, error) {
+	rows, err := a.db.Query(`SELECT port FROM ports WHERE taken = true ORDER BY port ASC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ports []int64
+	for rows.Next() {
+		var port int64
+		if err := rows.Scan(&port); err != nil {
+			return nil, err
+		}
+		ports = append(ports, port)
+	}
+
+	return ports, rows.Err()
+}
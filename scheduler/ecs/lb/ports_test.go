@@ -22,6 +22,27 @@ func TestDBPortAllocator_Get(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestDBPortAllocator_Taken(t *testing.T) {
+	db := newDB(t)
+	a := &DBPortAllocator{
+		db: db,
+	}
+
+	port, err := a.Get()
+	assert.NoError(t, err)
+
+	taken, err := a.Taken()
+	assert.NoError(t, err)
+	assert.Contains(t, taken, port)
+
+	err = a.Put(port)
+	assert.NoError(t, err)
+
+	taken, err = a.Taken()
+	assert.NoError(t, err)
+	assert.NotContains(t, taken, port)
+}
+
 func newDB(t testing.TB) *sql.DB {
 	db, err := sql.Open("postgres", "postgres://localhost/empire?sslmode=disable")
 	if err != nil {
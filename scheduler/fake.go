@@ -67,6 +67,16 @@ func (m *FakeScheduler) Instances(ctx context.Context, appID string) ([]*Instanc
 	return instances, nil
 }
 
+func (m *FakeScheduler) DesiredCounts(ctx context.Context, appID string) (map[string]uint, error) {
+	desired := make(map[string]uint)
+	if a, ok := m.apps[appID]; ok {
+		for _, p := range a.Processes {
+			desired[p.Type] = p.Instances
+		}
+	}
+	return desired, nil
+}
+
 func (m *FakeScheduler) Stop(ctx context.Context, instanceID string) error {
 	return nil
 }
@@ -0,0 +1,289 @@
+package kubernetes
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"sync"
+)
+
+// The websocket subprotocol that Kubernetes uses to multiplex stdin, stdout
+// and stderr over a single connection. Every message is prefixed with a single
+// byte that identifies the stream.
+const channelProtocol = "channel.k8s.io"
+
+// Stream identifiers for channelProtocol.
+const (
+	stdinChannel  byte = 0
+	stdoutChannel byte = 1
+	stderrChannel byte = 2
+	errorChannel  byte = 3
+)
+
+// websocket opcodes that we care about.
+const (
+	opContinuation byte = 0x0
+	opText         byte = 0x1
+	opBinary       byte = 0x2
+	opClose        byte = 0x8
+	opPing         byte = 0x9
+	opPong         byte = 0xA
+)
+
+// websocketGUID is the magic value used when computing the
+// Sec-WebSocket-Accept header.
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// AttachOptions are options provided to Attach.
+type AttachOptions struct {
+	Namespace string
+	Pod       string
+	Container string
+
+	// If provided, input will be read from this and sent to the container.
+	Stdin io.Reader
+
+	// If provided, output from the container will be written to this.
+	Stdout io.Writer
+
+	// Whether the container was started with a TTY.
+	TTY bool
+}
+
+// Attach attaches to a running container, streaming input from opts.Stdin and
+// copying output to opts.Stdout until the container exits.
+func (c *Client) Attach(opts AttachOptions) error {
+	q := url.Values{}
+	q.Set("container", opts.Container)
+	q.Set("stdin", fmt.Sprintf("%t", opts.Stdin != nil))
+	q.Set("stdout", "true")
+	q.Set("stderr", fmt.Sprintf("%t", !opts.TTY))
+	q.Set("tty", fmt.Sprintf("%t", opts.TTY))
+
+	path := fmt.Sprintf("%s/attach?%s", resourcePath(coreV1, opts.Namespace, "pods", opts.Pod), q.Encode())
+	req, err := c.NewRequest("GET", path, nil)
+	if err != nil {
+		return err
+	}
+
+	key, err := websocketKey()
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	req.Header.Set("Sec-WebSocket-Protocol", channelProtocol)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != 101 {
+		defer resp.Body.Close()
+		return fmt.Errorf("error attaching to %s: unexpected status %d", opts.Pod, resp.StatusCode)
+	}
+
+	if got, want := resp.Header.Get("Sec-WebSocket-Accept"), websocketAccept(key); got != want {
+		resp.Body.Close()
+		return fmt.Errorf("error attaching to %s: invalid Sec-WebSocket-Accept header", opts.Pod)
+	}
+
+	conn, ok := resp.Body.(io.ReadWriteCloser)
+	if !ok {
+		resp.Body.Close()
+		return errors.New("error attaching: connection does not support writing")
+	}
+
+	ws := &websocketConn{rw: conn, r: bufio.NewReader(conn)}
+	defer ws.Close()
+
+	if opts.Stdin != nil {
+		go func() {
+			io.Copy(&channelWriter{ws: ws, channel: stdinChannel}, opts.Stdin)
+		}()
+	}
+
+	return ws.copyOutput(opts.Stdout)
+}
+
+// websocketConn is a minimal client side implementation of RFC 6455, which
+// only implements the features needed to attach to a container.
+type websocketConn struct {
+	sync.Mutex
+	rw io.ReadWriteCloser
+	r  *bufio.Reader
+}
+
+// copyOutput reads messages from the connection and writes stdout and stderr
+// messages to w, until the connection is closed.
+func (c *websocketConn) copyOutput(w io.Writer) error {
+	var channel byte
+	for {
+		op, payload, err := c.readFrame()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		switch op {
+		case opClose:
+			return nil
+		case opPing:
+			if err := c.writeFrame(opPong, payload); err != nil {
+				return err
+			}
+			continue
+		case opPong:
+			continue
+		case opText, opBinary:
+			if len(payload) == 0 {
+				continue
+			}
+			channel, payload = payload[0], payload[1:]
+		case opContinuation:
+			// Continuation frames belong to the channel of the
+			// initial frame.
+		}
+
+		switch channel {
+		case stdoutChannel, stderrChannel:
+			if w != nil {
+				if _, err := w.Write(payload); err != nil {
+					return err
+				}
+			}
+		case errorChannel:
+			if len(payload) > 0 {
+				return fmt.Errorf("error from container: %s", payload)
+			}
+		}
+	}
+}
+
+// readFrame reads a single websocket frame from the connection.
+func (c *websocketConn) readFrame() (byte, []byte, error) {
+	var header [2]byte
+	if _, err := io.ReadFull(c.r, header[:]); err != nil {
+		return 0, nil, err
+	}
+
+	op := header[0] & 0x0F
+	masked := header[1]&0x80 != 0
+	length := uint64(header[1] & 0x7F)
+
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.r, ext[:]); err != nil {
+			return 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.r, ext[:]); err != nil {
+			return 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+
+	var mask [4]byte
+	if masked {
+		if _, err := io.ReadFull(c.r, mask[:]); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.r, payload); err != nil {
+		return 0, nil, err
+	}
+
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+
+	return op, payload, nil
+}
+
+// writeFrame writes a single, masked, websocket frame to the connection.
+func (c *websocketConn) writeFrame(op byte, payload []byte) error {
+	c.Lock()
+	defer c.Unlock()
+
+	frame := []byte{0x80 | op}
+
+	n := len(payload)
+	switch {
+	case n < 126:
+		frame = append(frame, 0x80|byte(n))
+	case n <= 0xFFFF:
+		frame = append(frame, 0x80|126, 0, 0)
+		binary.BigEndian.PutUint16(frame[2:], uint16(n))
+	default:
+		frame = append(frame, 0x80|127, 0, 0, 0, 0, 0, 0, 0, 0)
+		binary.BigEndian.PutUint64(frame[2:], uint64(n))
+	}
+
+	var mask [4]byte
+	if _, err := rand.Read(mask[:]); err != nil {
+		return err
+	}
+	frame = append(frame, mask[:]...)
+
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+
+	_, err := c.rw.Write(frame)
+	return err
+}
+
+// Close sends a close frame and closes the underlying connection.
+func (c *websocketConn) Close() error {
+	c.writeFrame(opClose, nil)
+	return c.rw.Close()
+}
+
+// channelWriter is an io.Writer that writes binary messages to the given
+// channel.
+type channelWriter struct {
+	ws      *websocketConn
+	channel byte
+}
+
+func (w *channelWriter) Write(p []byte) (int, error) {
+	if err := w.ws.writeFrame(opBinary, append([]byte{w.channel}, p...)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// websocketKey generates a random Sec-WebSocket-Key.
+func websocketKey() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(b[:]), nil
+}
+
+// websocketAccept returns the expected Sec-WebSocket-Accept value for the
+// key.
+func websocketAccept(key string) string {
+	h := sha1.Sum([]byte(key + websocketGUID))
+	return base64.StdEncoding.EncodeToString(h[:])
+}
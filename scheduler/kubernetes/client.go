@@ -0,0 +1,336 @@
+package kubernetes
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// Paths to the service account credentials that Kubernetes mounts into every
+// pod.
+const (
+	serviceAccountToken = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+	serviceAccountCA    = "/var/run/secrets/kubernetes.io/serviceaccount/ca.crt"
+)
+
+// API group paths for the resources that we manage.
+const (
+	coreV1  = "/api/v1"
+	appsV1  = "/apis/apps/v1"
+	batchV1 = "/apis/batch/v1"
+)
+
+// Client is a minimal client for the Kubernetes REST API.
+type Client struct {
+	// The base URL of the Kubernetes API server (e.g.
+	// https://10.0.0.1:443).
+	URL string
+
+	// If provided, this token will be sent as a bearer token in the
+	// Authorization header.
+	BearerToken string
+
+	client *http.Client
+}
+
+// NewClient returns a new Client that will connect to the API server at the
+// given url.
+func NewClient(url string, c *http.Client) *Client {
+	if c == nil {
+		c = http.DefaultClient
+	}
+
+	return &Client{
+		URL:    strings.TrimSuffix(url, "/"),
+		client: c,
+	}
+}
+
+// NewInClusterClient returns a new Client configured with the service account
+// that Kubernetes provides to pods.
+func NewInClusterClient() (*Client, error) {
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return nil, fmt.Errorf("not running in a kubernetes cluster: KUBERNETES_SERVICE_HOST and KUBERNETES_SERVICE_PORT must be set")
+	}
+
+	return NewClientWithCredentials(fmt.Sprintf("https://%s:%s", host, port), serviceAccountToken, serviceAccountCA)
+}
+
+// NewClientWithCredentials returns a new Client that reads the bearer token
+// and CA certificate from the given files. Either path can be empty.
+func NewClientWithCredentials(url, tokenFile, caFile string) (*Client, error) {
+	transport := &http.Transport{Proxy: http.ProxyFromEnvironment}
+
+	if caFile != "" {
+		raw, err := ioutil.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading CA certificate: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(raw) {
+			return nil, fmt.Errorf("no certificates found in %s", caFile)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+
+	c := NewClient(url, &http.Client{Transport: transport})
+
+	if tokenFile != "" {
+		raw, err := ioutil.ReadFile(tokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading bearer token: %v", err)
+		}
+		c.BearerToken = strings.TrimSpace(string(raw))
+	}
+
+	return c, nil
+}
+
+// Error is returned when the API server responds with a non 2xx status code.
+type Error struct {
+	Status
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	return fmt.Sprintf("kubernetes: %s (%d)", e.Message, e.Code)
+}
+
+// isNotFound returns true if the error is a 404 from the API server.
+func isNotFound(err error) bool {
+	if err, ok := err.(*Error); ok {
+		return err.Code == http.StatusNotFound
+	}
+	return false
+}
+
+// NewRequest builds a new http.Request for the given path. If v is non-nil, it
+// will be json encoded as the request body.
+func (c *Client) NewRequest(method, path string, v interface{}) (*http.Request, error) {
+	var body io.Reader
+	if v != nil {
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewReader(raw)
+	}
+
+	req, err := http.NewRequest(method, c.URL+path, body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.BearerToken != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.BearerToken))
+	}
+
+	return req, nil
+}
+
+// Do performs the request and decodes the response into v, if provided.
+func (c *Client) Do(req *http.Request, v interface{}) error {
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		var status Status
+		raw, _ := ioutil.ReadAll(resp.Body)
+		if err := json.Unmarshal(raw, &status); err != nil || status.Message == "" {
+			status.Message = strings.TrimSpace(string(raw))
+		}
+		status.Code = resp.StatusCode
+		return &Error{status}
+	}
+
+	if v == nil {
+		return nil
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func (c *Client) do(method, path string, in, out interface{}) error {
+	req, err := c.NewRequest(method, path, in)
+	if err != nil {
+		return err
+	}
+	return c.Do(req, out)
+}
+
+// collectionPath returns the path for a collection of resources in a
+// namespace.
+func collectionPath(group, namespace, resource string) string {
+	return fmt.Sprintf("%s/namespaces/%s/%s", group, namespace, resource)
+}
+
+// resourcePath returns the path for a named resource in a namespace.
+func resourcePath(group, namespace, resource, name string) string {
+	return fmt.Sprintf("%s/%s", collectionPath(group, namespace, resource), name)
+}
+
+// withSelector adds a labelSelector query param to the path.
+func withSelector(path string, labels map[string]string) string {
+	var selector []string
+	for k, v := range labels {
+		selector = append(selector, fmt.Sprintf("%s=%s", k, v))
+	}
+	return fmt.Sprintf("%s?labelSelector=%s", path, url.QueryEscape(strings.Join(selector, ",")))
+}
+
+// Deployments ========================
+
+func (c *Client) GetDeployment(namespace, name string) (*Deployment, error) {
+	var d Deployment
+	err := c.do("GET", resourcePath(appsV1, namespace, "deployments", name), nil, &d)
+	return &d, err
+}
+
+func (c *Client) ListDeployments(namespace string, labels map[string]string) ([]Deployment, error) {
+	var l DeploymentList
+	err := c.do("GET", withSelector(collectionPath(appsV1, namespace, "deployments"), labels), nil, &l)
+	return l.Items, err
+}
+
+func (c *Client) CreateDeployment(namespace string, d *Deployment) error {
+	d.APIVersion, d.Kind = "apps/v1", "Deployment"
+	return c.do("POST", collectionPath(appsV1, namespace, "deployments"), d, nil)
+}
+
+func (c *Client) UpdateDeployment(namespace string, d *Deployment) error {
+	d.APIVersion, d.Kind = "apps/v1", "Deployment"
+	return c.do("PUT", resourcePath(appsV1, namespace, "deployments", d.Metadata.Name), d, nil)
+}
+
+func (c *Client) DeleteDeployment(namespace, name string) error {
+	// Foreground propagation ensures that the ReplicaSets and Pods that the
+	// Deployment owns are removed as well.
+	body := map[string]interface{}{
+		"kind":              "DeleteOptions",
+		"apiVersion":        "v1",
+		"propagationPolicy": "Foreground",
+	}
+	return c.do("DELETE", resourcePath(appsV1, namespace, "deployments", name), body, nil)
+}
+
+// Services ===========================
+
+func (c *Client) GetService(namespace, name string) (*Service, error) {
+	var s Service
+	err := c.do("GET", resourcePath(coreV1, namespace, "services", name), nil, &s)
+	return &s, err
+}
+
+func (c *Client) ListServices(namespace string, labels map[string]string) ([]Service, error) {
+	var l ServiceList
+	err := c.do("GET", withSelector(collectionPath(coreV1, namespace, "services"), labels), nil, &l)
+	return l.Items, err
+}
+
+func (c *Client) CreateService(namespace string, s *Service) error {
+	s.APIVersion, s.Kind = "v1", "Service"
+	return c.do("POST", collectionPath(coreV1, namespace, "services"), s, nil)
+}
+
+func (c *Client) UpdateService(namespace string, s *Service) error {
+	s.APIVersion, s.Kind = "v1", "Service"
+	return c.do("PUT", resourcePath(coreV1, namespace, "services", s.Metadata.Name), s, nil)
+}
+
+func (c *Client) DeleteService(namespace, name string) error {
+	return c.do("DELETE", resourcePath(coreV1, namespace, "services", name), nil, nil)
+}
+
+// CronJobs ===========================
+
+func (c *Client) GetCronJob(namespace, name string) (*CronJob, error) {
+	var j CronJob
+	err := c.do("GET", resourcePath(batchV1, namespace, "cronjobs", name), nil, &j)
+	return &j, err
+}
+
+func (c *Client) ListCronJobs(namespace string, labels map[string]string) ([]CronJob, error) {
+	var l CronJobList
+	err := c.do("GET", withSelector(collectionPath(batchV1, namespace, "cronjobs"), labels), nil, &l)
+	return l.Items, err
+}
+
+func (c *Client) CreateCronJob(namespace string, j *CronJob) error {
+	j.APIVersion, j.Kind = "batch/v1", "CronJob"
+	return c.do("POST", collectionPath(batchV1, namespace, "cronjobs"), j, nil)
+}
+
+func (c *Client) UpdateCronJob(namespace string, j *CronJob) error {
+	j.APIVersion, j.Kind = "batch/v1", "CronJob"
+	return c.do("PUT", resourcePath(batchV1, namespace, "cronjobs", j.Metadata.Name), j, nil)
+}
+
+func (c *Client) DeleteCronJob(namespace, name string) error {
+	body := map[string]interface{}{
+		"kind":              "DeleteOptions",
+		"apiVersion":        "v1",
+		"propagationPolicy": "Background",
+	}
+	return c.do("DELETE", resourcePath(batchV1, namespace, "cronjobs", name), body, nil)
+}
+
+// Pods ===============================
+
+func (c *Client) GetPod(namespace, name string) (*Pod, error) {
+	var p Pod
+	err := c.do("GET", resourcePath(coreV1, namespace, "pods", name), nil, &p)
+	return &p, err
+}
+
+func (c *Client) ListPods(namespace string, labels map[string]string) ([]Pod, error) {
+	var l PodList
+	err := c.do("GET", withSelector(collectionPath(coreV1, namespace, "pods"), labels), nil, &l)
+	return l.Items, err
+}
+
+func (c *Client) CreatePod(namespace string, p *Pod) error {
+	p.APIVersion, p.Kind = "v1", "Pod"
+	return c.do("POST", collectionPath(coreV1, namespace, "pods"), p, nil)
+}
+
+func (c *Client) DeletePod(namespace, name string) error {
+	return c.do("DELETE", resourcePath(coreV1, namespace, "pods", name), nil, nil)
+}
+
+// Secrets ============================
+
+func (c *Client) GetSecret(namespace, name string) (*Secret, error) {
+	var s Secret
+	err := c.do("GET", resourcePath(coreV1, namespace, "secrets", name), nil, &s)
+	return &s, err
+}
+
+func (c *Client) CreateSecret(namespace string, s *Secret) error {
+	s.APIVersion, s.Kind = "v1", "Secret"
+	return c.do("POST", collectionPath(coreV1, namespace, "secrets"), s, nil)
+}
+
+func (c *Client) UpdateSecret(namespace string, s *Secret) error {
+	s.APIVersion, s.Kind = "v1", "Secret"
+	return c.do("PUT", resourcePath(coreV1, namespace, "secrets", s.Metadata.Name), s, nil)
+}
+
+func (c *Client) DeleteSecret(namespace, name string) error {
+	return c.do("DELETE", resourcePath(coreV1, namespace, "secrets", name), nil, nil)
+}
@@ -1,4 +1,993 @@
 // Package kubernetes implements the Scheduler interface backed by Kubernetes.
 //
-// TODO: Implement this.
+// Long running processes are mapped to Deployments, exposed processes get a
+// Service in front of them, and scheduled processes are mapped to CronJobs.
+// One-off runs are executed as bare Pods.
 package kubernetes
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.google.com/p/go-uuid/uuid"
+	"github.com/remind101/empire/scheduler"
+	"golang.org/x/net/context"
+)
+
+// newUUID returns a new UUID. Set to a var so we can stub it out in tests.
+var newUUID = uuid.New
+
+const (
+	// For exposed processes, this is the port that processes within the
+	// container should bind to. This value is also exposed to the container
+	// through the PORT environment variable.
+	ExposedPort = 8080
+
+	// DefaultNamespace is the namespace that resources are created in when
+	// one isn't provided.
+	DefaultNamespace = "default"
+)
+
+const (
+	// Label that determines what app the resource relates to.
+	appLabel = "empire.app.id"
+
+	// Label that determines what the name of the process is.
+	processLabel = "empire.app.process"
+
+	// Label that determines whether the pod is from a one-off run or not.
+	// The value of this label will be `attached` or `detached`.
+	runLabel = "run"
+
+	// Annotation on the pod template that gets changed when a new release
+	// is submitted, or the app is restarted, to trigger a rolling restart
+	// of the Deployment.
+	restartAnnotation = "empire.restart-key"
+
+	// Annotation on the pod template that determines what release the pods
+	// are from.
+	releaseAnnotation = "empire.release"
+)
+
+// Values for `runLabel`.
+const (
+	Attached = "attached"
+	Detached = "detached"
+)
+
+// Variables to control polling of the Kubernetes API.
+var (
+	// Controls how long we'll wait for a Deployment rollout to complete
+	// when a StatusStream is provided to Submit.
+	rolloutTimeout = 10 * time.Minute
+
+	// Controls how long we'll wait for a pod from an attached run to start.
+	podStartTimeout = 5 * time.Minute
+
+	// Controls how long we'll wait for the container from an attached run
+	// to terminate, after we've detached from it.
+	podExitTimeout = 1 * time.Minute
+
+	// Controls how long we'll wait between polling requests.
+	pollWait = 2 * time.Second
+)
+
+// kubernetesClient duck types the Client methods that we use.
+type kubernetesClient interface {
+	GetDeployment(namespace, name string) (*Deployment, error)
+	ListDeployments(namespace string, labels map[string]string) ([]Deployment, error)
+	CreateDeployment(namespace string, d *Deployment) error
+	UpdateDeployment(namespace string, d *Deployment) error
+	DeleteDeployment(namespace, name string) error
+
+	GetService(namespace, name string) (*Service, error)
+	ListServices(namespace string, labels map[string]string) ([]Service, error)
+	CreateService(namespace string, s *Service) error
+	UpdateService(namespace string, s *Service) error
+	DeleteService(namespace, name string) error
+
+	GetCronJob(namespace, name string) (*CronJob, error)
+	ListCronJobs(namespace string, labels map[string]string) ([]CronJob, error)
+	CreateCronJob(namespace string, j *CronJob) error
+	UpdateCronJob(namespace string, j *CronJob) error
+	DeleteCronJob(namespace, name string) error
+
+	GetPod(namespace, name string) (*Pod, error)
+	ListPods(namespace string, labels map[string]string) ([]Pod, error)
+	CreatePod(namespace string, p *Pod) error
+	DeletePod(namespace, name string) error
+
+	GetSecret(namespace, name string) (*Secret, error)
+	CreateSecret(namespace string, s *Secret) error
+	UpdateSecret(namespace string, s *Secret) error
+	DeleteSecret(namespace, name string) error
+
+	Attach(AttachOptions) error
+}
+
+// Scheduler is an implementation of the scheduler.Scheduler interface backed
+// by Kubernetes.
+type Scheduler struct {
+	// The namespace to create resources in. Defaults to DefaultNamespace.
+	Namespace string
+
+	// Resolves the app's secrets, which are stored in a Kubernetes Secret
+	// that the pods reference.
+	Secrets scheduler.SecretResolver
+
+	client kubernetesClient
+
+	after func(time.Duration) <-chan time.Time
+}
+
+// NewScheduler returns a new Scheduler instance that uses the given client to
+// interact with the Kubernetes API.
+func NewScheduler(client *Client) *Scheduler {
+	return &Scheduler{
+		client: client,
+		after:  time.After,
+	}
+}
+
+// Submit creates or updates the Deployments, Services and CronJobs for each
+// process in the app, and removes resources for processes that no longer
+// exist. If a StatusStream is provided, Submit will wait until the rollout of
+// each Deployment has completed.
+//
+// Pods are only restarted when the release changes, or when their pod
+// template does, so scaling a process doesn't restart it.
+func (s *Scheduler) Submit(ctx context.Context, app *scheduler.App, ss scheduler.StatusStream) error {
+	return s.submit(ctx, app, ss, false)
+}
+
+// Restart submits the app, and triggers a rolling restart of all of its
+// Deployments.
+func (s *Scheduler) Restart(ctx context.Context, app *scheduler.App, ss scheduler.StatusStream) error {
+	return s.submit(ctx, app, ss, true)
+}
+
+func (s *Scheduler) submit(ctx context.Context, app *scheduler.App, ss scheduler.StatusStream, restart bool) error {
+	if app.Stable != nil {
+		return scheduler.ErrCanaryNotSupported
+	}
+
+	if err := s.applySecret(ctx, app, false); err != nil {
+		return fmt.Errorf("error applying secret: %v", err)
+	}
+
+	restartKey := newUUID()
+
+	var deployments []string
+	processes := make(map[string]*scheduler.Process)
+	for _, p := range app.Processes {
+		processes[p.Type] = p
+
+		if p.Schedule != nil {
+			if err := s.applyCronJob(app, p); err != nil {
+				return fmt.Errorf("error applying cronjob for %s: %v", p.Type, err)
+			}
+			continue
+		}
+
+		if err := s.applyDeployment(app, p, restartKey, restart); err != nil {
+			return fmt.Errorf("error applying deployment for %s: %v", p.Type, err)
+		}
+		deployments = append(deployments, resourceName(app, p))
+
+		if err := s.applyService(app, p); err != nil {
+			return fmt.Errorf("error applying service for %s: %v", p.Type, err)
+		}
+	}
+
+	if err := s.prune(app, processes); err != nil {
+		return err
+	}
+
+	scheduler.Publish(ctx, ss, fmt.Sprintf("Submitted %d deployments to kubernetes", len(deployments)))
+
+	if ss != nil {
+		var unstable error
+		for _, name := range deployments {
+			if err := s.waitForRollout(name); err != nil {
+				scheduler.Publish(ctx, ss, fmt.Sprintf("Deployment %s failed to roll out: %v", name, err))
+				if _, ok := err.(*scheduler.UnstableError); ok {
+					unstable = err
+				}
+				continue
+			}
+			scheduler.Publish(ctx, ss, fmt.Sprintf("Deployment %s became stable", name))
+		}
+		return unstable
+	}
+
+	return nil
+}
+
+// applySecret resolves the app's secrets and stores their values in the
+// app's Secret, which the pods reference. Values are keyed by their reference,
+// so a key always refers to the same value. Unless merge is true, values that
+// the app no longer references are removed.
+func (s *Scheduler) applySecret(ctx context.Context, app *scheduler.App, merge bool) error {
+	ns := s.namespace()
+	name := secretName(app.ID)
+
+	existing, err := s.client.GetSecret(ns, name)
+	if err != nil && !isNotFound(err) {
+		return err
+	}
+	found := err == nil
+
+	if len(app.Secrets) == 0 {
+		if found && !merge {
+			return s.client.DeleteSecret(ns, name)
+		}
+		return nil
+	}
+
+	data := make(map[string][]byte)
+	if found && merge {
+		for k, v := range existing.Data {
+			data[k] = v
+		}
+	}
+	for k, ref := range app.Secrets {
+		key := secretKey(ref)
+		if v, ok := existing.Data[key]; found && ok {
+			data[key] = v
+			continue
+		}
+		if s.Secrets == nil {
+			return fmt.Errorf("unable to resolve secret %s: %v", k, scheduler.ErrNoSecretResolver)
+		}
+		v, err := s.Secrets.Get(ctx, ref)
+		if err != nil {
+			return fmt.Errorf("unable to resolve secret %s: %v", k, err)
+		}
+		data[key] = []byte(v)
+	}
+
+	secret := &Secret{
+		Metadata: ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{appLabel: app.ID},
+		},
+		Type: "Opaque",
+		Data: data,
+	}
+	if !found {
+		return s.client.CreateSecret(ns, secret)
+	}
+
+	secret.Metadata.ResourceVersion = existing.Metadata.ResourceVersion
+	return s.client.UpdateSecret(ns, secret)
+}
+
+// applyDeployment creates or updates the Deployment for a process. Unless
+// restart is true, the existing restart key is kept when the Deployment is
+// already running the release, so that only changes to the pod template
+// restart the pods.
+func (s *Scheduler) applyDeployment(app *scheduler.App, p *scheduler.Process, restartKey string, restart bool) error {
+	ns := s.namespace()
+	d := newDeployment(app, p, restartKey)
+
+	existing, err := s.client.GetDeployment(ns, d.Metadata.Name)
+	if isNotFound(err) {
+		return s.client.CreateDeployment(ns, d)
+	}
+	if err != nil {
+		return err
+	}
+
+	annotations := existing.Spec.Template.Metadata.Annotations
+	if key := annotations[restartAnnotation]; !restart && key != "" && annotations[releaseAnnotation] == app.Release {
+		d.Spec.Template.Metadata.Annotations[restartAnnotation] = key
+	}
+
+	d.Metadata.ResourceVersion = existing.Metadata.ResourceVersion
+	return s.client.UpdateDeployment(ns, d)
+}
+
+// applyService creates or updates the Service for a process. If the process
+// isn't exposed, any existing Service is removed.
+func (s *Scheduler) applyService(app *scheduler.App, p *scheduler.Process) error {
+	ns := s.namespace()
+	name := resourceName(app, p)
+
+	existing, err := s.client.GetService(ns, name)
+	if err != nil && !isNotFound(err) {
+		return err
+	}
+	found := err == nil
+
+	if p.Exposure == nil {
+		if found {
+			return s.client.DeleteService(ns, name)
+		}
+		return nil
+	}
+
+	svc := newService(app, p)
+	if !found {
+		return s.client.CreateService(ns, svc)
+	}
+
+	// The cluster ip is immutable, so we need to provide the existing
+	// value.
+	svc.Metadata.ResourceVersion = existing.Metadata.ResourceVersion
+	svc.Spec.ClusterIP = existing.Spec.ClusterIP
+	return s.client.UpdateService(ns, svc)
+}
+
+// applyCronJob creates or updates the CronJob for a scheduled process.
+func (s *Scheduler) applyCronJob(app *scheduler.App, p *scheduler.Process) error {
+	ns := s.namespace()
+	j, err := newCronJob(app, p)
+	if err != nil {
+		return err
+	}
+
+	existing, err := s.client.GetCronJob(ns, j.Metadata.Name)
+	if isNotFound(err) {
+		return s.client.CreateCronJob(ns, j)
+	}
+	if err != nil {
+		return err
+	}
+
+	j.Metadata.ResourceVersion = existing.Metadata.ResourceVersion
+	return s.client.UpdateCronJob(ns, j)
+}
+
+// prune removes any resources for the app that don't match the given set of
+// processes. Resources are matched by name, so that resources named after a
+// previous name of the app are removed too, as are pods from detached runs
+// that have finished.
+func (s *Scheduler) prune(app *scheduler.App, processes map[string]*scheduler.Process) error {
+	ns := s.namespace()
+	selector := map[string]string{appLabel: app.ID}
+
+	deployments, err := s.client.ListDeployments(ns, selector)
+	if err != nil {
+		return fmt.Errorf("error listing deployments: %v", err)
+	}
+	for _, d := range deployments {
+		if p, ok := processes[d.Metadata.Labels[processLabel]]; ok && p.Schedule == nil && d.Metadata.Name == resourceName(app, p) {
+			continue
+		}
+		if err := s.client.DeleteDeployment(ns, d.Metadata.Name); err != nil {
+			return fmt.Errorf("error removing deployment %s: %v", d.Metadata.Name, err)
+		}
+	}
+
+	services, err := s.client.ListServices(ns, selector)
+	if err != nil {
+		return fmt.Errorf("error listing services: %v", err)
+	}
+	for _, svc := range services {
+		if p, ok := processes[svc.Metadata.Labels[processLabel]]; ok && p.Schedule == nil && p.Exposure != nil && svc.Metadata.Name == resourceName(app, p) {
+			continue
+		}
+		if err := s.client.DeleteService(ns, svc.Metadata.Name); err != nil {
+			return fmt.Errorf("error removing service %s: %v", svc.Metadata.Name, err)
+		}
+	}
+
+	jobs, err := s.client.ListCronJobs(ns, selector)
+	if err != nil {
+		return fmt.Errorf("error listing cronjobs: %v", err)
+	}
+	for _, j := range jobs {
+		if p, ok := processes[j.Metadata.Labels[processLabel]]; ok && p.Schedule != nil && j.Metadata.Name == resourceName(app, p) {
+			continue
+		}
+		if err := s.client.DeleteCronJob(ns, j.Metadata.Name); err != nil {
+			return fmt.Errorf("error removing cronjob %s: %v", j.Metadata.Name, err)
+		}
+	}
+
+	return s.pruneDetachedPods(app.ID)
+}
+
+// pruneDetachedPods removes the pods from detached runs that have finished.
+// Nothing waits on these pods, so they'd otherwise be left around forever.
+func (s *Scheduler) pruneDetachedPods(appID string) error {
+	ns := s.namespace()
+
+	pods, err := s.client.ListPods(ns, map[string]string{appLabel: appID, runLabel: Detached})
+	if err != nil {
+		return fmt.Errorf("error listing pods: %v", err)
+	}
+	for _, pod := range pods {
+		switch pod.Status.Phase {
+		case "Succeeded", "Failed":
+			if err := s.client.DeletePod(ns, pod.Metadata.Name); err != nil && !isNotFound(err) {
+				return fmt.Errorf("error removing pod %s: %v", pod.Metadata.Name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// waitForRollout polls the Deployment until all of the replicas have been
+// updated and are available.
+func (s *Scheduler) waitForRollout(name string) error {
+	timeout := s.after(rolloutTimeout)
+	for {
+		d, err := s.client.GetDeployment(s.namespace(), name)
+		if err != nil {
+			return err
+		}
+
+		if deploymentComplete(d) {
+			return nil
+		}
+
+		select {
+		case <-timeout:
+			return &scheduler.UnstableError{Err: errors.New("timed out waiting for rollout")}
+		case <-s.after(pollWait):
+		}
+	}
+}
+
+// deploymentComplete returns true if the Deployment has finished rolling out.
+func deploymentComplete(d *Deployment) bool {
+	replicas := 1
+	if d.Spec.Replicas != nil {
+		replicas = *d.Spec.Replicas
+	}
+
+	return d.Status.ObservedGeneration >= d.Metadata.Generation &&
+		d.Status.UpdatedReplicas == replicas &&
+		d.Status.AvailableReplicas == replicas &&
+		d.Status.Replicas == replicas
+}
+
+// Remove removes all of the resources for the app.
+func (s *Scheduler) Remove(ctx context.Context, appID string) error {
+	if err := s.prune(&scheduler.App{ID: appID}, nil); err != nil {
+		return err
+	}
+
+	if err := s.client.DeleteSecret(s.namespace(), secretName(appID)); err != nil && !isNotFound(err) {
+		return fmt.Errorf("error removing secret: %v", err)
+	}
+
+	return nil
+}
+
+// Instances returns the pods for the app.
+func (s *Scheduler) Instances(ctx context.Context, appID string) ([]*scheduler.Instance, error) {
+	var instances []*scheduler.Instance
+
+	pods, err := s.client.ListPods(s.namespace(), map[string]string{appLabel: appID})
+	if err != nil {
+		return nil, fmt.Errorf("error listing pods: %v", err)
+	}
+
+	for _, pod := range pods {
+		if len(pod.Spec.Containers) == 0 {
+			continue
+		}
+
+		c := pod.Spec.Containers[0]
+
+		updatedAt := pod.Metadata.CreationTimestamp
+		if pod.Status.StartTime != nil {
+			updatedAt = pod.Status.StartTime
+		}
+
+		instance := &scheduler.Instance{
+			ID:    pod.Metadata.Name,
+			State: strings.ToUpper(pod.Status.Phase),
+			Process: &scheduler.Process{
+				Type:        pod.Metadata.Labels[processLabel],
+				Command:     c.Command,
+				Env:         envMap(c.Env),
+				MemoryLimit: uint(parseQuantity(c.Resources.Limits["memory"])),
+				CPUShares:   sharesFromMilliCPU(parseMilliQuantity(c.Resources.Requests["cpu"])),
+			},
+		}
+		if updatedAt != nil {
+			instance.UpdatedAt = *updatedAt
+		}
+
+		instances = append(instances, instance)
+	}
+
+	return instances, nil
+}
+
+// Stop deletes the pod. If the pod is managed by a Deployment, a new pod will
+// be started in it's place.
+func (s *Scheduler) Stop(ctx context.Context, podName string) error {
+	pod, err := s.client.GetPod(s.namespace(), podName)
+	if err != nil {
+		return err
+	}
+
+	// Some extra protection around stopping pods. We don't want to allow
+	// users to stop pods that weren't started by Empire.
+	if _, ok := pod.Metadata.Labels[appLabel]; !ok {
+		return fmt.Errorf("pod %s is not managed by empire", podName)
+	}
+
+	return s.client.DeletePod(s.namespace(), podName)
+}
+
+// Run runs a one-off process as a Pod. If input or output is provided, this
+// attaches to the pod, then removes it when the process exits. Pods from
+// detached runs are removed once they've finished, the next time the app is
+// run or submitted.
+func (s *Scheduler) Run(ctx context.Context, app *scheduler.App, p *scheduler.Process, in io.Reader, out io.Writer) error {
+	ns := s.namespace()
+	attached := out != nil || in != nil
+
+	if err := s.pruneDetachedPods(app.ID); err != nil {
+		return err
+	}
+
+	// Secrets are merged, so that pods from the current release keep
+	// resolving their values.
+	if err := s.applySecret(ctx, app, true); err != nil {
+		return fmt.Errorf("error applying secret: %v", err)
+	}
+
+	pod := newPod(app, p, attached)
+	if err := s.client.CreatePod(ns, pod); err != nil {
+		return fmt.Errorf("error creating pod: %v", err)
+	}
+
+	if !attached {
+		return nil
+	}
+
+	defer s.client.DeletePod(ns, pod.Metadata.Name)
+
+	if err := s.waitForPod(pod.Metadata.Name); err != nil {
+		return err
+	}
+
+	if err := s.client.Attach(AttachOptions{
+		Namespace: ns,
+		Pod:       pod.Metadata.Name,
+		Container: pod.Spec.Containers[0].Name,
+		Stdin:     in,
+		Stdout:    out,
+		TTY:       true,
+	}); err != nil {
+		return fmt.Errorf("error attaching to pod: %v", err)
+	}
+
+	code, err := s.waitForExit(pod.Metadata.Name, pod.Spec.Containers[0].Name)
+	if err != nil {
+		return err
+	}
+
+	if code != 0 {
+		return &scheduler.ExitError{Code: code}
+	}
+
+	return nil
+}
+
+// waitForExit waits until the container in the pod has terminated, and returns
+// its exit code.
+func (s *Scheduler) waitForExit(name, container string) (int, error) {
+	timeout := s.after(podExitTimeout)
+	for {
+		pod, err := s.client.GetPod(s.namespace(), name)
+		if err != nil {
+			return 0, err
+		}
+
+		for _, status := range pod.Status.ContainerStatuses {
+			if status.Name == container && status.State.Terminated != nil {
+				return status.State.Terminated.ExitCode, nil
+			}
+		}
+
+		select {
+		case <-timeout:
+			return 0, fmt.Errorf("timed out waiting for pod %s to exit", name)
+		case <-s.after(pollWait):
+		}
+	}
+}
+
+// waitForPod waits until the pod is no longer pending.
+func (s *Scheduler) waitForPod(name string) error {
+	timeout := s.after(podStartTimeout)
+	for {
+		pod, err := s.client.GetPod(s.namespace(), name)
+		if err != nil {
+			return err
+		}
+
+		switch pod.Status.Phase {
+		case "Running", "Succeeded":
+			return nil
+		case "Failed":
+			return fmt.Errorf("pod %s failed to start", name)
+		}
+
+		select {
+		case <-timeout:
+			return fmt.Errorf("timed out waiting for pod %s to start", name)
+		case <-s.after(pollWait):
+		}
+	}
+}
+
+func (s *Scheduler) namespace() string {
+	if s.Namespace == "" {
+		return DefaultNamespace
+	}
+	return s.Namespace
+}
+
+// newDeployment returns the Deployment for a process.
+func newDeployment(app *scheduler.App, p *scheduler.Process, restartKey string) *Deployment {
+	replicas := int(p.Instances)
+	template := newPodTemplate(app, p)
+	template.Metadata.Annotations = map[string]string{
+		restartAnnotation: restartKey,
+		releaseAnnotation: app.Release,
+	}
+	template.Spec.RestartPolicy = "Always"
+
+	return &Deployment{
+		Metadata: ObjectMeta{
+			Name:   resourceName(app, p),
+			Labels: selectorLabels(app, p),
+		},
+		Spec: DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &LabelSelector{
+				MatchLabels: selectorLabels(app, p),
+			},
+			Template: template,
+		},
+	}
+}
+
+// newService returns the Service that exposes a process.
+func newService(app *scheduler.App, p *scheduler.Process) *Service {
+	svc := &Service{
+		Metadata: ObjectMeta{
+			Name:   resourceName(app, p),
+			Labels: selectorLabels(app, p),
+		},
+		Spec: ServiceSpec{
+			Type:     "ClusterIP",
+			Selector: selectorLabels(app, p),
+			Ports: []ServicePort{
+				{Name: "http", Protocol: "TCP", Port: 80, TargetPort: ExposedPort},
+			},
+		},
+	}
+
+	if p.Exposure.External {
+		svc.Spec.Type = "LoadBalancer"
+	}
+
+	switch e := p.Exposure.Type.(type) {
+	case *scheduler.HTTPSExposure:
+		svc.Spec.Ports = append(svc.Spec.Ports, ServicePort{
+			Name: "https", Protocol: "TCP", Port: 443, TargetPort: ExposedPort,
+		})
+		svc.Metadata.Annotations = map[string]string{
+			"service.beta.kubernetes.io/aws-load-balancer-ssl-cert":         e.Cert,
+			"service.beta.kubernetes.io/aws-load-balancer-ssl-ports":        "https",
+			"service.beta.kubernetes.io/aws-load-balancer-backend-protocol": "http",
+		}
+	case *scheduler.TCPExposure:
+		svc.Spec.Ports = tcpServicePorts(e.Ports)
+	case *scheduler.SSLExposure:
+		svc.Spec.Ports = tcpServicePorts(e.Ports)
+		svc.Metadata.Annotations = map[string]string{
+			"service.beta.kubernetes.io/aws-load-balancer-ssl-cert":         e.Cert,
+			"service.beta.kubernetes.io/aws-load-balancer-ssl-ports":        "*",
+			"service.beta.kubernetes.io/aws-load-balancer-backend-protocol": "tcp",
+		}
+	}
+
+	return svc
+}
+
+// tcpServicePorts returns a ServicePort for each port, which forwards to the
+// exposed port of the container.
+func tcpServicePorts(ports []int) []ServicePort {
+	var servicePorts []ServicePort
+	for _, port := range ports {
+		servicePorts = append(servicePorts, ServicePort{
+			Name: fmt.Sprintf("tcp-%d", port), Protocol: "TCP", Port: port, TargetPort: ExposedPort,
+		})
+	}
+	return servicePorts
+}
+
+// newCronJob returns the CronJob for a scheduled process.
+func newCronJob(app *scheduler.App, p *scheduler.Process) (*CronJob, error) {
+	schedule, err := cronExpression(p.Schedule)
+	if err != nil {
+		return nil, err
+	}
+
+	parallelism := int(p.Instances)
+	suspend := p.Instances == 0
+	template := newPodTemplate(app, p)
+	template.Spec.RestartPolicy = "Never"
+
+	return &CronJob{
+		Metadata: ObjectMeta{
+			Name:   resourceName(app, p),
+			Labels: selectorLabels(app, p),
+		},
+		Spec: CronJobSpec{
+			Schedule:          schedule,
+			ConcurrencyPolicy: "Forbid",
+			Suspend:           &suspend,
+			JobTemplate: JobTemplateSpec{
+				Metadata: ObjectMeta{
+					Labels: selectorLabels(app, p),
+				},
+				Spec: JobSpec{
+					Parallelism: &parallelism,
+					Template:    template,
+				},
+			},
+		},
+	}, nil
+}
+
+// newPod returns a Pod suitable for a one-off run of a process.
+func newPod(app *scheduler.App, p *scheduler.Process, attached bool) *Pod {
+	template := newPodTemplate(app, p)
+	template.Spec.RestartPolicy = "Never"
+
+	run := Detached
+	if attached {
+		run = Attached
+		c := &template.Spec.Containers[0]
+		c.Stdin = true
+		c.StdinOnce = true
+		c.TTY = true
+	}
+	template.Metadata.Labels[runLabel] = run
+	template.Metadata.Name = fmt.Sprintf("%s-%s", resourceName(app, p), strings.Split(newUUID(), "-")[0])
+
+	return &Pod{
+		Metadata: template.Metadata,
+		Spec:     template.Spec,
+	}
+}
+
+// newPodTemplate returns a PodTemplateSpec that will run a process.
+func newPodTemplate(app *scheduler.App, p *scheduler.Process) PodTemplateSpec {
+	env := scheduler.Env(app, p)
+
+	var ports []ContainerPort
+	if p.Exposure != nil {
+		env["PORT"] = fmt.Sprintf("%d", ExposedPort)
+		ports = append(ports, ContainerPort{ContainerPort: ExposedPort, Protocol: "TCP"})
+	}
+
+	resources := ResourceRequirements{
+		Requests: map[string]string{},
+		Limits:   map[string]string{},
+	}
+	if p.CPUShares != 0 {
+		resources.Requests["cpu"] = fmt.Sprintf("%dm", milliCPUFromShares(p.CPUShares))
+	}
+	if p.MemoryLimit != 0 {
+		memory := fmt.Sprintf("%d", p.MemoryLimit)
+		resources.Requests["memory"] = memory
+		resources.Limits["memory"] = memory
+	}
+
+	vars := sortedEnv(env)
+	secrets := scheduler.Secrets(app, p)
+	for _, k := range sortedKeys(secrets) {
+		if _, ok := env[k]; ok {
+			continue
+		}
+		vars = append(vars, EnvVar{
+			Name: k,
+			ValueFrom: &EnvVarSource{
+				SecretKeyRef: &SecretKeySelector{
+					Name: secretName(app.ID),
+					Key:  secretKey(secrets[k]),
+				},
+			},
+		})
+	}
+
+	return PodTemplateSpec{
+		Metadata: ObjectMeta{
+			Labels: podLabels(app, p),
+		},
+		Spec: PodSpec{
+			Containers: []Container{
+				{
+					Name:      containerName(p.Type),
+					Image:     p.Image.String(),
+					Command:   p.Command,
+					Env:       vars,
+					Ports:     ports,
+					Resources: resources,
+				},
+			},
+		},
+	}
+}
+
+// selectorLabels returns the labels used to select the resources for a
+// process. These need to be stable across releases, since the selector of a
+// Deployment is immutable.
+func selectorLabels(app *scheduler.App, p *scheduler.Process) map[string]string {
+	return map[string]string{
+		appLabel:     app.ID,
+		processLabel: p.Type,
+	}
+}
+
+// podLabels returns the labels to add to a pod, which is the combination of
+// the app and process labels, with any values that kubernetes won't accept
+// removed.
+func podLabels(app *scheduler.App, p *scheduler.Process) map[string]string {
+	labels := make(map[string]string)
+	for k, v := range scheduler.Labels(app, p) {
+		if labelValueRegex.MatchString(v) {
+			labels[k] = v
+		}
+	}
+	for k, v := range selectorLabels(app, p) {
+		labels[k] = v
+	}
+	return labels
+}
+
+// Kubernetes resource names must be valid DNS-1123 labels.
+var invalidNameRegex = regexp.MustCompile("[^a-z0-9-]")
+
+// Kubernetes label values must be 63 characters or less, and consist of
+// alphanumerics, `-`, `_` or `.`.
+var labelValueRegex = regexp.MustCompile(`^(([A-Za-z0-9][-A-Za-z0-9_.]{0,61})?[A-Za-z0-9])?$`)
+
+// resourceName returns the name of the Deployment, Service or CronJob for a
+// process.
+func resourceName(app *scheduler.App, p *scheduler.Process) string {
+	return fmt.Sprintf("%s-%s", app.Name, containerName(p.Type))
+}
+
+// secretName returns the name of the Secret that holds the values of the
+// app's secrets.
+func secretName(appID string) string {
+	return fmt.Sprintf("%s-secrets", appID)
+}
+
+// secretKey returns the key in the app's Secret for the value of a secret.
+// Keys must be alphanumeric, so the reference is hashed.
+func secretKey(ref string) string {
+	h := sha1.Sum([]byte(ref))
+	return hex.EncodeToString(h[:])
+}
+
+// containerName normalizes the process type into a valid kubernetes name.
+func containerName(process string) string {
+	return strings.Trim(invalidNameRegex.ReplaceAllString(strings.ToLower(process), "-"), "-")
+}
+
+// cronExpression converts a scheduler.Schedule into a standard 5 field cron
+// expression that kubernetes understands.
+func cronExpression(s scheduler.Schedule) (string, error) {
+	switch v := s.(type) {
+	case scheduler.CRONSchedule:
+		fields := strings.Fields(string(v))
+		// Empire uses the CloudWatch Events cron format, which includes
+		// a trailing year field and uses `?` as a wildcard.
+		if len(fields) == 6 {
+			fields = fields[:5]
+		}
+		if len(fields) != 5 {
+			return "", fmt.Errorf("invalid cron expression: %s", v)
+		}
+		for i, f := range fields {
+			if f == "?" {
+				fields[i] = "*"
+			}
+		}
+		return strings.Join(fields, " "), nil
+	case time.Duration:
+		minutes := int64(v / time.Minute)
+		if minutes < 1 || minutes > 59 {
+			return "", fmt.Errorf("unsupported schedule interval: %v", v)
+		}
+		return fmt.Sprintf("*/%d * * * *", minutes), nil
+	default:
+		return "", fmt.Errorf("unknown schedule: %v", s)
+	}
+}
+
+// milliCPUFromShares converts docker CPU shares, out of 1024, into kubernetes
+// millicores.
+func milliCPUFromShares(shares uint) uint {
+	return shares * 1000 / 1024
+}
+
+// sharesFromMilliCPU converts kubernetes millicores into docker CPU shares.
+func sharesFromMilliCPU(milli int64) uint {
+	return uint((milli*1024 + 500) / 1000)
+}
+
+// Binary and decimal suffixes used in kubernetes resource quantities.
+var quantitySuffixes = map[string]int64{
+	"Ki": 1 << 10, "Mi": 1 << 20, "Gi": 1 << 30, "Ti": 1 << 40,
+	"k": 1e3, "M": 1e6, "G": 1e9, "T": 1e12,
+}
+
+// parseQuantity parses a kubernetes resource quantity (e.g. 512Mi) into an
+// integer value.
+func parseQuantity(q string) int64 {
+	for suffix, multiplier := range quantitySuffixes {
+		if strings.HasSuffix(q, suffix) {
+			n, _ := strconv.ParseFloat(strings.TrimSuffix(q, suffix), 64)
+			return int64(n * float64(multiplier))
+		}
+	}
+	n, _ := strconv.ParseFloat(q, 64)
+	return int64(n)
+}
+
+// parseMilliQuantity parses a kubernetes resource quantity (e.g. 250m or 1)
+// into thousandths of a unit.
+func parseMilliQuantity(q string) int64 {
+	if strings.HasSuffix(q, "m") {
+		n, _ := strconv.ParseInt(strings.TrimSuffix(q, "m"), 10, 64)
+		return n
+	}
+	n, _ := strconv.ParseFloat(q, 64)
+	return int64(n * 1000)
+}
+
+// sortedEnv converts the map into a slice of EnvVar, sorted by name so that
+// the pod template doesn't change between submits.
+func sortedEnv(env map[string]string) []EnvVar {
+	var vars []EnvVar
+	for _, k := range sortedKeys(env) {
+		vars = append(vars, EnvVar{Name: k, Value: env[k]})
+	}
+	return vars
+}
+
+func sortedKeys(m map[string]string) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// envMap converts the environment of a container back into a map. Variables
+// that reference a Secret are omitted, since their values aren't known.
+func envMap(vars []EnvVar) map[string]string {
+	env := make(map[string]string)
+	for _, v := range vars {
+		if v.ValueFrom != nil {
+			continue
+		}
+		env[v.Name] = v.Value
+	}
+	return env
+}
@@ -0,0 +1,800 @@
+package kubernetes
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/remind101/empire/pkg/image"
+	"github.com/remind101/empire/scheduler"
+	"github.com/stretchr/testify/assert"
+)
+
+var ctx = context.Background()
+
+func init() {
+	newUUID = func() string { return "c9366591-ab68-4d49-a333-95ce5a23df68" }
+}
+
+func TestScheduler_Submit(t *testing.T) {
+	api := newFakeAPI()
+	s, done := newTestScheduler(api)
+	defer done()
+
+	err := s.Submit(ctx, testApp(), nil)
+	assert.NoError(t, err)
+
+	var d Deployment
+	api.get(t, "/apis/apps/v1/namespaces/default/deployments/acme-inc-web", &d)
+	assert.Equal(t, 2, *d.Spec.Replicas)
+	assert.Equal(t, map[string]string{
+		"empire.app.id":      "c9366591-ab68-4d49-a333-95ce5a23df68",
+		"empire.app.process": "web",
+	}, d.Spec.Selector.MatchLabels)
+	assert.Equal(t, "c9366591-ab68-4d49-a333-95ce5a23df68", d.Spec.Template.Metadata.Annotations[restartAnnotation])
+
+	c := d.Spec.Template.Spec.Containers[0]
+	assert.Equal(t, "web", c.Name)
+	assert.Equal(t, "remind101/acme-inc:latest", c.Image)
+	assert.Equal(t, []string{"./bin/web"}, c.Command)
+	assert.Equal(t, []ContainerPort{{ContainerPort: 8080, Protocol: "TCP"}}, c.Ports)
+	assert.Equal(t, fmt.Sprintf("%d", 128*1024*1024), c.Resources.Limits["memory"])
+	assert.Equal(t, "250m", c.Resources.Requests["cpu"])
+	assert.Equal(t, "8080", envMap(c.Env)["PORT"])
+	assert.Equal(t, "bar", envMap(c.Env)["FOO"])
+
+	var svc Service
+	api.get(t, "/api/v1/namespaces/default/services/acme-inc-web", &svc)
+	assert.Equal(t, "LoadBalancer", svc.Spec.Type)
+	assert.Equal(t, []ServicePort{
+		{Name: "http", Protocol: "TCP", Port: 80, TargetPort: 8080},
+	}, svc.Spec.Ports)
+
+	var worker Deployment
+	api.get(t, "/apis/apps/v1/namespaces/default/deployments/acme-inc-worker", &worker)
+	assert.Equal(t, 0, len(worker.Spec.Template.Spec.Containers[0].Ports))
+	api.notFound(t, "/api/v1/namespaces/default/services/acme-inc-worker")
+
+	var j CronJob
+	api.get(t, "/apis/batch/v1/namespaces/default/cronjobs/acme-inc-scheduled", &j)
+	assert.Equal(t, "0 12 * * *", j.Spec.Schedule)
+	assert.Equal(t, false, *j.Spec.Suspend)
+	assert.Equal(t, "Never", j.Spec.JobTemplate.Spec.Template.Spec.RestartPolicy)
+}
+
+func TestScheduler_Submit_Update(t *testing.T) {
+	api := newFakeAPI()
+	s, done := newTestScheduler(api)
+	defer done()
+
+	app := testApp()
+	err := s.Submit(ctx, app, nil)
+	assert.NoError(t, err)
+
+	// Remove the worker process and the exposure of the web process.
+	app.Processes = app.Processes[:1]
+	app.Processes[0].Exposure = nil
+	app.Processes[0].Instances = 5
+
+	err = s.Submit(ctx, app, nil)
+	assert.NoError(t, err)
+
+	var d Deployment
+	api.get(t, "/apis/apps/v1/namespaces/default/deployments/acme-inc-web", &d)
+	assert.Equal(t, 5, *d.Spec.Replicas)
+	assert.Equal(t, "2", d.Metadata.ResourceVersion)
+
+	api.notFound(t, "/api/v1/namespaces/default/services/acme-inc-web")
+	api.notFound(t, "/apis/apps/v1/namespaces/default/deployments/acme-inc-worker")
+	api.notFound(t, "/apis/batch/v1/namespaces/default/cronjobs/acme-inc-scheduled")
+}
+
+func TestScheduler_Submit_RestartKey(t *testing.T) {
+	api := newFakeAPI()
+	s, done := newTestScheduler(api)
+	defer done()
+
+	defer func(f func() string) { newUUID = f }(newUUID)
+	restartKey := func() string {
+		var d Deployment
+		api.get(t, "/apis/apps/v1/namespaces/default/deployments/acme-inc-web", &d)
+		return d.Spec.Template.Metadata.Annotations[restartAnnotation]
+	}
+
+	app := testApp()
+	newUUID = func() string { return "key-1" }
+	err := s.Submit(ctx, app, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "key-1", restartKey())
+
+	// Scaling the process shouldn't restart the pods.
+	app.Processes[0].Instances = 5
+	newUUID = func() string { return "key-2" }
+	err = s.Submit(ctx, app, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "key-1", restartKey())
+
+	// A new release should.
+	app.Release = "v2"
+	err = s.Submit(ctx, app, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "key-2", restartKey())
+
+	// So should an explicit restart.
+	newUUID = func() string { return "key-3" }
+	err = s.Restart(ctx, app, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "key-3", restartKey())
+}
+
+func TestScheduler_Submit_Rename(t *testing.T) {
+	api := newFakeAPI()
+	s, done := newTestScheduler(api)
+	defer done()
+
+	app := testApp()
+	err := s.Submit(ctx, app, nil)
+	assert.NoError(t, err)
+
+	app.Name = "acme"
+	err = s.Submit(ctx, app, nil)
+	assert.NoError(t, err)
+
+	var d Deployment
+	api.get(t, "/apis/apps/v1/namespaces/default/deployments/acme-web", &d)
+	var svc Service
+	api.get(t, "/api/v1/namespaces/default/services/acme-web", &svc)
+	var j CronJob
+	api.get(t, "/apis/batch/v1/namespaces/default/cronjobs/acme-scheduled", &j)
+
+	// Resources named after the previous name are removed.
+	api.notFound(t, "/apis/apps/v1/namespaces/default/deployments/acme-inc-web")
+	api.notFound(t, "/apis/apps/v1/namespaces/default/deployments/acme-inc-worker")
+	api.notFound(t, "/api/v1/namespaces/default/services/acme-inc-web")
+	api.notFound(t, "/apis/batch/v1/namespaces/default/cronjobs/acme-inc-scheduled")
+}
+
+func TestScheduler_Submit_StatusStream(t *testing.T) {
+	api := newFakeAPI()
+	s, done := newTestScheduler(api)
+	defer done()
+
+	var statuses []string
+	ss := scheduler.StatusStreamFunc(func(status scheduler.Status) error {
+		statuses = append(statuses, status.Message)
+		return nil
+	})
+
+	err := s.Submit(ctx, testApp(), ss)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"Submitted 2 deployments to kubernetes",
+		"Deployment acme-inc-web became stable",
+		"Deployment acme-inc-worker became stable",
+	}, statuses)
+}
+
+func TestScheduler_Remove(t *testing.T) {
+	api := newFakeAPI()
+	s, done := newTestScheduler(api)
+	defer done()
+
+	err := s.Submit(ctx, testApp(), nil)
+	assert.NoError(t, err)
+
+	err = s.Remove(ctx, "c9366591-ab68-4d49-a333-95ce5a23df68")
+	assert.NoError(t, err)
+
+	assert.Equal(t, 0, api.len())
+}
+
+func TestScheduler_Submit_Secrets(t *testing.T) {
+	api := newFakeAPI()
+	s, done := newTestScheduler(api)
+	defer done()
+	s.Secrets = secretResolverFunc(func(ref string) (string, error) {
+		return map[string]string{
+			"fake:1": "postgres://localhost",
+			"fake:2": "postgres://example.com",
+		}[ref], nil
+	})
+
+	app := testApp()
+	app.Secrets = map[string]string{"DATABASE_URL": "fake:1"}
+	err := s.Submit(ctx, app, nil)
+	assert.NoError(t, err)
+
+	var d Deployment
+	api.get(t, "/apis/apps/v1/namespaces/default/deployments/acme-inc-web", &d)
+	c := d.Spec.Template.Spec.Containers[0]
+	assert.Contains(t, c.Env, EnvVar{
+		Name: "DATABASE_URL",
+		ValueFrom: &EnvVarSource{
+			SecretKeyRef: &SecretKeySelector{
+				Name: "c9366591-ab68-4d49-a333-95ce5a23df68-secrets",
+				Key:  secretKey("fake:1"),
+			},
+		},
+	})
+	_, ok := envMap(c.Env)["DATABASE_URL"]
+	assert.False(t, ok)
+
+	var secret Secret
+	api.get(t, "/api/v1/namespaces/default/secrets/c9366591-ab68-4d49-a333-95ce5a23df68-secrets", &secret)
+	assert.Equal(t, map[string][]byte{
+		secretKey("fake:1"): []byte("postgres://localhost"),
+	}, secret.Data)
+
+	// Values that are no longer referenced are removed.
+	app.Secrets = map[string]string{"DATABASE_URL": "fake:2"}
+	err = s.Submit(ctx, app, nil)
+	assert.NoError(t, err)
+
+	var updated Secret
+	api.get(t, "/api/v1/namespaces/default/secrets/c9366591-ab68-4d49-a333-95ce5a23df68-secrets", &updated)
+	assert.Equal(t, map[string][]byte{
+		secretKey("fake:2"): []byte("postgres://example.com"),
+	}, updated.Data)
+
+	err = s.Remove(ctx, app.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, api.len())
+}
+
+func TestScheduler_Submit_Secrets_NoResolver(t *testing.T) {
+	api := newFakeAPI()
+	s, done := newTestScheduler(api)
+	defer done()
+
+	app := testApp()
+	app.Secrets = map[string]string{"DATABASE_URL": "fake:1"}
+	err := s.Submit(ctx, app, nil)
+	assert.EqualError(t, err, "error applying secret: unable to resolve secret DATABASE_URL: no secrets backend is configured to resolve secrets")
+}
+
+func TestScheduler_Instances(t *testing.T) {
+	api := newFakeAPI()
+	s, done := newTestScheduler(api)
+	defer done()
+
+	started := time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)
+	api.put("/api/v1/namespaces/default/pods/acme-inc-web-1234", &Pod{
+		Metadata: ObjectMeta{
+			Name: "acme-inc-web-1234",
+			Labels: map[string]string{
+				"empire.app.id":      "c9366591-ab68-4d49-a333-95ce5a23df68",
+				"empire.app.process": "web",
+			},
+		},
+		Spec: PodSpec{
+			Containers: []Container{
+				{
+					Name:    "web",
+					Command: []string{"./bin/web"},
+					Env:     []EnvVar{{Name: "FOO", Value: "bar"}},
+					Resources: ResourceRequirements{
+						Limits:   map[string]string{"memory": "128Mi"},
+						Requests: map[string]string{"cpu": "500m"},
+					},
+				},
+			},
+		},
+		Status: PodStatus{
+			Phase:     "Running",
+			StartTime: &started,
+		},
+	})
+
+	instances, err := s.Instances(ctx, "c9366591-ab68-4d49-a333-95ce5a23df68")
+	assert.NoError(t, err)
+	assert.Equal(t, []*scheduler.Instance{
+		{
+			ID:        "acme-inc-web-1234",
+			State:     "RUNNING",
+			UpdatedAt: started,
+			Process: &scheduler.Process{
+				Type:        "web",
+				Command:     []string{"./bin/web"},
+				Env:         map[string]string{"FOO": "bar"},
+				MemoryLimit: 128 * 1024 * 1024,
+				CPUShares:   512,
+			},
+		},
+	}, instances)
+}
+
+func TestScheduler_Stop(t *testing.T) {
+	api := newFakeAPI()
+	s, done := newTestScheduler(api)
+	defer done()
+
+	api.put("/api/v1/namespaces/default/pods/acme-inc-web-1234", &Pod{
+		Metadata: ObjectMeta{
+			Name:   "acme-inc-web-1234",
+			Labels: map[string]string{"empire.app.id": "c9366591-ab68-4d49-a333-95ce5a23df68"},
+		},
+	})
+	api.put("/api/v1/namespaces/default/pods/kube-dns", &Pod{
+		Metadata: ObjectMeta{Name: "kube-dns"},
+	})
+
+	err := s.Stop(ctx, "acme-inc-web-1234")
+	assert.NoError(t, err)
+	api.notFound(t, "/api/v1/namespaces/default/pods/acme-inc-web-1234")
+
+	err = s.Stop(ctx, "kube-dns")
+	assert.EqualError(t, err, "pod kube-dns is not managed by empire")
+}
+
+func TestScheduler_Run_Attached(t *testing.T) {
+	api := newFakeAPI()
+	s, done := newTestScheduler(api)
+	defer done()
+
+	app := testApp()
+	p := &scheduler.Process{
+		Type:    "run",
+		Image:   app.Processes[0].Image,
+		Command: []string{"/bin/sh"},
+	}
+
+	stdout := new(bytes.Buffer)
+	err := s.Run(ctx, app, p, strings.NewReader(""), stdout)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", stdout.String())
+
+	// The pod should have been cleaned up.
+	assert.Equal(t, 0, api.len())
+	assert.Equal(t, "attached", api.created["/api/v1/namespaces/default/pods/acme-inc-run-c9366591"].Metadata.Labels["run"])
+}
+
+func TestScheduler_Run_Attached_ExitError(t *testing.T) {
+	api := newFakeAPI()
+	api.exitCode = 1
+	s, done := newTestScheduler(api)
+	defer done()
+
+	app := testApp()
+	p := &scheduler.Process{
+		Type:    "run",
+		Image:   app.Processes[0].Image,
+		Command: []string{"/bin/false"},
+	}
+
+	err := s.Run(ctx, app, p, strings.NewReader(""), new(bytes.Buffer))
+	assert.Equal(t, &scheduler.ExitError{Code: 1}, err)
+
+	// The pod should have been cleaned up.
+	assert.Equal(t, 0, api.len())
+}
+
+func TestScheduler_Run_Detached(t *testing.T) {
+	api := newFakeAPI()
+	s, done := newTestScheduler(api)
+	defer done()
+
+	app := testApp()
+	p := &scheduler.Process{
+		Type:    "run",
+		Image:   app.Processes[0].Image,
+		Command: []string{"/bin/sh"},
+	}
+
+	err := s.Run(ctx, app, p, nil, nil)
+	assert.NoError(t, err)
+
+	var pod Pod
+	api.get(t, "/api/v1/namespaces/default/pods/acme-inc-run-c9366591", &pod)
+	assert.Equal(t, "detached", pod.Metadata.Labels["run"])
+	assert.Equal(t, "Never", pod.Spec.RestartPolicy)
+}
+
+func TestCronExpression(t *testing.T) {
+	tests := []struct {
+		in  scheduler.Schedule
+		out string
+		err bool
+	}{
+		{scheduler.CRONSchedule("0 12 * * ? *"), "0 12 * * *", false},
+		{scheduler.CRONSchedule("*/5 * * * *"), "*/5 * * * *", false},
+		{scheduler.CRONSchedule("* *"), "", true},
+		{5 * time.Minute, "*/5 * * * *", false},
+		{2 * time.Hour, "", true},
+	}
+
+	for _, tt := range tests {
+		out, err := cronExpression(tt.in)
+		if tt.err {
+			assert.Error(t, err)
+			continue
+		}
+		assert.NoError(t, err)
+		assert.Equal(t, tt.out, out)
+	}
+}
+
+func TestParseQuantity(t *testing.T) {
+	tests := []struct {
+		in  string
+		out int64
+	}{
+		{"134217728", 134217728},
+		{"128Mi", 134217728},
+		{"1Gi", 1073741824},
+		{"1k", 1000},
+		{"", 0},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.out, parseQuantity(tt.in))
+	}
+}
+
+func TestParseMilliQuantity(t *testing.T) {
+	tests := []struct {
+		in  string
+		out int64
+	}{
+		{"250m", 250},
+		{"1", 1000},
+		{"0.5", 500},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.out, parseMilliQuantity(tt.in))
+	}
+}
+
+func testApp() *scheduler.App {
+	return &scheduler.App{
+		ID:      "c9366591-ab68-4d49-a333-95ce5a23df68",
+		Name:    "acme-inc",
+		Release: "v1",
+		Env:     map[string]string{"FOO": "bar"},
+		Processes: []*scheduler.Process{
+			{
+				Type:        "web",
+				Image:       image.Image{Repository: "remind101/acme-inc", Tag: "latest"},
+				Command:     []string{"./bin/web"},
+				Instances:   2,
+				MemoryLimit: 128 * 1024 * 1024,
+				CPUShares:   256,
+				Exposure: &scheduler.Exposure{
+					External: true,
+					Type:     &scheduler.HTTPExposure{},
+				},
+			},
+			{
+				Type:      "worker",
+				Image:     image.Image{Repository: "remind101/acme-inc", Tag: "latest"},
+				Command:   []string{"./bin/worker"},
+				Instances: 1,
+			},
+			{
+				Type:      "scheduled",
+				Image:     image.Image{Repository: "remind101/acme-inc", Tag: "latest"},
+				Command:   []string{"./bin/scheduled"},
+				Instances: 1,
+				Schedule:  scheduler.CRONSchedule("0 12 * * ? *"),
+			},
+		},
+	}
+}
+
+type secretResolverFunc func(string) (string, error)
+
+func (fn secretResolverFunc) Get(ctx context.Context, ref string) (string, error) {
+	return fn(ref)
+}
+
+func TestScheduler_Run_Detached_Prune(t *testing.T) {
+	api := newFakeAPI()
+	s, done := newTestScheduler(api)
+	defer done()
+
+	app := testApp()
+	for name, phase := range map[string]string{
+		"acme-inc-run-succeeded": "Succeeded",
+		"acme-inc-run-failed":    "Failed",
+		"acme-inc-run-running":   "Running",
+	} {
+		api.put("/api/v1/namespaces/default/pods/"+name, &Pod{
+			Metadata: ObjectMeta{
+				Name: name,
+				Labels: map[string]string{
+					"empire.app.id": app.ID,
+					"run":           "detached",
+				},
+			},
+			Status: PodStatus{Phase: phase},
+		})
+	}
+	api.put("/api/v1/namespaces/default/pods/acme-inc-web-1234", &Pod{
+		Metadata: ObjectMeta{
+			Name:   "acme-inc-web-1234",
+			Labels: map[string]string{"empire.app.id": app.ID},
+		},
+		Status: PodStatus{Phase: "Succeeded"},
+	})
+
+	err := s.Run(ctx, app, &scheduler.Process{
+		Type:    "run",
+		Image:   app.Processes[0].Image,
+		Command: []string{"/bin/sh"},
+	}, nil, nil)
+	assert.NoError(t, err)
+
+	api.notFound(t, "/api/v1/namespaces/default/pods/acme-inc-run-succeeded")
+	api.notFound(t, "/api/v1/namespaces/default/pods/acme-inc-run-failed")
+
+	var pod Pod
+	api.get(t, "/api/v1/namespaces/default/pods/acme-inc-run-running", &pod)
+	api.get(t, "/api/v1/namespaces/default/pods/acme-inc-web-1234", &pod)
+	api.get(t, "/api/v1/namespaces/default/pods/acme-inc-run-c9366591", &pod)
+}
+
+func newTestScheduler(api *fakeAPI) (*Scheduler, func()) {
+	srv := httptest.NewServer(api)
+	s := &Scheduler{
+		client: NewClient(srv.URL, nil),
+		after: func(time.Duration) <-chan time.Time {
+			return time.After(time.Millisecond)
+		},
+	}
+	return s, srv.Close
+}
+
+// fakeAPI is a minimal, in memory, implementation of the Kubernetes API
+// server.
+type fakeAPI struct {
+	sync.Mutex
+
+	// Maps the path of a resource to its json representation.
+	resources map[string][]byte
+
+	// Maps the path of a resource to the metadata and spec it was created
+	// with.
+	created map[string]*Pod
+
+	// The exit code that containers terminate with after they're attached
+	// to.
+	exitCode int
+}
+
+func newFakeAPI() *fakeAPI {
+	return &fakeAPI{
+		resources: make(map[string][]byte),
+		created:   make(map[string]*Pod),
+	}
+}
+
+func (f *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.Lock()
+	defer f.Unlock()
+
+	if strings.HasSuffix(r.URL.Path, "/attach") {
+		f.attach(w, r)
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+		if _, ok := r.URL.Query()["labelSelector"]; ok {
+			f.list(w, r)
+			return
+		}
+		raw, ok := f.resources[r.URL.Path]
+		if !ok {
+			notFound(w)
+			return
+		}
+		w.Write(raw)
+	case "POST":
+		var obj map[string]interface{}
+		raw, _ := ioutil.ReadAll(r.Body)
+		json.Unmarshal(raw, &obj)
+		meta := obj["metadata"].(map[string]interface{})
+		path := fmt.Sprintf("%s/%s", r.URL.Path, meta["name"])
+		if _, ok := f.resources[path]; ok {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		meta["resourceVersion"] = "1"
+		meta["generation"] = 1
+		if strings.HasSuffix(r.URL.Path, "/pods") {
+			var pod Pod
+			json.Unmarshal(raw, &pod)
+			f.created[path] = &pod
+			obj["status"] = map[string]interface{}{"phase": "Running"}
+		}
+		f.store(path, obj)
+		w.WriteHeader(http.StatusCreated)
+	case "PUT":
+		existing, ok := f.resources[r.URL.Path]
+		if !ok {
+			notFound(w)
+			return
+		}
+		var prev, obj map[string]interface{}
+		json.Unmarshal(existing, &prev)
+		json.NewDecoder(r.Body).Decode(&obj)
+		meta := obj["metadata"].(map[string]interface{})
+		prevMeta := prev["metadata"].(map[string]interface{})
+		if meta["resourceVersion"] != prevMeta["resourceVersion"] {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		var version, generation int
+		fmt.Sscanf(fmt.Sprint(prevMeta["resourceVersion"]), "%d", &version)
+		fmt.Sscanf(fmt.Sprint(prevMeta["generation"]), "%d", &generation)
+		meta["resourceVersion"] = fmt.Sprintf("%d", version+1)
+		meta["generation"] = generation + 1
+		f.store(r.URL.Path, obj)
+	case "DELETE":
+		if _, ok := f.resources[r.URL.Path]; !ok {
+			notFound(w)
+			return
+		}
+		delete(f.resources, r.URL.Path)
+	}
+}
+
+// store stores the object, marking deployments as fully rolled out.
+func (f *fakeAPI) store(path string, obj map[string]interface{}) {
+	if strings.Contains(path, "/deployments/") {
+		spec := obj["spec"].(map[string]interface{})
+		replicas := spec["replicas"]
+		obj["status"] = map[string]interface{}{
+			"observedGeneration": obj["metadata"].(map[string]interface{})["generation"],
+			"replicas":           replicas,
+			"updatedReplicas":    replicas,
+			"availableReplicas":  replicas,
+		}
+	}
+	raw, _ := json.Marshal(obj)
+	f.resources[path] = raw
+}
+
+func (f *fakeAPI) list(w http.ResponseWriter, r *http.Request) {
+	var selector []string
+	if s := r.URL.Query().Get("labelSelector"); s != "" {
+		selector = strings.Split(s, ",")
+	}
+
+	var items []json.RawMessage
+	for path, raw := range f.resources {
+		if !strings.HasPrefix(path, r.URL.Path+"/") {
+			continue
+		}
+
+		var obj struct {
+			Metadata ObjectMeta `json:"metadata"`
+		}
+		json.Unmarshal(raw, &obj)
+
+		match := true
+		for _, s := range selector {
+			parts := strings.SplitN(s, "=", 2)
+			if obj.Metadata.Labels[parts[0]] != parts[1] {
+				match = false
+			}
+		}
+		if match {
+			items = append(items, raw)
+		}
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{"items": items})
+}
+
+// attach upgrades the connection to a websocket, writes "hello" to stdout,
+// then closes the connection and marks the container as terminated.
+func (f *fakeAPI) attach(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimSuffix(r.URL.Path, "/attach")
+	var obj map[string]interface{}
+	json.Unmarshal(f.resources[path], &obj)
+	obj["status"] = map[string]interface{}{
+		"phase": "Succeeded",
+		"containerStatuses": []map[string]interface{}{
+			{
+				"name": r.URL.Query().Get("container"),
+				"state": map[string]interface{}{
+					"terminated": map[string]interface{}{"exitCode": f.exitCode},
+				},
+			},
+		},
+	}
+	f.store(path, obj)
+
+	conn, buf, err := w.(http.Hijacker).Hijack()
+	if err != nil {
+		panic(err)
+	}
+	defer conn.Close()
+
+	fmt.Fprintf(buf, "HTTP/1.1 101 Switching Protocols\r\n")
+	fmt.Fprintf(buf, "Upgrade: websocket\r\nConnection: Upgrade\r\n")
+	fmt.Fprintf(buf, "Sec-WebSocket-Protocol: %s\r\n", channelProtocol)
+	fmt.Fprintf(buf, "Sec-WebSocket-Accept: %s\r\n\r\n", websocketAccept(r.Header.Get("Sec-WebSocket-Key")))
+
+	msg := append([]byte{stdoutChannel}, []byte("hello")...)
+	buf.Write(append([]byte{0x80 | opBinary, byte(len(msg))}, msg...))
+	buf.Write([]byte{0x80 | opClose, 0})
+	buf.Flush()
+}
+
+func (f *fakeAPI) put(path string, v interface{}) {
+	f.Lock()
+	defer f.Unlock()
+	raw, _ := json.Marshal(v)
+	f.resources[path] = raw
+}
+
+func (f *fakeAPI) get(t testing.TB, path string, v interface{}) {
+	f.Lock()
+	defer f.Unlock()
+	raw, ok := f.resources[path]
+	if !ok {
+		t.Fatalf("expected %s to exist", path)
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func (f *fakeAPI) notFound(t testing.TB, path string) {
+	f.Lock()
+	defer f.Unlock()
+	if _, ok := f.resources[path]; ok {
+		t.Errorf("expected %s to not exist", path)
+	}
+}
+
+func (f *fakeAPI) len() int {
+	f.Lock()
+	defer f.Unlock()
+	return len(f.resources)
+}
+
+func notFound(w http.ResponseWriter) {
+	w.WriteHeader(http.StatusNotFound)
+	json.NewEncoder(w).Encode(Status{Status: "Failure", Reason: "NotFound", Code: 404, Message: "not found"})
+}
+
+func TestNewService(t *testing.T) {
+	app := &scheduler.App{ID: "c9366591-ab68-4d49-a333-95ce5a23df68", Name: "acme-inc"}
+
+	svc := newService(app, &scheduler.Process{
+		Type: "grpc",
+		Exposure: &scheduler.Exposure{
+			External: true,
+			Type:     &scheduler.TCPExposure{Ports: []int{50051}},
+		},
+	})
+	assert.Equal(t, "LoadBalancer", svc.Spec.Type)
+	assert.Equal(t, []ServicePort{
+		{Name: "tcp-50051", Protocol: "TCP", Port: 50051, TargetPort: ExposedPort},
+	}, svc.Spec.Ports)
+	assert.Nil(t, svc.Metadata.Annotations)
+
+	svc = newService(app, &scheduler.Process{
+		Type: "admin",
+		Exposure: &scheduler.Exposure{
+			Type: &scheduler.SSLExposure{Ports: []int{443}, Cert: "arn:aws:acm:us-east-1:012345678901:certificate/1234"},
+		},
+	})
+	assert.Equal(t, "ClusterIP", svc.Spec.Type)
+	assert.Equal(t, []ServicePort{
+		{Name: "tcp-443", Protocol: "TCP", Port: 443, TargetPort: ExposedPort},
+	}, svc.Spec.Ports)
+	assert.Equal(t, "tcp", svc.Metadata.Annotations["service.beta.kubernetes.io/aws-load-balancer-backend-protocol"])
+}
@@ -3,6 +3,7 @@
 package scheduler
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"time"
@@ -26,11 +27,76 @@ type App struct {
 	// The application environment.
 	Env map[string]string
 
+	// Environment variables whose values are secrets. The values are
+	// references to the secrets in a secret store, which the scheduler
+	// resolves when instances are launched (see ResolveEnv), so that the
+	// values themselves are never stored by the scheduler.
+	Secrets map[string]string
+
 	// The application labels.
 	Labels map[string]string
 
 	// Process that belong to this app.
 	Processes []*Process
+
+	// Custom domains that should be routed to the web process of this
+	// app. Schedulers that don't do any routing can ignore these.
+	Domains []Domain
+
+	// Previous names of the app, which haven't expired yet. Schedulers
+	// that create DNS records named after the app should keep records
+	// for these names too, so that clients of the previous hostnames keep
+	// working after a rename.
+	Aliases []string
+
+	// If provided, this App is a canary, and Stable is the currently
+	// running release that the canary should run alongside. The instance
+	// counts for the processes in this App are the number of canary
+	// instances to run, and the instance counts in Stable are the number
+	// of stable instances to keep running.
+	//
+	// Schedulers that don't support running releases side by side should
+	// return ErrCanaryNotSupported.
+	Stable *App
+}
+
+// Domain represents a custom domain for an app.
+type Domain struct {
+	// The host that requests are routed from (e.g. api.example.com).
+	Hostname string
+
+	// If provided, only requests with a path starting with Path (e.g.
+	// /v2) are routed to the app.
+	Path string
+}
+
+// ErrCanaryNotSupported is returned by schedulers that don't support running a
+// canary release alongside a stable release.
+var ErrCanaryNotSupported = errors.New("canary deployments are not supported by this scheduler")
+
+// ErrSecretsNotSupported is returned when an app has secrets, but the scheduler
+// has no way to resolve them when instances are launched.
+var ErrSecretsNotSupported = errors.New("secrets are not supported by this scheduler")
+
+// UnstableError is returned by Submit when the processes for the new release
+// failed to become stable (e.g. because they're crash looping), or didn't become
+// stable in time.
+type UnstableError struct {
+	Err error
+}
+
+func (e *UnstableError) Error() string {
+	return fmt.Sprintf("release failed to stabilize: %v", e.Err)
+}
+
+// ExitError is returned by Run when an attached process exits with a non-zero
+// status code.
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("process exited with status %d", e.Code)
 }
 
 type Process struct {
@@ -67,6 +133,56 @@ type Process struct {
 
 	// Can be used to setup a CRON schedule to run this task periodically.
 	Schedule Schedule
+
+	// If provided, the scheduler should use this to check the health of
+	// instances of this process, and stop routing traffic to, or replace,
+	// unhealthy instances.
+	HealthCheck *HealthCheck
+
+	// If provided, the scheduler should scale the number of instances of
+	// this process automatically, within these bounds. Instances is used
+	// as the initial number of instances.
+	Autoscaling *Autoscaling
+}
+
+// Autoscaling configures how the number of instances of a process is scaled
+// automatically, to keep the average utilization near a target.
+type Autoscaling struct {
+	// The minimum and maximum number of instances.
+	MinInstances uint
+	MaxInstances uint
+
+	// The target average CPU and memory utilization, as a percentage. Zero
+	// means that the metric isn't used for scaling.
+	TargetCPUUtilization    int
+	TargetMemoryUtilization int
+
+	// The amount of time to wait after scaling in, or out, before scaling
+	// in the same direction again.
+	ScaleInCooldown  time.Duration
+	ScaleOutCooldown time.Duration
+}
+
+// HealthCheck configures how the health of the instances of a process is
+// checked.
+type HealthCheck struct {
+	// The HTTP path to request. If empty, a TCP connection check should be
+	// performed.
+	Path string
+
+	// The amount of time between checks.
+	Interval time.Duration
+
+	// The amount of time to wait for a response.
+	Timeout time.Duration
+
+	// The number of consecutive successful checks before an instance is
+	// considered healthy.
+	HealthyThreshold int
+
+	// The number of consecutive failed checks before an instance is
+	// considered unhealthy.
+	UnhealthyThreshold int
 }
 
 // Schedule represents a Schedule for scheduled tasks that run periodically.
@@ -106,6 +222,27 @@ type HTTPSExposure struct {
 
 func (e *HTTPSExposure) Protocol() string { return "https" }
 
+// TCPExposure represents a raw TCP exposure, where TCP connections on each of
+// the ports are forwarded to the process.
+type TCPExposure struct {
+	// The ports that the load balancer should listen on.
+	Ports []int
+}
+
+func (e *TCPExposure) Protocol() string { return "tcp" }
+
+// SSLExposure represents a TCP exposure, where SSL is terminated at the load
+// balancer.
+type SSLExposure struct {
+	// The ports that the load balancer should listen on.
+	Ports []int
+
+	// The certificate to attach to the process.
+	Cert string
+}
+
+func (e *SSLExposure) Protocol() string { return "ssl" }
+
 // Instance represents an Instance of a Process.
 type Instance struct {
 	Process *Process
@@ -121,7 +258,9 @@ type Instance struct {
 }
 
 type Runner interface {
-	// Run runs a process.
+	// Run runs a process. When the process is attached, Run blocks until
+	// it exits, and returns an ExitError if it exited with a non-zero
+	// status code.
 	Run(ctx context.Context, app *App, process *Process, in io.Reader, out io.Writer) error
 }
 
@@ -147,12 +286,237 @@ type Scheduler interface {
 	Stop(ctx context.Context, instanceID string) error
 }
 
+// Planner is an optional interface that Schedulers can implement to preview
+// the changes that Submit would make to the resources for an app, without
+// making them.
+type Planner interface {
+	// Plan returns the changes that submitting the App would make.
+	Plan(context.Context, *App) (*Plan, error)
+}
+
+// ErrPlanNotSupported is returned by schedulers that can't preview changes.
+var ErrPlanNotSupported = errors.New("previewing changes is not supported by this scheduler")
+
+// PlanSubmit returns the changes that submitting the App to the Scheduler
+// would make. If the Scheduler doesn't implement the Planner interface,
+// ErrPlanNotSupported is returned.
+func PlanSubmit(ctx context.Context, s Scheduler, app *App) (*Plan, error) {
+	p, ok := s.(Planner)
+	if !ok {
+		return nil, ErrPlanNotSupported
+	}
+	return p.Plan(ctx, app)
+}
+
+// Restarter is an optional interface that Schedulers can implement when
+// Submit doesn't restart processes that haven't changed.
+type Restarter interface {
+	// Restart submits the App, and restarts all of its processes.
+	Restart(context.Context, *App, StatusStream) error
+}
+
+// Restart submits the App to the Scheduler, restarting all of its processes.
+// If the Scheduler doesn't implement the Restarter interface, the App is
+// submitted with Submit.
+func Restart(ctx context.Context, s Scheduler, app *App, ss StatusStream) error {
+	r, ok := s.(Restarter)
+	if !ok {
+		return s.Submit(ctx, app, ss)
+	}
+	return r.Restart(ctx, app, ss)
+}
+
+// DesiredCounter is an optional interface that Schedulers can implement to
+// report the number of instances of each process that they're configured to
+// run, which can differ from the number of instances that are running, and
+// from what was last submitted (e.g. if someone changed it by hand).
+type DesiredCounter interface {
+	// DesiredCounts returns the desired number of instances of each
+	// process, keyed by process type.
+	DesiredCounts(ctx context.Context, app string) (map[string]uint, error)
+}
+
+// DesiredCounts returns the desired number of instances of each process for the
+// app, from the Scheduler. If the Scheduler doesn't implement the
+// DesiredCounter interface, nil is returned.
+func DesiredCounts(ctx context.Context, s Scheduler, app string) (map[string]uint, error) {
+	c, ok := s.(DesiredCounter)
+	if !ok {
+		return nil, nil
+	}
+	return c.DesiredCounts(ctx, app)
+}
+
+// CapacityReporter is an optional interface that Schedulers can implement to
+// report the resources that are left on the hosts in the cluster.
+type CapacityReporter interface {
+	// Hosts returns the hosts that processes can be placed on.
+	Hosts(ctx context.Context) ([]*Host, error)
+}
+
+// Hosts returns the hosts that the Scheduler can place processes on. If the
+// Scheduler doesn't implement the CapacityReporter interface, nil is returned.
+func Hosts(ctx context.Context, s Scheduler) ([]*Host, error) {
+	c, ok := s.(CapacityReporter)
+	if !ok {
+		return nil, nil
+	}
+	return c.Hosts(ctx)
+}
+
+// Host represents a host in the cluster, and the resources on it that haven't
+// been reserved yet.
+type Host struct {
+	// The identifier of the host (e.g. a container instance ARN).
+	ID string
+
+	// The amount of memory, in bytes, that's left.
+	MemoryLimit uint
+
+	// The number of CPU shares that are left.
+	CPUShares uint
+}
+
+// Actions that can be performed on a resource.
+const (
+	ActionAdd     = "Add"
+	ActionModify  = "Modify"
+	ActionReplace = "Replace"
+	ActionRemove  = "Remove"
+)
+
+// Plan is a preview of the changes that Submit would make.
+type Plan struct {
+	// The resources that would be changed. If empty, submitting the App
+	// won't change anything.
+	Changes []*ResourceChange
+}
+
+// ResourceChange represents a change to a single resource.
+type ResourceChange struct {
+	// The action that would be performed on the resource (e.g.
+	// ActionAdd, ActionReplace).
+	Action string
+
+	// The name of the resource within the app (e.g. webLoadBalancer).
+	Name string
+
+	// The type of resource (e.g. AWS::ElasticLoadBalancing::LoadBalancer).
+	Type string
+
+	// The identifier of the existing resource, if there is one.
+	ID string
+
+	// When true, the resource may or may not be replaced, depending on
+	// values that can't be determined until the change is made.
+	Conditional bool
+}
+
+// String implements the fmt.Stringer interface.
+func (c *ResourceChange) String() string {
+	action := c.Action
+	if c.Conditional {
+		action += " (conditional)"
+	}
+	return fmt.Sprintf("%s %s (%s)", action, c.Name, c.Type)
+}
+
+// GarbageCollector is an optional interface that Schedulers can implement to
+// find, and remove, resources that they created for apps that no longer exist.
+type GarbageCollector interface {
+	// Orphans returns the resources that were created for apps other than
+	// the given app ids.
+	Orphans(ctx context.Context, apps []string) ([]*Orphan, error)
+
+	// RemoveOrphan removes a resource that was returned from Orphans.
+	RemoveOrphan(ctx context.Context, orphan *Orphan) error
+}
+
+// Orphans returns the resources that the Scheduler created for apps other than
+// the given app ids. If the Scheduler doesn't implement the GarbageCollector
+// interface, nil is returned.
+func Orphans(ctx context.Context, s Scheduler, apps []string) ([]*Orphan, error) {
+	gc, ok := s.(GarbageCollector)
+	if !ok {
+		return nil, nil
+	}
+	return gc.Orphans(ctx, apps)
+}
+
+// RemoveOrphan removes a resource that was returned from Orphans.
+func RemoveOrphan(ctx context.Context, s Scheduler, orphan *Orphan) error {
+	gc, ok := s.(GarbageCollector)
+	if !ok {
+		return fmt.Errorf("scheduler can't remove %s", orphan)
+	}
+	return gc.RemoveOrphan(ctx, orphan)
+}
+
+// Orphan represents a resource that a Scheduler created, which no longer
+// belongs to an app.
+type Orphan struct {
+	// The type of resource (e.g. AWS::CloudFormation::Stack).
+	Type string
+
+	// The identifier of the resource (e.g. the name of the stack).
+	ID string
+
+	// The id of the app that the resource was created for, if known.
+	App string
+}
+
+// String implements the fmt.Stringer interface.
+func (o *Orphan) String() string {
+	return fmt.Sprintf("%s %s", o.Type, o.ID)
+}
+
 // Env merges the App environment with any environment variables provided
 // in the process.
 func Env(app *App, process *Process) map[string]string {
 	return merge(app.Env, process.Env)
 }
 
+// Secrets returns the secrets of the App, without any that are overridden by
+// environment variables provided in the process.
+func Secrets(app *App, process *Process) map[string]string {
+	secrets := make(map[string]string)
+	for k, ref := range app.Secrets {
+		if _, ok := process.Env[k]; ok {
+			continue
+		}
+		secrets[k] = ref
+	}
+	return secrets
+}
+
+// SecretResolver resolves references to secrets into their values.
+type SecretResolver interface {
+	Get(ctx context.Context, ref string) (string, error)
+}
+
+// ErrNoSecretResolver is returned when an app has secrets, but the scheduler
+// wasn't given a SecretResolver to resolve them with.
+var ErrNoSecretResolver = errors.New("no secrets backend is configured to resolve secrets")
+
+// ResolveEnv returns the environment for the process, including the values of
+// the app's secrets, which are resolved with r. Schedulers that launch
+// instances themselves should call this right before launching them.
+func ResolveEnv(ctx context.Context, r SecretResolver, app *App, process *Process) (map[string]string, error) {
+	env := Env(app, process)
+	for k, ref := range Secrets(app, process) {
+		if r == nil {
+			return nil, fmt.Errorf("unable to resolve secret %s: %v", k, ErrNoSecretResolver)
+		}
+
+		v, err := r.Get(ctx, ref)
+		if err != nil {
+			return nil, fmt.Errorf("unable to resolve secret %s: %v", k, err)
+		}
+		env[k] = v
+	}
+	return env, nil
+}
+
 // Labels merges the App labels with any labels provided in the process.
 func Labels(app *App, process *Process) map[string]string {
 	return merge(app.Labels, process.Labels)
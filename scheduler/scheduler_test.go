@@ -0,0 +1,56 @@
+package scheduler
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestResolveEnv(t *testing.T) {
+	r := secretResolverFunc(func(ref string) (string, error) {
+		if ref == "fake:acme-inc/DATABASE_URL" {
+			return "postgres://localhost", nil
+		}
+		return "", errors.New("not found")
+	})
+
+	app := &App{
+		Env: map[string]string{
+			"RAILS_ENV": "production",
+		},
+		Secrets: map[string]string{
+			"DATABASE_URL": "fake:acme-inc/DATABASE_URL",
+		},
+	}
+	p := &Process{Type: "web", Env: map[string]string{"PORT": "8080"}}
+
+	env, err := ResolveEnv(context.Background(), r, app, p)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"RAILS_ENV":    "production",
+		"DATABASE_URL": "postgres://localhost",
+		"PORT":         "8080",
+	}, env)
+
+	// The process environment takes precedence over secrets.
+	p.Env["DATABASE_URL"] = "postgres://example.com"
+	env, err = ResolveEnv(context.Background(), r, app, p)
+	assert.NoError(t, err)
+	assert.Equal(t, "postgres://example.com", env["DATABASE_URL"])
+	delete(p.Env, "DATABASE_URL")
+
+	app.Secrets["REDIS_URL"] = "fake:acme-inc/REDIS_URL"
+	_, err = ResolveEnv(context.Background(), r, app, p)
+	assert.EqualError(t, err, "unable to resolve secret REDIS_URL: not found")
+
+	_, err = ResolveEnv(context.Background(), nil, app, p)
+	assert.Error(t, err)
+}
+
+type secretResolverFunc func(string) (string, error)
+
+func (fn secretResolverFunc) Get(ctx context.Context, ref string) (string, error) {
+	return fn(ref)
+}
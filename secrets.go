@@ -0,0 +1,84 @@
+package empire
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/context"
+)
+
+// MaskedSecret is shown in place of the value of a secret config var.
+const MaskedSecret = "[secret]"
+
+// secretPrefix is prepended to the reference of a secret when it's stored as
+// the value of a config var, to distinguish it from a plain value.
+const secretPrefix = "empire-secret:"
+
+// ErrNoSecretStore is returned when a secret config var is set, or needs to be
+// resolved, and no SecretStore is configured.
+var ErrNoSecretStore = errors.New("no secrets backend is configured")
+
+// SecretStore is a backend for storing the values of secret config vars. Only a
+// reference to the value is stored in the config. References are passed along
+// to the scheduler, which resolves them when tasks are launched (see
+// scheduler.App.Secrets), so a SecretStore is also a scheduler.SecretResolver.
+type SecretStore interface {
+	// Put stores the value of a secret, and returns a reference to it.
+	// key identifies the secret (e.g. acme-inc/DATABASE_URL).
+	Put(ctx context.Context, key, value string) (ref string, err error)
+
+	// Get returns the value of the secret that ref refers to.
+	Get(ctx context.Context, ref string) (string, error)
+}
+
+// isSecret returns true if the value of a config var is a reference to a
+// secret.
+func isSecret(v string) bool {
+	return strings.HasPrefix(v, secretPrefix)
+}
+
+// Masked returns a copy of the Vars with the values of secrets masked.
+func (v Vars) Masked() Vars {
+	masked := make(Vars)
+	for k, val := range v {
+		if val != nil && isSecret(*val) {
+			s := MaskedSecret
+			val = &s
+		}
+		masked[k] = val
+	}
+	return masked
+}
+
+// putSecrets stores the values of vars in the SecretStore, and returns a copy
+// of vars with references to the stored values.
+func putSecrets(ctx context.Context, store SecretStore, app *App, vars Vars) (Vars, error) {
+	if store == nil {
+		return nil, ErrNoSecretStore
+	}
+
+	secrets := make(Vars)
+	for k, v := range vars {
+		// Unsetting a secret is the same as unsetting any other var.
+		if v == nil {
+			secrets[k] = nil
+			continue
+		}
+
+		ref, err := store.Put(ctx, fmt.Sprintf("%s/%s", app.Name, k), *v)
+		if err != nil {
+			return nil, fmt.Errorf("error storing secret %s: %v", k, err)
+		}
+		ref = secretPrefix + ref
+		secrets[k] = &ref
+	}
+
+	return secrets, nil
+}
+
+// secretRef returns the reference to the secret in the SecretStore, from the
+// value of a secret config var.
+func secretRef(v string) string {
+	return strings.TrimPrefix(v, secretPrefix)
+}
@@ -0,0 +1,91 @@
+// Package local provides an empire.SecretStore implementation that encrypts
+// secrets with a local key, and stores the encrypted value as the reference, so
+// that the secret is encrypted at rest in the configs table.
+package local
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/net/context"
+)
+
+// The prefix for references to secrets in this store.
+const refPrefix = "local:"
+
+// KeySize is the required size of the key, in bytes (AES-256).
+const KeySize = 32
+
+// SecretStore is an empire.SecretStore that encrypts secrets using AES-GCM.
+type SecretStore struct {
+	aead cipher.AEAD
+
+	// Used to generate nonces.
+	rand io.Reader
+}
+
+// NewSecretStore returns a new SecretStore that encrypts secrets with the given
+// key, which should be KeySize random bytes.
+func NewSecretStore(key []byte) (*SecretStore, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("key must be %d bytes, got %d", KeySize, len(key))
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SecretStore{aead: aead, rand: rand.Reader}, nil
+}
+
+// Put encrypts the value, and returns the ciphertext as the reference. The key
+// is used as additional data, so a reference can't be used for a different
+// key.
+func (s *SecretStore) Put(ctx context.Context, key, value string) (string, error) {
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := io.ReadFull(s.rand, nonce); err != nil {
+		return "", err
+	}
+
+	ciphertext := s.aead.Seal(nonce, nonce, []byte(value), []byte(key))
+	return fmt.Sprintf("%s%s:%s", refPrefix, key, base64.RawURLEncoding.EncodeToString(ciphertext)), nil
+}
+
+// Get decrypts the value of the reference.
+func (s *SecretStore) Get(ctx context.Context, ref string) (string, error) {
+	if !strings.HasPrefix(ref, refPrefix) {
+		return "", fmt.Errorf("not a local secret: %s", ref)
+	}
+
+	i := strings.LastIndex(ref, ":")
+	key, encoded := ref[len(refPrefix):i], ref[i+1:]
+
+	ciphertext, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", err
+	}
+
+	n := s.aead.NonceSize()
+	if len(ciphertext) < n {
+		return "", errors.New("ciphertext is too short")
+	}
+
+	value, err := s.aead.Open(nil, ciphertext[:n], ciphertext[n:], []byte(key))
+	if err != nil {
+		return "", err
+	}
+
+	return string(value), nil
+}
@@ -0,0 +1,44 @@
+package local
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+var ctx = context.Background()
+
+func TestSecretStore(t *testing.T) {
+	s, err := NewSecretStore(bytes.Repeat([]byte("a"), KeySize))
+	assert.NoError(t, err)
+
+	ref, err := s.Put(ctx, "acme-inc/DATABASE_URL", "postgres://localhost")
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(ref, "local:acme-inc/DATABASE_URL:"))
+	assert.NotContains(t, ref, "postgres")
+
+	value, err := s.Get(ctx, ref)
+	assert.NoError(t, err)
+	assert.Equal(t, "postgres://localhost", value)
+
+	// The reference is bound to the key.
+	_, err = s.Get(ctx, strings.Replace(ref, "acme-inc", "other-app", 1))
+	assert.Error(t, err)
+
+	// A different key can't decrypt the secret.
+	other, err := NewSecretStore(bytes.Repeat([]byte("b"), KeySize))
+	assert.NoError(t, err)
+	_, err = other.Get(ctx, ref)
+	assert.Error(t, err)
+
+	_, err = s.Get(ctx, "vault:secret/empire/acme-inc/DATABASE_URL")
+	assert.Error(t, err)
+}
+
+func TestNewSecretStore_InvalidKey(t *testing.T) {
+	_, err := NewSecretStore([]byte("short"))
+	assert.EqualError(t, err, "key must be 32 bytes, got 5")
+}
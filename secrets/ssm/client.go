@@ -0,0 +1,107 @@
+package ssm
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/client/metadata"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/signer/v4"
+	"github.com/aws/aws-sdk-go/private/protocol/jsonrpc"
+)
+
+// The vendored aws-sdk-go predates SSM Parameter Store, so this file contains
+// a minimal client for the two operations that the SecretStore uses.
+
+// ServiceName is the name of the service the client will make API calls to.
+const ServiceName = "ssm"
+
+type PutParameterInput struct {
+	_ struct{} `type:"structure"`
+
+	KeyId     *string `type:"string"`
+	Name      *string `type:"string" required:"true"`
+	Overwrite *bool   `type:"boolean"`
+	Type      *string `type:"string" required:"true"`
+	Value     *string `type:"string" required:"true"`
+}
+
+type PutParameterOutput struct {
+	_ struct{} `type:"structure"`
+
+	Version *int64 `type:"long"`
+}
+
+type GetParameterInput struct {
+	_ struct{} `type:"structure"`
+
+	Name           *string `type:"string" required:"true"`
+	WithDecryption *bool   `type:"boolean"`
+}
+
+type GetParameterOutput struct {
+	_ struct{} `type:"structure"`
+
+	Parameter *Parameter `type:"structure"`
+}
+
+type Parameter struct {
+	_ struct{} `type:"structure"`
+
+	Name    *string `type:"string"`
+	Type    *string `type:"string"`
+	Value   *string `type:"string"`
+	Version *int64  `type:"long"`
+}
+
+// ssm is a client for the AWS SSM API.
+type ssm struct {
+	*client.Client
+}
+
+// newClient returns a new SSM client from the given config.
+func newClient(p client.ConfigProvider, cfgs ...*aws.Config) *ssm {
+	c := p.ClientConfig(ServiceName, cfgs...)
+
+	svc := &ssm{
+		Client: client.New(
+			*c.Config,
+			metadata.ClientInfo{
+				ServiceName:   ServiceName,
+				SigningRegion: c.SigningRegion,
+				Endpoint:      c.Endpoint,
+				APIVersion:    "2014-11-06",
+				JSONVersion:   "1.1",
+				TargetPrefix:  "AmazonSSM",
+			},
+			c.Handlers,
+		),
+	}
+
+	svc.Handlers.Sign.PushBackNamed(v4.SignRequestHandler)
+	svc.Handlers.Build.PushBackNamed(jsonrpc.BuildHandler)
+	svc.Handlers.Unmarshal.PushBackNamed(jsonrpc.UnmarshalHandler)
+	svc.Handlers.UnmarshalMeta.PushBackNamed(jsonrpc.UnmarshalMetaHandler)
+	svc.Handlers.UnmarshalError.PushBackNamed(jsonrpc.UnmarshalErrorHandler)
+
+	return svc
+}
+
+func (c *ssm) PutParameter(input *PutParameterInput) (*PutParameterOutput, error) {
+	output := &PutParameterOutput{}
+	req := c.NewRequest(&request.Operation{
+		Name:       "PutParameter",
+		HTTPMethod: "POST",
+		HTTPPath:   "/",
+	}, input, output)
+	return output, req.Send()
+}
+
+func (c *ssm) GetParameter(input *GetParameterInput) (*GetParameterOutput, error) {
+	output := &GetParameterOutput{}
+	req := c.NewRequest(&request.Operation{
+		Name:       "GetParameter",
+		HTTPMethod: "POST",
+		HTTPPath:   "/",
+	}, input, output)
+	return output, req.Send()
+}
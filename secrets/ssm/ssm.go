@@ -0,0 +1,121 @@
+// Package ssm provides an empire.SecretStore implementation that stores
+// secrets as SecureString parameters in AWS SSM Parameter Store.
+//
+// Unlike other secret stores, references to secrets in this store can be
+// resolved by ECS itself when tasks are launched, so the values never pass
+// through Empire or CloudFormation.
+package ssm
+
+import (
+	"fmt"
+	"strings"
+
+	"code.google.com/p/go-uuid/uuid"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"golang.org/x/net/context"
+)
+
+// The prefix for references to secrets in this store.
+const refPrefix = "ssm:"
+
+// DefaultPath is the default path that parameters are created under.
+const DefaultPath = "/empire"
+
+// ssmClient duck types the SSM API methods that we use.
+type ssmClient interface {
+	PutParameter(*PutParameterInput) (*PutParameterOutput, error)
+	GetParameter(*GetParameterInput) (*GetParameterOutput, error)
+}
+
+// SecretStore is an empire.SecretStore that stores secrets in SSM Parameter
+// Store. Every value is written to a new parameter, so references from old
+// releases continue to resolve to the value at the time.
+type SecretStore struct {
+	// The path that parameters are created under. The default is
+	// DefaultPath.
+	Path string
+
+	// The ID of the KMS key used to encrypt parameters. The default is the
+	// account's default key for SSM.
+	KeyID string
+
+	ssm ssmClient
+
+	// Generates the id for a new version of a secret.
+	newID func() string
+}
+
+// NewSecretStore returns a new SecretStore.
+func NewSecretStore(config client.ConfigProvider) *SecretStore {
+	return &SecretStore{
+		Path: DefaultPath,
+		ssm:  newClient(config),
+	}
+}
+
+// Put creates a SecureString parameter named <Path>/<key>/<id> and returns the
+// name of the parameter as the reference.
+func (s *SecretStore) Put(ctx context.Context, key, value string) (string, error) {
+	name := fmt.Sprintf("%s/%s/%s", s.path(), key, s.id())
+
+	input := &PutParameterInput{
+		Name:  aws.String(name),
+		Type:  aws.String("SecureString"),
+		Value: aws.String(value),
+	}
+	if s.KeyID != "" {
+		input.KeyId = aws.String(s.KeyID)
+	}
+
+	if _, err := s.ssm.PutParameter(input); err != nil {
+		return "", err
+	}
+
+	return refPrefix + name, nil
+}
+
+// Get reads and decrypts the value of the parameter in the reference.
+func (s *SecretStore) Get(ctx context.Context, ref string) (string, error) {
+	name, ok := ParameterName(ref)
+	if !ok {
+		return "", fmt.Errorf("not an ssm secret: %s", ref)
+	}
+
+	resp, err := s.ssm.GetParameter(&GetParameterInput{
+		Name:           aws.String(name),
+		WithDecryption: aws.Bool(true),
+	})
+	if err != nil {
+		return "", err
+	}
+	if resp.Parameter == nil || resp.Parameter.Value == nil {
+		return "", fmt.Errorf("no value found at %s", ref)
+	}
+
+	return *resp.Parameter.Value, nil
+}
+
+// ParameterName returns the name of the SSM parameter that a reference to a
+// secret refers to. The boolean is false if the reference isn't to a secret in
+// this store.
+func ParameterName(ref string) (string, bool) {
+	if !strings.HasPrefix(ref, refPrefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(ref, refPrefix), true
+}
+
+func (s *SecretStore) path() string {
+	if s.Path == "" {
+		return DefaultPath
+	}
+	return "/" + strings.Trim(s.Path, "/")
+}
+
+func (s *SecretStore) id() string {
+	if s.newID == nil {
+		return uuid.New()
+	}
+	return s.newID()
+}
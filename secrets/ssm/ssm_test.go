@@ -0,0 +1,76 @@
+package ssm
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+var ctx = context.Background()
+
+func TestSecretStore(t *testing.T) {
+	parameters := make(map[string]string)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var input map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&input)
+
+		switch r.Header.Get("X-Amz-Target") {
+		case "AmazonSSM.PutParameter":
+			assert.Equal(t, "SecureString", input["Type"])
+			parameters[input["Name"].(string)] = input["Value"].(string)
+			w.Write([]byte(`{"Version":1}`))
+		case "AmazonSSM.GetParameter":
+			assert.Equal(t, true, input["WithDecryption"])
+			value, ok := parameters[input["Name"].(string)]
+			if !ok {
+				w.WriteHeader(400)
+				w.Write([]byte(`{"__type":"ParameterNotFound","message":"not found"}`))
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"Parameter": map[string]string{"Name": input["Name"].(string), "Value": value},
+			})
+		default:
+			w.WriteHeader(400)
+		}
+	}))
+	defer server.Close()
+
+	s := NewSecretStore(session.New(&aws.Config{
+		Credentials: credentials.NewStaticCredentials(" ", " ", " "),
+		Endpoint:    aws.String(server.URL),
+		Region:      aws.String("localhost"),
+	}))
+	s.newID = func() string { return "1" }
+
+	ref, err := s.Put(ctx, "acme-inc/DATABASE_URL", "postgres://localhost")
+	assert.NoError(t, err)
+	assert.Equal(t, "ssm:/empire/acme-inc/DATABASE_URL/1", ref)
+	assert.Equal(t, "postgres://localhost", parameters["/empire/acme-inc/DATABASE_URL/1"])
+
+	value, err := s.Get(ctx, ref)
+	assert.NoError(t, err)
+	assert.Equal(t, "postgres://localhost", value)
+
+	_, err = s.Get(ctx, "ssm:/empire/acme-inc/REDIS_URL/1")
+	assert.Error(t, err)
+
+	_, err = s.Get(ctx, "vault:secret/empire/acme-inc/DATABASE_URL/1")
+	assert.EqualError(t, err, "not an ssm secret: vault:secret/empire/acme-inc/DATABASE_URL/1")
+}
+
+func TestParameterName(t *testing.T) {
+	name, ok := ParameterName("ssm:/empire/acme-inc/DATABASE_URL/1")
+	assert.True(t, ok)
+	assert.Equal(t, "/empire/acme-inc/DATABASE_URL/1", name)
+
+	_, ok = ParameterName("vault:secret/empire/acme-inc/DATABASE_URL/1")
+	assert.False(t, ok)
+}
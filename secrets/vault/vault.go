@@ -0,0 +1,139 @@
+// Package vault provides an empire.SecretStore implementation that stores
+// secrets in a HashiCorp Vault key/value secrets engine.
+package vault
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"code.google.com/p/go-uuid/uuid"
+	"golang.org/x/net/context"
+)
+
+// The prefix for references to secrets in this store.
+const refPrefix = "vault:"
+
+// DefaultPath is the default path that secrets are written under.
+const DefaultPath = "secret/empire"
+
+// SecretStore is an empire.SecretStore that stores secrets in Vault. Every
+// value is written to a new path, so references from old releases continue to
+// resolve to the value at the time.
+type SecretStore struct {
+	// The address of the Vault server (e.g. https://vault.example.com:8200).
+	URL string
+
+	// The token used to authenticate with Vault.
+	Token string
+
+	// The path that secrets are written under. The default is DefaultPath.
+	Path string
+
+	// The http.Client to use to make requests. The default is
+	// http.DefaultClient.
+	Client *http.Client
+
+	// Generates the id for a new version of a secret.
+	newID func() string
+}
+
+// NewSecretStore returns a new SecretStore.
+func NewSecretStore(url, token string) *SecretStore {
+	return &SecretStore{
+		URL:   url,
+		Token: token,
+		Path:  DefaultPath,
+	}
+}
+
+// Put writes the value to <Path>/<key>/<id> and returns the path as the
+// reference.
+func (s *SecretStore) Put(ctx context.Context, key, value string) (string, error) {
+	path := fmt.Sprintf("%s/%s/%s", s.path(), key, s.id())
+
+	raw, err := json.Marshal(map[string]string{"value": value})
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := s.do(ctx, "PUT", path, bytes.NewReader(raw))
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+
+	return refPrefix + path, nil
+}
+
+// Get reads the value from the path in the reference.
+func (s *SecretStore) Get(ctx context.Context, ref string) (string, error) {
+	if !strings.HasPrefix(ref, refPrefix) {
+		return "", fmt.Errorf("not a vault secret: %s", ref)
+	}
+
+	resp, err := s.do(ctx, "GET", strings.TrimPrefix(ref, refPrefix), nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var secret struct {
+		Data struct {
+			Value *string `json:"value"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&secret); err != nil {
+		return "", err
+	}
+	if secret.Data.Value == nil {
+		return "", fmt.Errorf("no value found at %s", ref)
+	}
+
+	return *secret.Data.Value, nil
+}
+
+func (s *SecretStore) do(ctx context.Context, method, path string, body io.Reader) (*http.Response, error) {
+	url := fmt.Sprintf("%s/v1/%s", strings.TrimSuffix(s.URL, "/"), path)
+
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", s.Token)
+	req.Header.Set("Content-Type", "application/json")
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode/100 != 2 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("unexpected response from vault for %s %s: %s", method, path, resp.Status)
+	}
+
+	return resp, nil
+}
+
+func (s *SecretStore) path() string {
+	if s.Path == "" {
+		return DefaultPath
+	}
+	return strings.Trim(s.Path, "/")
+}
+
+func (s *SecretStore) id() string {
+	if s.newID == nil {
+		return uuid.New()
+	}
+	return s.newID()
+}
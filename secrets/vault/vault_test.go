@@ -0,0 +1,63 @@
+package vault
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+var ctx = context.Background()
+
+func TestSecretStore(t *testing.T) {
+	secrets := make(map[string]string)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "token" {
+			w.WriteHeader(403)
+			return
+		}
+
+		switch r.Method {
+		case "PUT":
+			var data map[string]string
+			json.NewDecoder(r.Body).Decode(&data)
+			secrets[r.URL.Path] = data["value"]
+			w.WriteHeader(204)
+		case "GET":
+			value, ok := secrets[r.URL.Path]
+			if !ok {
+				w.WriteHeader(404)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"data": map[string]string{"value": value},
+			})
+		}
+	}))
+	defer server.Close()
+
+	s := NewSecretStore(server.URL, "token")
+	s.newID = func() string { return "1234" }
+
+	ref, err := s.Put(ctx, "acme-inc/DATABASE_URL", "postgres://localhost")
+	assert.NoError(t, err)
+	assert.Equal(t, "vault:secret/empire/acme-inc/DATABASE_URL/1234", ref)
+	assert.Equal(t, map[string]string{"/v1/secret/empire/acme-inc/DATABASE_URL/1234": "postgres://localhost"}, secrets)
+
+	value, err := s.Get(ctx, ref)
+	assert.NoError(t, err)
+	assert.Equal(t, "postgres://localhost", value)
+
+	_, err = s.Get(ctx, "vault:secret/empire/acme-inc/DATABASE_URL/5678")
+	assert.Error(t, err)
+
+	_, err = s.Get(ctx, "local:acme-inc/DATABASE_URL:abcd")
+	assert.Error(t, err)
+
+	s.Token = "bad"
+	_, err = s.Put(ctx, "acme-inc/DATABASE_URL", "postgres://localhost")
+	assert.Error(t, err)
+}
@@ -0,0 +1,109 @@
+package empire
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestVars_Masked(t *testing.T) {
+	plain, secret := "production", secretPrefix+"fake:acme-inc/DATABASE_URL"
+
+	vars := Vars{
+		"RAILS_ENV":    &plain,
+		"DATABASE_URL": &secret,
+	}
+
+	masked := vars.Masked()
+	assert.Equal(t, "production", *masked["RAILS_ENV"])
+	assert.Equal(t, MaskedSecret, *masked["DATABASE_URL"])
+
+	// The original vars are left alone.
+	assert.Equal(t, secret, *vars["DATABASE_URL"])
+}
+
+func TestPutSecrets(t *testing.T) {
+	store := newFakeSecretStore()
+	app := &App{Name: "acme-inc"}
+	value := "postgres://localhost"
+
+	secrets, err := putSecrets(context.Background(), store, app, Vars{
+		"DATABASE_URL": &value,
+		"REDIS_URL":    nil,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, secretPrefix+"fake:acme-inc/DATABASE_URL", *secrets["DATABASE_URL"])
+	assert.Nil(t, secrets["REDIS_URL"])
+	assert.Equal(t, "postgres://localhost", store.secrets["fake:acme-inc/DATABASE_URL"])
+
+	_, err = putSecrets(context.Background(), nil, app, Vars{"DATABASE_URL": &value})
+	assert.Equal(t, ErrNoSecretStore, err)
+}
+
+func TestNewSchedulerApp_Secrets(t *testing.T) {
+	plain, secret := "production", secretPrefix+"fake:acme-inc/DATABASE_URL"
+
+	a := newSchedulerApp(&Release{
+		App: &App{ID: "1234", Name: "acme-inc"},
+		Config: &Config{Vars: Vars{
+			"RAILS_ENV":    &plain,
+			"DATABASE_URL": &secret,
+		}},
+		Slug: &Slug{},
+	})
+
+	// Only the reference is passed to the scheduler, which resolves it
+	// when instances are launched.
+	assert.Equal(t, "production", a.Env["RAILS_ENV"])
+	_, ok := a.Env["DATABASE_URL"]
+	assert.False(t, ok)
+	assert.Equal(t, map[string]string{
+		"DATABASE_URL": "fake:acme-inc/DATABASE_URL",
+	}, a.Secrets)
+}
+
+func TestSetOpts_Validate_Secret(t *testing.T) {
+	e := &Empire{}
+	app := &App{Name: "acme-inc"}
+	ref := secretPrefix + "fake:acme-inc/DATABASE_URL"
+
+	err := SetOpts{App: app, Secret: true}.Validate(e)
+	assert.IsType(t, &ValidationError{}, err)
+
+	// References can't be set directly.
+	e.Secrets = newFakeSecretStore()
+	err = SetOpts{App: app, Vars: Vars{"DATABASE_URL": &ref}}.Validate(e)
+	assert.IsType(t, &ValidationError{}, err)
+
+	err = SetOpts{App: app, Secret: true}.Validate(e)
+	assert.NoError(t, err)
+}
+
+// fakeSecretStore is an in memory SecretStore.
+type fakeSecretStore struct {
+	secrets map[string]string
+}
+
+func newFakeSecretStore() *fakeSecretStore {
+	return &fakeSecretStore{secrets: make(map[string]string)}
+}
+
+func (s *fakeSecretStore) Put(ctx context.Context, key, value string) (string, error) {
+	ref := "fake:" + key
+	s.secrets[ref] = value
+	return ref, nil
+}
+
+func (s *fakeSecretStore) Get(ctx context.Context, ref string) (string, error) {
+	if !strings.HasPrefix(ref, "fake:") {
+		return "", errors.New("not a fake secret")
+	}
+	value, ok := s.secrets[ref]
+	if !ok {
+		return "", errors.New("not found")
+	}
+	return value, nil
+}
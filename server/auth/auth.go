@@ -57,6 +57,20 @@ func (fn AuthorizerFunc) Authorize(user *empire.User) error {
 	return fn(user)
 }
 
+// TeamFinder represents something that can find the teams that a user is a
+// member of.
+type TeamFinder interface {
+	// FindTeams should return the GitHub teams, as org/slug, that the user
+	// is a member of.
+	FindTeams(*empire.User) ([]string, error)
+}
+
+type TeamFinderFunc func(*empire.User) ([]string, error)
+
+func (fn TeamFinderFunc) FindTeams(user *empire.User) ([]string, error) {
+	return fn(user)
+}
+
 // StaticAuthenticator returns an Authenticator that returns the provided user
 // when the given credentials are provided.
 func StaticAuthenticator(username, password, otp string, user *empire.User) Authenticator {
@@ -130,3 +144,22 @@ func WithAuthorization(authenticator Authenticator, authorizer Authorizer) Authe
 		return user, nil
 	})
 }
+
+// WithTeams wraps an Authenticator to populate the teams that the user is a
+// member of, after the user is successfully authenticated.
+func WithTeams(authenticator Authenticator, finder TeamFinder) Authenticator {
+	return AuthenticatorFunc(func(username, password, otp string) (*empire.User, error) {
+		user, err := authenticator.Authenticate(username, password, otp)
+		if err != nil {
+			return user, err
+		}
+
+		teams, err := finder.FindTeams(user)
+		if err != nil {
+			return user, err
+		}
+		user.Teams = teams
+
+		return user, nil
+	})
+}
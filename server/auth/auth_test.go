@@ -105,3 +105,17 @@ func (m *mockAuthenticator) Authenticate(username, password, otp string) (*empir
 	}
 	return nil, args.Error(1)
 }
+
+func TestWithTeams(t *testing.T) {
+	u := &empire.User{Name: "ejholmes"}
+	m := new(mockAuthenticator)
+	a := WithTeams(m, TeamFinderFunc(func(user *empire.User) ([]string, error) {
+		return []string{"remind101/engineering"}, nil
+	}))
+
+	m.On("Authenticate", "username", "password", "").Return(u, nil)
+
+	user, err := a.Authenticate("username", "password", "")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"remind101/engineering"}, user.Teams)
+}
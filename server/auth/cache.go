@@ -47,3 +47,40 @@ func (a *cachedAuthorizer) Authorize(user *empire.User) error {
 
 	return err
 }
+
+// CacheTeams wraps a TeamFinder in an in memory cache that expires after the
+// given expiration.
+func CacheTeams(f TeamFinder, expiration time.Duration) TeamFinder {
+	cache := cache.New(expiration, 30*time.Second)
+
+	return &cachedTeamFinder{
+		TeamFinder: f,
+		cache:      cache,
+	}
+}
+
+// cachedTeamFinder is a TeamFinder middleware that caches the teams of a
+// user.
+type cachedTeamFinder struct {
+	TeamFinder
+
+	cache interface {
+		Set(k string, x interface{}, d time.Duration)
+		Get(k string) (interface{}, bool)
+	}
+}
+
+func (f *cachedTeamFinder) FindTeams(user *empire.User) ([]string, error) {
+	if teams, ok := f.cache.Get(user.Name); ok {
+		return teams.([]string), nil
+	}
+
+	teams, err := f.TeamFinder.FindTeams(user)
+	if err != nil {
+		return nil, err
+	}
+
+	f.cache.Set(user.Name, teams, 0)
+
+	return teams, nil
+}
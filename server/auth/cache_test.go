@@ -85,3 +85,32 @@ func (m *mockAuthorizer) Authorize(user *empire.User) error {
 	args := m.Called(user)
 	return args.Error(0)
 }
+
+func TestCachedTeamFinder(t *testing.T) {
+	u := &empire.User{Name: "ejholmes"}
+	c := new(mockCache)
+	calls := 0
+	f := &cachedTeamFinder{
+		TeamFinder: TeamFinderFunc(func(user *empire.User) ([]string, error) {
+			calls++
+			return []string{"remind101/engineering"}, nil
+		}),
+		cache: c,
+	}
+
+	c.On("Get", "ejholmes").Return(nil, false).Once()
+	c.On("Set", "ejholmes", []string{"remind101/engineering"}, time.Duration(0))
+
+	teams, err := f.FindTeams(u)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"remind101/engineering"}, teams)
+
+	c.On("Get", "ejholmes").Return([]string{"remind101/engineering"}, true)
+
+	teams, err = f.FindTeams(u)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"remind101/engineering"}, teams)
+	assert.Equal(t, 1, calls)
+
+	c.AssertExpectations(t)
+}
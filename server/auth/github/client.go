@@ -79,6 +79,14 @@ type User struct {
 	Login string `json:"login"`
 }
 
+// Team represents a GitHub team.
+type Team struct {
+	Slug         string `json:"slug"`
+	Organization struct {
+		Login string `json:"login"`
+	} `json:"organization"`
+}
+
 type TeamMembership struct {
 	State string `json:"state"`
 }
@@ -205,6 +213,29 @@ func (c *Client) IsTeamMember(teamID, token string) (bool, error) {
 	return t.State == "active", nil
 }
 
+// ListTeams returns the teams that the authenticated user is a member of.
+func (c *Client) ListTeams(token string) ([]Team, error) {
+	req, err := c.NewRequest("GET", "/user/teams?per_page=100", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	tokenAuth(req, token)
+
+	var teams []Team
+
+	resp, err := c.Do(req, &teams)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkResponse(resp); err != nil {
+		return nil, err
+	}
+
+	return teams, nil
+}
+
 func (c *Client) NewRequest(method, path string, v interface{}) (*http.Request, error) {
 	var r io.Reader
 	if v != nil {
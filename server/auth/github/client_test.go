@@ -249,6 +249,30 @@ func TestClient_IsTeamMember(t *testing.T) {
 	}
 }
 
+func TestClient_ListTeams(t *testing.T) {
+	h := new(mockHTTPClient)
+	c := &Client{
+		Config: oauthConfig,
+		client: h,
+	}
+
+	req, _ := http.NewRequest("GET", "https://api.github.com/user/teams?per_page=100", nil)
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+	req.SetBasicAuth("access_token", "x-oauth-basic")
+
+	h.On("Do", req).Return(&http.Response{
+		Request:    req,
+		StatusCode: http.StatusOK,
+		Body:       ioutil.NopCloser(bytes.NewBufferString(`[{"slug":"engineering","organization":{"login":"remind101"}}]`)),
+	}, nil)
+
+	teams, err := c.ListTeams("access_token")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(teams))
+	assert.Equal(t, "engineering", teams[0].Slug)
+	assert.Equal(t, "remind101", teams[0].Organization.Login)
+}
+
 type mockHTTPClient struct {
 	mock.Mock
 }
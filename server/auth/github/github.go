@@ -121,3 +121,30 @@ func (a *TeamAuthorizer) Authorize(user *empire.User) error {
 
 	return nil
 }
+
+// TeamFinder is an implementation of the auth.TeamFinder interface that finds
+// the GitHub teams that the user is a member of.
+type TeamFinder struct {
+	client interface {
+		ListTeams(token string) ([]Team, error)
+	}
+}
+
+// NewTeamFinder returns a new TeamFinder instance.
+func NewTeamFinder(c *Client) *TeamFinder {
+	return &TeamFinder{client: c}
+}
+
+func (f *TeamFinder) FindTeams(user *empire.User) ([]string, error) {
+	teams, err := f.client.ListTeams(user.GitHubToken)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, len(teams))
+	for i, t := range teams {
+		names[i] = fmt.Sprintf("%s/%s", t.Organization.Login, t.Slug)
+	}
+
+	return names, nil
+}
@@ -122,6 +122,25 @@ func TestTeamAuthorizer_Unauthorized(t *testing.T) {
 	assert.EqualError(t, err, `ejholmes is not a member of team 123.`)
 }
 
+func TestTeamFinder(t *testing.T) {
+	c := new(mockClient)
+	f := &TeamFinder{
+		client: c,
+	}
+
+	var team Team
+	team.Slug = "engineering"
+	team.Organization.Login = "remind101"
+	c.On("ListTeams", "access_token").Return([]Team{team}, nil)
+
+	teams, err := f.FindTeams(&empire.User{
+		Name:        "ejholmes",
+		GitHubToken: "access_token",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"remind101/engineering"}, teams)
+}
+
 type mockClient struct {
 	mock.Mock
 }
@@ -153,3 +172,8 @@ func (m *mockClient) IsTeamMember(teamID, token string) (bool, error) {
 	args := m.Called(teamID, token)
 	return args.Bool(0), args.Error(1)
 }
+
+func (m *mockClient) ListTeams(token string) ([]Team, error) {
+	args := m.Called(token)
+	return args.Get(0).([]Team), args.Error(1)
+}
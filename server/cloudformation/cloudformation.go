@@ -57,6 +57,10 @@ func NewCustomResourceProvisioner(db *sql.DB, config client.ConfigProvider) *Cus
 		ports: lb.NewDBPortAllocator(db),
 	})
 
+	p.add("Custom::ListenerRulePriority", newListenerRulePriorityProvisioner(&ListenerRulePriorityResource{
+		priorities: &dbPriorityAllocator{db: db},
+	}))
+
 	ecs := newECSClient(config)
 	p.add("Custom::ECSService", &ECSServiceResource{
 		ecs: ecs,
@@ -0,0 +1,167 @@
+package cloudformation
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/mitchellh/hashstructure"
+	"github.com/remind101/empire/pkg/cloudformation/customresources"
+	"golang.org/x/net/context"
+)
+
+// Listener rules are evaluated in order of priority, lowest first. Rules that
+// match on a path get a priority from the lower range, so that they take
+// precedence over rules that only match on the host.
+const (
+	minPathRulePriority = 1
+	maxPathRulePriority = 24999
+	minHostRulePriority = 25000
+	maxHostRulePriority = 50000
+)
+
+// The path range is split into bands of priorities, one for each depth of
+// path, so that rules for longer paths (e.g. /v2/admin) are evaluated before
+// rules for the paths they're nested under (e.g. /v2).
+const (
+	pathRulePriorityBand = 1000
+	maxPathDepth         = 24
+)
+
+// errNoPriorities is returned when all of the priorities in a range have been
+// allocated.
+var errNoPriorities = errors.New("no listener rule priorities available")
+
+type priorityAllocator interface {
+	Get(min, max int64) (int64, error)
+	Put(priority int64) error
+}
+
+// ListenerRulePriorityProperties represents the properties for the
+// Custom::ListenerRulePriority resource.
+type ListenerRulePriorityProperties struct {
+	// The path that the listener rule matches on, if any.
+	Path *string
+}
+
+func (p *ListenerRulePriorityProperties) ReplacementHash() (uint64, error) {
+	return hashstructure.Hash(p, nil)
+}
+
+// ListenerRulePriorityResource is a custom resource that allocates a unique
+// priority for an Application Load Balancer listener rule.
+type ListenerRulePriorityResource struct {
+	priorities priorityAllocator
+}
+
+func newListenerRulePriorityProvisioner(resource *ListenerRulePriorityResource) *provisioner {
+	return &provisioner{
+		properties: func() properties {
+			return &ListenerRulePriorityProperties{}
+		},
+		Create: resource.Create,
+		Update: resource.Update,
+		Delete: resource.Delete,
+	}
+}
+
+func (p *ListenerRulePriorityResource) Create(ctx context.Context, req customresources.Request) (string, interface{}, error) {
+	properties := req.ResourceProperties.(*ListenerRulePriorityProperties)
+
+	min, max := int64(minHostRulePriority), int64(maxHostRulePriority)
+	if properties.Path != nil && *properties.Path != "" {
+		min, max = pathRulePriorities(*properties.Path)
+	}
+
+	priority, err := p.priorities.Get(min, max)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return fmt.Sprintf("%d", priority), map[string]int64{"Priority": priority}, nil
+}
+
+func (p *ListenerRulePriorityResource) Update(ctx context.Context, req customresources.Request) (interface{}, error) {
+	priority, err := parsePriority(req.PhysicalResourceId)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]int64{"Priority": priority}, nil
+}
+
+func (p *ListenerRulePriorityResource) Delete(ctx context.Context, req customresources.Request) error {
+	priority, err := parsePriority(req.PhysicalResourceId)
+	if err != nil {
+		return err
+	}
+	return p.priorities.Put(priority)
+}
+
+// pathRulePriorities returns the range of priorities for a rule that matches on
+// the given path. The more segments the path has, the lower the priorities.
+func pathRulePriorities(path string) (min, max int64) {
+	var depth int64
+	for _, segment := range strings.Split(path, "/") {
+		if segment != "" {
+			depth++
+		}
+	}
+	if depth < 1 {
+		depth = 1
+	}
+	if depth > maxPathDepth {
+		depth = maxPathDepth
+	}
+
+	min = minPathRulePriority + (maxPathDepth-depth)*pathRulePriorityBand
+	return min, min + pathRulePriorityBand - 1
+}
+
+func parsePriority(id string) (int64, error) {
+	priority, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("physical resource id should have been a priority: %v", err)
+	}
+	return priority, nil
+}
+
+// dbPriorityAllocator is a priorityAllocator backed by the
+// `listener_rule_priorities` table.
+type dbPriorityAllocator struct {
+	db *sql.DB
+}
+
+// Get allocates the lowest priority between min and max that isn't already
+// allocated.
+func (a *dbPriorityAllocator) Get(min, max int64) (int64, error) {
+	tx, err := a.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+
+	// Prevent concurrent allocations from picking the same priority.
+	if _, err := tx.Exec(`LOCK TABLE listener_rule_priorities IN EXCLUSIVE MODE`); err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	query := `INSERT INTO listener_rule_priorities (priority) (SELECT p FROM generate_series($1::integer, $2::integer) p WHERE p NOT IN (SELECT priority FROM listener_rule_priorities) ORDER BY p ASC LIMIT 1) RETURNING priority`
+	var priority int64
+	if err := tx.QueryRow(query, min, max).Scan(&priority); err != nil {
+		tx.Rollback()
+		if err == sql.ErrNoRows {
+			return 0, errNoPriorities
+		}
+		return 0, err
+	}
+
+	return priority, tx.Commit()
+}
+
+// Put releases the priority.
+func (a *dbPriorityAllocator) Put(priority int64) error {
+	_, err := a.db.Exec(`DELETE FROM listener_rule_priorities WHERE priority = $1`, priority)
+	return err
+}
@@ -0,0 +1,119 @@
+package cloudformation
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/remind101/empire/pkg/cloudformation/customresources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestListenerRulePriorityResource_Create(t *testing.T) {
+	a := new(mockPriorityAllocator)
+	p := newListenerRulePriorityProvisioner(&ListenerRulePriorityResource{
+		priorities: a,
+	})
+
+	a.On("Get", int64(minHostRulePriority), int64(maxHostRulePriority)).Return(int64(25000), nil)
+	id, data, err := p.Provision(ctx, customresources.Request{
+		RequestType:        customresources.Create,
+		ResourceProperties: &ListenerRulePriorityProperties{Path: aws.String("")},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "25000", id)
+	assert.Equal(t, map[string]int64{"Priority": 25000}, data)
+
+	a.On("Get", int64(23001), int64(24000)).Return(int64(23001), nil)
+	id, data, err = p.Provision(ctx, customresources.Request{
+		RequestType:        customresources.Create,
+		ResourceProperties: &ListenerRulePriorityProperties{Path: aws.String("/v2")},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "23001", id)
+	assert.Equal(t, map[string]int64{"Priority": 23001}, data)
+
+	a.AssertExpectations(t)
+}
+
+func TestListenerRulePriorityResource_Update(t *testing.T) {
+	a := new(mockPriorityAllocator)
+	p := newListenerRulePriorityProvisioner(&ListenerRulePriorityResource{
+		priorities: a,
+	})
+
+	id, data, err := p.Provision(ctx, customresources.Request{
+		RequestType:           customresources.Update,
+		PhysicalResourceId:    "25000",
+		ResourceProperties:    &ListenerRulePriorityProperties{Path: aws.String("")},
+		OldResourceProperties: &ListenerRulePriorityProperties{Path: aws.String("")},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "25000", id)
+	assert.Equal(t, map[string]int64{"Priority": 25000}, data)
+
+	// Changing the path allocates a new priority.
+	a.On("Get", int64(23001), int64(24000)).Return(int64(23001), nil)
+	id, data, err = p.Provision(ctx, customresources.Request{
+		RequestType:           customresources.Update,
+		PhysicalResourceId:    "25000",
+		ResourceProperties:    &ListenerRulePriorityProperties{Path: aws.String("/v2")},
+		OldResourceProperties: &ListenerRulePriorityProperties{Path: aws.String("")},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "23001", id)
+	assert.Equal(t, map[string]int64{"Priority": 23001}, data)
+
+	a.AssertExpectations(t)
+}
+
+func TestListenerRulePriorityResource_Delete(t *testing.T) {
+	a := new(mockPriorityAllocator)
+	p := newListenerRulePriorityProvisioner(&ListenerRulePriorityResource{
+		priorities: a,
+	})
+
+	a.On("Put", int64(25000)).Return(nil)
+	_, _, err := p.Provision(ctx, customresources.Request{
+		RequestType:        customresources.Delete,
+		PhysicalResourceId: "25000",
+		ResourceProperties: &ListenerRulePriorityProperties{Path: aws.String("")},
+	})
+	assert.NoError(t, err)
+
+	a.AssertExpectations(t)
+}
+
+func TestPathRulePriorities(t *testing.T) {
+	tests := []struct {
+		path     string
+		min, max int64
+	}{
+		{"/v2", 23001, 24000},
+		{"/v2/", 23001, 24000},
+		{"/v2/admin", 22001, 23000},
+		{strings.Repeat("/a", 30), 1, 1000},
+	}
+
+	for _, tt := range tests {
+		min, max := pathRulePriorities(tt.path)
+		assert.Equal(t, tt.min, min, tt.path)
+		assert.Equal(t, tt.max, max, tt.path)
+		assert.True(t, min >= minPathRulePriority && max <= maxPathRulePriority)
+	}
+}
+
+type mockPriorityAllocator struct {
+	mock.Mock
+}
+
+func (m *mockPriorityAllocator) Get(min, max int64) (int64, error) {
+	args := m.Called(min, max)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *mockPriorityAllocator) Put(priority int64) error {
+	args := m.Called(priority)
+	return args.Error(0)
+}
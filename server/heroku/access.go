@@ -0,0 +1,145 @@
+package heroku
+
+import (
+	"net/http"
+
+	"github.com/remind101/empire"
+	"github.com/remind101/empire/pkg/heroku"
+	"github.com/remind101/pkg/httpx"
+	"golang.org/x/net/context"
+)
+
+// AppAuthorization is middleware that checks that the authenticated user has a
+// role on the app in the path before calling the wrapped handler.
+type AppAuthorization struct {
+	*empire.Empire
+
+	// The role that's required.
+	Role empire.Role
+
+	// handler is the wrapped httpx.Handler. This handler is called when the
+	// user has the role on the app.
+	handler httpx.Handler
+
+	// apps is used to find the app in the path. The default is Empire.
+	apps interface {
+		AppsFind(empire.AppsQuery) (*empire.App, error)
+	}
+}
+
+// AuthorizeApp wraps an httpx.Handler in the AppAuthorization middleware.
+func AuthorizeApp(e *empire.Empire, role empire.Role, h httpx.Handler) httpx.Handler {
+	return &AppAuthorization{
+		Empire:  e,
+		Role:    role,
+		handler: h,
+		apps:    e,
+	}
+}
+
+// ServeHTTPContext implements the httpx.Handler interface.
+func (h *AppAuthorization) ServeHTTPContext(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	if !h.AccessControl {
+		return h.handler.ServeHTTPContext(ctx, w, r)
+	}
+
+	// The app in the path may be the previous name of a renamed app, so
+	// access is checked against the app's current name.
+	a, err := findApp(ctx, h.apps)
+	if err != nil {
+		return err
+	}
+
+	if err := h.Authorize(UserFromContext(ctx), a.Name, h.Role); err != nil {
+		return err
+	}
+
+	return h.handler.ServeHTTPContext(ctx, w, r)
+}
+
+type AccessGrant heroku.AccessGrant
+
+func newAccessGrant(g *empire.AccessGrant) *AccessGrant {
+	return &AccessGrant{
+		Id:        g.ID,
+		User:      g.User,
+		Team:      g.Team,
+		App:       g.App,
+		Role:      string(g.Role),
+		CreatedAt: *g.CreatedAt,
+	}
+}
+
+func newAccessGrants(gs []*empire.AccessGrant) []*AccessGrant {
+	grants := make([]*AccessGrant, len(gs))
+
+	for i := 0; i < len(gs); i++ {
+		grants[i] = newAccessGrant(gs[i])
+	}
+
+	return grants
+}
+
+type GetAccessGrants struct {
+	*empire.Empire
+}
+
+func (h *GetAccessGrants) ServeHTTPContext(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	grants, err := h.AccessGrantsVisible(UserFromContext(ctx))
+	if err != nil {
+		return err
+	}
+
+	w.WriteHeader(200)
+	return Encode(w, newAccessGrants(grants))
+}
+
+type PostAccessGrants struct {
+	*empire.Empire
+}
+
+func (h *PostAccessGrants) ServeHTTPContext(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	var form heroku.AccessGrantCreateOpts
+
+	if err := Decode(r, &form); err != nil {
+		return err
+	}
+
+	g, err := h.AccessGrantsCreate(ctx, empire.AccessGrantsCreateOpts{
+		User: UserFromContext(ctx),
+		Grant: &empire.AccessGrant{
+			User: form.User,
+			Team: form.Team,
+			App:  form.App,
+			Role: empire.Role(form.Role),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	w.WriteHeader(201)
+	return Encode(w, newAccessGrant(g))
+}
+
+type DeleteAccessGrant struct {
+	*empire.Empire
+}
+
+func (h *DeleteAccessGrant) ServeHTTPContext(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	id := httpx.Vars(ctx)["id"]
+
+	g, err := h.AccessGrantsFind(empire.AccessGrantsQuery{ID: &id})
+	if err != nil {
+		return err
+	}
+
+	if err := h.AccessGrantsDestroy(ctx, empire.AccessGrantsDestroyOpts{
+		User:  UserFromContext(ctx),
+		Grant: g,
+	}); err != nil {
+		return err
+	}
+
+	return NoContent(w)
+}
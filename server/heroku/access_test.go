@@ -0,0 +1,69 @@
+package heroku
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/remind101/empire"
+	"github.com/remind101/pkg/httpx"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestAppAuthorization(t *testing.T) {
+	e := empire.New(&empire.DB{})
+	e.AccessControl = true
+	e.Admins = []string{"ejholmes"}
+
+	called := false
+	h := AuthorizeApp(e, empire.RoleAdmin, httpx.HandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		called = true
+		return nil
+	})).(*AppAuthorization)
+
+	// The app in the path is the previous name of a renamed app.
+	apps := &fakeAppFinder{apps: map[string]*empire.App{
+		"acme-inc": {Name: "acme"},
+	}}
+	h.apps = apps
+
+	ctx := httpx.WithVars(context.Background(), map[string]string{"app": "acme-inc"})
+	req, _ := http.NewRequest("DELETE", "/apps/acme-inc", nil)
+
+	// Unauthenticated users are always denied.
+	var anonymous *empire.User
+	err := h.ServeHTTPContext(WithUser(ctx, anonymous), httptest.NewRecorder(), req)
+	assert.IsType(t, &empire.AccessDeniedError{}, err)
+	assert.False(t, called)
+
+	err = h.ServeHTTPContext(WithUser(ctx, &empire.User{Name: "ejholmes"}), httptest.NewRecorder(), req)
+	assert.NoError(t, err)
+	assert.True(t, called)
+	assert.Equal(t, []string{"acme-inc", "acme-inc"}, apps.found)
+
+	// Apps that don't exist aren't found.
+	called = false
+	ctx = httpx.WithVars(context.Background(), map[string]string{"app": "foo"})
+	err = h.ServeHTTPContext(WithUser(ctx, &empire.User{Name: "ejholmes"}), httptest.NewRecorder(), req)
+	assert.Equal(t, errAppNotFound, err)
+	assert.False(t, called)
+}
+
+var errAppNotFound = errors.New("app not found")
+
+// fakeAppFinder finds apps by name, without a database.
+type fakeAppFinder struct {
+	apps  map[string]*empire.App
+	found []string
+}
+
+func (f *fakeAppFinder) AppsFind(q empire.AppsQuery) (*empire.App, error) {
+	f.found = append(f.found, *q.Name)
+	a, ok := f.apps[*q.Name]
+	if !ok {
+		return nil, errAppNotFound
+	}
+	return a, nil
+}
@@ -18,6 +18,8 @@ func newApp(a *empire.App) *App {
 		Name:      a.Name,
 		CreatedAt: *a.CreatedAt,
 		Cert:      a.Cert,
+
+		AutoRollback: a.AutoRollback,
 	}
 }
 
@@ -41,8 +43,25 @@ func (h *GetApps) ServeHTTPContext(ctx context.Context, w http.ResponseWriter, r
 		return err
 	}
 
+	// Only show the apps that the user can view.
+	perms, err := h.Permissions(UserFromContext(ctx))
+	if err != nil {
+		return err
+	}
+	var visible []*empire.App
+	for _, a := range apps {
+		err := perms.Authorize(a.Name, empire.RoleViewer)
+		if _, ok := err.(*empire.AccessDeniedError); ok {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		visible = append(visible, a)
+	}
+
 	w.WriteHeader(200)
-	return Encode(w, newApps(apps))
+	return Encode(w, newApps(visible))
 }
 
 type GetAppInfo struct {
@@ -154,7 +173,37 @@ func (h *PatchApp) ServeHTTPContext(ctx context.Context, w http.ResponseWriter,
 	}
 
 	if form.Cert != nil {
-		if err := h.CertsAttach(ctx, a, *form.Cert); err != nil {
+		if err := h.CertsAttach(ctx, empire.CertsAttachOpts{
+			User: UserFromContext(ctx),
+			App:  a,
+			Cert: *form.Cert,
+		}); err != nil {
+			return err
+		}
+	}
+
+	if form.AutoRollback != nil {
+		if err := h.SetAutoRollback(ctx, empire.SetAutoRollbackOpts{
+			User:    UserFromContext(ctx),
+			App:     a,
+			Enabled: *form.AutoRollback,
+		}); err != nil {
+			return err
+		}
+	}
+
+	if form.Name != nil && *form.Name != a.Name {
+		m, err := findMessage(r)
+		if err != nil {
+			return err
+		}
+
+		if err := h.Rename(ctx, empire.RenameOpts{
+			User:    UserFromContext(ctx),
+			App:     a,
+			Name:    *form.Name,
+			Message: m,
+		}); err != nil {
 			return err
 		}
 	}
@@ -169,6 +218,9 @@ func findApp(ctx context.Context, e interface {
 	name := vars["app"]
 
 	a, err := e.AppsFind(empire.AppsQuery{Name: &name})
+	if err != nil {
+		return nil, err
+	}
 	reporter.AddContext(ctx, "app", a.Name)
-	return a, err
+	return a, nil
 }
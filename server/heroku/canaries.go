@@ -0,0 +1,68 @@
+package heroku
+
+import (
+	"net/http"
+
+	"github.com/remind101/empire"
+	"golang.org/x/net/context"
+)
+
+// PostPromote is a Handler for the POST /apps/{app}/canary/promote endpoint.
+type PostPromote struct {
+	*empire.Empire
+}
+
+// ServeHTTPContext implements the Handler interface.
+func (h *PostPromote) ServeHTTPContext(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	app, err := findApp(ctx, h)
+	if err != nil {
+		return err
+	}
+
+	m, err := findMessage(r)
+	if err != nil {
+		return err
+	}
+
+	release, err := h.Promote(ctx, empire.PromoteOpts{
+		User:    UserFromContext(ctx),
+		App:     app,
+		Message: m,
+	})
+	if err != nil {
+		return err
+	}
+
+	w.WriteHeader(200)
+	return Encode(w, newRelease(release))
+}
+
+// PostAbort is a Handler for the POST /apps/{app}/canary/abort endpoint.
+type PostAbort struct {
+	*empire.Empire
+}
+
+// ServeHTTPContext implements the Handler interface.
+func (h *PostAbort) ServeHTTPContext(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	app, err := findApp(ctx, h)
+	if err != nil {
+		return err
+	}
+
+	m, err := findMessage(r)
+	if err != nil {
+		return err
+	}
+
+	release, err := h.Abort(ctx, empire.AbortOpts{
+		User:    UserFromContext(ctx),
+		App:     app,
+		Message: m,
+	})
+	if err != nil {
+		return err
+	}
+
+	w.WriteHeader(200)
+	return Encode(w, newRelease(release))
+}
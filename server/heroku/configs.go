@@ -2,8 +2,10 @@ package heroku
 
 import (
 	"net/http"
+	"strconv"
 
 	"github.com/remind101/empire"
+	"github.com/remind101/empire/pkg/heroku"
 	"golang.org/x/net/context"
 )
 
@@ -23,7 +25,7 @@ func (h *GetConfigs) ServeHTTPContext(ctx context.Context, w http.ResponseWriter
 	}
 
 	w.WriteHeader(200)
-	return Encode(w, c.Vars)
+	return Encode(w, c.Vars.Masked())
 }
 
 type PatchConfigs struct {
@@ -52,6 +54,7 @@ func (h *PatchConfigs) ServeHTTPContext(ctx context.Context, w http.ResponseWrit
 		User:    UserFromContext(ctx),
 		App:     a,
 		Vars:    configVars,
+		Secret:  r.Header.Get(heroku.SecretHeader) == "true",
 		Message: m,
 	})
 	if err != nil {
@@ -59,5 +62,94 @@ func (h *PatchConfigs) ServeHTTPContext(ctx context.Context, w http.ResponseWrit
 	}
 
 	w.WriteHeader(200)
-	return Encode(w, c.Vars)
+	return Encode(w, c.Vars.Masked())
+}
+
+// newConfigVarChanges converts the changes to their API representation,
+// masking secrets. If masked is true, the values are omitted entirely.
+func newConfigVarChanges(d empire.ConfigDiff, masked bool) []heroku.ConfigVarChange {
+	changes := make([]heroku.ConfigVarChange, 0, len(d))
+	for _, c := range d.Masked() {
+		change := heroku.ConfigVarChange{
+			Name:   string(c.Name),
+			Change: string(c.Type),
+		}
+		if !masked {
+			change.OldValue = c.Old
+			change.NewValue = c.New
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+type ConfigVarHistory heroku.ConfigVarHistory
+
+func newConfigVarHistory(v *empire.ConfigVersion, masked bool) *ConfigVarHistory {
+	var h ConfigVarHistory
+	h.Release.Id = v.Release.ID
+	h.Release.Version = v.Release.Version
+	h.Release.Description = v.Release.Description
+	h.Release.CreatedAt = *v.Release.CreatedAt
+	h.Changes = newConfigVarChanges(v.Changes, masked)
+	return &h
+}
+
+type GetConfigHistory struct {
+	*empire.Empire
+}
+
+func (h *GetConfigHistory) ServeHTTPContext(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	a, err := findApp(ctx, h)
+	if err != nil {
+		return err
+	}
+
+	rangeHeader, err := RangeHeader(r)
+	if err != nil {
+		return err
+	}
+
+	versions, err := h.ConfigHistory(empire.ReleasesQuery{App: a, Range: rangeHeader})
+	if err != nil {
+		return err
+	}
+
+	masked := r.URL.Query().Get("masked") == "true"
+	history := make([]*ConfigVarHistory, len(versions))
+	for i, v := range versions {
+		history[i] = newConfigVarHistory(v, masked)
+	}
+
+	w.WriteHeader(200)
+	return Encode(w, history)
+}
+
+type GetConfigDiff struct {
+	*empire.Empire
+}
+
+func (h *GetConfigDiff) ServeHTTPContext(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	a, err := findApp(ctx, h)
+	if err != nil {
+		return err
+	}
+
+	q := r.URL.Query()
+	from, err := strconv.Atoi(q.Get("from"))
+	if err != nil {
+		return ErrBadRequest
+	}
+	to, err := strconv.Atoi(q.Get("to"))
+	if err != nil {
+		return ErrBadRequest
+	}
+
+	diff, err := h.ConfigDiff(a, from, to)
+	if err != nil {
+		return err
+	}
+
+	w.WriteHeader(200)
+	return Encode(w, newConfigVarChanges(diff, q.Get("masked") == "true"))
 }
@@ -0,0 +1,134 @@
+package heroku
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/remind101/empire"
+	"github.com/remind101/empire/pkg/heroku"
+	"golang.org/x/net/context"
+)
+
+// The maximum number of months of history that can be requested.
+const maxCostMonths = 24
+
+type AppCost heroku.AppCost
+
+func newAppCost(sizes empire.DynoSizes, c *empire.AppCost) *AppCost {
+	var cost AppCost
+	cost.App.Id = c.App.ID
+	cost.App.Name = c.App.Name
+	cost.Monthly = c.Monthly
+
+	cost.Processes = make([]heroku.ProcessCost, len(c.Processes))
+	for i, p := range c.Processes {
+		cost.Processes[i] = heroku.ProcessCost{
+			Type:         p.Type,
+			Quantity:     p.Quantity,
+			Size:         sizes.Format(p.Constraints),
+			LoadBalancer: p.LoadBalancer,
+			Monthly:      p.Monthly,
+		}
+	}
+
+	cost.History = make([]heroku.MonthlyCost, len(c.History))
+	for i, m := range c.History {
+		cost.History[i] = heroku.MonthlyCost{
+			Month: m.Month,
+			Cost:  m.Cost,
+		}
+	}
+
+	return &cost
+}
+
+func newAppCosts(sizes empire.DynoSizes, cs []*empire.AppCost) []*AppCost {
+	costs := make([]*AppCost, len(cs))
+
+	for i := 0; i < len(cs); i++ {
+		costs[i] = newAppCost(sizes, cs[i])
+	}
+
+	return costs
+}
+
+// costMonths returns the number of months of history requested with the
+// `months` query parameter, or 0 for the default.
+func costMonths(r *http.Request) (int, error) {
+	v := r.URL.Query().Get("months")
+	if v == "" {
+		return 0, nil
+	}
+
+	months, err := strconv.Atoi(v)
+	if err != nil || months < 1 || months > maxCostMonths {
+		return 0, ErrBadRequest
+	}
+
+	return months, nil
+}
+
+type GetAppCost struct {
+	*empire.Empire
+}
+
+func (h *GetAppCost) ServeHTTPContext(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	months, err := costMonths(r)
+	if err != nil {
+		return err
+	}
+
+	a, err := findApp(ctx, h)
+	if err != nil {
+		return err
+	}
+
+	c, err := h.AppCost(ctx, a, months)
+	if err != nil {
+		return err
+	}
+
+	w.WriteHeader(200)
+	return Encode(w, newAppCost(h.DynoSizes(), c))
+}
+
+type GetCosts struct {
+	*empire.Empire
+}
+
+func (h *GetCosts) ServeHTTPContext(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	months, err := costMonths(r)
+	if err != nil {
+		return err
+	}
+
+	apps, err := h.Apps(empire.AppsQuery{})
+	if err != nil {
+		return err
+	}
+
+	// Only include the apps that the user can view.
+	perms, err := h.Permissions(UserFromContext(ctx))
+	if err != nil {
+		return err
+	}
+	var visible []*empire.App
+	for _, a := range apps {
+		err := perms.Authorize(a.Name, empire.RoleViewer)
+		if _, ok := err.(*empire.AccessDeniedError); ok {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		visible = append(visible, a)
+	}
+
+	costs, err := h.AppCosts(ctx, visible, months)
+	if err != nil {
+		return err
+	}
+
+	w.WriteHeader(200)
+	return Encode(w, newAppCosts(h.DynoSizes(), costs))
+}
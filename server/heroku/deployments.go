@@ -19,6 +19,18 @@ type PostDeploys struct {
 type PostDeployForm struct {
 	Image  image.Image
 	Stream bool
+
+	// If provided, the image will be deployed as a canary with this
+	// percentage of instances.
+	Canary int `json:"canary"`
+
+	// If true, the image will be deployed alongside the current release
+	// with a full set of instances.
+	BlueGreen bool `json:"blue_green"`
+
+	// If true, nothing is deployed. Instead, a preview of the changes that
+	// the deploy would make is streamed back.
+	Plan bool `json:"plan"`
 }
 
 // ServeHTTPContext implements the Handler interface.
@@ -58,6 +70,11 @@ func newDeployOpts(ctx context.Context, w http.ResponseWriter, req *http.Request
 		Output:  empire.NewDeploymentStream(streamhttp.StreamingResponseWriter(w)),
 		Message: m,
 		Stream:  form.Stream,
+		Strategy: empire.DeployStrategy{
+			Canary:    form.Canary,
+			BlueGreen: form.BlueGreen,
+		},
+		Plan: form.Plan,
 	}
 	return &opts, nil
 }
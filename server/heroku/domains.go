@@ -64,7 +64,11 @@ func (h *PostDomains) ServeHTTPContext(ctx context.Context, w http.ResponseWrite
 		AppID:    a.ID,
 		Hostname: form.Hostname,
 	}
-	d, err := h.DomainsCreate(ctx, domain)
+	d, err := h.DomainsCreate(ctx, empire.DomainsCreateOpts{
+		User:   UserFromContext(ctx),
+		App:    a,
+		Domain: domain,
+	})
 	if err != nil {
 		if err == empire.ErrDomainInUse {
 			return fmt.Errorf("%s is currently in use by another app.", domain.Hostname)
@@ -103,7 +107,11 @@ func (h *DeleteDomain) ServeHTTPContext(ctx context.Context, w http.ResponseWrit
 		return err
 	}
 
-	if err = h.DomainsDestroy(ctx, d); err != nil {
+	if err = h.DomainsDestroy(ctx, empire.DomainsDestroyOpts{
+		User:   UserFromContext(ctx),
+		App:    a,
+		Domain: d,
+	}); err != nil {
 		return err
 	}
 
@@ -0,0 +1,83 @@
+package heroku
+
+import (
+	"net/http"
+
+	"github.com/remind101/empire"
+	"github.com/remind101/empire/pkg/heroku"
+	"golang.org/x/net/context"
+)
+
+func newDrift(d *empire.Drift) *heroku.Drift {
+	processes := make([]heroku.ProcessDrift, 0, len(d.Processes))
+	for _, p := range d.Processes {
+		pd := heroku.ProcessDrift{
+			Type:     p.Type,
+			Quantity: p.Quantity,
+			Running:  p.Running,
+			Outdated: p.Outdated,
+			Drifted:  p.Drifted(),
+		}
+		if p.Desired >= 0 {
+			desired := p.Desired
+			pd.Desired = &desired
+		}
+		processes = append(processes, pd)
+	}
+
+	return &heroku.Drift{
+		Release:   d.Release,
+		Drifted:   d.Drifted(),
+		Processes: processes,
+	}
+}
+
+type GetDrift struct {
+	*empire.Empire
+}
+
+func (h *GetDrift) ServeHTTPContext(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	a, err := findApp(ctx, h)
+	if err != nil {
+		return err
+	}
+
+	d, err := h.Drift(ctx, empire.DriftOpts{
+		User: UserFromContext(ctx),
+		App:  a,
+	})
+	if err != nil {
+		return err
+	}
+
+	w.WriteHeader(200)
+	return Encode(w, newDrift(d))
+}
+
+type PostDrift struct {
+	*empire.Empire
+}
+
+func (h *PostDrift) ServeHTTPContext(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	a, err := findApp(ctx, h)
+	if err != nil {
+		return err
+	}
+
+	m, err := findMessage(r)
+	if err != nil {
+		return err
+	}
+
+	d, err := h.Reconcile(ctx, empire.ReconcileOpts{
+		User:    UserFromContext(ctx),
+		App:     a,
+		Message: m,
+	})
+	if err != nil {
+		return err
+	}
+
+	w.WriteHeader(200)
+	return Encode(w, newDrift(d))
+}
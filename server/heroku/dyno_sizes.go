@@ -0,0 +1,72 @@
+package heroku
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/remind101/empire"
+	"github.com/remind101/empire/pkg/bytesize"
+	"github.com/remind101/empire/pkg/heroku"
+	"github.com/remind101/pkg/httpx"
+	"golang.org/x/net/context"
+)
+
+type DynoSize heroku.DynoSize
+
+func newDynoSize(s empire.DynoSize) *DynoSize {
+	return &DynoSize{
+		Id:      s.Name,
+		Name:    s.Name,
+		Compute: int(s.CPUShare),
+		Memory:  float64(s.Memory) / float64(bytesize.GB),
+		Nproc:   int(s.Nproc),
+	}
+}
+
+func newDynoSizes(ss empire.DynoSizes) []*DynoSize {
+	sizes := make([]*DynoSize, len(ss))
+
+	for i := 0; i < len(ss); i++ {
+		sizes[i] = newDynoSize(ss[i])
+	}
+
+	return sizes
+}
+
+// parseSize parses the size of a process from a request, using the dyno sizes
+// that Empire is configured with.
+func parseSize(e *empire.Empire, size *string) (*empire.Constraints, error) {
+	if size == nil {
+		return nil, nil
+	}
+
+	c, err := e.DynoSizes().Parse(*size)
+	if err != nil {
+		return nil, &empire.ValidationError{Err: fmt.Errorf("invalid size %q: %v", *size, err)}
+	}
+
+	return c, nil
+}
+
+type GetDynoSizes struct {
+	*empire.Empire
+}
+
+func (h *GetDynoSizes) ServeHTTPContext(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	w.WriteHeader(200)
+	return Encode(w, newDynoSizes(h.DynoSizes()))
+}
+
+type GetDynoSize struct {
+	*empire.Empire
+}
+
+func (h *GetDynoSize) ServeHTTPContext(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	s, ok := h.DynoSizes().Find(httpx.Vars(ctx)["size"])
+	if !ok {
+		return ErrNotFound
+	}
+
+	w.WriteHeader(200)
+	return Encode(w, newDynoSize(s))
+}
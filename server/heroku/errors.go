@@ -74,6 +74,12 @@ func newError(err error) *ErrorResource {
 		return ErrMessageRequired
 	case *empire.ValidationError:
 		return ErrBadRequest
+	case *empire.AccessDeniedError:
+		return &ErrorResource{
+			Status:  http.StatusForbidden,
+			ID:      "forbidden",
+			Message: err.Error(),
+		}
 	default:
 		return &ErrorResource{
 			Message: err.Error(),
@@ -0,0 +1,115 @@
+package heroku
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/remind101/empire"
+	"github.com/remind101/empire/pkg/heroku"
+	"github.com/remind101/pkg/httpx"
+	"github.com/remind101/pkg/timex"
+	"golang.org/x/net/context"
+)
+
+type Event heroku.Event
+
+func newEvent(e *empire.AuditEvent) *Event {
+	return &Event{
+		Id:          e.ID,
+		Type:        e.Type,
+		User:        e.User,
+		App:         e.App,
+		Message:     e.Message,
+		Payload:     json.RawMessage(e.Payload),
+		Status:      string(e.Status()),
+		Attempts:    e.Attempts,
+		LastError:   e.LastError,
+		DeliveredAt: e.DeliveredAt,
+		CreatedAt:   *e.CreatedAt,
+	}
+}
+
+type GetEvents struct {
+	*empire.Empire
+}
+
+func (h *GetEvents) ServeHTTPContext(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	rangeHeader, err := RangeHeader(r)
+	if err != nil {
+		return err
+	}
+
+	q := empire.AuditEventsQuery{Range: rangeHeader}
+
+	params := r.URL.Query()
+	if app := params.Get("app"); app != "" {
+		q.App = &app
+	}
+	if user := params.Get("user"); user != "" {
+		q.User = &user
+	}
+	if typ := params.Get("type"); typ != "" {
+		q.Type = &typ
+	}
+	if status := params.Get("status"); status != "" {
+		s := empire.EventStatus(status)
+		if !s.Valid() {
+			return ErrBadRequest
+		}
+		q.Status = &s
+	}
+	if since := params.Get("since"); since != "" {
+		t, err := parseSince(since)
+		if err != nil {
+			return ErrBadRequest
+		}
+		q.Since = &t
+	}
+
+	// Only show the events for apps that the user can view.
+	events, err := h.AuditEventsVisible(UserFromContext(ctx), q)
+	if err != nil {
+		return err
+	}
+
+	resp := make([]*Event, 0, len(events))
+	for _, e := range events {
+		resp = append(resp, newEvent(e))
+	}
+
+	w.WriteHeader(200)
+	return Encode(w, resp)
+}
+
+type PostEventRequeue struct {
+	*empire.Empire
+}
+
+func (h *PostEventRequeue) ServeHTTPContext(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	vars := httpx.Vars(ctx)
+	id := vars["id"]
+
+	event, err := h.AuditEventsFind(empire.AuditEventsQuery{ID: &id})
+	if err != nil {
+		return err
+	}
+
+	if err := h.EventsRequeue(ctx, empire.EventsRequeueOpts{
+		User:  UserFromContext(ctx),
+		Event: event,
+	}); err != nil {
+		return err
+	}
+
+	return NoContent(w)
+}
+
+// parseSince parses the since parameter, which can either be an RFC3339
+// timestamp, or a duration (e.g. 24h) before now.
+func parseSince(since string) (time.Time, error) {
+	if d, err := time.ParseDuration(since); err == nil {
+		return timex.Now().Add(-d), nil
+	}
+	return time.Parse(time.RFC3339, since)
+}
@@ -5,20 +5,40 @@ import (
 
 	"github.com/remind101/empire"
 	"github.com/remind101/empire/pkg/heroku"
+	"github.com/remind101/pkg/httpx"
 	"golang.org/x/net/context"
 )
 
 type Formation heroku.Formation
 
+func newFormation(sizes empire.DynoSizes, name string, p *empire.Process) *Formation {
+	f := &Formation{
+		Type:     name,
+		Quantity: p.Quantity,
+		Size:     sizes.Format(p.Constraints()),
+	}
+	if a := p.Autoscaling; a != nil {
+		f.Autoscaling = &heroku.FormationAutoscaling{
+			Min:              a.Min,
+			Max:              a.Max,
+			CPU:              a.CPU,
+			Memory:           a.Memory,
+			ScaleInCooldown:  a.ScaleInCooldown,
+			ScaleOutCooldown: a.ScaleOutCooldown,
+		}
+	}
+	return f
+}
+
 type PatchFormation struct {
 	*empire.Empire
 }
 
 type PatchFormationForm struct {
 	Updates []struct {
-		Process  string              `json:"process"` // Refers to process type
-		Quantity int                 `json:"quantity"`
-		Size     *empire.Constraints `json:"size"`
+		Process  string  `json:"process"` // Refers to process type
+		Quantity int     `json:"quantity"`
+		Size     *string `json:"size"`
 	} `json:"updates"`
 }
 
@@ -41,10 +61,14 @@ func (h *PatchFormation) ServeHTTPContext(ctx context.Context, w http.ResponseWr
 
 	var updates []*empire.ProcessUpdate
 	for _, up := range form.Updates {
+		c, err := parseSize(h.Empire, up.Size)
+		if err != nil {
+			return err
+		}
 		updates = append(updates, &empire.ProcessUpdate{
 			Process:     up.Process,
 			Quantity:    up.Quantity,
-			Constraints: up.Size,
+			Constraints: c,
 		})
 	}
 	ps, err := h.Scale(ctx, empire.ScaleOpts{
@@ -59,12 +83,7 @@ func (h *PatchFormation) ServeHTTPContext(ctx context.Context, w http.ResponseWr
 
 	var resp []*Formation
 	for i, p := range ps {
-		up := updates[i]
-		resp = append(resp, &Formation{
-			Type:     up.Process,
-			Quantity: p.Quantity,
-			Size:     p.Constraints().String(),
-		})
+		resp = append(resp, newFormation(h.DynoSizes(), updates[i].Process, p))
 	}
 
 	w.WriteHeader(200)
@@ -90,13 +109,84 @@ func (h *GetFormation) ServeHTTPContext(ctx context.Context, w http.ResponseWrit
 
 	var resp []*Formation
 	for name, proc := range formation {
-		resp = append(resp, &Formation{
-			Type:     name,
-			Quantity: proc.Quantity,
-			Size:     proc.Constraints().String(),
-		})
+		proc := proc
+		resp = append(resp, newFormation(h.DynoSizes(), name, &proc))
 	}
 
 	w.WriteHeader(200)
 	return Encode(w, resp)
 }
+
+type PutAutoscaling struct {
+	*empire.Empire
+}
+
+type PutAutoscalingForm heroku.FormationAutoscaling
+
+func (h *PutAutoscaling) ServeHTTPContext(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	var form PutAutoscalingForm
+
+	if err := Decode(r, &form); err != nil {
+		return err
+	}
+
+	app, err := findApp(ctx, h)
+	if err != nil {
+		return err
+	}
+
+	m, err := findMessage(r)
+	if err != nil {
+		return err
+	}
+
+	vars := httpx.Vars(ctx)
+	p, err := h.Autoscale(ctx, empire.AutoscaleOpts{
+		User:    UserFromContext(ctx),
+		App:     app,
+		Process: vars["process"],
+		Autoscaling: &empire.Autoscaling{
+			Min:              form.Min,
+			Max:              form.Max,
+			CPU:              form.CPU,
+			Memory:           form.Memory,
+			ScaleInCooldown:  form.ScaleInCooldown,
+			ScaleOutCooldown: form.ScaleOutCooldown,
+		},
+		Message: m,
+	})
+	if err != nil {
+		return err
+	}
+
+	w.WriteHeader(200)
+	return Encode(w, newFormation(h.DynoSizes(), vars["process"], p))
+}
+
+type DeleteAutoscaling struct {
+	*empire.Empire
+}
+
+func (h *DeleteAutoscaling) ServeHTTPContext(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	app, err := findApp(ctx, h)
+	if err != nil {
+		return err
+	}
+
+	m, err := findMessage(r)
+	if err != nil {
+		return err
+	}
+
+	vars := httpx.Vars(ctx)
+	if _, err := h.Autoscale(ctx, empire.AutoscaleOpts{
+		User:    UserFromContext(ctx),
+		App:     app,
+		Process: vars["process"],
+		Message: m,
+	}); err != nil {
+		return err
+	}
+
+	return NoContent(w)
+}
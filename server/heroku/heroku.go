@@ -26,42 +26,89 @@ const AcceptHeader = "application/vnd.heroku+json; version=3"
 func New(e *empire.Empire, authenticator auth.Authenticator) httpx.Handler {
 	r := httpx.NewRouter()
 
+	// Roles required to act on an app. These are only checked when access
+	// control is enabled.
+	viewer := func(h httpx.Handler) httpx.Handler { return AuthorizeApp(e, empire.RoleViewer, h) }
+	deployer := func(h httpx.Handler) httpx.Handler { return AuthorizeApp(e, empire.RoleDeployer, h) }
+	admin := func(h httpx.Handler) httpx.Handler { return AuthorizeApp(e, empire.RoleAdmin, h) }
+
 	// Apps
-	r.Handle("/apps", &GetApps{e}).Methods("GET")                  // hk apps
-	r.Handle("/apps/{app}", &GetAppInfo{e}).Methods("GET")         // hk info
-	r.Handle("/apps/{app}", &DeleteApp{e}).Methods("DELETE")       // hk destroy
-	r.Handle("/apps/{app}", &PatchApp{e}).Methods("PATCH")         // hk destroy
-	r.Handle("/apps/{app}/deploys", &DeployApp{e}).Methods("POST") // Deploy an image to an app
-	r.Handle("/apps", &PostApps{e}).Methods("POST")                // hk create
-	r.Handle("/organizations/apps", &PostApps{e}).Methods("POST")  // hk create
+	r.Handle("/apps", &GetApps{e}).Methods("GET")                            // hk apps
+	r.Handle("/apps/{app}", viewer(&GetAppInfo{e})).Methods("GET")           // hk info
+	r.Handle("/apps/{app}", admin(&DeleteApp{e})).Methods("DELETE")          // hk destroy
+	r.Handle("/apps/{app}", admin(&PatchApp{e})).Methods("PATCH")            // hk destroy
+	r.Handle("/apps/{app}/deploys", deployer(&DeployApp{e})).Methods("POST") // Deploy an image to an app
+	r.Handle("/apps", &PostApps{e}).Methods("POST")                          // hk create
+	r.Handle("/organizations/apps", &PostApps{e}).Methods("POST")            // hk create
 
 	// Domains
-	r.Handle("/apps/{app}/domains", &GetDomains{e}).Methods("GET")                 // hk domains
-	r.Handle("/apps/{app}/domains", &PostDomains{e}).Methods("POST")               // hk domain-add
-	r.Handle("/apps/{app}/domains/{hostname}", &DeleteDomain{e}).Methods("DELETE") // hk domain-remove
+	r.Handle("/apps/{app}/domains", viewer(&GetDomains{e})).Methods("GET")                   // hk domains
+	r.Handle("/apps/{app}/domains", admin(&PostDomains{e})).Methods("POST")                  // hk domain-add
+	r.Handle("/apps/{app}/domains/{hostname:.+}", admin(&DeleteDomain{e})).Methods("DELETE") // hk domain-remove
 
 	// Deploys
 	r.Handle("/deploys", &PostDeploys{e}).Methods("POST") // Deploy an app
 
 	// Releases
-	r.Handle("/apps/{app}/releases", &GetReleases{e}).Methods("GET")          // hk releases
-	r.Handle("/apps/{app}/releases/{version}", &GetRelease{e}).Methods("GET") // hk release-info
-	r.Handle("/apps/{app}/releases", &PostReleases{e}).Methods("POST")        // hk rollback
+	r.Handle("/apps/{app}/releases", viewer(&GetReleases{e})).Methods("GET")          // hk releases
+	r.Handle("/apps/{app}/releases/{version}", viewer(&GetRelease{e})).Methods("GET") // hk release-info
+	r.Handle("/apps/{app}/releases", deployer(&PostReleases{e})).Methods("POST")      // hk rollback
+
+	// Canaries
+	r.Handle("/apps/{app}/canary/promote", deployer(&PostPromote{e})).Methods("POST") // emp promote
+	r.Handle("/apps/{app}/canary/abort", deployer(&PostAbort{e})).Methods("POST")     // emp abort
 
 	// Configs
-	r.Handle("/apps/{app}/config-vars", &GetConfigs{e}).Methods("GET")     // hk env, hk get
-	r.Handle("/apps/{app}/config-vars", &PatchConfigs{e}).Methods("PATCH") // hk set, hk unset
+	r.Handle("/apps/{app}/config-vars", viewer(&GetConfigs{e})).Methods("GET")               // hk env, hk get
+	r.Handle("/apps/{app}/config-vars", deployer(&PatchConfigs{e})).Methods("PATCH")         // hk set, hk unset
+	r.Handle("/apps/{app}/config-vars/history", viewer(&GetConfigHistory{e})).Methods("GET") // emp env-history
+	r.Handle("/apps/{app}/config-vars/diff", viewer(&GetConfigDiff{e})).Methods("GET")       // emp env-diff
 
 	// Processes
-	r.Handle("/apps/{app}/dynos", &GetProcesses{e}).Methods("GET")                     // hk dynos
-	r.Handle("/apps/{app}/dynos", &PostProcess{e}).Methods("POST")                     // hk run
-	r.Handle("/apps/{app}/dynos", &DeleteProcesses{e}).Methods("DELETE")               // hk restart
-	r.Handle("/apps/{app}/dynos/{ptype}.{pid}", &DeleteProcesses{e}).Methods("DELETE") // hk restart web.1
-	r.Handle("/apps/{app}/dynos/{pid}", &DeleteProcesses{e}).Methods("DELETE")         // hk restart web
+	r.Handle("/apps/{app}/dynos", viewer(&GetProcesses{e})).Methods("GET")                       // hk dynos
+	r.Handle("/apps/{app}/dynos", deployer(&PostProcess{e})).Methods("POST")                     // hk run
+	r.Handle("/apps/{app}/dynos", deployer(&DeleteProcesses{e})).Methods("DELETE")               // hk restart
+	r.Handle("/apps/{app}/dynos/{ptype}.{pid}", deployer(&DeleteProcesses{e})).Methods("DELETE") // hk restart web.1
+	r.Handle("/apps/{app}/dynos/{pid}", deployer(&DeleteProcesses{e})).Methods("DELETE")         // hk restart web
+
+	// Costs
+	r.Handle("/cost", &GetCosts{e}).Methods("GET")                      // emp cost
+	r.Handle("/apps/{app}/cost", viewer(&GetAppCost{e})).Methods("GET") // emp cost -a <app>
+
+	// Dyno sizes
+	r.Handle("/dyno-sizes", &GetDynoSizes{e}).Methods("GET")       // emp sizes
+	r.Handle("/dyno-sizes/{size}", &GetDynoSize{e}).Methods("GET") // hk dyno-size-info
 
 	// Formations
-	r.Handle("/apps/{app}/formation", &GetFormation{e}).Methods("GET")     // hk scale -l
-	r.Handle("/apps/{app}/formation", &PatchFormation{e}).Methods("PATCH") // hk scale
+	r.Handle("/apps/{app}/formation", viewer(&GetFormation{e})).Methods("GET")                                 // hk scale -l
+	r.Handle("/apps/{app}/formation", deployer(&PatchFormation{e})).Methods("PATCH")                           // hk scale
+	r.Handle("/apps/{app}/formation/{process}/autoscaling", deployer(&PutAutoscaling{e})).Methods("PUT")       // emp autoscale
+	r.Handle("/apps/{app}/formation/{process}/autoscaling", deployer(&DeleteAutoscaling{e})).Methods("DELETE") // emp autoscale --disable
+
+	// Scale schedules
+	r.Handle("/apps/{app}/scale-schedules", viewer(&GetScaleSchedules{e})).Methods("GET")             // emp schedule-scales
+	r.Handle("/apps/{app}/scale-schedules", deployer(&PostScaleSchedules{e})).Methods("POST")         // emp schedule-scale
+	r.Handle("/apps/{app}/scale-schedules/{id}", deployer(&DeleteScaleSchedule{e})).Methods("DELETE") // emp schedule-scale-remove
+
+	// Manifests
+	r.Handle("/apps/{app}/manifest", viewer(&GetManifest{e})).Methods("GET") // emp export
+	r.Handle("/apps/{app}/manifest", &PutManifest{e}).Methods("PUT")         // emp apply
+
+	// Plans
+	r.Handle("/apps/{app}/plan", deployer(&GetPlan{e})).Methods("GET") // emp plan
+
+	// Drift
+	r.Handle("/apps/{app}/drift", viewer(&GetDrift{e})).Methods("GET")     // emp drift
+	r.Handle("/apps/{app}/drift", deployer(&PostDrift{e})).Methods("POST") // emp drift --fix
+
+	// Events
+	r.Handle("/events", &GetEvents{e}).Methods("GET")                      // emp history
+	r.Handle("/events/{id}/requeue", &PostEventRequeue{e}).Methods("POST") // emp event-requeue
+
+	// Access
+	r.Handle("/access", &GetAccessGrants{e}).Methods("GET")           // emp access
+	r.Handle("/access", &PostAccessGrants{e}).Methods("POST")         // emp access-add
+	r.Handle("/access/{id}", &DeleteAccessGrant{e}).Methods("DELETE") // emp access-remove
 
 	// OAuth
 	r.Handle("/oauth/authorizations", &PostAuthorizations{e}).Methods("POST")
@@ -74,7 +121,7 @@ func New(e *empire.Empire, authenticator auth.Authenticator) httpx.Handler {
 	r.Handle("/apps/{app}/ssl-endpoints/{cert}", sslRemoved).Methods("DELETE") // hk ssl-destroy
 
 	// Logs
-	r.Handle("/apps/{app}/log-sessions", &PostLogs{e}).Methods("POST") // hk log
+	r.Handle("/apps/{app}/log-sessions", viewer(&PostLogs{e})).Methods("POST") // hk log
 
 	api := Authenticate(r, authenticator)
 
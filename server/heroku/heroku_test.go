@@ -42,6 +42,7 @@ func TestError(t *testing.T) {
 		{ErrNotFound, 400, `{"id":"not_found","message":"Request failed, the specified resource does not exist","url":""}` + "\n", 404},
 		{&ErrorResource{Message: "custom"}, 400, `{"id":"","message":"custom","url":""}` + "\n", 400},
 		{&empire.ValidationError{Err: errors.New("boom")}, 500, `{"id":"bad_request","message":"Request invalid, validate usage and try again","url":""}` + "\n", 400},
+		{&empire.AccessDeniedError{User: &empire.User{Name: "ejholmes"}, App: "acme-inc", Role: empire.RoleAdmin}, 500, `{"id":"forbidden","message":"ejholmes does not have the admin role on acme-inc.","url":""}` + "\n", 403},
 	}
 
 	for _, tt := range tests {
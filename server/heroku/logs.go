@@ -36,7 +36,12 @@ func (h *PostLogs) ServeHTTPContext(ctx context.Context, w http.ResponseWriter,
 	// Prevent the ELB idle connection timeout to close the connection.
 	defer close(streamhttp.Heartbeat(rw, 10*time.Second))
 
-	err = h.StreamLogs(a, rw, time.Duration(form.Duration))
+	err = h.StreamLogs(ctx, empire.StreamLogsOpts{
+		User:     UserFromContext(ctx),
+		App:      a,
+		Output:   rw,
+		Duration: time.Duration(form.Duration),
+	})
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,114 @@
+package heroku
+
+import (
+	"net/http"
+
+	"github.com/remind101/empire"
+	"github.com/remind101/empire/pkg/heroku"
+	"github.com/remind101/pkg/httpx"
+	"golang.org/x/net/context"
+)
+
+func newManifest(m *empire.Manifest) *heroku.Manifest {
+	formation := make(map[string]heroku.ManifestProcess)
+	for name, p := range m.Formation {
+		formation[name] = heroku.ManifestProcess{
+			Quantity: p.Quantity,
+			Size:     p.Size,
+		}
+	}
+
+	return &heroku.Manifest{
+		Name:      m.Name,
+		Image:     m.Image,
+		Config:    m.Config,
+		Formation: formation,
+		Domains:   m.Domains,
+		Exposure:  m.Exposure,
+		Cert:      m.Cert,
+	}
+}
+
+func newManifestChanges(cs []*empire.ManifestChange) []heroku.ManifestChange {
+	changes := make([]heroku.ManifestChange, 0, len(cs))
+	for _, c := range cs {
+		changes = append(changes, heroku.ManifestChange{
+			Type:        c.Type,
+			Description: c.Description,
+		})
+	}
+	return changes
+}
+
+type GetManifest struct {
+	*empire.Empire
+}
+
+func (h *GetManifest) ServeHTTPContext(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	a, err := findApp(ctx, h)
+	if err != nil {
+		return err
+	}
+
+	m, err := h.Manifest(a)
+	if err != nil {
+		return err
+	}
+
+	w.WriteHeader(200)
+	return Encode(w, newManifest(m))
+}
+
+type PutManifest struct {
+	*empire.Empire
+}
+
+func (h *PutManifest) ServeHTTPContext(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	var form heroku.Manifest
+
+	if err := Decode(r, &form); err != nil {
+		return err
+	}
+
+	// The app doesn't need to exist, since applying the manifest will
+	// create it.
+	name := httpx.Vars(ctx)["app"]
+	if form.Name != "" && form.Name != name {
+		return ErrBadRequest
+	}
+
+	m, err := findMessage(r)
+	if err != nil {
+		return err
+	}
+
+	manifest := &empire.Manifest{
+		Name:     name,
+		Image:    form.Image,
+		Config:   form.Config,
+		Domains:  form.Domains,
+		Exposure: form.Exposure,
+		Cert:     form.Cert,
+	}
+	if form.Formation != nil {
+		manifest.Formation = make(map[string]empire.ManifestProcess)
+		for name, p := range form.Formation {
+			manifest.Formation[name] = empire.ManifestProcess{
+				Quantity: p.Quantity,
+				Size:     p.Size,
+			}
+		}
+	}
+
+	changes, err := h.Apply(ctx, empire.ApplyOpts{
+		User:     UserFromContext(ctx),
+		Manifest: manifest,
+		Message:  m,
+	})
+	if err != nil {
+		return err
+	}
+
+	w.WriteHeader(200)
+	return Encode(w, newManifestChanges(changes))
+}
@@ -0,0 +1,46 @@
+package heroku
+
+import (
+	"net/http"
+
+	"github.com/remind101/empire"
+	"github.com/remind101/empire/pkg/heroku"
+	"github.com/remind101/empire/scheduler"
+	"golang.org/x/net/context"
+)
+
+func newResourcePlan(p *scheduler.Plan) *heroku.ResourcePlan {
+	changes := make([]heroku.ResourceChange, 0, len(p.Changes))
+	for _, c := range p.Changes {
+		changes = append(changes, heroku.ResourceChange{
+			Action:      c.Action,
+			Name:        c.Name,
+			Type:        c.Type,
+			ID:          c.ID,
+			Conditional: c.Conditional,
+		})
+	}
+	return &heroku.ResourcePlan{Changes: changes}
+}
+
+type GetPlan struct {
+	*empire.Empire
+}
+
+func (h *GetPlan) ServeHTTPContext(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	a, err := findApp(ctx, h)
+	if err != nil {
+		return err
+	}
+
+	plan, err := h.Plan(ctx, empire.PlanOpts{
+		User: UserFromContext(ctx),
+		App:  a,
+	})
+	if err != nil {
+		return err
+	}
+
+	w.WriteHeader(200)
+	return Encode(w, newResourcePlan(plan))
+}
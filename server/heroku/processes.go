@@ -16,22 +16,22 @@ import (
 
 type Dyno heroku.Dyno
 
-func newDyno(task *empire.Task) *Dyno {
+func newDyno(sizes empire.DynoSizes, task *empire.Task) *Dyno {
 	return &Dyno{
 		Command:   task.Command.String(),
 		Type:      task.Type,
 		Name:      task.Name,
 		State:     task.State,
-		Size:      task.Constraints.String(),
+		Size:      sizes.Format(task.Constraints),
 		UpdatedAt: task.UpdatedAt,
 	}
 }
 
-func newDynos(tasks []*empire.Task) []*Dyno {
+func newDynos(sizes empire.DynoSizes, tasks []*empire.Task) []*Dyno {
 	dynos := make([]*Dyno, len(tasks))
 
 	for i := 0; i < len(tasks); i++ {
-		dynos[i] = newDyno(tasks[i])
+		dynos[i] = newDyno(sizes, tasks[i])
 	}
 
 	return dynos
@@ -54,14 +54,14 @@ func (h *GetProcesses) ServeHTTPContext(ctx context.Context, w http.ResponseWrit
 	}
 
 	w.WriteHeader(200)
-	return Encode(w, newDynos(js))
+	return Encode(w, newDynos(h.DynoSizes(), js))
 }
 
 type PostProcessForm struct {
-	Command string              `json:"command"`
-	Attach  bool                `json:"attach"`
-	Env     map[string]string   `json:"env"`
-	Size    *empire.Constraints `json:"size"`
+	Command string            `json:"command"`
+	Attach  bool              `json:"attach"`
+	Env     map[string]string `json:"env"`
+	Size    *string           `json:"size"`
 }
 
 type PostProcess struct {
@@ -90,12 +90,17 @@ func (h *PostProcess) ServeHTTPContext(ctx context.Context, w http.ResponseWrite
 		return err
 	}
 
+	constraints, err := parseSize(h.Empire, form.Size)
+	if err != nil {
+		return err
+	}
+
 	opts := empire.RunOpts{
 		User:        UserFromContext(ctx),
 		App:         a,
 		Command:     command,
 		Env:         form.Env,
-		Constraints: form.Size,
+		Constraints: constraints,
 		Message:     m,
 	}
 
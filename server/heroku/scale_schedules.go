@@ -0,0 +1,114 @@
+package heroku
+
+import (
+	"net/http"
+
+	"github.com/remind101/empire"
+	"github.com/remind101/empire/pkg/heroku"
+	"github.com/remind101/pkg/httpx"
+	"golang.org/x/net/context"
+)
+
+type ScaleSchedule heroku.ScaleSchedule
+
+func newScaleSchedule(s *empire.ScaleSchedule) *ScaleSchedule {
+	return &ScaleSchedule{
+		Id:        s.ID,
+		Schedule:  s.Schedule,
+		Formation: s.Formation,
+		Restore:   s.Restore,
+		NextRunAt: s.NextRunAt,
+		LastRunAt: s.LastRunAt,
+		CreatedAt: *s.CreatedAt,
+	}
+}
+
+func newScaleSchedules(ss []*empire.ScaleSchedule) []*ScaleSchedule {
+	schedules := make([]*ScaleSchedule, len(ss))
+
+	for i := 0; i < len(ss); i++ {
+		schedules[i] = newScaleSchedule(ss[i])
+	}
+
+	return schedules
+}
+
+type GetScaleSchedules struct {
+	*empire.Empire
+}
+
+func (h *GetScaleSchedules) ServeHTTPContext(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	a, err := findApp(ctx, h)
+	if err != nil {
+		return err
+	}
+
+	schedules, err := h.ScaleSchedules(empire.ScaleSchedulesQuery{App: a})
+	if err != nil {
+		return err
+	}
+
+	w.WriteHeader(200)
+	return Encode(w, newScaleSchedules(schedules))
+}
+
+type PostScaleSchedules struct {
+	*empire.Empire
+}
+
+func (h *PostScaleSchedules) ServeHTTPContext(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	var form heroku.ScaleScheduleCreateOpts
+
+	if err := Decode(r, &form); err != nil {
+		return err
+	}
+
+	a, err := findApp(ctx, h)
+	if err != nil {
+		return err
+	}
+
+	s, err := h.ScaleSchedulesCreate(ctx, empire.ScaleSchedulesCreateOpts{
+		User: UserFromContext(ctx),
+		Schedule: &empire.ScaleSchedule{
+			App:       a,
+			Schedule:  form.Schedule,
+			Formation: form.Formation,
+			Restore:   form.Restore,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	w.WriteHeader(201)
+	return Encode(w, newScaleSchedule(s))
+}
+
+type DeleteScaleSchedule struct {
+	*empire.Empire
+}
+
+func (h *DeleteScaleSchedule) ServeHTTPContext(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	a, err := findApp(ctx, h)
+	if err != nil {
+		return err
+	}
+
+	id := httpx.Vars(ctx)["id"]
+
+	s, err := h.ScaleSchedulesFind(empire.ScaleSchedulesQuery{ID: &id, App: a})
+	if err != nil {
+		return err
+	}
+	s.App = a
+
+	if err := h.ScaleSchedulesDestroy(ctx, empire.ScaleSchedulesDestroyOpts{
+		User:     UserFromContext(ctx),
+		Schedule: s,
+	}); err != nil {
+		return err
+	}
+
+	return NoContent(w)
+}
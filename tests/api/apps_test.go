@@ -6,8 +6,12 @@ import (
 	"testing"
 
 	"github.com/remind101/empire"
+	"github.com/remind101/empire/empiretest"
 	"github.com/remind101/empire/pkg/heroku"
+	"github.com/remind101/empire/server"
+	"github.com/remind101/empire/server/auth"
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
 )
 
 func TestAppCreate(t *testing.T) {
@@ -43,6 +47,74 @@ func TestAttachCert(t *testing.T) {
 	assert.Equal(t, cert, app.Cert)
 }
 
+func TestAppRename(t *testing.T) {
+	c, s := NewTestClient(t)
+	defer s.Close()
+
+	mustAppCreate(t, c, empire.App{
+		Name: "acme-inc",
+	})
+
+	app, err := c.AppRename("acme-inc", "acme", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "acme", app.Name)
+
+	// The old name is an alias for the app.
+	app, err = c.AppInfo("acme-inc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "acme", app.Name)
+}
+
+func TestAppRename_AccessControl(t *testing.T) {
+	e := empiretest.NewEmpire(t)
+	e.AccessControl = true
+	e.Admins = []string{"phobologic"}
+
+	admin := &empire.User{Name: "phobologic"}
+	user := &empire.User{Name: "ejholmes"}
+
+	s := empiretest.NewTestServer(t, e, server.Options{
+		Authenticator: auth.Anyone(user),
+	})
+	defer s.Close()
+
+	c := &heroku.Client{}
+	c.URL = s.URL
+
+	app, err := e.Create(context.Background(), empire.CreateOpts{
+		User: admin,
+		Name: "acme-inc",
+	})
+	assert.NoError(t, err)
+
+	_, err = e.AccessGrantsCreate(context.Background(), empire.AccessGrantsCreateOpts{
+		User:  admin,
+		Grant: &empire.AccessGrant{User: "ejholmes", App: "acme-*", Role: empire.RoleViewer},
+	})
+	assert.NoError(t, err)
+
+	_, err = c.AppInfo("acme-inc")
+	assert.NoError(t, err)
+
+	err = e.Rename(context.Background(), empire.RenameOpts{
+		User: admin,
+		App:  app,
+		Name: "billing",
+	})
+	assert.NoError(t, err)
+
+	// The grant matches the previous name, but not the app's current
+	// name.
+	_, err = c.AppInfo("acme-inc")
+	assert.Error(t, err)
+	_, err = c.AppInfo("billing")
+	assert.Error(t, err)
+}
+
 func TestAppList(t *testing.T) {
 	c, s := NewTestClient(t)
 	defer s.Close()
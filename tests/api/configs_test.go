@@ -95,3 +95,51 @@ func mustConfigVarInfo(t testing.TB, c *heroku.Client, appName string) map[strin
 
 	return vars
 }
+
+func TestConfigVarHistory(t *testing.T) {
+	c, s := NewTestClient(t)
+	defer s.Close()
+
+	mustDeploy(t, c, DefaultImage)
+
+	env := "production"
+	mustConfigVarUpdate(t, c, "acme-inc", map[string]*string{
+		"RAILS_ENV": &env,
+	})
+
+	env = "staging"
+	mustConfigVarUpdate(t, c, "acme-inc", map[string]*string{
+		"RAILS_ENV": &env,
+	})
+
+	history, err := c.ConfigVarHistory("acme-inc", false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := len(history), 2; got != want {
+		t.Fatalf("len(history) => %d; want %d", got, want)
+	}
+
+	production, staging := "production", "staging"
+	expected := []heroku.ConfigVarChange{
+		{Name: "RAILS_ENV", Change: "changed", OldValue: &production, NewValue: &staging},
+	}
+
+	if got, want := history[0].Changes, expected; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Changes => %v; want %v", got, want)
+	}
+
+	changes, err := c.ConfigVarDiff("acme-inc", 1, 3, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = []heroku.ConfigVarChange{
+		{Name: "RAILS_ENV", Change: "added"},
+	}
+
+	if got, want := changes, expected; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Changes => %v; want %v", got, want)
+	}
+}
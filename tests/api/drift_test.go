@@ -0,0 +1,23 @@
+package api_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDriftInfo(t *testing.T) {
+	c, s := NewTestClient(t)
+	defer s.Close()
+
+	mustDeploy(t, c, DefaultImage)
+
+	d, err := c.DriftInfo("acme-inc")
+	assert.NoError(t, err)
+	assert.False(t, d.Drifted)
+	assert.Equal(t, 1, d.Release)
+
+	d, err = c.DriftReconcile("acme-inc", "")
+	assert.NoError(t, err)
+	assert.False(t, d.Drifted)
+}
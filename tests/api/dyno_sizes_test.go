@@ -0,0 +1,33 @@
+package api_test
+
+import (
+	"testing"
+
+	"github.com/remind101/empire/pkg/heroku"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDynoSizeList(t *testing.T) {
+	c, s := NewTestClient(t)
+	defer s.Close()
+
+	sizes, err := c.DynoSizeList(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []heroku.DynoSize{
+		{Id: "1X", Name: "1X", Compute: 256, Memory: 0.5, Nproc: 256},
+		{Id: "2X", Name: "2X", Compute: 512, Memory: 1, Nproc: 512},
+		{Id: "PX", Name: "PX", Compute: 1024, Memory: 6},
+	}, sizes)
+}
+
+func TestDynoSizeInfo(t *testing.T) {
+	c, s := NewTestClient(t)
+	defer s.Close()
+
+	size, err := c.DynoSizeInfo("2x")
+	assert.NoError(t, err)
+	assert.Equal(t, "2X", size.Name)
+
+	_, err = c.DynoSizeInfo("4X")
+	assert.Error(t, err)
+}
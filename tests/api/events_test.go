@@ -0,0 +1,49 @@
+package api_test
+
+import (
+	"testing"
+
+	"github.com/remind101/empire/pkg/heroku"
+)
+
+func TestEventList(t *testing.T) {
+	c, s := NewTestClient(t)
+	defer s.Close()
+
+	mustDeploy(t, c, DefaultImage)
+
+	q := 2
+	mustFormationBatchUpdate(t, c, "acme-inc", []heroku.FormationBatchUpdateOpts{
+		{
+			Process:  "web",
+			Quantity: &q,
+		},
+	})
+
+	events, err := c.EventList(&heroku.EventListOpts{App: "acme-inc"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := len(events), 2; got != want {
+		t.Fatalf("len(events) => %d; want %d", got, want)
+	}
+
+	// Most recent first.
+	if got, want := events[0].Type, "scale"; got != want {
+		t.Fatalf("Type => %s; want %s", got, want)
+	}
+
+	if got, want := events[0].User, "fake"; got != want {
+		t.Fatalf("User => %s; want %s", got, want)
+	}
+
+	events, err = c.EventList(&heroku.EventListOpts{Type: "deploy"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := len(events), 1; got != want {
+		t.Fatalf("len(events) => %d; want %d", got, want)
+	}
+}
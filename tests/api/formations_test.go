@@ -25,6 +25,57 @@ func TestFormationBatchUpdate(t *testing.T) {
 	}
 }
 
+func TestFormationAutoscale(t *testing.T) {
+	c, s := NewTestClient(t)
+	defer s.Close()
+
+	mustDeploy(t, c, DefaultImage)
+
+	f, err := c.FormationAutoscale("acme-inc", "web", &heroku.FormationAutoscaling{
+		Min: 2,
+		Max: 20,
+		CPU: 60,
+	}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The quantity is raised to the minimum.
+	if got, want := f.Quantity, 2; got != want {
+		t.Fatalf("Quantity => %d; want %d", got, want)
+	}
+
+	formations, err := c.FormationList("acme-inc", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, f := range formations {
+		if f.Type != "web" {
+			continue
+		}
+		if got, want := f.Autoscaling, (&heroku.FormationAutoscaling{Min: 2, Max: 20, CPU: 60}); *got != *want {
+			t.Fatalf("Autoscaling => %#v; want %#v", got, want)
+		}
+	}
+
+	// Scaling outside of the bounds isn't allowed.
+	q := 30
+	if _, err := c.FormationBatchUpdate("acme-inc", []heroku.FormationBatchUpdateOpts{
+		{Process: "web", Quantity: &q},
+	}, ""); err == nil {
+		t.Fatal("Expected an error when scaling outside of the autoscaling bounds")
+	}
+
+	if err := c.FormationAutoscaleDisable("acme-inc", "web", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	mustFormationBatchUpdate(t, c, "acme-inc", []heroku.FormationBatchUpdateOpts{
+		{Process: "web", Quantity: &q},
+	})
+}
+
 func mustFormationBatchUpdate(t testing.TB, c *heroku.Client, appName string, updates []heroku.FormationBatchUpdateOpts) []heroku.Formation {
 	f, err := c.FormationBatchUpdate(appName, updates, "")
 	if err != nil {
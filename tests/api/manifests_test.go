@@ -0,0 +1,69 @@
+package api_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/remind101/empire/pkg/heroku"
+)
+
+func TestManifestApply(t *testing.T) {
+	c, s := NewTestClient(t)
+	defer s.Close()
+
+	manifest := &heroku.Manifest{
+		Name:  "acme-inc",
+		Image: DefaultImage,
+		Config: map[string]string{
+			"RAILS_ENV": "production",
+		},
+		Formation: map[string]heroku.ManifestProcess{
+			"web": {Quantity: 2, Size: "2X"},
+		},
+		Domains: []string{"acme-inc.example.com"},
+	}
+
+	changes, err := c.ManifestApply("acme-inc", manifest, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []heroku.ManifestChange{
+		{Type: "create", Description: "create acme-inc"},
+		{Type: "image", Description: "deploy " + DefaultImage},
+		{Type: "config", Description: "set RAILS_ENV"},
+		{Type: "scale", Description: "scale web to 2"},
+		{Type: "domain", Description: "add domain acme-inc.example.com"},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Fatalf("changes => %#v; want %#v", changes, want)
+	}
+
+	exported, err := c.ManifestInfo("acme-inc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := exported.Formation["web"], (heroku.ManifestProcess{Quantity: 2, Size: "2X"}); got != want {
+		t.Fatalf("Formation[web] => %#v; want %#v", got, want)
+	}
+
+	if got, want := exported.Exposure, "public"; got != want {
+		t.Fatalf("Exposure => %s; want %s", got, want)
+	}
+
+	// Applying the exported manifest doesn't change anything.
+	changes, err = c.ManifestApply("acme-inc", exported, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := len(changes), 0; got != want {
+		t.Fatalf("len(changes) => %d; want %d", got, want)
+	}
+
+	releases := mustReleaseList(t, c, "acme-inc")
+	if got, want := len(releases), 1; got != want {
+		t.Fatalf("len(releases) => %d; want %d", got, want)
+	}
+}
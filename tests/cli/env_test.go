@@ -113,3 +113,33 @@ func TestConfigConsistency(t *testing.T) {
 		},
 	})
 }
+
+func TestConfigDiff(t *testing.T) {
+	run(t, []Command{
+		DeployCommand("latest", "v1"),
+		{
+			"set FOO1=foo1 -a acme-inc",
+			"Set env vars and restarted acme-inc.",
+		},
+		{
+			"set FOO1=bar FOO2=foo2 -a acme-inc",
+			"Set env vars and restarted acme-inc.",
+		},
+		{
+			"unset FOO1 -a acme-inc",
+			"Unset env vars and restarted acme-inc.",
+		},
+		{
+			"env-diff v2 v3 -a acme-inc",
+			"~ FOO1=foo1 -> bar\n+ FOO2=foo2",
+		},
+		{
+			"env-diff v1 v4 -a acme-inc",
+			"+ FOO2=foo2",
+		},
+		{
+			"env-diff --masked v2 v4 -a acme-inc",
+			"- FOO1\n+ FOO2",
+		},
+	})
+}
@@ -1,6 +1,7 @@
 package empire_test
 
 import (
+	"bytes"
 	"errors"
 	"io"
 	"io/ioutil"
@@ -12,6 +13,7 @@ import (
 
 	"github.com/remind101/empire"
 	"github.com/remind101/empire/empiretest"
+	"github.com/remind101/empire/pkg/headerutil"
 	"github.com/remind101/empire/pkg/image"
 	"github.com/remind101/empire/procfile"
 	"github.com/remind101/empire/scheduler"
@@ -60,6 +62,130 @@ func TestEmpire_AccessTokens(t *testing.T) {
 	assert.Equal(t, empire.ErrUserName, err)
 }
 
+func TestEmpire_AccessControl(t *testing.T) {
+	e := empiretest.NewEmpire(t)
+	e.AccessControl = true
+	e.Admins = []string{"remind101/ops"}
+
+	admin := &empire.User{Name: "phobologic", Teams: []string{"remind101/ops"}}
+	user := &empire.User{Name: "ejholmes", Teams: []string{"remind101/engineering"}}
+
+	app, err := e.Create(context.Background(), empire.CreateOpts{
+		User: admin,
+		Name: "acme-inc",
+	})
+	assert.NoError(t, err)
+
+	prod := "production"
+	setOpts := empire.SetOpts{
+		User: user,
+		App:  app,
+		Vars: empire.Vars{"RAILS_ENV": &prod},
+	}
+
+	_, err = e.Set(context.Background(), setOpts)
+	assert.IsType(t, &empire.AccessDeniedError{}, err)
+
+	// Only admins can grant access.
+	_, err = e.AccessGrantsCreate(context.Background(), empire.AccessGrantsCreateOpts{
+		User:  user,
+		Grant: &empire.AccessGrant{User: "ejholmes", App: "acme-*", Role: empire.RoleAdmin},
+	})
+	assert.IsType(t, &empire.AccessDeniedError{}, err)
+
+	grant, err := e.AccessGrantsCreate(context.Background(), empire.AccessGrantsCreateOpts{
+		User:  admin,
+		Grant: &empire.AccessGrant{Team: "remind101/engineering", App: "acme-*", Role: empire.RoleDeployer},
+	})
+	assert.NoError(t, err)
+
+	_, err = e.Set(context.Background(), setOpts)
+	assert.NoError(t, err)
+
+	// Deployers can't destroy apps.
+	err = e.Destroy(context.Background(), empire.DestroyOpts{
+		User: user,
+		App:  app,
+	})
+	assert.IsType(t, &empire.AccessDeniedError{}, err)
+
+	// Or manage domains, certificates and automatic rollbacks.
+	_, err = e.DomainsCreate(context.Background(), empire.DomainsCreateOpts{
+		User:   user,
+		App:    app,
+		Domain: &empire.Domain{AppID: app.ID, Hostname: "acme.example.com"},
+	})
+	assert.IsType(t, &empire.AccessDeniedError{}, err)
+
+	err = e.CertsAttach(context.Background(), empire.CertsAttachOpts{
+		User: user,
+		App:  app,
+		Cert: "serverCertificate",
+	})
+	assert.IsType(t, &empire.AccessDeniedError{}, err)
+
+	err = e.SetAutoRollback(context.Background(), empire.SetAutoRollbackOpts{
+		User:    user,
+		App:     app,
+		Enabled: true,
+	})
+	assert.IsType(t, &empire.AccessDeniedError{}, err)
+
+	// Users only see the grants on apps they administer, and their own.
+	grants, err := e.AccessGrantsVisible(user)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(grants))
+
+	grants, err = e.AccessGrantsVisible(&empire.User{Name: "bob"})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(grants))
+
+	err = e.AccessGrantsDestroy(context.Background(), empire.AccessGrantsDestroyOpts{
+		User:  admin,
+		Grant: grant,
+	})
+	assert.NoError(t, err)
+
+	_, err = e.Set(context.Background(), setOpts)
+	assert.IsType(t, &empire.AccessDeniedError{}, err)
+}
+
+func TestEmpire_AuditEventsVisible(t *testing.T) {
+	e := empiretest.NewEmpire(t)
+	e.AccessControl = true
+	e.Admins = []string{"remind101/ops"}
+
+	admin := &empire.User{Name: "phobologic", Teams: []string{"remind101/ops"}}
+	user := &empire.User{Name: "ejholmes"}
+
+	for _, name := range []string{"acme-inc", "acme-api"} {
+		_, err := e.Create(context.Background(), empire.CreateOpts{
+			User: admin,
+			Name: name,
+		})
+		assert.NoError(t, err)
+	}
+
+	_, err := e.AccessGrantsCreate(context.Background(), empire.AccessGrantsCreateOpts{
+		User:  admin,
+		Grant: &empire.AccessGrant{User: "ejholmes", App: "acme-inc", Role: empire.RoleViewer},
+	})
+	assert.NoError(t, err)
+
+	// The most recent event is for acme-api, which the user can't view, so
+	// it shouldn't take up the page.
+	max := 1
+	typ := "create"
+	events, err := e.AuditEventsVisible(user, empire.AuditEventsQuery{Type: &typ, Range: headerutil.Range{Max: &max}})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(events))
+	assert.Equal(t, "acme-inc", events[0].App)
+
+	events, err = e.AuditEventsVisible(&empire.User{Name: "bob"}, empire.AuditEventsQuery{Type: &typ})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(events))
+}
+
 func TestEmpire_CertsAttach(t *testing.T) {
 	e := empiretest.NewEmpire(t)
 	s := new(mockScheduler)
@@ -74,7 +200,11 @@ func TestEmpire_CertsAttach(t *testing.T) {
 	assert.NoError(t, err)
 
 	cert := "serverCertificate"
-	err = e.CertsAttach(context.Background(), app, cert)
+	err = e.CertsAttach(context.Background(), empire.CertsAttachOpts{
+		User: user,
+		App:  app,
+		Cert: cert,
+	})
 	assert.NoError(t, err)
 
 	app, err = e.AppsFind(empire.AppsQuery{ID: &app.ID})
@@ -179,6 +309,175 @@ func TestEmpire_Deploy(t *testing.T) {
 	s.AssertExpectations(t)
 }
 
+func TestEmpire_Deploy_ReleaseProcess(t *testing.T) {
+	e := empiretest.NewEmpire(t)
+	s := new(mockScheduler)
+	e.Scheduler = s
+	e.ProcfileExtractor = empiretest.ExtractProcfile(procfile.ExtendedProcfile{
+		"web": procfile.Process{
+			Command: []string{"./bin/web"},
+		},
+		"release": procfile.Process{
+			Command: []string{"./bin/release"},
+		},
+	})
+
+	user := &empire.User{Name: "ejholmes"}
+
+	app, err := e.Create(context.Background(), empire.CreateOpts{
+		User: user,
+		Name: "acme-inc",
+	})
+	assert.NoError(t, err)
+
+	img := image.Image{Repository: "remind101/acme-inc"}
+
+	// When the release process fails, the deploy is aborted.
+	s.On("Run", mock.Anything, mock.Anything, nil, mock.Anything).Return(&scheduler.ExitError{Code: 1}).Once()
+
+	_, err = e.Deploy(context.Background(), empire.DeployOpts{
+		App:    app,
+		User:   user,
+		Output: empire.NewDeploymentStream(ioutil.Discard),
+		Image:  img,
+	})
+	assert.EqualError(t, err, "release process failed: process exited with status 1")
+
+	releases, err := e.Releases(empire.ReleasesQuery{App: app})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(releases))
+
+	// When the release process succeeds, the release is submitted without
+	// the release process.
+	s.On("Run", mock.Anything, &scheduler.Process{
+		Type:        "release",
+		Image:       img,
+		Command:     []string{"./bin/release"},
+		Instances:   1,
+		MemoryLimit: 536870912,
+		CPUShares:   256,
+		Nproc:       256,
+		Env: map[string]string{
+			"EMPIRE_PROCESS": "release",
+			"SOURCE":         "acme-inc.release.v1",
+		},
+		Labels: map[string]string{
+			"empire.app.process": "release",
+		},
+	}, nil, mock.Anything).Return(nil).Once()
+
+	s.On("Submit", &scheduler.App{
+		ID:      app.ID,
+		Name:    "acme-inc",
+		Release: "v1",
+		Env: map[string]string{
+			"EMPIRE_APPID":   app.ID,
+			"EMPIRE_APPNAME": "acme-inc",
+			"EMPIRE_RELEASE": "v1",
+		},
+		Labels: map[string]string{
+			"empire.app.name":    "acme-inc",
+			"empire.app.id":      app.ID,
+			"empire.app.release": "v1",
+		},
+		Processes: []*scheduler.Process{
+			{
+				Type:    "web",
+				Image:   img,
+				Command: []string{"./bin/web"},
+				Exposure: &scheduler.Exposure{
+					Type: &scheduler.HTTPExposure{},
+				},
+				Instances:   1,
+				MemoryLimit: 536870912,
+				CPUShares:   256,
+				Nproc:       256,
+				Env: map[string]string{
+					"EMPIRE_PROCESS": "web",
+					"SOURCE":         "acme-inc.web.v1",
+				},
+				Labels: map[string]string{
+					"empire.app.process": "web",
+				},
+			},
+		},
+	}).Return(nil)
+
+	_, err = e.Deploy(context.Background(), empire.DeployOpts{
+		App:    app,
+		User:   user,
+		Output: empire.NewDeploymentStream(ioutil.Discard),
+		Image:  img,
+	})
+	assert.NoError(t, err)
+
+	s.AssertExpectations(t)
+}
+
+func TestEmpire_Deploy_Plan(t *testing.T) {
+	e := empiretest.NewEmpire(t)
+	s := new(mockPlanner)
+	e.Scheduler = s
+	e.ProcfileExtractor = empiretest.ExtractProcfile(procfile.ExtendedProcfile{
+		"web": procfile.Process{
+			Command: []string{"./bin/web"},
+		},
+	})
+
+	user := &empire.User{Name: "ejholmes"}
+
+	app, err := e.Create(context.Background(), empire.CreateOpts{
+		User: user,
+		Name: "acme-inc",
+	})
+	assert.NoError(t, err)
+
+	s.On("Plan", mock.Anything).Return(&scheduler.Plan{
+		Changes: []*scheduler.ResourceChange{
+			{Action: scheduler.ActionReplace, Name: "webLoadBalancer", Type: "AWS::ElasticLoadBalancing::LoadBalancer"},
+		},
+	}, nil)
+
+	w := new(bytes.Buffer)
+	_, err = e.Deploy(context.Background(), empire.DeployOpts{
+		App:    app,
+		User:   user,
+		Output: empire.NewDeploymentStream(w),
+		Image:  image.Image{Repository: "remind101/acme-inc"},
+		Plan:   true,
+	})
+	assert.NoError(t, err)
+	assert.Contains(t, w.String(), "Status: Replace webLoadBalancer (AWS::ElasticLoadBalancing::LoadBalancer)")
+
+	// Nothing should have been released.
+	releases, err := e.Releases(empire.ReleasesQuery{App: app})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(releases))
+
+	s.AssertExpectations(t)
+}
+
+func TestEmpire_Deploy_Plan_NotSupported(t *testing.T) {
+	e := empiretest.NewEmpire(t)
+	s := new(mockScheduler)
+	e.Scheduler = s
+
+	_, err := e.Deploy(context.Background(), empire.DeployOpts{
+		User:   &empire.User{Name: "ejholmes"},
+		Output: empire.NewDeploymentStream(ioutil.Discard),
+		Image:  image.Image{Repository: "remind101/acme-inc"},
+		Plan:   true,
+	})
+	assert.IsType(t, &empire.ValidationError{}, err)
+
+	// The app shouldn't have been created.
+	apps, err := e.Apps(empire.AppsQuery{})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(apps))
+
+	s.AssertExpectations(t)
+}
+
 func TestEmpire_Deploy_ImageNotFound(t *testing.T) {
 	e := empiretest.NewEmpire(t)
 	s := new(mockScheduler)
@@ -271,44 +570,184 @@ func TestEmpire_Deploy_Concurrent(t *testing.T) {
 	s.AssertExpectations(t)
 }
 
-func TestEmpire_Run(t *testing.T) {
+func TestEmpire_Deploy_Canary(t *testing.T) {
 	e := empiretest.NewEmpire(t)
+	s := new(mockScheduler)
+	e.Scheduler = s
+	e.ProcfileExtractor = empiretest.ExtractProcfile(procfile.ExtendedProcfile{
+		"web": procfile.Process{
+			Command: []string{"./bin/web"},
+		},
+	})
 
 	user := &empire.User{Name: "ejholmes"}
 
-	app, err := e.Create(context.Background(), empire.CreateOpts{
+	var submitted []*scheduler.App
+	s.On("Submit", mock.AnythingOfType("*scheduler.App")).Return(nil).Run(func(args mock.Arguments) {
+		submitted = append(submitted, args.Get(0).(*scheduler.App))
+	})
+
+	deploy := func(tag string, strategy empire.DeployStrategy) (*empire.Release, error) {
+		return e.Deploy(context.Background(), empire.DeployOpts{
+			User:     user,
+			Output:   empire.NewDeploymentStream(ioutil.Discard),
+			Image:    image.Image{Repository: "remind101/acme-inc", Tag: tag},
+			Strategy: strategy,
+		})
+	}
+
+	// The first release can't be a canary.
+	_, err := deploy("v1", empire.DeployStrategy{Canary: 10})
+	assert.IsType(t, &empire.ValidationError{}, err)
+
+	r, err := deploy("v1", empire.DeployStrategy{})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, r.Version)
+	app := r.App
+
+	r, err = deploy("v2", empire.DeployStrategy{Canary: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, r.Version)
+
+	canary := submitted[len(submitted)-1]
+	assert.Equal(t, "v2", canary.Release)
+	if assert.NotNil(t, canary.Stable) {
+		assert.Equal(t, "v1", canary.Stable.Release)
+	}
+
+	// New releases can't be created while the canary is in progress.
+	_, err = deploy("v3", empire.DeployStrategy{})
+	assert.IsType(t, &empire.ValidationError{}, err)
+
+	r, err = e.Promote(context.Background(), empire.PromoteOpts{
 		User: user,
-		Name: "acme-inc",
+		App:  app,
 	})
 	assert.NoError(t, err)
+	assert.Equal(t, 2, r.Version)
+	assert.Nil(t, submitted[len(submitted)-1].Stable)
 
-	img := image.Image{Repository: "remind101/acme-inc"}
-	_, err = e.Deploy(context.Background(), empire.DeployOpts{
-		App:    app,
-		User:   user,
-		Output: empire.NewDeploymentStream(ioutil.Discard),
-		Image:  img,
+	r, err = deploy("v3", empire.DeployStrategy{BlueGreen: true})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, r.Version)
+
+	r, err = e.Abort(context.Background(), empire.AbortOpts{
+		User: user,
+		App:  app,
 	})
 	assert.NoError(t, err)
+	assert.Equal(t, 4, r.Version)
+	assert.Equal(t, "remind101/acme-inc:v2", r.Slug.Image.String())
+
+	_, err = e.Abort(context.Background(), empire.AbortOpts{
+		User: user,
+		App:  app,
+	})
+	assert.Equal(t, empire.ErrNoCanary, err)
+
+	s.AssertExpectations(t)
+}
 
+func TestEmpire_Deploy_AutoRollback(t *testing.T) {
+	e := empiretest.NewEmpire(t)
 	s := new(mockScheduler)
 	e.Scheduler = s
-
-	s.On("Run", &scheduler.App{
-		ID:      app.ID,
-		Name:    "acme-inc",
-		Release: "v1",
-		Env: map[string]string{
-			"EMPIRE_APPID":   app.ID,
-			"EMPIRE_APPNAME": "acme-inc",
-			"EMPIRE_RELEASE": "v1",
-		},
-		Labels: map[string]string{
-			"empire.app.name":    "acme-inc",
-			"empire.app.id":      app.ID,
-			"empire.app.release": "v1",
+	e.ProcfileExtractor = empiretest.ExtractProcfile(procfile.ExtendedProcfile{
+		"web": procfile.Process{
+			Command: []string{"./bin/web"},
 		},
-	},
+	})
+
+	user := &empire.User{Name: "ejholmes"}
+
+	deploy := func(tag string) (*empire.Release, error) {
+		return e.Deploy(context.Background(), empire.DeployOpts{
+			User:   user,
+			Output: empire.NewDeploymentStream(ioutil.Discard),
+			Image:  image.Image{Repository: "remind101/acme-inc", Tag: tag},
+		})
+	}
+
+	s.On("Submit", mock.AnythingOfType("*scheduler.App")).Return(nil).Once()
+
+	r, err := deploy("v1")
+	assert.NoError(t, err)
+	app := r.App
+
+	err = e.SetAutoRollback(context.Background(), empire.SetAutoRollbackOpts{
+		User:    user,
+		App:     app,
+		Enabled: true,
+	})
+	assert.NoError(t, err)
+
+	unstable := &scheduler.UnstableError{Err: errors.New("timed out")}
+	s.On("Submit", mock.AnythingOfType("*scheduler.App")).Return(unstable).Once()
+	s.On("Submit", mock.AnythingOfType("*scheduler.App")).Return(nil).Once()
+
+	_, err = deploy("v2")
+	assert.Equal(t, unstable, err)
+
+	r, err = e.ReleasesFind(empire.ReleasesQuery{App: app})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, r.Version)
+	assert.Equal(t, "remind101/acme-inc:v1", r.Slug.Image.String())
+	assert.Equal(t, "Rollback to v1 (automatic: release failed to stabilize: timed out) (ejholmes)", r.Description)
+
+	typ := "auto_rollback"
+	events, err := e.AuditEvents(empire.AuditEventsQuery{Type: &typ})
+	assert.NoError(t, err)
+	if assert.Equal(t, 1, len(events)) {
+		assert.Equal(t, "acme-inc was automatically rolled back to v1 after a deploy by ejholmes: release failed to stabilize: timed out", events[0].Message)
+	}
+
+	// The deploy that was rolled back is still recorded.
+	typ = "deploy"
+	events, err = e.AuditEvents(empire.AuditEventsQuery{Type: &typ, App: &app.Name})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(events))
+
+	s.AssertExpectations(t)
+}
+
+func TestEmpire_Run(t *testing.T) {
+	e := empiretest.NewEmpire(t)
+
+	user := &empire.User{Name: "ejholmes"}
+
+	app, err := e.Create(context.Background(), empire.CreateOpts{
+		User: user,
+		Name: "acme-inc",
+	})
+	assert.NoError(t, err)
+
+	img := image.Image{Repository: "remind101/acme-inc"}
+	_, err = e.Deploy(context.Background(), empire.DeployOpts{
+		App:    app,
+		User:   user,
+		Output: empire.NewDeploymentStream(ioutil.Discard),
+		Image:  img,
+	})
+	assert.NoError(t, err)
+
+	s := new(mockScheduler)
+	e.Scheduler = s
+
+	s.On("Run", &scheduler.App{
+		ID:      app.ID,
+		Name:    "acme-inc",
+		Release: "v1",
+		Env: map[string]string{
+			"EMPIRE_APPID":   app.ID,
+			"EMPIRE_APPNAME": "acme-inc",
+			"EMPIRE_RELEASE": "v1",
+		},
+		Labels: map[string]string{
+			"empire.app.name":    "acme-inc",
+			"empire.app.id":      app.ID,
+			"empire.app.release": "v1",
+		},
+	},
 		&scheduler.Process{
 			Type:        "run",
 			Image:       img,
@@ -401,7 +840,7 @@ func TestEmpire_Run_WithConstraints(t *testing.T) {
 			},
 		}, nil, nil).Return(nil)
 
-	constraints := empire.NamedConstraints["2X"]
+	constraints := empire.Constraints2X
 	err = e.Run(context.Background(), empire.RunOpts{
 		User:    user,
 		App:     app,
@@ -550,6 +989,440 @@ func TestEmpire_Set(t *testing.T) {
 	s.AssertExpectations(t)
 }
 
+func TestEmpire_Apply(t *testing.T) {
+	e := empiretest.NewEmpire(t)
+	s := new(mockScheduler)
+	e.Scheduler = s
+
+	user := &empire.User{Name: "ejholmes"}
+
+	// When any part of the manifest can't be applied, nothing is changed.
+	_, err := e.Apply(context.Background(), empire.ApplyOpts{
+		User: user,
+		Manifest: &empire.Manifest{
+			Name:   "acme-inc",
+			Image:  "remind101/acme-inc:latest",
+			Config: map[string]string{"RAILS_ENV": "production"},
+			Formation: map[string]empire.ManifestProcess{
+				"admin": {Quantity: 1},
+			},
+		},
+	})
+	assert.EqualError(t, err, "no admin process type in release")
+
+	apps, err := e.Apps(empire.AppsQuery{})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(apps))
+
+	s.On("Submit", mock.Anything).Return(nil).Once()
+
+	changes, err := e.Apply(context.Background(), empire.ApplyOpts{
+		User: user,
+		Manifest: &empire.Manifest{
+			Name:   "acme-inc",
+			Image:  "remind101/acme-inc:latest",
+			Config: map[string]string{"RAILS_ENV": "production"},
+			Formation: map[string]empire.ManifestProcess{
+				"worker": {Quantity: 2},
+			},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 4, len(changes))
+
+	name := "acme-inc"
+	app, err := e.AppsFind(empire.AppsQuery{Name: &name})
+	assert.NoError(t, err)
+
+	formation, err := e.ListScale(context.Background(), app)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, formation["worker"].Quantity)
+
+	s.AssertExpectations(t)
+}
+
+func TestEmpire_Rename(t *testing.T) {
+	e := empiretest.NewEmpire(t)
+	s := new(mockScheduler)
+	e.Scheduler = s
+	e.ProcfileExtractor = empiretest.ExtractProcfile(procfile.ExtendedProcfile{
+		"web": procfile.Process{
+			Command: []string{"./bin/web"},
+		},
+	})
+
+	user := &empire.User{Name: "ejholmes"}
+
+	s.On("Submit", mock.Anything).Return(nil)
+
+	r, err := e.Deploy(context.Background(), empire.DeployOpts{
+		User:   user,
+		Output: empire.NewDeploymentStream(ioutil.Discard),
+		Image:  image.Image{Repository: "remind101/acme-inc"},
+	})
+	assert.NoError(t, err)
+	app := r.App
+
+	_, err = e.Create(context.Background(), empire.CreateOpts{
+		User: user,
+		Name: "other-app",
+	})
+	assert.NoError(t, err)
+
+	// Can't rename to the name of another app.
+	err = e.Rename(context.Background(), empire.RenameOpts{
+		User: user,
+		App:  app,
+		Name: "other-app",
+	})
+	assert.EqualError(t, err, "An app named other-app already exists.")
+	assert.Equal(t, "acme-inc", app.Name)
+
+	err = e.Rename(context.Background(), empire.RenameOpts{
+		User: user,
+		App:  app,
+		Name: "acme",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "acme", app.Name)
+
+	// The latest release should have been re-submitted with the new name.
+	calls := s.Calls
+	submitted := calls[len(calls)-1].Arguments.Get(0).(*scheduler.App)
+	assert.Equal(t, "acme", submitted.Name)
+	// DNS records for the previous name are kept until the alias expires.
+	assert.Equal(t, []string{"acme-inc"}, submitted.Aliases)
+
+	// The old name is an alias for the app.
+	previous := "acme-inc"
+	a, err := e.AppsFind(empire.AppsQuery{Name: &previous})
+	assert.NoError(t, err)
+	assert.Equal(t, app.ID, a.ID)
+
+	typ := "rename"
+	events, err := e.AuditEvents(empire.AuditEventsQuery{Type: &typ})
+	assert.NoError(t, err)
+	if assert.Equal(t, 1, len(events)) {
+		assert.Equal(t, "ejholmes renamed acme-inc to acme", events[0].Message)
+	}
+
+	// Aliases expire.
+	e.AppAliasTTL = -time.Second
+	err = e.Rename(context.Background(), empire.RenameOpts{
+		User: user,
+		App:  app,
+		Name: "acme-api",
+	})
+	assert.NoError(t, err)
+
+	name := "acme"
+	_, err = e.AppsFind(empire.AppsQuery{Name: &name})
+	assert.Error(t, err)
+}
+
+func TestEmpire_ScaleSchedules(t *testing.T) {
+	e := empiretest.NewEmpire(t)
+	s := new(mockScheduler)
+	e.Scheduler = s
+	e.ProcfileExtractor = empiretest.ExtractProcfile(procfile.ExtendedProcfile{
+		"web": procfile.Process{
+			Command: []string{"./bin/web"},
+		},
+		"worker": procfile.Process{
+			Command: []string{"./bin/worker"},
+		},
+	})
+
+	user := &empire.User{Name: "ejholmes"}
+
+	s.On("Submit", mock.Anything).Return(nil)
+
+	r, err := e.Deploy(context.Background(), empire.DeployOpts{
+		User:   user,
+		Output: empire.NewDeploymentStream(ioutil.Discard),
+		Image:  image.Image{Repository: "remind101/acme-inc"},
+	})
+	assert.NoError(t, err)
+	app := r.App
+
+	_, err = e.Scale(context.Background(), empire.ScaleOpts{
+		User: user,
+		App:  app,
+		Updates: []*empire.ProcessUpdate{
+			{Process: "web", Quantity: 2},
+		},
+	})
+	assert.NoError(t, err)
+
+	_, err = e.ScaleSchedulesCreate(context.Background(), empire.ScaleSchedulesCreateOpts{
+		User: user,
+		Schedule: &empire.ScaleSchedule{
+			App:       app,
+			Schedule:  "0 20 * * *",
+			Formation: empire.Quantities{"bogus": 0},
+		},
+	})
+	assert.EqualError(t, err, "no bogus process type in release")
+
+	sleep, err := e.ScaleSchedulesCreate(context.Background(), empire.ScaleSchedulesCreateOpts{
+		User: user,
+		Schedule: &empire.ScaleSchedule{
+			App:       app,
+			Schedule:  "0 20 * * *",
+			Formation: empire.Quantities{"*": 0},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2015, time.January, 1, 20, 0, 0, 0, time.UTC), sleep.NextRunAt)
+
+	_, err = e.ScaleSchedulesCreate(context.Background(), empire.ScaleSchedulesCreateOpts{
+		User: user,
+		Schedule: &empire.ScaleSchedule{
+			App:      app,
+			Schedule: "0 7 * * *",
+			Restore:  true,
+		},
+	})
+	assert.NoError(t, err)
+
+	defer func() {
+		timex.Now = func() time.Time { return fakeNow }
+	}()
+	runAt := func(t time.Time) {
+		timex.Now = func() time.Time { return t }
+	}
+
+	quantities := func() map[string]int {
+		f, err := e.ListScale(context.Background(), app)
+		assert.NoError(t, err)
+		return map[string]int{"web": f["web"].Quantity, "worker": f["worker"].Quantity}
+	}
+
+	// Nothing is due yet.
+	runAt(time.Date(2015, time.January, 1, 19, 0, 0, 0, time.UTC))
+	assert.NoError(t, e.ApplyScaleSchedules(context.Background()))
+	assert.Equal(t, map[string]int{"web": 2, "worker": 0}, quantities())
+
+	// Scale everything down.
+	runAt(time.Date(2015, time.January, 1, 20, 0, 30, 0, time.UTC))
+	assert.NoError(t, e.ApplyScaleSchedules(context.Background()))
+	assert.Equal(t, map[string]int{"web": 0, "worker": 0}, quantities())
+
+	// And restore it in the morning.
+	runAt(time.Date(2015, time.January, 2, 7, 0, 30, 0, time.UTC))
+	assert.NoError(t, e.ApplyScaleSchedules(context.Background()))
+	assert.Equal(t, map[string]int{"web": 2, "worker": 0}, quantities())
+
+	typ := "scale"
+	events, err := e.AuditEvents(empire.AuditEventsQuery{Type: &typ})
+	assert.NoError(t, err)
+	if assert.Equal(t, 3, len(events)) {
+		assert.Equal(t, "empire", events[0].User)
+	}
+
+	schedules, err := e.ScaleSchedules(empire.ScaleSchedulesQuery{App: app})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(schedules))
+	assert.Equal(t, time.Date(2015, time.January, 2, 20, 0, 0, 0, time.UTC), schedules[0].NextRunAt)
+
+	err = e.ScaleSchedulesDestroy(context.Background(), empire.ScaleSchedulesDestroyOpts{
+		User:     user,
+		Schedule: schedules[0],
+	})
+	assert.NoError(t, err)
+}
+
+func TestEmpire_Quotas(t *testing.T) {
+	e := empiretest.NewEmpire(t)
+	s := new(mockScheduler)
+	e.Scheduler = s
+	e.ProcfileExtractor = empiretest.ExtractProcfile(procfile.ExtendedProcfile{
+		"web": procfile.Process{
+			Command: []string{"./bin/web"},
+		},
+	})
+	e.AppQuota = empire.Resources{Instances: 3}
+
+	user := &empire.User{Name: "ejholmes"}
+
+	s.On("Submit", mock.Anything).Return(nil)
+
+	deploy := func(repo string) *empire.App {
+		r, err := e.Deploy(context.Background(), empire.DeployOpts{
+			User:   user,
+			Output: empire.NewDeploymentStream(ioutil.Discard),
+			Image:  image.Image{Repository: repo},
+		})
+		assert.NoError(t, err)
+		return r.App
+	}
+
+	scale := func(app *empire.App, quantity int) error {
+		_, err := e.Scale(context.Background(), empire.ScaleOpts{
+			User: user,
+			App:  app,
+			Updates: []*empire.ProcessUpdate{
+				{Process: "web", Quantity: quantity},
+			},
+		})
+		return err
+	}
+
+	app := deploy("remind101/acme-inc")
+
+	err := scale(app, 4)
+	assert.IsType(t, &empire.ValidationError{}, err)
+	assert.EqualError(t, err, "acme-inc would run 4 instances, which is more than the quota of 3.")
+
+	assert.NoError(t, scale(app, 3))
+
+	e.Quota = empire.Resources{Instances: 4}
+
+	other := deploy("remind101/other")
+	err = scale(other, 2)
+	assert.EqualError(t, err, "All apps together would run 5 instances, which is more than the quota of 4.")
+
+	// Scaling down is always allowed.
+	e.AppQuota = empire.Resources{Instances: 1}
+	assert.NoError(t, scale(app, 2))
+
+	s.AssertExpectations(t)
+}
+
+func TestEmpire_Cost(t *testing.T) {
+	e := empiretest.NewEmpire(t)
+	cron := "0 * * * ? *"
+	s := new(mockScheduler)
+	e.Scheduler = s
+	e.ProcfileExtractor = empiretest.ExtractProcfile(procfile.ExtendedProcfile{
+		"web": procfile.Process{
+			Command: []string{"./bin/web"},
+		},
+		"worker": procfile.Process{
+			Command: []string{"./bin/worker"},
+		},
+		"scheduled": procfile.Process{
+			Command: []string{"./bin/scheduled"},
+			Cron:    &cron,
+		},
+	})
+	e.CostModel = empire.CostModel{GBHour: 1, LoadBalancerHour: 0.5}
+
+	user := &empire.User{Name: "ejholmes"}
+
+	s.On("Submit", mock.Anything).Return(nil)
+
+	r, err := e.Deploy(context.Background(), empire.DeployOpts{
+		User:   user,
+		Output: empire.NewDeploymentStream(ioutil.Discard),
+		Image:  image.Image{Repository: "remind101/acme-inc"},
+	})
+	assert.NoError(t, err)
+	app := r.App
+
+	defer func() {
+		timex.Now = func() time.Time { return fakeNow }
+	}()
+
+	// Scaling changes the formation of the current release, halfway
+	// through the day. Scheduled processes only run to completion, so
+	// they don't add to the cost.
+	timex.Now = func() time.Time { return fakeNow.Add(12 * time.Hour) }
+	_, err = e.Scale(context.Background(), empire.ScaleOpts{
+		User: user,
+		App:  app,
+		Updates: []*empire.ProcessUpdate{
+			{Process: "web", Quantity: 2},
+			{Process: "scheduled", Quantity: 1},
+		},
+	})
+	assert.NoError(t, err)
+
+	timex.Now = func() time.Time { return fakeNow.Add(24 * time.Hour) }
+
+	c, err := e.AppCost(context.Background(), app, 2)
+	assert.NoError(t, err)
+
+	// Two 1X web processes (512MB), and their load balancer.
+	assert.Equal(t, 1.5*empire.HoursPerMonth, c.Monthly)
+	assert.Equal(t, 2, len(c.Processes))
+	assert.Equal(t, "web", c.Processes[0].Type)
+	assert.True(t, c.Processes[0].LoadBalancer)
+	assert.Equal(t, "worker", c.Processes[1].Type)
+	assert.Equal(t, 0.0, c.Processes[1].Monthly)
+
+	assert.Equal(t, []*empire.MonthlyCost{
+		{Month: time.Date(2014, time.December, 1, 0, 0, 0, 0, time.UTC), Cost: 0},
+		{Month: time.Date(2015, time.January, 1, 0, 0, 0, 0, time.UTC), Cost: 12 + 12*1.5},
+	}, c.History)
+
+	costs, err := e.AppCosts(context.Background(), []*empire.App{app}, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(costs))
+	assert.Equal(t, empire.DefaultCostMonths, len(costs[0].History))
+
+	s.AssertExpectations(t)
+}
+
+func TestEmpire_Drift(t *testing.T) {
+	e := empiretest.NewEmpire(t)
+	s := scheduler.NewFakeScheduler()
+	e.Scheduler = s
+	e.ProcfileExtractor = empiretest.ExtractProcfile(procfile.ExtendedProcfile{
+		"web": procfile.Process{
+			Command: []string{"./bin/web"},
+		},
+	})
+
+	user := &empire.User{Name: "ejholmes"}
+
+	r, err := e.Deploy(context.Background(), empire.DeployOpts{
+		User:   user,
+		Output: empire.NewDeploymentStream(ioutil.Discard),
+		Image:  image.Image{Repository: "remind101/acme-inc"},
+	})
+	assert.NoError(t, err)
+	app := r.App
+
+	d, err := e.Drift(context.Background(), empire.DriftOpts{
+		User: user,
+		App:  app,
+	})
+	assert.NoError(t, err)
+	assert.False(t, d.Drifted())
+
+	// Someone scales the web process outside of Empire.
+	err = s.Scale(context.Background(), app.ID, "web", 3)
+	assert.NoError(t, err)
+
+	d, err = e.Drift(context.Background(), empire.DriftOpts{
+		User: user,
+		App:  app,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []*empire.ProcessDrift{
+		{Type: "web", Quantity: 1, Desired: 3, Running: 3},
+	}, d.Processes)
+
+	d, err = e.Reconcile(context.Background(), empire.ReconcileOpts{
+		User: user,
+		App:  app,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"web"}, d.DriftedProcesses())
+
+	d, err = e.Drift(context.Background(), empire.DriftOpts{
+		User: user,
+		App:  app,
+	})
+	assert.NoError(t, err)
+	assert.False(t, d.Drifted())
+
+	events, err := e.AuditEvents(empire.AuditEventsQuery{Type: &[]string{"reconcile"}[0]})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(events))
+}
+
 type mockScheduler struct {
 	scheduler.Scheduler
 	mock.Mock
@@ -576,3 +1449,139 @@ func (m *mockScheduler) Run(_ context.Context, app *scheduler.App, process *sche
 	args := m.Called(app, process, in, out)
 	return args.Error(0)
 }
+
+type mockPlanner struct {
+	mockScheduler
+}
+
+func (m *mockPlanner) Plan(_ context.Context, app *scheduler.App) (*scheduler.Plan, error) {
+	args := m.Called(app)
+	return args.Get(0).(*scheduler.Plan), args.Error(1)
+}
+
+func TestEmpire_Orphans(t *testing.T) {
+	e := empiretest.NewEmpire(t)
+	s := new(mockGarbageCollector)
+	e.Scheduler = s
+
+	user := &empire.User{Name: "ejholmes"}
+
+	app, err := e.Create(context.Background(), empire.CreateOpts{
+		User: user,
+		Name: "acme-inc",
+	})
+	assert.NoError(t, err)
+
+	orphan := &scheduler.Orphan{Type: "AWS::CloudFormation::Stack", ID: "old-app", App: "c9366591-ab68-4d49-a333-95ce5a23df68"}
+	failed := &scheduler.Orphan{Type: "AWS::CloudFormation::Stack", ID: "other-app", App: "ae69bb4c-3903-4844-82fe-548ac5b74570"}
+	s.On("Orphans", []string{app.ID}).Return([]*scheduler.Orphan{orphan, failed}, nil)
+
+	orphans, err := e.Orphans(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []*scheduler.Orphan{orphan, failed}, orphans)
+
+	// Only resources that are still orphaned are confirmed.
+	created := &scheduler.Orphan{Type: "AWS::CloudFormation::Stack", ID: "new-app", App: "e2b7a3b9-5f0b-4c1e-8d3a-4f7b1c2d9e10"}
+	confirmed, err := e.ConfirmOrphans(context.Background(), []*scheduler.Orphan{orphan, created})
+	assert.NoError(t, err)
+	assert.Equal(t, []*scheduler.Orphan{orphan}, confirmed)
+
+	// A failure shouldn't stop the other resources from being removed.
+	s.On("RemoveOrphan", failed).Return(errors.New("boom"))
+	s.On("RemoveOrphan", orphan).Return(nil)
+
+	err = e.RemoveOrphans(context.Background(), []*scheduler.Orphan{failed, orphan})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "error removing AWS::CloudFormation::Stack other-app: boom")
+
+	s.AssertExpectations(t)
+}
+
+type mockGarbageCollector struct {
+	mockScheduler
+}
+
+func (m *mockGarbageCollector) Orphans(_ context.Context, apps []string) ([]*scheduler.Orphan, error) {
+	args := m.Called(apps)
+	return args.Get(0).([]*scheduler.Orphan), args.Error(1)
+}
+
+func (m *mockGarbageCollector) RemoveOrphan(_ context.Context, orphan *scheduler.Orphan) error {
+	args := m.Called(orphan)
+	return args.Error(0)
+}
+
+func TestEmpire_DispatchEvents(t *testing.T) {
+	e := empiretest.NewEmpire(t)
+	e.EventMaxAttempts = 2
+
+	var published []empire.Event
+	var fail bool
+	e.EventStream = empire.EventStreamFunc(func(event empire.Event) error {
+		if fail {
+			return errors.New("boom")
+		}
+		published = append(published, event)
+		return nil
+	})
+
+	user := &empire.User{Name: "ejholmes"}
+
+	_, err := e.Create(context.Background(), empire.CreateOpts{
+		User: user,
+		Name: "acme-inc",
+	})
+	assert.NoError(t, err)
+
+	// The event is stored, but not published until it's dispatched.
+	assert.Equal(t, 0, len(published))
+
+	n, err := e.DispatchPendingEvents(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, 1, len(published))
+	assert.Equal(t, "create", published[0].Event())
+	assert.Equal(t, "ejholmes created acme-inc", published[0].String())
+
+	// Delivered events aren't delivered again.
+	n, err = e.DispatchPendingEvents(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, n)
+
+	// When the event stream fails, the event is retried until it's dead.
+	fail = true
+	_, err = e.Create(context.Background(), empire.CreateOpts{
+		User: user,
+		Name: "acme-corp",
+	})
+	assert.NoError(t, err)
+
+	defer func(now time.Time) { fakeNow = now }(fakeNow)
+	for i := 0; i < 2; i++ {
+		// Move time forward past the backoff.
+		fakeNow = fakeNow.Add(time.Hour)
+		n, err = e.DispatchPendingEvents(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, 0, n)
+	}
+
+	dead := empire.EventDead
+	events, err := e.AuditEvents(empire.AuditEventsQuery{Status: &dead})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(events))
+	assert.Equal(t, 2, events[0].Attempts)
+	assert.Equal(t, "boom", events[0].LastError)
+
+	// Requeued events are delivered again.
+	fail = false
+	err = e.EventsRequeue(context.Background(), empire.EventsRequeueOpts{
+		User:  user,
+		Event: events[0],
+	})
+	assert.NoError(t, err)
+
+	n, err = e.DispatchPendingEvents(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, "ejholmes created acme-corp", published[1].String())
+}
@@ -7,6 +7,38 @@ type User struct {
 
 	// GitHubToken is a GitHub access token.
 	GitHubToken string `json:"-"`
+
+	// Teams are the GitHub teams (as org/slug) that the user is a member
+	// of, which are used to check access grants. It's populated after the
+	// user is authenticated.
+	Teams []string `json:"-"`
+
+	// True for the user that background jobs act as, which is authorized
+	// to perform any action.
+	system bool
+}
+
+// systemUser returns the user that background jobs, like scheduled scaling, act
+// as. The actions were authorized when they were configured, so the user isn't
+// subject to access control.
+func systemUser() *User {
+	return &User{Name: reconcilerUserName, system: true}
+}
+
+// Is returns true if the principal is the name of the user, or one of the
+// teams that the user is a member of.
+func (u *User) Is(principal string) bool {
+	if u.Name == principal {
+		return true
+	}
+
+	for _, team := range u.Teams {
+		if team == principal {
+			return true
+		}
+	}
+
+	return false
 }
 
 // IsValid returns nil if the User is valid.